@@ -14,8 +14,10 @@ limitations under the License.
 package autoscale
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 	"k8s.io/api/autoscaling/v2beta1"
@@ -109,3 +111,106 @@ func getHorizontalAutoscaleDefinition(name, ns, metric string, min, max int32, v
 		},
 	}, nil
 }
+
+// hpaBehaviorAnnotation is used by the API server to keep the autoscaling/v2beta2 behavior
+// of an HPA in the older API versions. Kubernetes 1.18 or newer is required.
+const hpaBehaviorAnnotation = "autoscaling.alpha.kubernetes.io/behavior"
+
+const (
+	maxStabilizationWindow = time.Hour
+	maxScalingPeriod       = 30 * time.Minute
+)
+
+// hpaScalingPolicy is a single scaling policy of the autoscaling/v2beta2 API
+type hpaScalingPolicy struct {
+	Type          string `json:"type"`
+	Value         int32  `json:"value"`
+	PeriodSeconds int32  `json:"periodSeconds"`
+}
+
+// hpaScalingRules configures the scaling of an HPA in one direction
+type hpaScalingRules struct {
+	StabilizationWindowSeconds *int32             `json:"stabilizationWindowSeconds,omitempty"`
+	SelectPolicy               string             `json:"selectPolicy,omitempty"`
+	Policies                   []hpaScalingPolicy `json:"policies"`
+}
+
+// hpaBehavior is the scale up and down behavior of an HPA
+type hpaBehavior struct {
+	ScaleUp   *hpaScalingRules `json:"scaleUp,omitempty"`
+	ScaleDown *hpaScalingRules `json:"scaleDown,omitempty"`
+}
+
+// hpaScalingOptions contains the flags of one scaling direction. A nil stabilization
+// and zero pods and percent mean that the direction is not configured.
+type hpaScalingOptions struct {
+	Stabilization *time.Duration
+	Pods          int32
+	Percent       int32
+	Period        time.Duration
+}
+
+// getScalingRules validates the options of a scaling direction ("up" or "down") and returns
+// its rules. Without pods or percent the default policies of Kubernetes are used.
+func getScalingRules(direction string, opts hpaScalingOptions) (*hpaScalingRules, error) {
+	if opts.Stabilization == nil && opts.Pods == 0 && opts.Percent == 0 {
+		return nil, nil
+	}
+	rules := &hpaScalingRules{}
+	if opts.Stabilization != nil {
+		window := *opts.Stabilization
+		if window < 0 || window > maxStabilizationWindow || window%time.Second != 0 {
+			return nil, fmt.Errorf("Invalid value %v for --scale-%s-stabilization. It should be a whole number of seconds between 0s and %v", window, direction, maxStabilizationWindow)
+		}
+		seconds := int32(window / time.Second)
+		rules.StabilizationWindowSeconds = &seconds
+	}
+	if opts.Pods < 0 || opts.Percent < 0 {
+		return nil, fmt.Errorf("--scale-%[1]s-pods-per-period and --scale-%[1]s-percent-per-period can't be negative", direction)
+	}
+	if opts.Period <= 0 || opts.Period > maxScalingPeriod || opts.Period%time.Second != 0 {
+		return nil, fmt.Errorf("Invalid value %v for --scale-%s-period. It should be a whole number of seconds between 1s and %v", opts.Period, direction, maxScalingPeriod)
+	}
+	period := int32(opts.Period / time.Second)
+	if opts.Pods > 0 {
+		rules.Policies = append(rules.Policies, hpaScalingPolicy{Type: "Pods", Value: opts.Pods, PeriodSeconds: period})
+	}
+	if opts.Percent > 0 {
+		rules.Policies = append(rules.Policies, hpaScalingPolicy{Type: "Percent", Value: opts.Percent, PeriodSeconds: period})
+	}
+	if len(rules.Policies) == 0 {
+		// Same policies that Kubernetes sets when none is given
+		rules.Policies = []hpaScalingPolicy{{Type: "Percent", Value: 100, PeriodSeconds: 15}}
+		if direction == "up" {
+			rules.Policies = append(rules.Policies, hpaScalingPolicy{Type: "Pods", Value: 4, PeriodSeconds: 15})
+		}
+	}
+	if len(rules.Policies) > 1 {
+		rules.SelectPolicy = "Max"
+	}
+	return rules, nil
+}
+
+// setAutoscaleBehavior stores the scaling behavior in the annotations of the HPA
+func setAutoscaleBehavior(hpa *v2beta1.HorizontalPodAutoscaler, up, down hpaScalingOptions) error {
+	behavior := hpaBehavior{}
+	var err error
+	if behavior.ScaleUp, err = getScalingRules("up", up); err != nil {
+		return err
+	}
+	if behavior.ScaleDown, err = getScalingRules("down", down); err != nil {
+		return err
+	}
+	if behavior.ScaleUp == nil && behavior.ScaleDown == nil {
+		return nil
+	}
+	content, err := json.Marshal(behavior)
+	if err != nil {
+		return err
+	}
+	if hpa.ObjectMeta.Annotations == nil {
+		hpa.ObjectMeta.Annotations = map[string]string{}
+	}
+	hpa.ObjectMeta.Annotations[hpaBehaviorAnnotation] = string(content)
+	return nil
+}
@@ -1,6 +1,9 @@
 package autoscale
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/kubeless/kubeless/pkg/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -59,6 +62,34 @@ var autoscaleCreateCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatal(err)
 		}
+		up, err := getScalingOptions(cmd, "up")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		down, err := getScalingOptions(cmd, "down")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if err := setAutoscaleBehavior(&hpa, up, down); err != nil {
+			logrus.Fatal(err)
+		}
+
+		dryrun, err := cmd.Flags().GetBool("dryrun")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if dryrun {
+			output, err := cmd.Flags().GetString("output")
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			res, err := utils.DryRunFmt(output, hpa)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			fmt.Println(res)
+			return
+		}
 		function.Spec.HorizontalPodAutoscaler = hpa
 
 		kubelessClient, err := utils.GetKubelessClientOutCluster()
@@ -74,10 +105,43 @@ var autoscaleCreateCmd = &cobra.Command{
 	},
 }
 
+// getScalingOptions reads the behavior flags of a scaling direction ("up" or "down")
+func getScalingOptions(cmd *cobra.Command, direction string) (hpaScalingOptions, error) {
+	opts := hpaScalingOptions{}
+	var err error
+	if cmd.Flags().Changed("scale-" + direction + "-stabilization") {
+		window, err := cmd.Flags().GetDuration("scale-" + direction + "-stabilization")
+		if err != nil {
+			return opts, err
+		}
+		opts.Stabilization = &window
+	}
+	if opts.Pods, err = cmd.Flags().GetInt32("scale-" + direction + "-pods-per-period"); err != nil {
+		return opts, err
+	}
+	if opts.Percent, err = cmd.Flags().GetInt32("scale-" + direction + "-percent-per-period"); err != nil {
+		return opts, err
+	}
+	if opts.Period, err = cmd.Flags().GetDuration("scale-" + direction + "-period"); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
 func init() {
 	autoscaleCreateCmd.Flags().Int32("min", 1, "minimum number of replicas")
 	autoscaleCreateCmd.Flags().Int32("max", 1, "maximum number of replicas")
 	autoscaleCreateCmd.Flags().String("metric", "cpu", "metric to use for calculating the autoscale. Supported metrics: cpu, qps")
 	autoscaleCreateCmd.Flags().String("value", "", "value of the average of the metric across all replicas. If metric is cpu, value is a number represented as percentage. If metric is qps, value must be in format of Quantity")
+	autoscaleCreateCmd.Flags().Duration("scale-up-stabilization", 0, "time the recommendations of the last scale ups are considered to avoid flapping (e.g. 1m). Requires Kubernetes 1.18 or newer, like the rest of scale-up and scale-down flags")
+	autoscaleCreateCmd.Flags().Int32("scale-up-pods-per-period", 0, "maximum number of pods added in each --scale-up-period")
+	autoscaleCreateCmd.Flags().Int32("scale-up-percent-per-period", 0, "maximum percentage of the current pods added in each --scale-up-period")
+	autoscaleCreateCmd.Flags().Duration("scale-up-period", 15*time.Second, "period of the scale up policies")
+	autoscaleCreateCmd.Flags().Duration("scale-down-stabilization", 0, "time the recommendations of the last scale downs are considered to avoid flapping (e.g. 5m)")
+	autoscaleCreateCmd.Flags().Int32("scale-down-pods-per-period", 0, "maximum number of pods removed in each --scale-down-period")
+	autoscaleCreateCmd.Flags().Int32("scale-down-percent-per-period", 0, "maximum percentage of the current pods removed in each --scale-down-period")
+	autoscaleCreateCmd.Flags().Duration("scale-down-period", 15*time.Second, "period of the scale down policies")
+	autoscaleCreateCmd.Flags().Bool("dryrun", false, "output the manifest of the autoscale rule without creating it")
+	autoscaleCreateCmd.Flags().StringP("output", "o", "yaml", "output format of --dryrun. One of: json|yaml")
 	autoscaleCreateCmd.MarkFlagRequired("value")
 }
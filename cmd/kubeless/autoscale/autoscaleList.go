@@ -14,9 +14,9 @@ limitations under the License.
 package autoscale
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
+	"text/template"
 
 	"github.com/ghodss/yaml"
 	"github.com/gosuri/uitable"
@@ -38,27 +38,35 @@ var autoscaleListCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatal(err.Error())
 		}
-		ns, err := cmd.Flags().GetString("namespace")
+		tmpl, err := utils.GetOutputTemplate(cmd.Flags(), output)
 		if err != nil {
-			logrus.Fatal(err.Error())
+			logrus.Fatal(err)
+		}
+		sortBy, err := cmd.Flags().GetString("sort-by")
+		if err != nil {
+			logrus.Fatal(err)
 		}
-		if ns == "" {
-			ns = utils.GetDefaultNamespace()
+		ns, err := utils.GetNamespaceOrAll(cmd.Flags())
+		if err != nil {
+			logrus.Fatal(err)
 		}
 
 		client := utils.GetClientOutOfCluster()
 
-		if err := doAutoscaleList(cmd.OutOrStdout(), client, ns, output); err != nil {
+		if err := doAutoscaleList(cmd.OutOrStdout(), client, ns, output, tmpl, sortBy); err != nil {
 			logrus.Fatal(err.Error())
 		}
 	},
 }
 
 func init() {
-	autoscaleListCmd.Flags().StringP("out", "o", "", "Output format. One of: json|yaml")
+	autoscaleListCmd.Flags().StringP("out", "o", "", "Output format. One of: json|yaml|template")
+	utils.AddTemplateFlags(autoscaleListCmd.Flags())
+	utils.AddSortByFlag(autoscaleListCmd.Flags())
+	utils.AddAllNamespacesFlag(autoscaleListCmd.Flags(), "List the autoscales of all the namespaces")
 }
 
-func doAutoscaleList(w io.Writer, client kubernetes.Interface, ns, output string) error {
+func doAutoscaleList(w io.Writer, client kubernetes.Interface, ns, output string, tmpl *template.Template, sortBy string) error {
 	asList, err := client.AutoscalingV2beta1().HorizontalPodAutoscalers(ns).List(metav1.ListOptions{
 		LabelSelector: "created-by=kubeless",
 	})
@@ -66,11 +74,14 @@ func doAutoscaleList(w io.Writer, client kubernetes.Interface, ns, output string
 		return err
 	}
 
-	return printAutoscale(w, asList.Items, output)
+	if err := utils.SortObjects(asList.Items, sortBy); err != nil {
+		return err
+	}
+	return printAutoscale(w, asList.Items, output, tmpl)
 }
 
 // printAutoscale formats the output of autoscale list
-func printAutoscale(w io.Writer, ass []v2beta1.HorizontalPodAutoscaler, output string) error {
+func printAutoscale(w io.Writer, ass []v2beta1.HorizontalPodAutoscaler, output string, tmpl *template.Template) error {
 	if output == "" {
 		table := uitable.New()
 		table.MaxColWidth = 50
@@ -103,7 +114,7 @@ func printAutoscale(w io.Writer, ass []v2beta1.HorizontalPodAutoscaler, output s
 		for _, i := range ass {
 			switch output {
 			case "json":
-				b, err := json.MarshalIndent(i, "", "  ")
+				b, err := utils.MarshalJSON(i, "  ")
 				if err != nil {
 					return err
 				}
@@ -114,8 +125,12 @@ func printAutoscale(w io.Writer, ass []v2beta1.HorizontalPodAutoscaler, output s
 					return err
 				}
 				fmt.Fprintln(w, string(b))
+			case utils.OutputTemplate:
+				if err := utils.PrintTemplate(w, tmpl, i); err != nil {
+					return err
+				}
 			default:
-				return fmt.Errorf("Wrong output format. Only accept json|yaml file")
+				return fmt.Errorf("Wrong output format. Only accept json|yaml|template file")
 			}
 		}
 	}
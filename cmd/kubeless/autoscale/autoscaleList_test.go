@@ -16,7 +16,7 @@ import (
 func listAutoscaleOutput(t *testing.T, client kubernetes.Interface, ns, output string) string {
 	var buf bytes.Buffer
 
-	if err := doAutoscaleList(&buf, client, ns, output); err != nil {
+	if err := doAutoscaleList(&buf, client, ns, output, nil, ""); err != nil {
 		t.Fatalf("doList returned error: %v", err)
 	}
 
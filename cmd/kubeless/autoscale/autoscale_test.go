@@ -3,6 +3,7 @@ package autoscale
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"k8s.io/api/autoscaling/v2beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -55,3 +56,39 @@ func TestGetHorizontalAutoscaleDefinition(t *testing.T) {
 		t.Error("Unexpected metric")
 	}
 }
+
+func TestSetAutoscaleBehavior(t *testing.T) {
+	hpa := v2beta1.HorizontalPodAutoscaler{}
+	if err := setAutoscaleBehavior(&hpa, hpaScalingOptions{Period: 15 * time.Second}, hpaScalingOptions{Period: 15 * time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := hpa.ObjectMeta.Annotations[hpaBehaviorAnnotation]; ok {
+		t.Error("Unexpected behavior without scaling flags")
+	}
+
+	window := 5 * time.Minute
+	up := hpaScalingOptions{Pods: 2, Percent: 50, Period: time.Minute}
+	down := hpaScalingOptions{Stabilization: &window, Period: 15 * time.Second}
+	if err := setAutoscaleBehavior(&hpa, up, down); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"scaleUp":{"selectPolicy":"Max","policies":[{"type":"Pods","value":2,"periodSeconds":60},{"type":"Percent","value":50,"periodSeconds":60}]},` +
+		`"scaleDown":{"stabilizationWindowSeconds":300,"policies":[{"type":"Percent","value":100,"periodSeconds":15}]}}`
+	if behavior := hpa.ObjectMeta.Annotations[hpaBehaviorAnnotation]; behavior != expected {
+		t.Errorf("Expecting behavior %s, got %s", expected, behavior)
+	}
+
+	negative := -time.Second
+	tooLong := 2 * time.Hour
+	for _, opts := range []hpaScalingOptions{
+		{Stabilization: &negative, Period: 15 * time.Second},
+		{Stabilization: &tooLong, Period: 15 * time.Second},
+		{Pods: -1, Period: 15 * time.Second},
+		{Pods: 1, Period: 0},
+		{Pods: 1, Period: 1500 * time.Millisecond},
+	} {
+		if err := setAutoscaleBehavior(&v2beta1.HorizontalPodAutoscaler{}, opts, hpaScalingOptions{Period: 15 * time.Second}); err == nil {
+			t.Errorf("Expecting an error for %+v", opts)
+		}
+	}
+}
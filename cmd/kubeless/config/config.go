@@ -0,0 +1,39 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// ConfigCmd contains first-class command for the CLI defaults
+var ConfigCmd = &cobra.Command{
+	Use:   "config SUBCOMMAND",
+	Short: "manage the defaults of the kubeless CLI",
+	Long: `config command allows user to set, get and unset the default values used by the kubeless CLI.
+Defaults are stored in ~/.kubeless/config.yaml and have a lower precedence than flags and KUBELESS_* environment variables.
+Supported keys: namespace, output, kubeconfig, context`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func init() {
+	ConfigCmd.AddCommand(setCmd)
+	ConfigCmd.AddCommand(getCmd)
+	ConfigCmd.AddCommand(unsetCmd)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gosuri/uitable"
+	"github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var getCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "print the defaults of the kubeless CLI",
+	Long:  `print the defaults of the kubeless CLI. If a key is given only its value is printed`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 1 {
+			logrus.Fatal("Need at most one argument - key")
+		}
+
+		config, err := utils.ReadCLIConfig()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		if len(args) == 1 {
+			if err := utils.ValidateCLIConfigKey(args[0]); err != nil {
+				logrus.Fatal(err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), config[args[0]])
+			return
+		}
+
+		keys := []string{}
+		for k := range config {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		table := uitable.New()
+		table.AddRow("KEY", "VALUE")
+		for _, k := range keys {
+			table.AddRow(k, config[k])
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), table)
+	},
+}
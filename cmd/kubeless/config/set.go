@@ -0,0 +1,48 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var setCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "set a default value for the kubeless CLI",
+	Long:  `set a default value for the kubeless CLI`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 2 {
+			logrus.Fatal("Need exactly two arguments - key and value")
+		}
+		key, value := args[0], args[1]
+		if err := utils.ValidateCLIConfigKey(key); err != nil {
+			logrus.Fatal(err)
+		}
+
+		config, err := utils.ReadCLIConfig()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		config[key] = value
+		if err := utils.WriteCLIConfig(config); err != nil {
+			logrus.Fatalf("Unable to write %s: %v", utils.CLIConfigPath(), err)
+		}
+		logrus.Infof("Default %s set to %s", key, value)
+	},
+}
@@ -18,7 +18,15 @@ package function
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -26,7 +34,10 @@ import (
 	"github.com/kubeless/kubeless/pkg/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
 
@@ -63,42 +74,109 @@ var callCmd = &cobra.Command{
 			ns = utils.GetDefaultNamespace()
 		}
 
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if timeout < 0 {
+			logrus.Fatalf("Invalid --timeout %v", timeout)
+		}
+		requests, err := cmd.Flags().GetInt("requests")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		concurrency, err := cmd.Flags().GetInt("concurrency")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if requests < 1 || concurrency < 1 {
+			logrus.Fatal("The number of requests and the concurrency should be at least 1")
+		}
+		loadTest := utils.IsFlagGiven(cmd.Flags(), "requests") || utils.IsFlagGiven(cmd.Flags(), "concurrency")
+
+		trace, err := cmd.Flags().GetBool("trace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if trace && loadTest {
+			logrus.Fatal("The flag --trace can't be used with --requests or --concurrency")
+		}
+
+		debugHTTP, err := cmd.Flags().GetBool("debug-http")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if debugHTTP && loadTest {
+			logrus.Fatal("The flag --debug-http can't be used with --requests or --concurrency")
+		}
+		redactedHeaders, err := cmd.Flags().GetStringSlice("redact-header")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
 		clientset := utils.GetClientOutOfCluster()
 		svc, err := clientset.CoreV1().Services(ns).Get(funcName, metav1.GetOptions{})
 		if err != nil {
 			logrus.Fatalf("Unable to find the service for %s", funcName)
 		}
 
-		port := strconv.Itoa(int(svc.Spec.Ports[0].Port))
-		if svc.Spec.Ports[0].Name != "" {
-			port = svc.Spec.Ports[0].Name
+		if loadTest {
+			report := runLoadTest(func() (int, error) {
+				req, err := newFunctionRequest(clientset.CoreV1().RESTClient(), ns, "services", funcName+":"+getServicePort(svc), str, get)
+				if err != nil {
+					return 0, err
+				}
+				if timeout > 0 {
+					req = req.Timeout(timeout)
+				}
+				status := 0
+				err = req.Do().StatusCode(&status).Error()
+				return status, err
+			}, requests, concurrency)
+			printLoadReport(cmd.OutOrStdout(), report)
+			if report.Succeeded < report.Requests {
+				logrus.Fatalf("%d of %d requests failed", report.Requests-report.Succeeded, report.Requests)
+			}
+			return
 		}
 
-		req := &rest.Request{}
-		if get {
-			req = clientset.CoreV1().RESTClient().Get().Namespace(ns).Resource("services").SubResource("proxy").Name(funcName + ":" + port)
-		} else {
-			req = clientset.CoreV1().RESTClient().Post().Namespace(ns).Resource("services").SubResource("proxy").Name(funcName + ":" + port).Body(bytes.NewBuffer(str))
-			if utils.IsJSON(string(str)) {
-				req.SetHeader("Content-Type", "application/json")
-				req.SetHeader("event-type", "application/json")
-			} else {
-				req.SetHeader("Content-Type", "application/x-www-form-urlencoded")
-				req.SetHeader("event-type", "application/x-www-form-urlencoded")
+		restClient := clientset.CoreV1().RESTClient()
+		if debugHTTP {
+			// Only the call to the function is logged, not the lookup of its service
+			config, err := utils.BuildOutOfClusterConfig()
+			if err != nil {
+				logrus.Fatalf("Can not get kubernetes config: %v", err)
+			}
+			config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+				return newDebugHTTPTransport(rt, os.Stderr, redactedHeaders)
+			}
+			debugClientset, err := kubernetes.NewForConfig(config)
+			if err != nil {
+				logrus.Fatalf("Can not get kubernetes client: %v", err)
 			}
-			// REST package removes trailing slash when building URLs
-			// Causing POST requests to be redirected with an empty body
-			// So we need to manually build the URL
-			req = req.AbsPath(req.URL().Path + "/")
+			restClient = debugClientset.CoreV1().RESTClient()
 		}
-		timestamp := time.Now().UTC()
-		eventID, err := utils.GetRandString(11)
+
+		req, err := newFunctionRequest(restClient, ns, "services", funcName+":"+getServicePort(svc), str, get)
 		if err != nil {
-			logrus.Fatalf("Unable to generate ID %v", err)
+			logrus.Fatal(err)
+		}
+		if timeout > 0 {
+			req = req.Timeout(timeout)
+		}
+
+		if trace {
+			traceparent, tracestate, err := getTraceHeaders(os.Getenv("TRACEPARENT"), os.Getenv("TRACESTATE"))
+			if err != nil {
+				logrus.Fatalf("Unable to generate trace headers: %v", err)
+			}
+			req.SetHeader("traceparent", traceparent)
+			if tracestate != "" {
+				req.SetHeader("tracestate", tracestate)
+			}
+			logrus.Infof("Trace ID: %s", strings.Split(traceparent, "-")[1])
 		}
-		req.SetHeader("event-id", eventID)
-		req.SetHeader("event-time", timestamp.Format(time.RFC3339))
-		req.SetHeader("event-namespace", "cli.kubeless.io")
+
 		res, err := req.Do().Raw()
 		if err != nil {
 			// Properly interpret line breaks
@@ -114,8 +192,200 @@ var callCmd = &cobra.Command{
 	},
 }
 
+// newFunctionRequest returns a request to the given resource (a service or a pod) through the API server proxy
+func newFunctionRequest(restClient rest.Interface, ns, resource, name string, body []byte, get bool) (*rest.Request, error) {
+	var req *rest.Request
+	if get {
+		req = restClient.Get().Namespace(ns).Resource(resource).SubResource("proxy").Name(name)
+	} else {
+		req = restClient.Post().Namespace(ns).Resource(resource).SubResource("proxy").Name(name).Body(bytes.NewBuffer(body))
+		if utils.IsJSON(string(body)) {
+			req.SetHeader("Content-Type", "application/json")
+			req.SetHeader("event-type", "application/json")
+		} else {
+			req.SetHeader("Content-Type", "application/x-www-form-urlencoded")
+			req.SetHeader("event-type", "application/x-www-form-urlencoded")
+		}
+		// REST package removes trailing slash when building URLs
+		// Causing POST requests to be redirected with an empty body
+		// So we need to manually build the URL
+		req = req.AbsPath(req.URL().Path + "/")
+	}
+	timestamp := time.Now().UTC()
+	eventID, err := utils.GetRandString(11)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to generate ID %v", err)
+	}
+	req.SetHeader("event-id", eventID)
+	req.SetHeader("event-time", timestamp.Format(time.RFC3339))
+	req.SetHeader("event-namespace", "cli.kubeless.io")
+	return req, nil
+}
+
+// getServicePort returns the port of the service of a function to use in proxy requests
+func getServicePort(svc *v1.Service) string {
+	if svc.Spec.Ports[0].Name != "" {
+		return svc.Spec.Ports[0].Name
+	}
+	return strconv.Itoa(int(svc.Spec.Ports[0].Port))
+}
+
+// verifyFunctionCall calls the function through its service until it responds with a 2xx
+// status or the timeout is reached. The last response is included in the error.
+func verifyFunctionCall(restClient rest.Interface, ns, funcName, port, data string, timeout time.Duration) error {
+	var (
+		status  int
+		body    []byte
+		callErr error
+	)
+	progress := utils.StartProgress(fmt.Sprintf("Calling %s", funcName))
+	err := wait.PollImmediate(time.Second, timeout, func() (bool, error) {
+		req, err := newFunctionRequest(restClient, ns, "services", funcName+":"+port, []byte(data), data == "")
+		if err != nil {
+			return false, err
+		}
+		status = 0
+		body, callErr = req.Do().StatusCode(&status).Raw()
+		progress.SetPhase(fmt.Sprintf("Calling %s: last status %d", funcName, status))
+		return callErr == nil && status >= 200 && status < 300, nil
+	})
+	progress.Stop()
+	if err == wait.ErrWaitTimeout {
+		if status == 0 {
+			return fmt.Errorf("The function %s didn't respond after %v: %v", funcName, timeout, callErr)
+		}
+		return fmt.Errorf("The function %s didn't respond successfully after %v. Last response (status %d):\n%s", funcName, timeout, status, string(body))
+	}
+	return err
+}
+
+// verifyDeployedFunction calls a function just deployed with verifyFunctionCall
+func verifyDeployedFunction(cli kubernetes.Interface, ns, funcName, data string, timeout time.Duration) error {
+	svc, err := cli.CoreV1().Services(ns).Get(funcName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Unable to find the service for %s: %v", funcName, err)
+	}
+	return verifyFunctionCall(cli.CoreV1().RESTClient(), ns, funcName, getServicePort(svc), data, timeout)
+}
+
 func init() {
 	callCmd.Flags().StringP("data", "d", "", "Specify data for function")
 	callCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function")
+	callCmd.Flags().Bool("trace", false, "Send W3C trace context headers. The TRACEPARENT and TRACESTATE env vars are propagated if present, otherwise a new trace is generated")
+	callCmd.Flags().Duration("timeout", 0, "Maximum time to wait for the response of each request (e.g. 10s). Zero means no timeout")
+	callCmd.Flags().Int("requests", 1, "Number of requests to send. With more than one request, a summary of the results is printed instead of the response")
+	callCmd.Flags().Int("concurrency", 1, "Maximum number of requests in flight with --requests")
+	callCmd.Flags().Bool("debug-http", false, "Log the request sent to the function and its response, including headers and bodies, to stderr")
+	callCmd.Flags().StringSlice("redact-header", defaultRedactedHeaders, "Headers whose value is hidden in the output of --debug-http")
+
+}
+
+var traceparentRegex = regexp.MustCompile("^[0-9a-f]{2}-[0-9a-f]{32}-[0-9a-f]{16}-[0-9a-f]{2}$")
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// getTraceHeaders returns the W3C traceparent and tracestate headers for a call.
+// The given parent is propagated if it is valid, if not, a new sampled trace is started.
+func getTraceHeaders(parent, state string) (string, string, error) {
+	if traceparentRegex.MatchString(parent) {
+		return parent, state, nil
+	}
+	if parent != "" {
+		logrus.Warnf("Ignoring invalid traceparent %q", parent)
+	}
+	traceID, err := randomHex(16)
+	if err != nil {
+		return "", "", err
+	}
+	spanID, err := randomHex(8)
+	if err != nil {
+		return "", "", err
+	}
+	return fmt.Sprintf("00-%s-%s-01", traceID, spanID), "kubeless=" + spanID, nil
+}
+
+// defaultRedactedHeaders are the headers hidden by --debug-http unless told otherwise
+var defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// debugHTTPTransport writes every request and response going through it, curl style:
+// lines starting with ">" are sent and lines starting with "<" are received.
+type debugHTTPTransport struct {
+	rt       http.RoundTripper
+	out      io.Writer
+	redacted map[string]bool
+}
+
+func newDebugHTTPTransport(rt http.RoundTripper, out io.Writer, redactedHeaders []string) *debugHTTPTransport {
+	redacted := map[string]bool{}
+	for _, h := range redactedHeaders {
+		redacted[http.CanonicalHeaderKey(strings.TrimSpace(h))] = true
+	}
+	return &debugHTTPTransport{rt: rt, out: out, redacted: redacted}
+}
+
+// RoundTrip logs the request, sends it and logs the response. Bodies are read
+// in full and replaced so the caller still receives them.
+func (t *debugHTTPTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	fmt.Fprintf(t.out, "> %s %s %s\n", req.Method, req.URL.String(), req.Proto)
+	t.writeHeaders(">", req.Header)
+	t.writeBody(">", body)
+
+	start := time.Now()
+	res, err := t.rt.RoundTrip(req)
+	if err != nil {
+		fmt.Fprintf(t.out, "* Request failed after %v: %v\n", time.Since(start), err)
+		return nil, err
+	}
+	body, err = ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	fmt.Fprintf(t.out, "< %s %s (%v)\n", res.Proto, res.Status, time.Since(start))
+	t.writeHeaders("<", res.Header)
+	t.writeBody("<", body)
+	return res, nil
+}
+
+func (t *debugHTTPTransport) writeHeaders(prefix string, headers http.Header) {
+	names := []string{}
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range headers[name] {
+			if t.redacted[http.CanonicalHeaderKey(name)] {
+				value = "[REDACTED]"
+			}
+			fmt.Fprintf(t.out, "%s %s: %s\n", prefix, name, value)
+		}
+	}
+	fmt.Fprintf(t.out, "%s\n", prefix)
+}
 
+func (t *debugHTTPTransport) writeBody(prefix string, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(string(body), "\n"), "\n") {
+		fmt.Fprintf(t.out, "%s %s\n", prefix, line)
+	}
 }
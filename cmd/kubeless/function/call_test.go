@@ -0,0 +1,155 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/scheme"
+	restFake "k8s.io/client-go/rest/fake"
+)
+
+func TestGetTraceHeaders(t *testing.T) {
+	// It should generate a new trace
+	traceparent, tracestate, err := getTraceHeaders("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !traceparentRegex.MatchString(traceparent) {
+		t.Errorf("Invalid traceparent %s", traceparent)
+	}
+	if tracestate == "" {
+		t.Error("Expecting a tracestate for a new trace")
+	}
+
+	// It should propagate a valid parent
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	traceparent, tracestate, err = getTraceHeaders(parent, "foo=bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if traceparent != parent || tracestate != "foo=bar" {
+		t.Errorf("Expecting %s and foo=bar, got %s and %s", parent, traceparent, tracestate)
+	}
+
+	// It should ignore an invalid parent
+	traceparent, _, err = getTraceHeaders("invalid", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if traceparent == "invalid" || !traceparentRegex.MatchString(traceparent) {
+		t.Errorf("Expecting a new traceparent, got %s", traceparent)
+	}
+}
+
+func fakeFunctionRESTClient(status int, body string, requests *[]*http.Request) *restFake.RESTClient {
+	return &restFake.RESTClient{
+		NegotiatedSerializer: scheme.Codecs,
+		Client: restFake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			*requests = append(*requests, req)
+			return &http.Response{
+				StatusCode: status,
+				Header:     http.Header{},
+				Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+			}, nil
+		}),
+	}
+}
+
+func TestVerifyFunctionCall(t *testing.T) {
+	requests := []*http.Request{}
+	client := fakeFunctionRESTClient(http.StatusOK, "hello", &requests)
+	if err := verifyFunctionCall(client, "myns", "foo", "http-function-port", `{"hello": "world"}`, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 || requests[0].Method != "POST" {
+		t.Fatalf("Expecting a single POST request, got %v", requests)
+	}
+	if !strings.Contains(requests[0].URL.Path, "/namespaces/myns/services/foo:http-function-port/proxy") {
+		t.Errorf("Expecting a request to the proxy of the service, got %s", requests[0].URL.Path)
+	}
+
+	// Without data the function is called with a GET and errors include the last response
+	requests = []*http.Request{}
+	client = fakeFunctionRESTClient(http.StatusInternalServerError, "something failed", &requests)
+	err := verifyFunctionCall(client, "myns", "foo", "8080", "", 10*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "status 500") || !strings.Contains(err.Error(), "something failed") {
+		t.Errorf("Expecting an error with the last response, got %v", err)
+	}
+	if len(requests) == 0 || requests[0].Method != "GET" {
+		t.Errorf("Expecting GET requests, got %v", requests)
+	}
+}
+
+func TestDebugHTTPTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("X-Echo", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(append([]byte("got "), body...))
+	}))
+	defer server.Close()
+
+	out := &bytes.Buffer{}
+	client := &http.Client{Transport: newDebugHTTPTransport(http.DefaultTransport, out, []string{"authorization", " set-cookie"})}
+	req, err := http.NewRequest("POST", server.URL+"/foo", strings.NewReader("hello\nworld"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("Content-Type", "text/plain")
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	// The caller still receives the full response
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "got hello\nworld" {
+		t.Errorf("Unexpected response body %q", string(body))
+	}
+
+	log := out.String()
+	for _, expected := range []string{
+		"> POST " + server.URL + "/foo HTTP/1.1\n",
+		"> Authorization: [REDACTED]\n",
+		"> Content-Type: text/plain\n",
+		"> hello\n> world\n",
+		"< HTTP/1.1 201 Created",
+		"< Set-Cookie: [REDACTED]\n",
+		"< X-Echo: yes\n",
+		"< got hello\n< world\n",
+	} {
+		if !strings.Contains(log, expected) {
+			t.Errorf("Expecting %q in the output, got:\n%s", expected, log)
+		}
+	}
+	if strings.Contains(log, "Bearer token") || strings.Contains(log, "session=secret") {
+		t.Errorf("Redacted headers found in the output:\n%s", log)
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"fmt"
+	"strconv"
+
+	httpApi "github.com/kubeless/http-trigger/pkg/apis/kubeless/v1beta1"
+	httpClientset "github.com/kubeless/http-trigger/pkg/client/clientset/versioned"
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	"github.com/kubeless/kubeless/pkg/client/clientset/versioned"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	canarySuffix = "-canary"
+	// canaryLabel is set in the canary function and triggers with the name of the stable function
+	canaryLabel = "canary-of"
+
+	nginxCanaryAnnotation       = "nginx.ingress.kubernetes.io/canary"
+	nginxCanaryWeightAnnotation = "nginx.ingress.kubernetes.io/canary-weight"
+)
+
+func getCanaryName(name string) string {
+	return name + canarySuffix
+}
+
+func validateCanaryWeight(weight int) error {
+	if weight < 0 || weight > 100 {
+		return fmt.Errorf("Invalid canary weight %d. It should be between 0 and 100", weight)
+	}
+	return nil
+}
+
+// getHTTPTriggers returns the HTTP triggers that expose the given function
+func getHTTPTriggers(httpClient httpClientset.Interface, ns, funcName string) ([]*httpApi.HTTPTrigger, error) {
+	list, err := httpClient.KubelessV1beta1().HTTPTriggers(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	triggers := []*httpApi.HTTPTrigger{}
+	for _, t := range list.Items {
+		if t.Spec.FunctionName == funcName {
+			triggers = append(triggers, t)
+		}
+	}
+	return triggers, nil
+}
+
+// getCanaryHTTPTrigger returns a trigger with the same host and path than the stable one
+// that sends the given percentage of the traffic to the canary function
+func getCanaryHTTPTrigger(stable *httpApi.HTTPTrigger, canaryFunction string, weight int) (*httpApi.HTTPTrigger, error) {
+	if stable.Spec.Gateway != "" && stable.Spec.Gateway != "nginx" {
+		return nil, fmt.Errorf("The HTTP trigger %s uses the %s gateway. Canary deployments are only supported with nginx", stable.Name, stable.Spec.Gateway)
+	}
+	canary := &httpApi.HTTPTrigger{
+		TypeMeta: stable.TypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getCanaryName(stable.Name),
+			Namespace: stable.Namespace,
+			Labels: map[string]string{
+				"created-by": "kubeless",
+				canaryLabel:  stable.Spec.FunctionName,
+			},
+			Annotations: map[string]string{},
+		},
+		Spec: stable.Spec,
+	}
+	for k, v := range stable.ObjectMeta.Annotations {
+		canary.ObjectMeta.Annotations[k] = v
+	}
+	canary.ObjectMeta.Annotations[nginxCanaryAnnotation] = "true"
+	canary.ObjectMeta.Annotations[nginxCanaryWeightAnnotation] = strconv.Itoa(weight)
+	canary.Spec.FunctionName = canaryFunction
+	return canary, nil
+}
+
+// deployCanary creates or updates the canary function of stableName and
+// the HTTP triggers that split the traffic between both functions
+func deployCanary(kubelessClient versioned.Interface, httpClient httpClientset.Interface, canary *kubelessApi.Function, stableName string, weight int) error {
+	ns := canary.Namespace
+	if _, err := kubelessClient.KubelessV1beta1().Functions(ns).Get(stableName, metav1.GetOptions{}); err != nil {
+		return fmt.Errorf("Unable to find the function %s in namespace %s: %v", stableName, ns, err)
+	}
+	stableTriggers, err := getHTTPTriggers(httpClient, ns, stableName)
+	if err != nil {
+		return err
+	}
+	if len(stableTriggers) == 0 {
+		return fmt.Errorf("The function %s is not exposed by any HTTP trigger. A canary requires an HTTP trigger to split the traffic", stableName)
+	}
+	canaryTriggers := []*httpApi.HTTPTrigger{}
+	for _, t := range stableTriggers {
+		canaryTrigger, err := getCanaryHTTPTrigger(t, canary.Name, weight)
+		if err != nil {
+			return err
+		}
+		canaryTriggers = append(canaryTriggers, canaryTrigger)
+	}
+
+	current, err := kubelessClient.KubelessV1beta1().Functions(ns).Get(canary.Name, metav1.GetOptions{})
+	if err == nil {
+		canary.ResourceVersion = current.ResourceVersion
+		_, err = kubelessClient.KubelessV1beta1().Functions(ns).Update(canary)
+	} else if k8sErrors.IsNotFound(err) {
+		_, err = kubelessClient.KubelessV1beta1().Functions(ns).Create(canary)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to deploy the canary %s: %v", canary.Name, err)
+	}
+
+	for _, t := range canaryTriggers {
+		current, err := httpClient.KubelessV1beta1().HTTPTriggers(ns).Get(t.Name, metav1.GetOptions{})
+		if err == nil {
+			t.ResourceVersion = current.ResourceVersion
+			_, err = httpClient.KubelessV1beta1().HTTPTriggers(ns).Update(t)
+		} else if k8sErrors.IsNotFound(err) {
+			_, err = httpClient.KubelessV1beta1().HTTPTriggers(ns).Create(t)
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to deploy the HTTP trigger %s: %v", t.Name, err)
+		}
+	}
+	return nil
+}
+
+// promoteCanary replaces the stable function with its canary and removes the canary
+// function and HTTP triggers so all the traffic goes to the stable function
+func promoteCanary(kubelessClient versioned.Interface, httpClient httpClientset.Interface, ns, stableName string) error {
+	canaryName := getCanaryName(stableName)
+	canary, err := kubelessClient.KubelessV1beta1().Functions(ns).Get(canaryName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Unable to find the canary %s in namespace %s: %v", canaryName, ns, err)
+	}
+	stable, err := kubelessClient.KubelessV1beta1().Functions(ns).Get(stableName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Unable to find the function %s in namespace %s: %v", stableName, ns, err)
+	}
+
+	// The service of the stable function should keep selecting its own pods
+	selector := stable.Spec.ServiceSpec.Selector
+	stable.Spec = canary.Spec
+	stable.Spec.ServiceSpec.Selector = selector
+	if _, err := kubelessClient.KubelessV1beta1().Functions(ns).Update(stable); err != nil {
+		return fmt.Errorf("Failed to update the function %s: %v", stableName, err)
+	}
+
+	triggers, err := getHTTPTriggers(httpClient, ns, canaryName)
+	if err != nil {
+		return err
+	}
+	for _, t := range triggers {
+		err := httpClient.KubelessV1beta1().HTTPTriggers(ns).Delete(t.Name, &metav1.DeleteOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return fmt.Errorf("Failed to delete the HTTP trigger %s: %v", t.Name, err)
+		}
+	}
+	err = kubelessClient.KubelessV1beta1().Functions(ns).Delete(canaryName, &metav1.DeleteOptions{})
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return fmt.Errorf("Failed to delete the canary %s: %v", canaryName, err)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"testing"
+
+	httpApi "github.com/kubeless/http-trigger/pkg/apis/kubeless/v1beta1"
+	httpFake "github.com/kubeless/http-trigger/pkg/client/clientset/versioned/fake"
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	fFake "github.com/kubeless/kubeless/pkg/client/clientset/versioned/fake"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newCanaryTestFunction(name, handler string) *kubelessApi.Function {
+	return &kubelessApi.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "myns",
+			Labels:    map[string]string{"function": name},
+		},
+		Spec: kubelessApi.FunctionSpec{
+			Handler: handler,
+			Runtime: "python2.7",
+			ServiceSpec: v1.ServiceSpec{
+				Selector: map[string]string{"function": name},
+			},
+		},
+	}
+}
+
+func TestValidateCanaryWeight(t *testing.T) {
+	for _, w := range []int{0, 10, 100} {
+		if err := validateCanaryWeight(w); err != nil {
+			t.Errorf("Unexpected error for %d: %v", w, err)
+		}
+	}
+	for _, w := range []int{-1, 101} {
+		if err := validateCanaryWeight(w); err == nil {
+			t.Errorf("Expecting an error for %d", w)
+		}
+	}
+}
+
+func TestDeployCanary(t *testing.T) {
+	stable := newCanaryTestFunction("foo", "foo.v1")
+	trigger := &httpApi.HTTPTrigger{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foo",
+			Namespace:   "myns",
+			Annotations: map[string]string{"nginx.ingress.kubernetes.io/enable-cors": "true"},
+		},
+		Spec: httpApi.HTTPTriggerSpec{
+			FunctionName: "foo",
+			HostName:     "foo.example.com",
+			Path:         "foo",
+			Gateway:      "nginx",
+		},
+	}
+	kubelessClient := fFake.NewSimpleClientset(stable)
+	httpClient := httpFake.NewSimpleClientset(trigger)
+
+	canary := newCanaryTestFunction("foo-canary", "foo.v2")
+	if err := deployCanary(kubelessClient, httpClient, canary, "foo", 20); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kubelessClient.KubelessV1beta1().Functions("myns").Get("foo-canary", metav1.GetOptions{}); err != nil {
+		t.Fatalf("Expecting the canary function to be created: %v", err)
+	}
+	canaryTrigger, err := httpClient.KubelessV1beta1().HTTPTriggers("myns").Get("foo-canary", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expecting the canary trigger to be created: %v", err)
+	}
+	if canaryTrigger.Spec.FunctionName != "foo-canary" || canaryTrigger.Spec.HostName != "foo.example.com" || canaryTrigger.Spec.Path != "foo" {
+		t.Errorf("Unexpected canary trigger spec %+v", canaryTrigger.Spec)
+	}
+	annotations := canaryTrigger.ObjectMeta.Annotations
+	if annotations[nginxCanaryAnnotation] != "true" || annotations[nginxCanaryWeightAnnotation] != "20" || annotations["nginx.ingress.kubernetes.io/enable-cors"] != "true" {
+		t.Errorf("Unexpected canary trigger annotations %v", annotations)
+	}
+
+	// Deploying again updates the weight
+	if err := deployCanary(kubelessClient, httpClient, newCanaryTestFunction("foo-canary", "foo.v2"), "foo", 50); err != nil {
+		t.Fatal(err)
+	}
+	canaryTrigger, err = httpClient.KubelessV1beta1().HTTPTriggers("myns").Get("foo-canary", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if canaryTrigger.ObjectMeta.Annotations[nginxCanaryWeightAnnotation] != "50" {
+		t.Errorf("Expecting the weight to be updated, got %v", canaryTrigger.ObjectMeta.Annotations)
+	}
+}
+
+func TestDeployCanaryRequiresHTTPTrigger(t *testing.T) {
+	kubelessClient := fFake.NewSimpleClientset(newCanaryTestFunction("foo", "foo.v1"))
+	httpClient := httpFake.NewSimpleClientset()
+	if err := deployCanary(kubelessClient, httpClient, newCanaryTestFunction("foo-canary", "foo.v2"), "foo", 10); err == nil {
+		t.Error("Expecting an error for a function without HTTP trigger")
+	}
+
+	httpClient = httpFake.NewSimpleClientset(&httpApi.HTTPTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns"},
+		Spec:       httpApi.HTTPTriggerSpec{FunctionName: "foo", Gateway: "kong"},
+	})
+	if err := deployCanary(kubelessClient, httpClient, newCanaryTestFunction("foo-canary", "foo.v2"), "foo", 10); err == nil {
+		t.Error("Expecting an error for a gateway without canary support")
+	}
+}
+
+func TestPromoteCanary(t *testing.T) {
+	kubelessClient := fFake.NewSimpleClientset(newCanaryTestFunction("foo", "foo.v1"), newCanaryTestFunction("foo-canary", "foo.v2"))
+	httpClient := httpFake.NewSimpleClientset(
+		&httpApi.HTTPTrigger{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns"},
+			Spec:       httpApi.HTTPTriggerSpec{FunctionName: "foo"},
+		},
+		&httpApi.HTTPTrigger{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo-canary", Namespace: "myns"},
+			Spec:       httpApi.HTTPTriggerSpec{FunctionName: "foo-canary"},
+		},
+	)
+	if err := promoteCanary(kubelessClient, httpClient, "myns", "foo"); err != nil {
+		t.Fatal(err)
+	}
+	stable, err := kubelessClient.KubelessV1beta1().Functions("myns").Get("foo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stable.Spec.Handler != "foo.v2" {
+		t.Errorf("Expecting the stable function to use the canary spec, got handler %s", stable.Spec.Handler)
+	}
+	if stable.Spec.ServiceSpec.Selector["function"] != "foo" {
+		t.Errorf("Expecting the service selector to be kept, got %v", stable.Spec.ServiceSpec.Selector)
+	}
+	if _, err := kubelessClient.KubelessV1beta1().Functions("myns").Get("foo-canary", metav1.GetOptions{}); err == nil {
+		t.Error("Expecting the canary function to be deleted")
+	}
+	if _, err := httpClient.KubelessV1beta1().HTTPTriggers("myns").Get("foo-canary", metav1.GetOptions{}); err == nil {
+		t.Error("Expecting the canary trigger to be deleted")
+	}
+	if _, err := httpClient.KubelessV1beta1().HTTPTriggers("myns").Get("foo", metav1.GetOptions{}); err != nil {
+		t.Error("Expecting the stable trigger to be kept")
+	}
+}
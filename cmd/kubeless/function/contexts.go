@@ -0,0 +1,133 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	kubelessutil "github.com/kubeless/kubeless/pkg/utils"
+)
+
+// contextResult is the result of running a command in a kubeconfig context
+type contextResult struct {
+	Context string
+	Err     error
+	Skipped bool
+}
+
+// multiContextFlags are the flags that select the contexts. They are removed from the
+// arguments given to the command run in each context.
+var multiContextFlags = map[string]bool{"contexts": true, "fail-fast": false}
+
+// runInContexts calls run for each context, in order. Failures don't stop the rest of
+// contexts unless failFast is true, in which case the remaining ones are skipped.
+func runInContexts(contexts []string, failFast bool, run func(context string) error) []contextResult {
+	results := []contextResult{}
+	failed := false
+	for _, context := range contexts {
+		if failed && failFast {
+			results = append(results, contextResult{Context: context, Skipped: true})
+			continue
+		}
+		err := run(context)
+		if err != nil {
+			failed = true
+		}
+		results = append(results, contextResult{Context: context, Err: err})
+	}
+	return results
+}
+
+// getSingleContextArgs removes the multi-context flags from the arguments of the command.
+// Flags with a value are removed both as "--flag value" and as "--flag=value".
+func getSingleContextArgs(args []string) []string {
+	result := []string{}
+	for i := 0; i < len(args); i++ {
+		name := strings.SplitN(strings.TrimPrefix(args[i], "--"), "=", 2)[0]
+		hasValue, ok := multiContextFlags[name]
+		if !strings.HasPrefix(args[i], "--") || !ok {
+			result = append(result, args[i])
+			continue
+		}
+		if hasValue && !strings.Contains(args[i], "=") {
+			// Skip the value too
+			i++
+		}
+	}
+	return result
+}
+
+// getSingleContextEnv removes the variables that would set the multi-context flags
+// from the environment, so the command run in each context doesn't run them again
+func getSingleContextEnv(environ []string, context string) []string {
+	result := []string{}
+	for _, env := range environ {
+		name := strings.SplitN(env, "=", 2)[0]
+		skip := name == kubelessutil.ContextEnv
+		for flag := range multiContextFlags {
+			if name == kubelessutil.FlagEnvName(flag) {
+				skip = true
+			}
+		}
+		if !skip {
+			result = append(result, env)
+		}
+	}
+	return append(result, kubelessutil.ContextEnv+"="+context)
+}
+
+// runCommandInContexts runs the current command once per context, without the multi-context
+// flags, and prints the result of each context. It returns false if any of them failed.
+func runCommandInContexts(contexts []string, failFast bool, out io.Writer) bool {
+	args := getSingleContextArgs(os.Args[1:])
+	results := runInContexts(contexts, failFast, func(context string) error {
+		fmt.Fprintf(out, "==> %s\n", context)
+		cmd := exec.Command(os.Args[0], args...)
+		cmd.Env = getSingleContextEnv(os.Environ(), context)
+		cmd.Stdout = out
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	})
+	return printContextResults(out, results)
+}
+
+// printContextResults prints a table with the result of each context. It returns false if any of them failed.
+func printContextResults(w io.Writer, results []contextResult) bool {
+	table := uitable.New()
+	table.MaxColWidth = 80
+	table.Wrap = true
+	table.AddRow("CONTEXT", "RESULT")
+	ok := true
+	for _, r := range results {
+		result := "OK"
+		if r.Skipped {
+			result = "SKIPPED"
+			ok = false
+		} else if r.Err != nil {
+			result = fmt.Sprintf("FAILED: %v", r.Err)
+			ok = false
+		}
+		table.AddRow(r.Context, result)
+	}
+	fmt.Fprintln(w, table)
+	return ok
+}
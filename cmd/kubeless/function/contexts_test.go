@@ -0,0 +1,68 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunInContexts(t *testing.T) {
+	run := func(context string) error {
+		if context == "broken" {
+			return fmt.Errorf("exit status 1")
+		}
+		return nil
+	}
+	results := runInContexts([]string{"staging", "broken", "production"}, false, run)
+	if len(results) != 3 || results[1].Err == nil || results[2].Err != nil || results[2].Skipped {
+		t.Errorf("Expecting to continue after the failure, got %+v", results)
+	}
+	results = runInContexts([]string{"staging", "broken", "production"}, true, run)
+	if !results[2].Skipped {
+		t.Errorf("Expecting production to be skipped with fail fast, got %+v", results)
+	}
+
+	out := &bytes.Buffer{}
+	if printContextResults(out, results) {
+		t.Error("Expecting a failed result")
+	}
+	for _, expected := range []string{"FAILED: exit status 1", "SKIPPED"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expecting %q in the output, got %s", expected, out.String())
+		}
+	}
+}
+
+func TestGetSingleContextArgs(t *testing.T) {
+	args := []string{"function", "deploy", "hello", "--contexts", "a,b", "--runtime=python3.8", "--fail-fast", "--contexts=c", "-f", "hello.py"}
+	expected := []string{"function", "deploy", "hello", "--runtime=python3.8", "-f", "hello.py"}
+	if result := getSingleContextArgs(args); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expecting %v, got %v", expected, result)
+	}
+}
+
+func TestGetSingleContextEnv(t *testing.T) {
+	env := getSingleContextEnv([]string{"HOME=/root", "KUBELESS_CONTEXTS=a,b", "KUBELESS_FAIL_FAST=true", "KUBELESS_CONTEXT=old"}, "b")
+	expected := []string{"HOME=/root", "KUBELESS_CONTEXT=b"}
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("Expecting %v, got %v", expected, env)
+	}
+}
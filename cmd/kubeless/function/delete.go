@@ -17,9 +17,12 @@ limitations under the License.
 package function
 
 import (
+	"time"
+
 	"github.com/kubeless/kubeless/pkg/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var deleteCmd = &cobra.Command{
@@ -40,6 +43,15 @@ var deleteCmd = &cobra.Command{
 			ns = utils.GetDefaultNamespace()
 		}
 
+		wait, err := cmd.Flags().GetBool("wait")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
 		kubelessClient, err := utils.GetKubelessClientOutCluster()
 		if err != nil {
 			logrus.Fatal(err)
@@ -49,9 +61,22 @@ var deleteCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatal(err)
 		}
+
+		if wait {
+			logrus.Infof("Waiting for function %s to be deleted...", funcName)
+			err = utils.WaitForDeletion("function", funcName, timeout, func() (metav1.Object, error) {
+				return kubelessClient.KubelessV1beta1().Functions(ns).Get(funcName, metav1.GetOptions{})
+			})
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			logrus.Infof("Function %s deleted", funcName)
+		}
 	},
 }
 
 func init() {
 	deleteCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function")
+	deleteCmd.Flags().Bool("wait", false, "Wait until the function and its finalizers are completely removed")
+	deleteCmd.Flags().Duration("timeout", time.Minute, "Maximum time to wait with --wait")
 }
@@ -17,11 +17,10 @@ limitations under the License.
 package function
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
-	"github.com/ghodss/yaml"
 	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
 	cronjobUtils "github.com/kubeless/cronjob-trigger/pkg/utils"
 	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
@@ -38,6 +37,53 @@ var deployCmd = &cobra.Command{
 	Short: "deploy a function to Kubeless",
 	Long:  `deploy a function to Kubeless`,
 	Run: func(cmd *cobra.Command, args []string) {
+		contexts, err := cmd.Flags().GetStringSlice("contexts")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		failFast, err := cmd.Flags().GetBool("fail-fast")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if len(contexts) > 0 {
+			if !runCommandInContexts(contexts, failFast, cmd.OutOrStdout()) {
+				logrus.Fatal("The function couldn't be deployed in every context")
+			}
+			return
+		}
+		if failFast {
+			logrus.Fatal("The flag --fail-fast requires --contexts")
+		}
+
+		fromSpec, err := cmd.Flags().GetString("from-spec")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if fromSpec != "" {
+			if kubelessutil.IsFlagGiven(cmd.Flags(), "wait") || kubelessutil.IsFlagGiven(cmd.Flags(), "reconcile-timeout") || kubelessutil.IsFlagGiven(cmd.Flags(), "verify-call") {
+				logrus.Fatal("The flags --wait and --verify-call can't be used with --from-spec")
+			}
+			deployFromSpec(cmd, args, fromSpec)
+			return
+		}
+		if kubelessutil.IsFlagGiven(cmd.Flags(), "set") {
+			logrus.Fatal("The flag --set requires --from-spec")
+		}
+		configFile, err := cmd.Flags().GetString("config-from-file")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if configFile != "" {
+			if kubelessutil.IsFlagGiven(cmd.Flags(), "wait") || kubelessutil.IsFlagGiven(cmd.Flags(), "reconcile-timeout") || kubelessutil.IsFlagGiven(cmd.Flags(), "verify-call") {
+				logrus.Fatal("The flags --wait and --verify-call can't be used with --config-from-file")
+			}
+			deployFromConfigFile(cmd, args, configFile)
+			return
+		}
+		if kubelessutil.IsFlagGiven(cmd.Flags(), "name") {
+			logrus.Fatal("The flag --name requires --config-from-file")
+		}
+
 		cli := kubelessutil.GetClientOutOfCluster()
 		apiExtensionsClientset := kubelessutil.GetAPIExtensionsClientOutOfCluster()
 
@@ -52,7 +98,7 @@ var deployCmd = &cobra.Command{
 		}
 
 		// Checking runtime parameter if allowed by RBAC, otherwide skip the check
-		config, err := kubelessutil.GetKubelessConfig(cli, apiExtensionsClientset)
+		config, err := kubelessutil.GetCachedKubelessConfig(cli, apiExtensionsClientset)
 		if config == nil || err != nil {
 			logrus.Warnf("%v. Runtime check is disabled.", err)
 		} else {
@@ -86,6 +132,47 @@ var deployCmd = &cobra.Command{
 			logrus.Fatal(err)
 		}
 
+		envFiles, err := cmd.Flags().GetStringSlice("env-from-file")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		decryptCmd, err := cmd.Flags().GetString("decrypt-cmd")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if decryptCmd != "" && len(envFiles) == 0 {
+			logrus.Fatal("The flag --decrypt-cmd requires at least one --env-from-file")
+		}
+		fileEnvs, err := parseEnvFromFile(envFiles, decryptCmd)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		envs = append(envs, fileEnvs...)
+
+		otelEndpoint, err := cmd.Flags().GetString("otel-endpoint")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		otelServiceName, err := cmd.Flags().GetString("otel-service-name")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		otelEnvs, err := getOTelEnv(otelEndpoint, otelServiceName, funcName)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		envs = append(envs, otelEnvs...)
+
+		buildArgs, err := cmd.Flags().GetStringArray("build-arg")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		buildEnv, err := parseBuildArgs(buildArgs)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
 		handler, err := cmd.Flags().GetString("handler")
 		if err != nil {
 			logrus.Fatal(err)
@@ -126,6 +213,15 @@ var deployCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatal(err)
 		}
+		requireDigest, err := cmd.Flags().GetBool("require-digest")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if runtimeImage != "" {
+			if err := validateRuntimeImage(runtimeImage, requireDigest); err != nil {
+				logrus.Fatal(err)
+			}
+		}
 
 		imagePullPolicy, err := cmd.Flags().GetString("image-pull-policy")
 		if err != nil {
@@ -151,6 +247,20 @@ var deployCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatal(err)
 		}
+		if kubelessutil.IsFlagGiven(cmd.Flags(), "function-timeout") && kubelessutil.IsFlagGiven(cmd.Flags(), "timeout") {
+			logrus.Fatal("The flags --timeout and --function-timeout can't be used together")
+		}
+		// A flag given in the command line wins over the default of the other one
+		if kubelessutil.IsFlagGiven(cmd.Flags(), "function-timeout") || (cmd.Flags().Changed("function-timeout") && !kubelessutil.IsFlagGiven(cmd.Flags(), "timeout")) {
+			functionTimeout, err := cmd.Flags().GetString("function-timeout")
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			timeout, err = parseFunctionTimeout(functionTimeout)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+		}
 
 		output, err := cmd.Flags().GetString("output")
 		if err != nil {
@@ -162,10 +272,39 @@ var deployCmd = &cobra.Command{
 			logrus.Fatal(err)
 		}
 
+		waitRollout, err := cmd.Flags().GetBool("wait")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		reconcileTimeout, err := cmd.Flags().GetDuration("reconcile-timeout")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		verifyCall, err := cmd.Flags().GetBool("verify-call")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		verifyData, err := cmd.Flags().GetString("verify-data")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if kubelessutil.IsFlagGiven(cmd.Flags(), "verify-data") && !verifyCall {
+			logrus.Fatal("The flag --verify-data can only be used with --verify-call")
+		}
+		// The function can only be called once it's ready
+		waitRollout = waitRollout || verifyCall
+
 		dryrun, err := cmd.Flags().GetBool("dryrun")
 		if err != nil {
 			logrus.Fatal(err)
 		}
+		validatePolicy, err := cmd.Flags().GetString("validate-policy")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if validatePolicy != "" && !dryrun {
+			logrus.Fatal("--validate-policy can only be used with --dryrun")
+		}
 
 		port, err := cmd.Flags().GetInt32("port")
 		if err != nil {
@@ -200,7 +339,14 @@ var deployCmd = &cobra.Command{
 		}
 
 		if runtime != "" && handler == "" {
-			logrus.Fatal("You must specify handler for the runtime.")
+			handler, err = inferHandler(runtime, file)
+			if err != nil {
+				logrus.Fatalf("You must specify handler for the runtime. %v", err)
+			}
+			logrus.Warnf("No handler specified, using %s. Use --handler to set a different one", handler)
+		}
+		if runtimeImage != "" {
+			logrus.Warn(getRuntimeImageWarning(runtime, runtimeImage))
 		}
 
 		nodeSelectors, err := cmd.Flags().GetStringSlice("node-selectors")
@@ -208,41 +354,264 @@ var deployCmd = &cobra.Command{
 			logrus.Fatal(err)
 		}
 
+		podAnnotationFlags, err := cmd.Flags().GetStringArray("pod-annotation")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		podAnnotations, err := parsePodAnnotations(podAnnotationFlags)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		scaleToZero, err := cmd.Flags().GetBool("scale-to-zero")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		scaleToZeroIdle, err := cmd.Flags().GetDuration("scale-to-zero-idle")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		maxReplicas, err := cmd.Flags().GetInt32("max-replicas")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if !scaleToZero && (kubelessutil.IsFlagGiven(cmd.Flags(), "scale-to-zero-idle") || kubelessutil.IsFlagGiven(cmd.Flags(), "max-replicas")) {
+			logrus.Fatal("The flags --scale-to-zero-idle and --max-replicas require --scale-to-zero")
+		}
+		if scaleToZero {
+			if err := validateScaleToZeroIdle(scaleToZeroIdle); err != nil {
+				logrus.Fatal(err)
+			}
+			if maxReplicas < 1 {
+				logrus.Fatal("The value of --max-replicas should be at least 1")
+			}
+			logrus.Warn("Functions scaled to zero are started again on their first call, so that call waits for the function pod to be ready (cold start). Avoid --scale-to-zero for latency sensitive functions")
+			if scaleToZeroIdle != defaultScaleToZeroIdle {
+				logrus.Warnf("The autoscaler can't set the idle time of a single function, it uses the downscale stabilization window of the cluster (%v by default). The idle time is only stored in the annotation %s", defaultScaleToZeroIdle, scaleToZeroIdleAnnotation)
+			}
+		}
+
+		priorityClass, err := cmd.Flags().GetString("priority-class")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		strict, err := cmd.Flags().GetBool("strict")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if priorityClass != "" {
+			if err := validatePriorityClassName(priorityClass); err != nil {
+				logrus.Fatal(err)
+			}
+			if err := validatePriorityClass(cli, priorityClass); err != nil {
+				if strict {
+					logrus.Fatal(err)
+				}
+				logrus.Warnf("%v. The pods of the function will be rejected until it's created", err)
+			}
+		}
+
+		terminationGracePeriod, err := cmd.Flags().GetString("termination-grace-period")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		maxSurge, err := cmd.Flags().GetString("max-surge")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		maxUnavailable, err := cmd.Flags().GetString("max-unavailable")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		preStopExec, err := cmd.Flags().GetString("prestop-exec")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		securityContextFile, err := cmd.Flags().GetString("security-context-from-file")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		sidecarFiles, err := cmd.Flags().GetStringArray("sidecar-from-file")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		runAsNonRoot, err := cmd.Flags().GetBool("run-as-non-root")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		readOnlyRootFs, err := cmd.Flags().GetBool("read-only-root-fs")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		canary, err := cmd.Flags().GetBool("canary")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		canaryWeight, err := cmd.Flags().GetInt("canary-weight")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if kubelessutil.IsFlagGiven(cmd.Flags(), "canary-weight") && !canary {
+			logrus.Fatal("The flag --canary-weight requires --canary")
+		}
+		if canary {
+			if err := validateCanaryWeight(canaryWeight); err != nil {
+				logrus.Fatal(err)
+			}
+			if schedule != "" {
+				logrus.Fatal("The flags --canary and --schedule can't be used together")
+			}
+		}
+
+		// A canary is deployed as a separate function next to the stable one
+		deployName := funcName
+		if canary {
+			deployName = getCanaryName(funcName)
+		}
+
 		defaultFunctionSpec := kubelessApi.Function{}
 		defaultFunctionSpec.ObjectMeta.Labels = map[string]string{
 			"created-by": "kubeless",
-			"function":   funcName,
+			"function":   deployName,
+		}
+		if canary {
+			defaultFunctionSpec.ObjectMeta.Labels[canaryLabel] = funcName
+		}
+
+		gpus, err := cmd.Flags().GetInt64("gpu")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		gpuType, err := cmd.Flags().GetString("gpu-type")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if !cmd.Flags().Changed("gpu") && kubelessutil.IsFlagGiven(cmd.Flags(), "gpu-type") {
+			logrus.Fatal("The flag --gpu-type requires --gpu")
+		}
+		if cmd.Flags().Changed("gpu") {
+			if err := validateGPU(gpus, gpuType); err != nil {
+				logrus.Fatal(err)
+			}
+		}
+		kubelessClient, err := kubelessutil.GetKubelessClientOutCluster()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		envs, err = resolveFunctionRefs(kubelessClient, cli, ns, envs)
+		if err != nil {
+			logrus.Fatal(err)
 		}
 
-		f, err := getFunctionDescription(funcName, ns, handler, file, funcDeps, runtime, runtimeImage, mem, cpu, timeout, imagePullPolicy, serviceAccount, port, servicePort, headless, envs, labels, secrets, nodeSelectors, defaultFunctionSpec)
+		f, err := getFunctionDescription(deployName, ns, handler, file, funcDeps, runtime, runtimeImage, mem, cpu, timeout, imagePullPolicy, serviceAccount, port, servicePort, headless, envs, labels, secrets, nodeSelectors, defaultFunctionSpec)
 		if err != nil {
 			logrus.Fatal(err)
 		}
+		if requireDigest {
+			// Without --runtime-image the image is the one of the runtime or the current one
+			if err := validateRuntimeImage(f.Spec.Deployment.Spec.Template.Spec.Containers[0].Image, true); err != nil {
+				logrus.Fatal(err)
+			}
+		}
+
+		setBuildEnv(f, buildEnv)
+		setPodAnnotations(f, podAnnotations)
+		if priorityClass != "" {
+			f.Spec.Deployment.Spec.Template.Spec.PriorityClassName = priorityClass
+		}
+		if scaleToZero {
+			f.Spec.HorizontalPodAutoscaler = getScaleToZeroAutoscaler(deployName, ns, maxReplicas, f.ObjectMeta.Labels)
+			if f.ObjectMeta.Annotations == nil {
+				f.ObjectMeta.Annotations = map[string]string{}
+			}
+			f.ObjectMeta.Annotations[scaleToZeroIdleAnnotation] = scaleToZeroIdle.String()
+		}
+
+		if terminationGracePeriod != "" {
+			gracePeriod, err := parseGracePeriod(terminationGracePeriod)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			f.Spec.Deployment.Spec.Template.Spec.TerminationGracePeriodSeconds = &gracePeriod
+		}
+
+		if cmd.Flags().Changed("prestop-exec") {
+			lifecycle, err := getPreStopHook(preStopExec)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			f.Spec.Deployment.Spec.Template.Spec.Containers[0].Lifecycle = lifecycle
+		}
+
+		if securityContextFile != "" {
+			if err := setPodSecurityContext(f, securityContextFile); err != nil {
+				logrus.Fatal(err)
+			}
+		}
+
+		if err := addSidecars(f, sidecarFiles); err != nil {
+			logrus.Fatal(err)
+		}
+
+		if cmd.Flags().Changed("gpu") {
+			setGPU(f, gpus, gpuType)
+		}
+
+		if maxSurge != "" || maxUnavailable != "" {
+			if err := setRollingUpdate(f, maxSurge, maxUnavailable); err != nil {
+				logrus.Fatal(err)
+			}
+		}
+
+		if runAsNonRoot || readOnlyRootFs {
+			f.Spec.Deployment.Spec.Template.Spec.Containers[0].SecurityContext = getContainerSecurityContext(runAsNonRoot, readOnlyRootFs)
+		}
 
 		if dryrun == true {
-			if output == "json" {
-				j, err := json.MarshalIndent(f, "", "    ")
-				if err != nil {
-					logrus.Fatal(err)
-				}
-				fmt.Println(string(j[:]))
+			if output != "json" && output != "yaml" {
+				logrus.Infof("Output format needs to be yaml or json")
 				return
-			} else if output == "yaml" {
-				y, err := yaml.Marshal(f)
-				if err != nil {
+			}
+			// Every object created by the command, in the order they are created
+			objects := []interface{}{f}
+			if schedule != "" {
+				objects = append(objects, getScheduleTrigger(funcName, ns, schedule))
+			}
+			if validatePolicy != "" {
+				if err := kubelessutil.CheckPolicyFile(validatePolicy, objects...); err != nil {
 					logrus.Fatal(err)
 				}
-				fmt.Println(string(y[:]))
-				return
-			} else {
-				logrus.Infof("Output format needs to be yaml or json")
-				return
 			}
+			res, err := kubelessutil.DryRunFmtList(output, objects...)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			fmt.Println(res)
+			return
 		}
 
-		kubelessClient, err := kubelessutil.GetKubelessClientOutCluster()
-		if err != nil {
-			logrus.Fatal(err)
+		if canary {
+			httpClient, err := kubelessutil.GetHTTPTriggerClientOutCluster()
+			if err != nil {
+				logrus.Fatalf("Can not create out-of-cluster client: %v", err)
+			}
+			logrus.Infof("Deploying canary of %s...", funcName)
+			if err := deployCanary(kubelessClient, httpClient, f, funcName, canaryWeight); err != nil {
+				logrus.Fatal(err)
+			}
+			logrus.Infof("Canary %s submitted for deployment, receiving %d%% of the traffic", deployName, canaryWeight)
+			logrus.Infof("Promote it executing 'kubeless function promote %s%s'", funcName, nsArg)
+			return
 		}
 
 		logrus.Infof("Deploying function...")
@@ -251,29 +620,27 @@ var deployCmd = &cobra.Command{
 			logrus.Fatalf("Failed to deploy %s. Received:\n%s", funcName, err)
 		}
 		logrus.Infof("Function %s submitted for deployment", funcName)
-		logrus.Infof("Check the deployment status executing 'kubeless function ls %s%s'", funcName, nsArg)
+		if waitRollout {
+			if err := waitForRollout(cli, ns, funcName, reconcileTimeout); err != nil {
+				logrus.Fatal(err)
+			}
+			logrus.Infof("Function %s deployed and ready", funcName)
+			if verifyCall {
+				if err := verifyDeployedFunction(cli, ns, funcName, verifyData, reconcileTimeout); err != nil {
+					logrus.Fatal(err)
+				}
+				logrus.Infof("Function %s responded successfully", funcName)
+			}
+		} else {
+			logrus.Infof("Check the deployment status executing 'kubeless function ls %s%s'", funcName, nsArg)
+		}
 
 		if schedule != "" {
-			cronJobTrigger := cronjobApi.CronJobTrigger{}
-			cronJobTrigger.TypeMeta = metav1.TypeMeta{
-				Kind:       "CronJobTrigger",
-				APIVersion: "kubeless.io/v1beta1",
-			}
-			cronJobTrigger.ObjectMeta = metav1.ObjectMeta{
-				Name:      funcName,
-				Namespace: ns,
-			}
-			cronJobTrigger.ObjectMeta.Labels = map[string]string{
-				"created-by": "kubeless",
-				"function":   funcName,
-			}
-			cronJobTrigger.Spec.FunctionName = funcName
-			cronJobTrigger.Spec.Schedule = schedule
-			cronjobClient, err := cronjobUtils.GetKubelessClientOutCluster()
+			cronjobClient, err := kubelessutil.GetCronJobTriggerClientOutCluster()
 			if err != nil {
 				logrus.Fatal(err)
 			}
-			err = cronjobUtils.CreateCronJobCustomResource(cronjobClient, &cronJobTrigger)
+			err = cronjobUtils.CreateCronJobCustomResource(cronjobClient, getScheduleTrigger(funcName, ns, schedule))
 			if err != nil {
 				logrus.Fatalf("Failed to deploy cron job trigger %s. Received:\n%s", funcName, err)
 			}
@@ -283,24 +650,80 @@ var deployCmd = &cobra.Command{
 
 func init() {
 	deployCmd.Flags().StringP("runtime", "r", "", "Specify runtime")
-	deployCmd.Flags().StringP("handler", "", "", "Specify handler")
+	deployCmd.Flags().StringP("handler", "", "", "Specify handler. If not given, it's inferred from the name of the file, e.g. hello.handler for hello.py")
 	deployCmd.Flags().StringP("from-file", "f", "", "Specify code file or a URL to the code file")
+	deployCmd.Flags().StringP("from-spec", "", "", "Specify a Function manifest (YAML or JSON) to deploy instead of building it with the rest of flags")
+	deployCmd.Flags().StringArray("set", []string{}, "Override a field of the manifest given in --from-spec (path=value). It can be repeated. For example: --set spec.runtime=python3.7")
+	deployCmd.Flags().String("config-from-file", "", "Specify a file (YAML or JSON) with the spec of the function to deploy. The name and namespace are taken from the command line")
+	deployCmd.Flags().String("name", "", "Name of the function deployed with --config-from-file, instead of giving it as argument")
 	deployCmd.Flags().StringSliceP("label", "l", []string{}, "Specify labels of the function. Both separator ':' and '=' are allowed. For example: --label foo1=bar1,foo2:bar2")
 	deployCmd.Flags().StringSliceP("secrets", "", []string{}, "Specify Secrets to be mounted to the functions container. For example: --secrets mySecret")
-	deployCmd.Flags().StringSliceP("env", "e", []string{}, "Specify environment variable of the function. Both separator ':' and '=' are allowed. For example: --env foo1=bar1,foo2:bar2")
+	deployCmd.Flags().StringSliceP("env", "e", []string{}, "Specify environment variable of the function. Both separator ':' and '=' are allowed. For example: --env foo1=bar1,foo2:bar2. Use @function:<name>:url as value to get the in-cluster URL of another function")
+	deployCmd.Flags().StringSliceP("env-from-file", "", []string{}, "Specify environment variables of the function whose value is read from a file. For example: --env-from-file TOKEN=token.enc")
+	deployCmd.Flags().StringP("decrypt-cmd", "", "", "Command used to decrypt the files given in --env-from-file. It's run with /bin/sh -c, so quotes and variables work like in a shell. The content of each file is piped to its stdin and its stdout is used as value. For example: --decrypt-cmd 'sops -d --input-type binary --output-type binary /dev/stdin'")
+	deployCmd.Flags().StringP("otel-endpoint", "", "", "Endpoint of the OpenTelemetry collector, set as OTEL_EXPORTER_OTLP_ENDPOINT in the function container. For example: --otel-endpoint http://otel-collector.monitoring:4317")
+	deployCmd.Flags().StringP("otel-service-name", "", "", "Service name reported by the function to OpenTelemetry, set as OTEL_SERVICE_NAME. Defaults to the function name when --otel-endpoint is given")
+	deployCmd.Flags().StringArray("build-arg", []string{}, "Specify an environment variable (KEY=VALUE) for the containers that build the function. It can be repeated. For example: --build-arg HTTPS_PROXY=http://proxy:3128")
 	deployCmd.Flags().StringSliceP("node-selectors", "", []string{}, "Specify node selectors for the function. Both separator ':' and '=' are allowed. For example: --node-selectors key1=val1,key2:val2")
+	deployCmd.Flags().Bool("scale-to-zero", false, "Scale the function to zero replicas when it receives no calls. It requires the HPAScaleToZero feature gate and the custom metrics API")
+	deployCmd.Flags().Duration("scale-to-zero-idle", defaultScaleToZeroIdle, "Time without calls after which the function is scaled to zero")
+	deployCmd.Flags().Int32("max-replicas", 1, "Maximum number of replicas of a function deployed with --scale-to-zero")
+	deployCmd.Flags().Int64("gpu", 0, "Number of GPUs of the function container. Use --node-selectors to choose the kind of GPU")
+	deployCmd.Flags().String("gpu-type", defaultGPUType, "Extended resource name of the GPUs given in --gpu")
+	deployCmd.Flags().StringP("priority-class", "", "", "Specify the PriorityClass of the pods of the function")
+	deployCmd.Flags().Bool("strict", false, "Fail instead of warning if the PriorityClass given in --priority-class doesn't exist")
+	deployCmd.Flags().StringArray("pod-annotation", []string{}, "Specify an annotation (key=value) for the pods of the function. It can be repeated. For example: --pod-annotation sidecar.istio.io/inject=false")
 	deployCmd.Flags().StringP("service-account", "", "", "Specify service account for the function. For example: --service-account controller-acct")
 	deployCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function")
+	deployCmd.Flags().StringSlice("contexts", []string{}, "Deploy the function in each of the given kubeconfig contexts, in order. For example: --contexts staging,production")
+	deployCmd.Flags().Bool("fail-fast", false, "Stop deploying in the rest of --contexts after the first failure")
 	deployCmd.Flags().StringP("dependencies", "d", "", "Specify a file containing list of dependencies for the function")
 	deployCmd.Flags().StringP("schedule", "", "", "Specify schedule in cron format for scheduled function")
 	deployCmd.Flags().StringP("memory", "", "", "Request amount of memory, which is measured in bytes, for the function. It is expressed as a plain integer or a fixed-point interger with one of these suffies: E, P, T, G, M, K, Ei, Pi, Ti, Gi, Mi, Ki")
 	deployCmd.Flags().StringP("cpu", "", "", "Request amount of cpu for the function, which is measured in units of cores. Please see the following link for more information: https://kubernetes.io/docs/concepts/configuration/manage-compute-resources-container/#meaning-of-cpu")
-	deployCmd.Flags().StringP("runtime-image", "", "", "Custom runtime image")
+	deployCmd.Flags().StringP("runtime-image", "", "", "Custom runtime image. It can be referenced by digest (repo@sha256:<digest>)")
+	deployCmd.Flags().Bool("require-digest", false, "Reject runtime images that are not referenced by digest")
 	deployCmd.Flags().StringP("image-pull-policy", "", "Always", "Image pull policy")
 	deployCmd.Flags().StringP("timeout", "", "180", "Maximum timeout (in seconds) for the function to complete its execution")
+	deployCmd.Flags().StringP("function-timeout", "", "", "Maximum time for the function to complete its execution, in seconds or as a duration (e.g. 5m). It's given to the runtime as FUNC_TIMEOUT")
 	deployCmd.Flags().StringP("output", "o", "yaml", "Output format")
+	deployCmd.Flags().StringP("termination-grace-period", "", "", "Time to wait for the function to stop gracefully before it is killed. In seconds or as a duration (e.g. 1m30s)")
+	deployCmd.Flags().StringP("prestop-exec", "", "", "Specify a shell command to run in the function container before it is stopped. For example: --prestop-exec 'sleep 5'")
+	deployCmd.Flags().StringP("security-context-from-file", "", "", "Specify a file (YAML or JSON) with the PodSecurityContext of the function. It may include a seccompProfile")
+	deployCmd.Flags().StringArray("sidecar-from-file", []string{}, "Specify a file (YAML or JSON) with a container to run next to the function. It can be repeated")
+	deployCmd.Flags().Bool("run-as-non-root", false, "Require the function container to run as a non-root user")
+	deployCmd.Flags().Bool("read-only-root-fs", false, "Mount the root filesystem of the function container as read-only")
+	deployCmd.Flags().String("max-surge", "", "Maximum number (e.g. 2) or percentage (e.g. 25%) of extra pods created during a rolling update of the function")
+	deployCmd.Flags().String("max-unavailable", "", "Maximum number (e.g. 1) or percentage (e.g. 10%) of pods of the function that can be unavailable during a rolling update. Defaults to 0")
 	deployCmd.Flags().Bool("headless", false, "Deploy http-based function without a single service IP and load balancing support from Kubernetes. See: https://kubernetes.io/docs/concepts/services-networking/service/#headless-services")
+	deployCmd.Flags().Bool("canary", false, "Deploy the function as a canary of an existing function exposed with an HTTP trigger (nginx gateway only)")
+	deployCmd.Flags().Int("canary-weight", 10, "Percentage of the traffic (0-100) sent to the canary")
+	deployCmd.Flags().Bool("wait", false, "Wait until the function is deployed and all its replicas are ready")
+	deployCmd.Flags().Bool("verify-call", false, "Once the function is ready, call it and fail if it doesn't respond with a 2xx status before --reconcile-timeout. Implies --wait")
+	deployCmd.Flags().String("verify-data", "", "Data to send in the call of --verify-call. Without it, the function is called with a GET request")
+	deployCmd.Flags().Duration("reconcile-timeout", 5*time.Minute, "Maximum time to wait with --wait for the controller to deploy the function. The time of each request to the cluster is limited by --request-timeout")
 	deployCmd.Flags().Bool("dryrun", false, "Output JSON manifest of the function without creating it")
+	deployCmd.Flags().String("validate-policy", "", "Check the --dryrun manifests against the Kyverno validate rules of the given file and fail if any of them is violated")
 	deployCmd.Flags().Int32("port", 8080, "Deploy http-based function with a custom port")
 	deployCmd.Flags().Int32("servicePort", 0, "Deploy http-based function with a custom service port. If not provided the value of 'port' will be used")
 }
+
+// getScheduleTrigger returns the cronjob trigger created for a function deployed with --schedule
+func getScheduleTrigger(funcName, ns, schedule string) *cronjobApi.CronJobTrigger {
+	cronJobTrigger := &cronjobApi.CronJobTrigger{}
+	cronJobTrigger.TypeMeta = metav1.TypeMeta{
+		Kind:       "CronJobTrigger",
+		APIVersion: "kubeless.io/v1beta1",
+	}
+	cronJobTrigger.ObjectMeta = metav1.ObjectMeta{
+		Name:      funcName,
+		Namespace: ns,
+	}
+	cronJobTrigger.ObjectMeta.Labels = map[string]string{
+		"created-by": "kubeless",
+		"function":   funcName,
+	}
+	cronJobTrigger.Spec.FunctionName = funcName
+	cronJobTrigger.Spec.Schedule = schedule
+	return cronJobTrigger
+}
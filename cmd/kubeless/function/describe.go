@@ -19,13 +19,24 @@ package function
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
 
 	"github.com/ghodss/yaml"
 	"github.com/gosuri/uitable"
+	httpApi "github.com/kubeless/http-trigger/pkg/apis/kubeless/v1beta1"
+	httpClientset "github.com/kubeless/http-trigger/pkg/client/clientset/versioned"
 	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	"github.com/kubeless/kubeless/pkg/client/clientset/versioned"
 	"github.com/kubeless/kubeless/pkg/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
 var describeCmd = &cobra.Command{
@@ -39,12 +50,19 @@ var describeCmd = &cobra.Command{
 		}
 		funcName := args[0]
 
-		ns, err := cmd.Flags().GetString("namespace")
+		ns, err := utils.GetNamespaceOrAll(cmd.Flags())
 		if err != nil {
 			logrus.Fatalf("Can not describe function: %v", err)
 		}
-		if ns == "" {
-			ns = utils.GetDefaultNamespace()
+		if ns == metav1.NamespaceAll {
+			kubelessClient, err := utils.GetKubelessClientOutCluster()
+			if err != nil {
+				logrus.Fatalf("Can not describe function: %v", err)
+			}
+			ns, err = findFunctionNamespace(kubelessClient, funcName)
+			if err != nil {
+				logrus.Fatalf("Can not describe function: %v", err)
+			}
 		}
 
 		output, err := cmd.Flags().GetString("out")
@@ -52,12 +70,28 @@ var describeCmd = &cobra.Command{
 			logrus.Fatalf("Can not describe function: %v", err)
 		}
 
+		tmpl, err := utils.GetOutputTemplate(cmd.Flags(), output)
+		if err != nil {
+			logrus.Fatalf("Can not describe function: %v", err)
+		}
+
 		f, err := utils.GetFunction(funcName, ns)
 		if err != nil {
 			logrus.Fatalf("Can not describe function: %v", err)
 		}
 
-		err = print(f, funcName, output)
+		if output == "env" {
+			httpClient, err := utils.GetHTTPTriggerClientOutCluster()
+			if err != nil {
+				logrus.Fatalf("Can not describe function: %v", err)
+			}
+			if err := printFunctionEnv(cmd.OutOrStdout(), &f, utils.GetClientOutOfCluster(), httpClient); err != nil {
+				logrus.Fatalf("Can not describe function: %v", err)
+			}
+			return
+		}
+
+		err = print(f, funcName, output, tmpl)
 		if err != nil {
 			logrus.Fatalf("Can not describe function: %v", err)
 		}
@@ -65,11 +99,29 @@ var describeCmd = &cobra.Command{
 }
 
 func init() {
-	describeCmd.Flags().StringP("out", "o", "", "Output format. One of: json|yaml")
+	describeCmd.Flags().StringP("out", "o", "", "Output format. One of: json|yaml|template|env")
+	utils.AddTemplateFlags(describeCmd.Flags())
 	describeCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function")
+	utils.AddAllNamespacesFlag(describeCmd.Flags(), "Look for the function in all the namespaces")
+}
+
+// findFunctionNamespace returns the namespace of the function with the given name. It fails
+// if there are functions with that name in several namespaces.
+func findFunctionNamespace(kubelessClient versioned.Interface, name string) (string, error) {
+	functions, err := kubelessClient.KubelessV1beta1().Functions(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	namespaces := []string{}
+	for _, f := range functions.Items {
+		if f.Name == name {
+			namespaces = append(namespaces, f.Namespace)
+		}
+	}
+	return utils.GetUniqueNamespace("function", name, namespaces)
 }
 
-func print(f kubelessApi.Function, name, output string) error {
+func print(f kubelessApi.Function, name, output string, tmpl *template.Template) error {
 	switch output {
 	case "":
 		table := uitable.New()
@@ -99,7 +151,7 @@ func print(f kubelessApi.Function, name, output string) error {
 		table.AddRow("Dependencies:", f.Spec.Deps)
 		fmt.Println(table)
 	case "json":
-		b, err := json.MarshalIndent(f, "", "  ")
+		b, err := utils.MarshalJSON(f, "  ")
 		if err != nil {
 			return err
 		}
@@ -110,9 +162,60 @@ func print(f kubelessApi.Function, name, output string) error {
 			return err
 		}
 		fmt.Println(string(b))
+	case utils.OutputTemplate:
+		return utils.PrintTemplate(os.Stdout, tmpl, f)
 	default:
-		fmt.Println("Wrong output format. Please use only json|yaml")
+		fmt.Println("Wrong output format. Please use only json|yaml|template|env")
 	}
 
 	return nil
 }
+
+// printFunctionEnv prints the connection info of a function as shell variables that can be
+// given to eval: the URL of its service and, if it has an HTTP trigger, its external URL
+func printFunctionEnv(w io.Writer, f *kubelessApi.Function, cli kubernetes.Interface, httpClient httpClientset.Interface) error {
+	svc, err := cli.CoreV1().Services(f.Namespace).Get(f.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Unable to find the service of %s: %v", f.Name, err)
+	}
+	if len(svc.Spec.Ports) == 0 {
+		return fmt.Errorf("The service of %s has no ports", f.Name)
+	}
+	port := svc.Spec.Ports[0].Port
+	vars := [][]string{
+		{"KUBELESS_FUNCTION_SERVICE", svc.Name},
+		{"KUBELESS_FUNCTION_PORT", strconv.Itoa(int(port))},
+		{"KUBELESS_FUNCTION_URL", fmt.Sprintf("http://%s.%s.svc.cluster.local:%d", svc.Name, svc.Namespace, port)},
+	}
+
+	triggers, err := getHTTPTriggers(httpClient, f.Namespace, f.Name)
+	if err != nil {
+		// The HTTP triggers may not be installed in the cluster
+		logrus.Debugf("Unable to list the HTTP triggers of %s: %v", f.Name, err)
+	}
+	if len(triggers) > 0 {
+		sort.Slice(triggers, func(i, j int) bool { return triggers[i].Name < triggers[j].Name })
+		vars = append(vars, []string{"KUBELESS_FUNCTION_EXTERNAL_URL", getHTTPTriggerURL(triggers[0])})
+	}
+
+	for _, v := range vars {
+		if _, err := fmt.Fprintf(w, "export %s=%s\n", v[0], shellQuote(v[1])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getHTTPTriggerURL returns the URL exposed by the ingress of an HTTP trigger
+func getHTTPTriggerURL(t *httpApi.HTTPTrigger) string {
+	scheme := "http"
+	if t.Spec.TLSAcme || t.Spec.TLSSecret != "" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/%s", scheme, t.Spec.HostName, strings.TrimPrefix(t.Spec.Path, "/"))
+}
+
+// shellQuote quotes a value so it's taken literally by a POSIX shell
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,92 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	httpApi "github.com/kubeless/http-trigger/pkg/apis/kubeless/v1beta1"
+	httpFake "github.com/kubeless/http-trigger/pkg/client/clientset/versioned/fake"
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	fFake "github.com/kubeless/kubeless/pkg/client/clientset/versioned/fake"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPrintFunctionEnv(t *testing.T) {
+	f := &kubelessApi.Function{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns"}}
+	cli := fake.NewSimpleClientset(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns"},
+		Spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http-function-port", Port: 8080}}},
+	})
+
+	var buf bytes.Buffer
+	if err := printFunctionEnv(&buf, f, cli, httpFake.NewSimpleClientset()); err != nil {
+		t.Fatal(err)
+	}
+	expected := `export KUBELESS_FUNCTION_SERVICE='foo'
+export KUBELESS_FUNCTION_PORT='8080'
+export KUBELESS_FUNCTION_URL='http://foo.myns.svc.cluster.local:8080'
+`
+	if buf.String() != expected {
+		t.Errorf("Expecting:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	httpClient := httpFake.NewSimpleClientset(&httpApi.HTTPTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns"},
+		Spec:       httpApi.HTTPTriggerSpec{FunctionName: "foo", HostName: "foo.example.com", Path: "/api", TLSSecret: "foo-tls"},
+	})
+	buf.Reset()
+	if err := printFunctionEnv(&buf, f, cli, httpClient); err != nil {
+		t.Fatal(err)
+	}
+	expected += "export KUBELESS_FUNCTION_EXTERNAL_URL='https://foo.example.com/api'\n"
+	if buf.String() != expected {
+		t.Errorf("Expecting:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	if err := printFunctionEnv(&buf, &kubelessApi.Function{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "myns"}}, cli, httpClient); err == nil {
+		t.Error("Expecting an error for a function without service")
+	}
+	if shellQuote("it's") != `'it'\''s'` {
+		t.Errorf("Unexpected quoting %s", shellQuote("it's"))
+	}
+}
+
+func TestFindFunctionNamespace(t *testing.T) {
+	client := fFake.NewSimpleClientset(
+		&kubelessApi.Function{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns"}},
+		&kubelessApi.Function{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "myns"}},
+		&kubelessApi.Function{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "other"}},
+	)
+	ns, err := findFunctionNamespace(client, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ns != "myns" {
+		t.Errorf("Expecting namespace myns, got %s", ns)
+	}
+	if _, err := findFunctionNamespace(client, "bar"); err == nil || !strings.Contains(err.Error(), "myns, other") {
+		t.Errorf("Expecting an error listing both namespaces, got %v", err)
+	}
+	if _, err := findFunctionNamespace(client, "missing"); err == nil {
+		t.Error("Expecting an error for a missing function")
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ghodss/yaml"
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	"github.com/kubeless/kubeless/pkg/client/clientset/versioned"
+	kubelessutil "github.com/kubeless/kubeless/pkg/utils"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+var diffCmd = &cobra.Command{
+	Use:   "diff FLAG",
+	Short: "show the differences between a function manifest and the deployed function",
+	Long: `show the differences between a Function manifest and the function deployed in the cluster.
+
+Fields set by the server, like the resourceVersion, are ignored. The command exits with 1 if
+there are differences (or if the function is not deployed) and with 0 if both are identical.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 0 {
+			logrus.Fatal("Unexpected arguments, the manifest is given with --file")
+		}
+
+		file, err := cmd.Flags().GetString("file")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if file == "" {
+			logrus.Fatal("The manifest of the function is required, use --file")
+		}
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		f, err := parseFunctionManifest(content)
+		if err != nil {
+			logrus.Fatalf("Unable to parse %s: %v", file, err)
+		}
+
+		ns, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if ns != "" {
+			f.Namespace = ns
+		} else if f.Namespace == "" {
+			f.Namespace = kubelessutil.GetDefaultNamespace()
+		}
+
+		kubelessClient, err := kubelessutil.GetKubelessClientOutCluster()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		live, err := getLiveFunction(kubelessClient, f.Name, f.Namespace)
+		if err != nil {
+			logrus.Fatalf("Unable to get function %s in namespace %s: %v", f.Name, f.Namespace, err)
+		}
+		if live == nil {
+			logrus.Warnf("Function %s is not deployed in namespace %s", f.Name, f.Namespace)
+		}
+
+		diff, err := diffFunctions(live, f, file)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if diff != "" {
+			fmt.Print(diff)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	diffCmd.Flags().StringP("file", "f", "", "Specify the Function manifest (YAML or JSON) to compare")
+	diffCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function. It overrides the namespace of the manifest")
+}
+
+// getLiveFunction returns the function deployed in the cluster or nil if it doesn't exist
+func getLiveFunction(kubelessClient versioned.Interface, name, ns string) (*kubelessApi.Function, error) {
+	f, err := kubelessClient.KubelessV1beta1().Functions(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// normalizeFunction returns a copy of the function without the fields managed by the server
+// so functions read from the cluster can be compared with manifests
+func normalizeFunction(f *kubelessApi.Function) *kubelessApi.Function {
+	n := f.DeepCopy()
+	n.TypeMeta = metav1.TypeMeta{
+		Kind:       "Function",
+		APIVersion: "kubeless.io/v1beta1",
+	}
+	n.ResourceVersion = ""
+	n.UID = ""
+	n.SelfLink = ""
+	n.Generation = 0
+	n.CreationTimestamp = metav1.Time{}
+	n.DeletionTimestamp = nil
+	n.DeletionGracePeriodSeconds = nil
+	n.Finalizers = nil
+	n.OwnerReferences = nil
+	delete(n.Annotations, lastAppliedAnnotation)
+	if len(n.Annotations) == 0 {
+		n.Annotations = nil
+	}
+	return n
+}
+
+// diffFunctions returns the unified diff between the live function and the local one.
+// A nil live function is compared as an empty document. It returns an empty string
+// if both functions are identical.
+func diffFunctions(live, local *kubelessApi.Function, localName string) (string, error) {
+	liveContent := []byte{}
+	if live != nil {
+		var err error
+		liveContent, err = yaml.Marshal(normalizeFunction(live))
+		if err != nil {
+			return "", err
+		}
+	}
+	localContent, err := yaml.Marshal(normalizeFunction(local))
+	if err != nil {
+		return "", err
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(liveContent)),
+		B:        difflib.SplitLines(string(localContent)),
+		FromFile: "live",
+		ToFile:   localName,
+		Context:  3,
+	})
+}
@@ -0,0 +1,97 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"strings"
+	"testing"
+
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	fFake "github.com/kubeless/kubeless/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDiffFunctions(t *testing.T) {
+	local := &kubelessApi.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hello",
+			Namespace: "default",
+		},
+		Spec: kubelessApi.FunctionSpec{
+			Handler: "hello.handler",
+			Runtime: "python3.7",
+		},
+	}
+	live := local.DeepCopy()
+	live.TypeMeta = metav1.TypeMeta{Kind: "Function", APIVersion: "kubeless.io/v1beta1"}
+	live.ResourceVersion = "42"
+	live.UID = "1234"
+	live.Generation = 3
+	live.CreationTimestamp = metav1.Now()
+	live.Finalizers = []string{"kubeless.io/function"}
+	live.Annotations = map[string]string{lastAppliedAnnotation: "{}"}
+
+	diff, err := diffFunctions(live, local, "hello.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != "" {
+		t.Errorf("Expecting server fields to be ignored, got:\n%s", diff)
+	}
+	if live.ResourceVersion != "42" || live.Annotations[lastAppliedAnnotation] != "{}" {
+		t.Error("The live function should not be modified")
+	}
+
+	live.Spec.Runtime = "python3.6"
+	diff, err = diffFunctions(live, local, "hello.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"--- live", "+++ hello.yaml", "-  runtime: python3.6", "+  runtime: python3.7"} {
+		if !strings.Contains(diff, expected) {
+			t.Errorf("Expecting %q in the diff, got:\n%s", expected, diff)
+		}
+	}
+
+	diff, err = diffFunctions(nil, local, "hello.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "+  name: hello") {
+		t.Errorf("Expecting the whole function to be added, got:\n%s", diff)
+	}
+}
+
+func TestGetLiveFunction(t *testing.T) {
+	cli := fFake.NewSimpleClientset(&kubelessApi.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"},
+	})
+	f, err := getLiveFunction(cli, "hello", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f == nil || f.Name != "hello" {
+		t.Errorf("Unexpected function %v", f)
+	}
+	f, err = getLiveFunction(cli, "bye", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f != nil {
+		t.Errorf("Expecting no function, got %v", f)
+	}
+}
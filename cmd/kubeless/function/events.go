@@ -0,0 +1,203 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// eventRowFormat is the format of each event printed by 'function events'
+const eventRowFormat = "%-20s %-8s %-20s %-40s %s\n"
+
+var eventsCmd = &cobra.Command{
+	Use:   "events <function_name> FLAG",
+	Short: "list the events of the deployment, replica sets and pods of a function",
+	Long: `list the Kubernetes events of the deployment, replica sets and pods of a function in chronological order.
+
+Only the events of existing objects are listed: the events of pods that have already been removed are not included.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			logrus.Fatal("Need exactly one argument - function name")
+		}
+		funcName := args[0]
+		ns, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if ns == "" {
+			ns = utils.GetDefaultNamespace()
+		}
+		watchFlag, err := cmd.Flags().GetBool("watch")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		cli := utils.GetClientOutOfCluster()
+		var stop <-chan struct{}
+		if watchFlag {
+			var release func()
+			stop, release = getWatchStop(0)
+			defer release()
+		}
+		if err := doEvents(cmd.OutOrStdout(), cli, ns, funcName, stop); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	eventsCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function")
+	eventsCmd.Flags().BoolP("watch", "w", false, "After listing the events, wait for new ones until interrupted")
+}
+
+// getFunctionObjects returns the UIDs of the deployment of a function and of the
+// replica sets and pods owned by it
+func getFunctionObjects(cli kubernetes.Interface, ns, funcName string) (map[types.UID]bool, error) {
+	uids := map[types.UID]bool{}
+	dpm, err := cli.AppsV1().Deployments(ns).Get(funcName, metav1.GetOptions{})
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil, fmt.Errorf("The function %s has no deployment in namespace %s. Check the logs of the Kubeless controller", funcName, ns)
+		}
+		return nil, err
+	}
+	uids[dpm.UID] = true
+
+	replicaSets, err := cli.AppsV1().ReplicaSets(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, rs := range replicaSets.Items {
+		if isOwnedBy(rs.OwnerReferences, uids) {
+			uids[rs.UID] = true
+		}
+	}
+	pods, err := cli.CoreV1().Pods(ns).List(metav1.ListOptions{LabelSelector: "function=" + funcName})
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods.Items {
+		if isOwnedBy(pod.OwnerReferences, uids) {
+			uids[pod.UID] = true
+		}
+	}
+	return uids, nil
+}
+
+func isOwnedBy(refs []metav1.OwnerReference, uids map[types.UID]bool) bool {
+	for _, ref := range refs {
+		if uids[ref.UID] {
+			return true
+		}
+	}
+	return false
+}
+
+// getEventTime returns the last time the event was seen
+func getEventTime(event v1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.FirstTimestamp.Time
+}
+
+func printEvent(w io.Writer, event v1.Event) {
+	object := strings.ToLower(event.InvolvedObject.Kind) + "/" + event.InvolvedObject.Name
+	fmt.Fprintf(w, eventRowFormat, getEventTime(event).Format(time.RFC3339), event.Type, event.Reason, object, event.Message)
+}
+
+// doEvents prints the events of the objects of the function sorted by time. If stop is not
+// nil, it then prints the new events until stop is closed.
+func doEvents(w io.Writer, cli kubernetes.Interface, ns, funcName string, stop <-chan struct{}) error {
+	uids, err := getFunctionObjects(cli, ns, funcName)
+	if err != nil {
+		return err
+	}
+	events, err := cli.CoreV1().Events(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	list := []v1.Event{}
+	for _, event := range events.Items {
+		if uids[event.InvolvedObject.UID] {
+			list = append(list, event)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return getEventTime(list[i]).Before(getEventTime(list[j]))
+	})
+	fmt.Fprintf(w, eventRowFormat, "LAST SEEN", "TYPE", "REASON", "OBJECT", "MESSAGE")
+	for _, event := range list {
+		printEvent(w, event)
+	}
+	if stop == nil {
+		return nil
+	}
+
+	resourceVersion := events.ResourceVersion
+	eventWatch, err := cli.CoreV1().Events(ns).Watch(metav1.ListOptions{ResourceVersion: resourceVersion})
+	if err != nil {
+		return err
+	}
+	defer func() { eventWatch.Stop() }()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case e, ok := <-eventWatch.ResultChan():
+			if !ok {
+				// The server closes watches after a while, resume from the last event received
+				eventWatch, err = cli.CoreV1().Events(ns).Watch(metav1.ListOptions{ResourceVersion: resourceVersion})
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			event, isEvent := e.Object.(*v1.Event)
+			if !isEvent || e.Type == watch.Deleted {
+				continue
+			}
+			resourceVersion = event.ResourceVersion
+			// Replica sets and pods created during the rollout are not known yet
+			if !uids[event.InvolvedObject.UID] && strings.HasPrefix(event.InvolvedObject.Name, funcName+"-") {
+				if uids, err = getFunctionObjects(cli, ns, funcName); err != nil {
+					return err
+				}
+			}
+			if uids[event.InvolvedObject.UID] {
+				printEvent(w, *event)
+			}
+		}
+	}
+}
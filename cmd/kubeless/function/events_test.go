@@ -0,0 +1,103 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func fakeEvent(name, kind, object string, uid types.UID, reason string, t time.Time) *v1.Event {
+	return &v1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "myns"},
+		InvolvedObject: v1.ObjectReference{Kind: kind, Name: object, UID: uid},
+		Type:           "Normal",
+		Reason:         reason,
+		Message:        reason + " " + object,
+		LastTimestamp:  metav1.NewTime(t),
+	}
+}
+
+func TestDoEvents(t *testing.T) {
+	now := time.Now()
+	owner := func(uid types.UID) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{UID: uid}}
+	}
+	cli := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns", UID: "dpm-uid"}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "foo-1234", Namespace: "myns", UID: "rs-uid", OwnerReferences: owner("dpm-uid")}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "foo-bar-1234", Namespace: "myns", UID: "other-rs-uid", OwnerReferences: owner("other-uid")}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo-1234-abcd", Namespace: "myns", UID: "pod-uid", OwnerReferences: owner("rs-uid"), Labels: map[string]string{"function": "foo"}}},
+		fakeEvent("e1", "Pod", "foo-1234-abcd", "pod-uid", "Pulled", now.Add(2*time.Second)),
+		fakeEvent("e2", "Deployment", "foo", "dpm-uid", "ScalingReplicaSet", now),
+		fakeEvent("e3", "ReplicaSet", "foo-1234", "rs-uid", "SuccessfulCreate", now.Add(time.Second)),
+		fakeEvent("e4", "ReplicaSet", "foo-bar-1234", "other-rs-uid", "SuccessfulCreate", now),
+	)
+
+	var buf bytes.Buffer
+	if err := doEvents(&buf, cli, "myns", "foo", nil); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	expected := []string{"LAST SEEN", "deployment/foo", "replicaset/foo-1234", "pod/foo-1234-abcd"}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expecting %d lines, got:\n%s", len(expected), buf.String())
+	}
+	for i := range expected {
+		if !strings.Contains(lines[i], expected[i]) {
+			t.Errorf("Expecting line %d to contain %q, got %q", i, expected[i], lines[i])
+		}
+	}
+
+	// New events are printed while watching, including the ones of new pods
+	eventWatch := watch.NewFake()
+	cli.PrependWatchReactor("events", ktesting.DefaultWatchReactor(eventWatch, nil))
+	buf.Reset()
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- doEvents(&buf, cli, "myns", "foo", stop)
+	}()
+	newPod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo-1234-efgh", Namespace: "myns", UID: "new-pod-uid", OwnerReferences: owner("rs-uid"), Labels: map[string]string{"function": "foo"}}}
+	if _, err := cli.CoreV1().Pods("myns").Create(newPod); err != nil {
+		t.Fatal(err)
+	}
+	eventWatch.Add(fakeEvent("e5", "Pod", "foo-1234-efgh", "new-pod-uid", "Scheduled", now.Add(3*time.Second)))
+	eventWatch.Add(fakeEvent("e6", "ReplicaSet", "foo-bar-1234", "other-rs-uid", "SuccessfulDelete", now.Add(3*time.Second)))
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+	if !strings.Contains(output, "pod/foo-1234-efgh") || strings.Contains(output, "SuccessfulDelete") {
+		t.Errorf("Unexpected events while watching:\n%s", output)
+	}
+
+	if err := doEvents(&buf, cli, "myns", "missing", nil); err == nil {
+		t.Error("Expecting an error for a function without deployment")
+	}
+}
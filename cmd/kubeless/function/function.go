@@ -17,17 +17,30 @@ limitations under the License.
 package function
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ghodss/yaml"
 	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
 	"github.com/kubeless/kubeless/pkg/client/clientset/versioned"
 	kubelessutil "github.com/kubeless/kubeless/pkg/utils"
 	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/autoscaling/v2beta1"
 	v1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -50,6 +63,15 @@ func init() {
 	FunctionCmd.AddCommand(describeCmd)
 	FunctionCmd.AddCommand(updateCmd)
 	FunctionCmd.AddCommand(topCmd)
+	FunctionCmd.AddCommand(invokeAllCmd)
+	FunctionCmd.AddCommand(promoteCmd)
+	FunctionCmd.AddCommand(importCmd)
+	FunctionCmd.AddCommand(diffCmd)
+	FunctionCmd.AddCommand(scaleCmd)
+	FunctionCmd.AddCommand(rollbackCmd)
+	FunctionCmd.AddCommand(healthCmd)
+	FunctionCmd.AddCommand(eventsCmd)
+	FunctionCmd.AddCommand(initCmd)
 }
 
 func getKV(input string) (string, string) {
@@ -87,6 +109,564 @@ func parseEnv(envs []string) []v1.EnvVar {
 	return funcEnv
 }
 
+// parseEnvFromFile reads the value of each NAME=path entry from the given file.
+// If a decrypt command is given, the content of the file is piped through it. The
+// command is run with /bin/sh -c, so it's quoted and expanded like in a shell.
+func parseEnvFromFile(envFiles []string, decryptCmd string) ([]string, error) {
+	envs := []string{}
+	for _, envFile := range envFiles {
+		name, file := getKV(envFile)
+		if name == "" || file == "" {
+			return nil, fmt.Errorf("Wrong format of the env file %q. It should be NAME=path", envFile)
+		}
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if decryptCmd != "" {
+			var stdout, stderr bytes.Buffer
+			c := exec.Command("/bin/sh", "-c", decryptCmd)
+			c.Stdin = bytes.NewReader(content)
+			c.Stdout = &stdout
+			c.Stderr = &stderr
+			if err := c.Run(); err != nil {
+				return nil, fmt.Errorf("Unable to decrypt %s: %v. %s", file, err, strings.TrimSpace(stderr.String()))
+			}
+			content = stdout.Bytes()
+		}
+		envs = append(envs, name+"="+strings.TrimRight(string(content), "\r\n"))
+	}
+	return envs, nil
+}
+
+// functionRefPrefix marks an env value that references another function, e.g. @function:hello:url
+const functionRefPrefix = "@function:"
+
+// resolveFunctionRefs replaces the env values referencing another function
+// (KEY=@function:<name>:url) with the in-cluster URL of the service of that function
+func resolveFunctionRefs(kubelessClient versioned.Interface, cli kubernetes.Interface, ns string, envs []string) ([]string, error) {
+	resolved := []string{}
+	for _, env := range envs {
+		k, v := getKV(env)
+		if !strings.HasPrefix(v, functionRefPrefix) {
+			resolved = append(resolved, env)
+			continue
+		}
+		ref := strings.Split(strings.TrimPrefix(v, functionRefPrefix), ":")
+		if len(ref) != 2 || ref[0] == "" {
+			return nil, fmt.Errorf("Wrong format of the reference %q in %s. It should be %s<name>:url", v, k, functionRefPrefix)
+		}
+		if ref[1] != "url" {
+			return nil, fmt.Errorf("Unsupported attribute %q in %s. Only url is supported", ref[1], k)
+		}
+		url, err := getFunctionURL(kubelessClient, cli, ns, ref[0])
+		if err != nil {
+			return nil, fmt.Errorf("Unable to resolve %s: %v", k, err)
+		}
+		resolved = append(resolved, k+"="+url)
+	}
+	return resolved, nil
+}
+
+// getFunctionURL returns the in-cluster URL of the service of the given function
+func getFunctionURL(kubelessClient versioned.Interface, cli kubernetes.Interface, ns, funcName string) (string, error) {
+	if _, err := kubelessClient.KubelessV1beta1().Functions(ns).Get(funcName, metav1.GetOptions{}); err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return "", fmt.Errorf("Function %s not found in namespace %s", funcName, ns)
+		}
+		return "", err
+	}
+	svc, err := cli.CoreV1().Services(ns).Get(funcName, metav1.GetOptions{})
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return "", fmt.Errorf("Function %s doesn't have a service", funcName)
+		}
+		return "", err
+	}
+	if len(svc.Spec.Ports) == 0 {
+		return "", fmt.Errorf("The service of the function %s doesn't expose any port", funcName)
+	}
+	return fmt.Sprintf("http://%s.%s.svc:%d", svc.Name, ns, svc.Spec.Ports[0].Port), nil
+}
+
+// getOTelEnv returns the env variables that configure the OpenTelemetry exporter of a function.
+// The service name defaults to the name of the function when only the endpoint is given.
+func getOTelEnv(endpoint, serviceName, funcName string) ([]string, error) {
+	envs := []string{}
+	if endpoint != "" {
+		u, err := url.ParseRequestURI(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("Invalid OpenTelemetry endpoint %q. It should be an http or https URL like http://otel-collector:4317", endpoint)
+		}
+		envs = append(envs, "OTEL_EXPORTER_OTLP_ENDPOINT="+endpoint)
+		if serviceName == "" {
+			serviceName = funcName
+		}
+	}
+	if serviceName != "" {
+		envs = append(envs, "OTEL_SERVICE_NAME="+serviceName)
+	}
+	return envs, nil
+}
+
+// handlerFunctionNames contains the function name used by default in the handler of each language
+var handlerFunctionNames = map[string]string{
+	"go": "Handler",
+}
+
+// inferHandler returns the default handler for a single-file function: the name of
+// the file as module and "handler" as function, following the convention of each runtime
+func inferHandler(runtime, file string) (string, error) {
+	if file == "" {
+		return "", fmt.Errorf("Unable to infer the handler without --from-file")
+	}
+	name := file
+	if u, err := url.Parse(file); err == nil && u.Scheme != "" && u.Host != "" {
+		name = u.Path
+	}
+	name = filepath.Base(name)
+	for _, ext := range []string{".zip", ".tar", ".tgz", ".taz", ".tbz", ".tbz2", ".tb2", ".tz2", ".gz", ".bz2", ".xz"} {
+		if strings.HasSuffix(name, ext) {
+			return "", fmt.Errorf("Unable to infer the handler of a compressed file")
+		}
+	}
+	module := strings.TrimSuffix(name, filepath.Ext(name))
+	if module == "" {
+		return "", fmt.Errorf("Unable to infer the handler from the file %s", file)
+	}
+	language := strings.TrimRight(runtime, "0123456789.")
+	if language == "dotnetcore" {
+		// .NET functions are always compiled in a module called "module"
+		module = "module"
+	}
+	function, ok := handlerFunctionNames[language]
+	if !ok {
+		function = "handler"
+	}
+	return module + "." + function, nil
+}
+
+// parseBuildArgs parses the KEY=VALUE build arguments of a function
+func parseBuildArgs(buildArgs []string) ([]v1.EnvVar, error) {
+	env := []v1.EnvVar{}
+	for _, arg := range buildArgs {
+		pos := strings.Index(arg, "=")
+		if pos == -1 {
+			return nil, fmt.Errorf("Wrong format of the build argument %q. It should be KEY=VALUE", arg)
+		}
+		key := arg[:pos]
+		if errs := validation.IsEnvVarName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("Invalid build argument name %q: %s", key, strings.Join(errs, ", "))
+		}
+		env = append(env, v1.EnvVar{Name: key, Value: arg[pos+1:]})
+	}
+	return env, nil
+}
+
+// setBuildEnv sets the env used by the containers that build the function.
+// They are read from the first init container of the function deployment.
+func setBuildEnv(f *kubelessApi.Function, env []v1.EnvVar) {
+	if len(env) == 0 {
+		return
+	}
+	podSpec := &f.Spec.Deployment.Spec.Template.Spec
+	if len(podSpec.InitContainers) == 0 {
+		podSpec.InitContainers = []v1.Container{{}}
+	}
+	podSpec.InitContainers[0].Env = append(podSpec.InitContainers[0].Env, env...)
+}
+
+// parseGracePeriod parses a termination grace period given either in seconds or as a duration (e.g. 1m30s)
+func parseGracePeriod(in string) (int64, error) {
+	return parseSeconds(in, "termination grace period")
+}
+
+// parseFunctionTimeout parses the timeout of a function given either in seconds or
+// as a duration (e.g. 5m). It returns the number of seconds expected in the spec.
+func parseFunctionTimeout(in string) (string, error) {
+	seconds, err := parseSeconds(in, "function timeout")
+	if err != nil {
+		return "", err
+	}
+	if seconds == 0 {
+		return "", fmt.Errorf("The function timeout should be greater than 0")
+	}
+	return strconv.FormatInt(seconds, 10), nil
+}
+
+// parseSeconds parses a non-negative number of seconds or a duration with whole seconds
+func parseSeconds(in, name string) (int64, error) {
+	seconds, err := strconv.ParseInt(in, 10, 64)
+	if err != nil {
+		duration, durationErr := time.ParseDuration(in)
+		if durationErr != nil {
+			return 0, fmt.Errorf("Wrong format of the %s %q. It should be a number of seconds or a duration like 1m30s", name, in)
+		}
+		if duration%time.Second != 0 {
+			return 0, fmt.Errorf("The %s %q should be a whole number of seconds", name, in)
+		}
+		seconds = int64(duration / time.Second)
+	}
+	if seconds < 0 {
+		return 0, fmt.Errorf("The %s cannot be negative", name)
+	}
+	return seconds, nil
+}
+
+// getPreStopHook returns a lifecycle running the given shell command before the container is stopped
+func getPreStopHook(command string) (*v1.Lifecycle, error) {
+	if strings.TrimSpace(command) == "" {
+		return nil, fmt.Errorf("The preStop command cannot be empty")
+	}
+	return &v1.Lifecycle{
+		PreStop: &v1.Handler{
+			Exec: &v1.ExecAction{
+				Command: []string{"/bin/sh", "-c", command},
+			},
+		},
+	}, nil
+}
+
+// seccompPodAnnotation sets the seccomp profile of a pod. This version of the API
+// doesn't have a seccompProfile field in the security context.
+const seccompPodAnnotation = "seccomp.security.alpha.kubernetes.io/pod"
+
+type seccompProfile struct {
+	Type             string `json:"type"`
+	LocalhostProfile string `json:"localhostProfile,omitempty"`
+}
+
+// podSecurityContextFile is the content of the file given in --security-context-from-file
+type podSecurityContextFile struct {
+	v1.PodSecurityContext
+	SeccompProfile *seccompProfile `json:"seccompProfile,omitempty"`
+}
+
+// getSeccompAnnotation returns the value of the seccomp annotation for the given profile
+func getSeccompAnnotation(profile *seccompProfile) (string, error) {
+	switch profile.Type {
+	case "RuntimeDefault":
+		return "runtime/default", nil
+	case "Unconfined":
+		return "unconfined", nil
+	case "Localhost":
+		if profile.LocalhostProfile == "" {
+			return "", fmt.Errorf("A localhostProfile is required for the seccomp profile type Localhost")
+		}
+		return "localhost/" + profile.LocalhostProfile, nil
+	default:
+		return "", fmt.Errorf("Unsupported seccomp profile type %q. Supported types are RuntimeDefault, Unconfined and Localhost", profile.Type)
+	}
+}
+
+// parsePodSecurityContext parses a PodSecurityContext in YAML or JSON. It returns
+// the security context and the value of the seccomp annotation (if any).
+func parsePodSecurityContext(content []byte) (*v1.PodSecurityContext, string, error) {
+	sc := podSecurityContextFile{}
+	if err := yaml.UnmarshalStrict(content, &sc, yaml.DisallowUnknownFields); err != nil {
+		return nil, "", fmt.Errorf("Unable to parse the pod security context: %v", err)
+	}
+	seccomp := ""
+	if sc.SeccompProfile != nil {
+		var err error
+		seccomp, err = getSeccompAnnotation(sc.SeccompProfile)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	return &sc.PodSecurityContext, seccomp, nil
+}
+
+// parsePodAnnotations parses the annotations given as key=value for the pods of the function
+func parsePodAnnotations(annotations []string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, annotation := range annotations {
+		parts := strings.SplitN(annotation, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid pod annotation %q. It should be in the form key=value", annotation)
+		}
+		if errs := validation.IsQualifiedName(parts[0]); len(errs) > 0 {
+			return nil, fmt.Errorf("Invalid pod annotation %q: %s", parts[0], strings.Join(errs, "; "))
+		}
+		result[parts[0]] = parts[1]
+	}
+	return result, nil
+}
+
+// setPodAnnotations adds the given annotations to the pod template of the function
+func setPodAnnotations(f *kubelessApi.Function, annotations map[string]string) {
+	if len(annotations) == 0 {
+		return
+	}
+	if f.Spec.Deployment.Spec.Template.Annotations == nil {
+		f.Spec.Deployment.Spec.Template.Annotations = map[string]string{}
+	}
+	for k, v := range annotations {
+		f.Spec.Deployment.Spec.Template.Annotations[k] = v
+	}
+}
+
+// parseRolloutValue parses a --max-surge or --max-unavailable value, either a
+// number of pods or a percentage (e.g. 25%)
+func parseRolloutValue(flag, in string) (*intstr.IntOrString, error) {
+	value := intstr.Parse(in)
+	if value.Type == intstr.Int {
+		if value.IntVal < 0 {
+			return nil, fmt.Errorf("Invalid value %q for --%s. It should be a non-negative number of pods or a percentage", in, flag)
+		}
+		return &value, nil
+	}
+	percent, err := strconv.Atoi(strings.TrimSuffix(in, "%"))
+	if !strings.HasSuffix(in, "%") || err != nil || percent < 0 || percent > 100 {
+		return nil, fmt.Errorf("Invalid value %q for --%s. It should be a non-negative number of pods or a percentage between 0%% and 100%%", in, flag)
+	}
+	return &value, nil
+}
+
+// setRollingUpdate sets the max surge and max unavailable pods of the rolling updates of
+// the function. Empty values keep the current ones. The controller uses 0 as default
+// max unavailable so both values can't be 0, the rollout would never progress.
+func setRollingUpdate(f *kubelessApi.Function, maxSurge, maxUnavailable string) error {
+	strategy := &f.Spec.Deployment.Spec.Strategy
+	if strategy.Type == appsv1.RecreateDeploymentStrategyType {
+		return fmt.Errorf("The function uses the Recreate strategy, --max-surge and --max-unavailable are not supported")
+	}
+	rollingUpdate := &appsv1.RollingUpdateDeployment{}
+	if strategy.RollingUpdate != nil {
+		rollingUpdate = strategy.RollingUpdate.DeepCopy()
+	}
+	if maxSurge != "" {
+		value, err := parseRolloutValue("max-surge", maxSurge)
+		if err != nil {
+			return err
+		}
+		rollingUpdate.MaxSurge = value
+	}
+	if maxUnavailable != "" {
+		value, err := parseRolloutValue("max-unavailable", maxUnavailable)
+		if err != nil {
+			return err
+		}
+		rollingUpdate.MaxUnavailable = value
+	}
+	if isZeroRolloutValue(rollingUpdate.MaxSurge, false) && isZeroRolloutValue(rollingUpdate.MaxUnavailable, true) {
+		return fmt.Errorf("--max-surge and --max-unavailable can't be both 0")
+	}
+	strategy.RollingUpdate = rollingUpdate
+	return nil
+}
+
+// isZeroRolloutValue returns true if the value doesn't allow any pod. Unset values
+// are 0 if defaultZero is true (max unavailable) and 25% otherwise (max surge).
+func isZeroRolloutValue(value *intstr.IntOrString, defaultZero bool) bool {
+	if value == nil {
+		return defaultZero
+	}
+	if value.Type == intstr.Int {
+		return value.IntVal == 0
+	}
+	percent, err := strconv.Atoi(strings.TrimSuffix(value.StrVal, "%"))
+	return err == nil && percent == 0
+}
+
+// validatePriorityClassName checks that the name is a valid PriorityClass name
+func validatePriorityClassName(name string) error {
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("Invalid priority class %q: %s", name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// validatePriorityClass checks that the given PriorityClass exists in the cluster
+func validatePriorityClass(cli kubernetes.Interface, name string) error {
+	_, err := cli.SchedulingV1alpha1().PriorityClasses().Get(name, metav1.GetOptions{})
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return fmt.Errorf("PriorityClass %s not found", name)
+		}
+		return fmt.Errorf("Unable to get the PriorityClass %s: %v", name, err)
+	}
+	return nil
+}
+
+// scaleToZeroIdleAnnotation records the idle time requested with --scale-to-zero-idle
+const scaleToZeroIdleAnnotation = "kubeless.io/scale-to-zero-idle"
+
+// defaultScaleToZeroIdle is the default downscale stabilization window of the HPA controller
+const defaultScaleToZeroIdle = 5 * time.Minute
+
+// validateScaleToZeroIdle checks the idle time after which a function is scaled to zero
+func validateScaleToZeroIdle(idle time.Duration) error {
+	if idle < time.Minute {
+		return fmt.Errorf("Invalid value %v for --scale-to-zero-idle. It should be at least 1m", idle)
+	}
+	if idle%time.Second != 0 {
+		return fmt.Errorf("Invalid value %v for --scale-to-zero-idle. It should be a whole number of seconds", idle)
+	}
+	return nil
+}
+
+// getScaleToZeroAutoscaler returns an autoscaler that allows the function to drop to zero
+// replicas when it receives no calls. Like 'kubeless autoscale create --metric qps', it scales
+// on the function_calls metric of the service, so it needs the custom metrics API.
+func getScaleToZeroAutoscaler(name, ns string, maxReplicas int32, labels map[string]string) v2beta1.HorizontalPodAutoscaler {
+	minReplicas := int32(0)
+	return v2beta1.HorizontalPodAutoscaler{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "autoscaling/v2beta1",
+			Kind:       "HorizontalPodAutoscaler",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    labels,
+		},
+		Spec: v2beta1.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: v2beta1.CrossVersionObjectReference{
+				APIVersion: "apps/v1beta1",
+				Kind:       "Deployment",
+				Name:       name,
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: maxReplicas,
+			Metrics: []v2beta1.MetricSpec{
+				{
+					Type: v2beta1.ObjectMetricSourceType,
+					Object: &v2beta1.ObjectMetricSource{
+						MetricName:  "function_calls",
+						TargetValue: resource.MustParse("1"),
+						Target: v2beta1.CrossVersionObjectReference{
+							Kind: "Service",
+							Name: name,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// setPodSecurityContext sets the pod security context read from the given file
+func setPodSecurityContext(f *kubelessApi.Function, file string) error {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	sc, seccomp, err := parsePodSecurityContext(content)
+	if err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	f.Spec.Deployment.Spec.Template.Spec.SecurityContext = sc
+	if seccomp != "" {
+		if f.Spec.Deployment.Spec.Template.Annotations == nil {
+			f.Spec.Deployment.Spec.Template.Annotations = map[string]string{}
+		}
+		f.Spec.Deployment.Spec.Template.Annotations[seccompPodAnnotation] = seccomp
+	}
+	return nil
+}
+
+// getContainerSecurityContext returns the security context of the function container
+func getContainerSecurityContext(runAsNonRoot, readOnlyRootFs bool) *v1.SecurityContext {
+	sc := &v1.SecurityContext{}
+	if runAsNonRoot {
+		sc.RunAsNonRoot = &runAsNonRoot
+	}
+	if readOnlyRootFs {
+		sc.ReadOnlyRootFilesystem = &readOnlyRootFs
+	}
+	return sc
+}
+
+// defaultGPUType is the extended resource of the NVIDIA device plugin
+const defaultGPUType = "nvidia.com/gpu"
+
+// validateGPU checks the number and the resource name of the GPUs of a function
+func validateGPU(gpus int64, gpuType string) error {
+	if gpus < 1 {
+		return fmt.Errorf("Invalid value %d for --gpu. It should be at least 1", gpus)
+	}
+	if errs := validation.IsQualifiedName(gpuType); len(errs) > 0 {
+		return fmt.Errorf("Invalid value %q for --gpu-type: %s", gpuType, strings.Join(errs, "; "))
+	}
+	// Extended resources are always prefixed with the domain of their vendor
+	if !strings.Contains(gpuType, "/") || strings.HasPrefix(gpuType, "kubernetes.io/") {
+		return fmt.Errorf("Invalid value %q for --gpu-type. It should be an extended resource like %s", gpuType, defaultGPUType)
+	}
+	return nil
+}
+
+// setGPU adds the GPUs to the limits of the function container. Extended resources
+// can't be overcommitted, so the request is the same as the limit. GPU nodes are
+// usually tainted with the name of the resource, so the pods tolerate that taint too.
+// Node selectors still apply, to choose a kind of GPU for example.
+func setGPU(f *kubelessApi.Function, gpus int64, gpuType string) {
+	container := &f.Spec.Deployment.Spec.Template.Spec.Containers[0]
+	// The limits may be shared with the requests, so they are copied
+	limits := v1.ResourceList{}
+	for name, quantity := range container.Resources.Limits {
+		limits[name] = quantity
+	}
+	limits[v1.ResourceName(gpuType)] = *resource.NewQuantity(gpus, resource.DecimalSI)
+	container.Resources.Limits = limits
+
+	podSpec := &f.Spec.Deployment.Spec.Template.Spec
+	for _, t := range podSpec.Tolerations {
+		if t.Key == gpuType && t.Operator == v1.TolerationOpExists && t.Effect == v1.TaintEffectNoSchedule {
+			return
+		}
+	}
+	podSpec.Tolerations = append(podSpec.Tolerations, v1.Toleration{
+		Key:      gpuType,
+		Operator: v1.TolerationOpExists,
+		Effect:   v1.TaintEffectNoSchedule,
+	})
+}
+
+// parseSidecar parses a container spec in YAML or JSON
+func parseSidecar(content []byte) (*v1.Container, error) {
+	container := &v1.Container{}
+	if err := yaml.UnmarshalStrict(content, container, yaml.DisallowUnknownFields); err != nil {
+		return nil, fmt.Errorf("Unable to parse the sidecar container: %v", err)
+	}
+	if container.Name == "" {
+		return nil, fmt.Errorf("The sidecar container should have a name")
+	}
+	if container.Image == "" {
+		return nil, fmt.Errorf("The sidecar container %s should have an image", container.Name)
+	}
+	return container, nil
+}
+
+// addSidecars appends the containers defined in the given files to the function pod.
+// The first container of the pod is the function itself and it's named after the function.
+func addSidecars(f *kubelessApi.Function, files []string) error {
+	podSpec := &f.Spec.Deployment.Spec.Template.Spec
+	if len(podSpec.Containers) == 0 {
+		podSpec.Containers = []v1.Container{{}}
+	}
+	names := map[string]bool{f.Name: true}
+	for _, c := range podSpec.Containers[1:] {
+		names[c.Name] = true
+	}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		sidecar, err := parseSidecar(content)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		if names[sidecar.Name] {
+			return fmt.Errorf("%s: the name of the sidecar container %s is already in use", file, sidecar.Name)
+		}
+		names[sidecar.Name] = true
+		podSpec.Containers = append(podSpec.Containers, *sidecar)
+	}
+	return nil
+}
+
 func parseResource(in string) (resource.Quantity, error) {
 	if in == "" {
 		return resource.Quantity{}, nil
@@ -214,6 +794,7 @@ func getFunctionDescription(funcName, ns, handler, file, deps, runtime, runtimeI
 
 	if len(defaultFunction.Spec.Deployment.Spec.Template.Spec.Containers) != 0 {
 		function.Spec.Deployment.Spec.Template.Spec.Containers[0].VolumeMounts = defaultFunction.Spec.Deployment.Spec.Template.Spec.Containers[0].VolumeMounts
+		function.Spec.Deployment.Spec.Template.Spec.Containers[0].Lifecycle = defaultFunction.Spec.Deployment.Spec.Template.Spec.Containers[0].Lifecycle
 	}
 
 	svcSpec := v1.ServiceSpec{
@@ -266,18 +847,72 @@ func getFunctionDescription(funcName, ns, handler, file, deps, runtime, runtimeI
 	return &function, nil
 }
 
+// imageDigestRegex matches the digest of an image reference (repo@sha256:...)
+var imageDigestRegex = regexp.MustCompile(`^(sha256:[a-f0-9]{64}|sha512:[a-f0-9]{128})$`)
+
+// imageNameRegex matches an image name with an optional registry and tag, following
+// the grammar of the Docker references (e.g. registry.example.com:5000/team/python:3.7)
+var imageNameRegex = regexp.MustCompile(`^` +
+	// Registry
+	`(?:(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?/)?` +
+	// Repository
+	`[a-z0-9]+(?:(?:[._]|__|-*)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-*)[a-z0-9]+)*)*` +
+	// Tag
+	`(?::[\w][\w.-]{0,127})?$`)
+
+// validateRuntimeImage checks the reference of a runtime image and its digest, if any. With
+// requireDigest, images referenced only by a tag (that can be moved) are rejected.
+func validateRuntimeImage(image string, requireDigest bool) error {
+	i := strings.LastIndex(image, "@")
+	if i < 0 {
+		if requireDigest {
+			if image == "" {
+				return fmt.Errorf("--require-digest needs a runtime image referenced by digest, set it with --runtime-image repo@sha256:<digest>")
+			}
+			return fmt.Errorf("The image %s is not referenced by digest and its tag can be moved. Use --runtime-image repo@sha256:<digest> or remove --require-digest", image)
+		}
+		return validateImageName(image, image)
+	}
+	if i == 0 {
+		return fmt.Errorf("Invalid image %s: missing the repository before the digest", image)
+	}
+	if !imageDigestRegex.MatchString(image[i+1:]) {
+		return fmt.Errorf("Invalid digest %q in the image %s. It should be sha256: followed by 64 hexadecimal characters", image[i+1:], image)
+	}
+	return validateImageName(image[:i], image)
+}
+
+// getRuntimeImageWarning reminds that a custom image replaces the one selected by the controller
+func getRuntimeImageWarning(runtime, image string) string {
+	if runtime == "" {
+		return fmt.Sprintf("Using the image %s for the function. Make sure that it can load the handler of the function", image)
+	}
+	return fmt.Sprintf("The image %s replaces the image of the runtime %s for this function. Kubeless doesn't check that it's compatible with the runtime and the handler of the function", image, runtime)
+}
+
+func validateImageName(name, image string) error {
+	if !imageNameRegex.MatchString(name) {
+		return fmt.Errorf("Invalid image %s. It should be in the form [registry/]repository[:tag][@digest], with a lowercase repository", image)
+	}
+	return nil
+}
+
 func getDeploymentStatus(cli kubernetes.Interface, funcName, ns string) (string, error) {
 	dpm, err := cli.AppsV1().Deployments(ns).Get(funcName, metav1.GetOptions{})
 	if err != nil {
 		return "", err
 	}
+	return formatDeploymentStatus(dpm), nil
+}
+
+func formatDeploymentStatus(dpm *appsv1.Deployment) string {
 	status := fmt.Sprintf("%d/%d", dpm.Status.ReadyReplicas, dpm.Status.Replicas)
 	if dpm.Status.ReadyReplicas > 0 {
 		status += " READY"
 	} else {
 		status += " NOT READY"
 	}
-	return status, nil
+	return status
 }
 
 func getFunctions(kubelessClient versioned.Interface, namespace, functionName string) ([]*kubelessApi.Function, error) {
@@ -29,15 +29,20 @@ import (
 	"net/http/httptest"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	fFake "github.com/kubeless/kubeless/pkg/client/clientset/versioned/fake"
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/api/autoscaling/v2beta1"
 	v1 "k8s.io/api/core/v1"
+	schedulingv1alpha1 "k8s.io/api/scheduling/v1alpha1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestParseLabel(t *testing.T) {
@@ -108,6 +113,328 @@ func TestParseNodeSelectors(t *testing.T) {
 	}
 }
 
+func TestParseEnvFromFile(t *testing.T) {
+	file, err := ioutil.TempFile("", "env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString("s3cr3t\n"); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	// It should read the value from the file
+	actual, err := parseEnvFromFile([]string{"TOKEN=" + file.Name()}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"TOKEN=s3cr3t"}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expect %v got %v", expected, actual)
+	}
+
+	// It should pipe the content through the decrypt command
+	actual, err = parseEnvFromFile([]string{"TOKEN=" + file.Name()}, "tr a-z A-Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"TOKEN=S3CR3T"}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expect %v got %v", expected, actual)
+	}
+
+	// The command is run by the shell, so quoted arguments are kept together
+	actual, err = parseEnvFromFile([]string{"TOKEN=" + file.Name()}, `sed 's/3/ and /g'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"TOKEN=s and cr and t"}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expect %v got %v", expected, actual)
+	}
+
+	// It should fail if the command fails or doesn't exist
+	if _, err := parseEnvFromFile([]string{"TOKEN=" + file.Name()}, "false"); err == nil {
+		t.Error("Expecting an error for a failing decrypt command")
+	}
+	if _, err := parseEnvFromFile([]string{"TOKEN=" + file.Name()}, "not-a-command"); err == nil {
+		t.Error("Expecting an error for an unknown decrypt command")
+	}
+	if _, err := parseEnvFromFile([]string{"TOKEN"}, ""); err == nil {
+		t.Error("Expecting an error for an entry without file")
+	}
+}
+
+func TestResolveFunctionRefs(t *testing.T) {
+	kubelessClient := fFake.NewSimpleClientset(
+		&kubelessApi.Function{ObjectMeta: metav1.ObjectMeta{Name: "backend", Namespace: "myns"}},
+		&kubelessApi.Function{ObjectMeta: metav1.ObjectMeta{Name: "nosvc", Namespace: "myns"}},
+	)
+	cli := fake.NewSimpleClientset(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "backend", Namespace: "myns"},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Name: "http-function-port", Port: 8080}},
+		},
+	})
+
+	envs, err := resolveFunctionRefs(kubelessClient, cli, "myns", []string{"foo=bar", "BACKEND_URL=@function:backend:url", "OTHER:@function:backend:url"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"foo=bar", "BACKEND_URL=http://backend.myns.svc:8080", "OTHER=http://backend.myns.svc:8080"}
+	if !reflect.DeepEqual(envs, expected) {
+		t.Errorf("Expecting %v, got %v", expected, envs)
+	}
+
+	for _, env := range []string{
+		"URL=@function:missing:url",
+		"URL=@function:nosvc:url",
+		"URL=@function:backend:port",
+		"URL=@function:backend",
+		"URL=@function::url",
+	} {
+		if _, err := resolveFunctionRefs(kubelessClient, cli, "myns", []string{env}); err == nil {
+			t.Errorf("Expecting an error for %s", env)
+		}
+	}
+}
+
+func TestGetOTelEnv(t *testing.T) {
+	envs, err := getOTelEnv("http://otel-collector.monitoring:4317", "", "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"OTEL_EXPORTER_OTLP_ENDPOINT=http://otel-collector.monitoring:4317", "OTEL_SERVICE_NAME=hello"}
+	if !reflect.DeepEqual(envs, expected) {
+		t.Errorf("Expecting %v, got %v", expected, envs)
+	}
+
+	envs, err = getOTelEnv("https://collector:4318", "checkout", "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"OTEL_EXPORTER_OTLP_ENDPOINT=https://collector:4318", "OTEL_SERVICE_NAME=checkout"}
+	if !reflect.DeepEqual(envs, expected) {
+		t.Errorf("Expecting %v, got %v", expected, envs)
+	}
+
+	envs, err = getOTelEnv("", "", "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(envs) != 0 {
+		t.Errorf("Expecting no env variables, got %v", envs)
+	}
+
+	for _, endpoint := range []string{"otel-collector:4317", "ftp://collector", "http://", "not a url"} {
+		if _, err := getOTelEnv(endpoint, "", "hello"); err == nil {
+			t.Errorf("Expecting an error for %s", endpoint)
+		}
+	}
+}
+
+func TestInferHandler(t *testing.T) {
+	for _, test := range []struct {
+		runtime  string
+		file     string
+		expected string
+	}{
+		{"python3.7", "handler.py", "handler.handler"},
+		{"nodejs10", "/tmp/functions/hello.js", "hello.handler"},
+		{"ruby2.4", "hello.rb", "hello.handler"},
+		{"go1.14", "hello.go", "hello.Handler"},
+		{"java1.8", "Foo.java", "Foo.handler"},
+		{"dotnetcore2.0", "helloget.cs", "module.handler"},
+		{"python3.7", "https://example.com/functions/hello.py?raw=true", "hello.handler"},
+	} {
+		handler, err := inferHandler(test.runtime, test.file)
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", test.file, err)
+		}
+		if handler != test.expected {
+			t.Errorf("Expecting %s for %s (%s), got %s", test.expected, test.file, test.runtime, handler)
+		}
+	}
+	for _, file := range []string{"", "function.zip", "function.tar.gz"} {
+		if _, err := inferHandler("python3.7", file); err == nil {
+			t.Errorf("Expecting an error for %q", file)
+		}
+	}
+}
+
+func TestParseBuildArgs(t *testing.T) {
+	env, err := parseBuildArgs([]string{"PIP_INDEX_URL=https://pypi.example.com/simple?a=b", "EMPTY="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []v1.EnvVar{
+		{Name: "PIP_INDEX_URL", Value: "https://pypi.example.com/simple?a=b"},
+		{Name: "EMPTY", Value: ""},
+	}
+	if !reflect.DeepEqual(expected, env) {
+		t.Errorf("Expect %v got %v", expected, env)
+	}
+	for _, arg := range []string{"FOO", "=bar", "1FOO=bar"} {
+		if _, err := parseBuildArgs([]string{arg}); err == nil {
+			t.Errorf("Expecting an error for %s", arg)
+		}
+	}
+}
+
+func TestSetBuildEnv(t *testing.T) {
+	f := &kubelessApi.Function{}
+	setBuildEnv(f, []v1.EnvVar{{Name: "FOO", Value: "bar"}})
+	initContainers := f.Spec.Deployment.Spec.Template.Spec.InitContainers
+	if len(initContainers) != 1 || initContainers[0].Env[0].Name != "FOO" {
+		t.Errorf("Expecting FOO in the first init container, got %v", initContainers)
+	}
+	if len(f.Spec.Deployment.Spec.Template.Spec.Containers) != 0 {
+		t.Error("The build env should not be set in the runtime container")
+	}
+}
+
+func TestParseGracePeriod(t *testing.T) {
+	for in, expected := range map[string]int64{"0": 0, "45": 45, "1m30s": 90} {
+		actual, err := parseGracePeriod(in)
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", in, err)
+		}
+		if actual != expected {
+			t.Errorf("Expect %d got %d", expected, actual)
+		}
+	}
+	for _, in := range []string{"-1", "1.5s", "foo", "-10s"} {
+		if _, err := parseGracePeriod(in); err == nil {
+			t.Errorf("Expecting an error for %s", in)
+		}
+	}
+}
+
+func TestParseFunctionTimeout(t *testing.T) {
+	for in, expected := range map[string]string{"30": "30", "5m": "300", "1h30m": "5400"} {
+		actual, err := parseFunctionTimeout(in)
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", in, err)
+		}
+		if actual != expected {
+			t.Errorf("Expect %s got %s", expected, actual)
+		}
+	}
+	for _, in := range []string{"0", "-5", "1.5s", "500ms", "foo"} {
+		if _, err := parseFunctionTimeout(in); err == nil {
+			t.Errorf("Expecting an error for %s", in)
+		}
+	}
+}
+
+func TestGetPreStopHook(t *testing.T) {
+	lifecycle, err := getPreStopHook("sleep 5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"/bin/sh", "-c", "sleep 5"}
+	if !reflect.DeepEqual(expected, lifecycle.PreStop.Exec.Command) {
+		t.Errorf("Expect %v got %v", expected, lifecycle.PreStop.Exec.Command)
+	}
+	if _, err := getPreStopHook("  "); err == nil {
+		t.Error("Expecting an error for an empty command")
+	}
+}
+
+func TestParsePodSecurityContext(t *testing.T) {
+	sc, seccomp, err := parsePodSecurityContext([]byte(`
+runAsNonRoot: true
+runAsUser: 1001
+fsGroup: 2000
+seccompProfile:
+  type: RuntimeDefault
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !*sc.RunAsNonRoot || *sc.RunAsUser != 1001 || *sc.FSGroup != 2000 {
+		t.Errorf("Unexpected security context %+v", sc)
+	}
+	if seccomp != "runtime/default" {
+		t.Errorf("Expecting runtime/default, got %s", seccomp)
+	}
+
+	sc, seccomp, err = parsePodSecurityContext([]byte(`{"seccompProfile": {"type": "Localhost", "localhostProfile": "profiles/audit.json"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seccomp != "localhost/profiles/audit.json" {
+		t.Errorf("Expecting localhost/profiles/audit.json, got %s", seccomp)
+	}
+
+	for _, content := range []string{
+		"runAsUser: foo",
+		"runAsNonRoot: true\nreadOnlyRootFilesystem: true",
+		"seccompProfile:\n  type: Foo",
+		"seccompProfile:\n  type: Localhost",
+	} {
+		if _, _, err := parsePodSecurityContext([]byte(content)); err == nil {
+			t.Errorf("Expecting an error for %q", content)
+		}
+	}
+}
+
+func TestGetContainerSecurityContext(t *testing.T) {
+	sc := getContainerSecurityContext(true, false)
+	if !*sc.RunAsNonRoot || sc.ReadOnlyRootFilesystem != nil {
+		t.Errorf("Unexpected security context %+v", sc)
+	}
+	sc = getContainerSecurityContext(false, true)
+	if sc.RunAsNonRoot != nil || !*sc.ReadOnlyRootFilesystem {
+		t.Errorf("Unexpected security context %+v", sc)
+	}
+}
+
+func TestAddSidecars(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sidecar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeSidecar := func(name, content string) string {
+		path := dir + "/" + name
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	proxy := writeSidecar("proxy.yaml", "name: proxy\nimage: envoyproxy/envoy:v1.14.1\nvolumeMounts:\n- name: config\n  mountPath: /etc/envoy\n")
+	shipper := writeSidecar("shipper.json", `{"name": "shipper", "image": "fluent/fluent-bit:1.4"}`)
+
+	f := &kubelessApi.Function{ObjectMeta: metav1.ObjectMeta{Name: "hello"}}
+	if err := addSidecars(f, []string{proxy, shipper}); err != nil {
+		t.Fatal(err)
+	}
+	containers := f.Spec.Deployment.Spec.Template.Spec.Containers
+	if len(containers) != 3 || containers[1].Name != "proxy" || containers[2].Name != "shipper" {
+		t.Fatalf("Unexpected containers %+v", containers)
+	}
+	if containers[1].VolumeMounts[0].MountPath != "/etc/envoy" {
+		t.Errorf("Expecting the volume mounts of the sidecar, got %+v", containers[1].VolumeMounts)
+	}
+
+	for _, test := range []struct {
+		name    string
+		content string
+	}{
+		{"no-name.yaml", "image: envoyproxy/envoy:v1.14.1"},
+		{"no-image.yaml", "name: proxy2"},
+		{"unknown.yaml", "name: proxy2\nimage: envoy\nfoo: bar"},
+		{"function.yaml", "name: hello\nimage: envoy"},
+		{"duplicated.yaml", "name: proxy\nimage: envoy"},
+	} {
+		if err := addSidecars(f, []string{writeSidecar(test.name, test.content)}); err == nil {
+			t.Errorf("Expecting an error for %s", test.name)
+		}
+	}
+}
+
 func TestGetFunctionDescription(t *testing.T) {
 	// It should parse the given values
 	file, err := ioutil.TempFile("", "test")
@@ -601,3 +928,192 @@ func getSha256(bytes []byte) (string, error) {
 	checksum := hex.EncodeToString(h.Sum(nil))
 	return "sha256:" + checksum, nil
 }
+
+func TestValidateRuntimeImage(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a1", 32)
+	for _, test := range []struct {
+		image         string
+		requireDigest bool
+		valid         bool
+	}{
+		{"kubeless/python:3.7", false, true},
+		{"kubeless/python:3.7", true, false},
+		{"", true, false},
+		{"kubeless/python@" + digest, true, true},
+		{"registry.example.com:5000/team/python:3.7@" + digest, true, true},
+		{"kubeless/python@sha256:1234", false, false},
+		{"kubeless/python@md5:" + strings.Repeat("a", 32), false, false},
+		{"@" + digest, false, false},
+		{"localhost:5000/python:3.7-cve-2020-1234", false, true},
+		{"python", false, true},
+		{"kubeless/Python:3.7", false, false},
+		{"kubeless/python:", false, false},
+		{"kubeless//python", false, false},
+		{"kubeless/python:3.7 ", false, false},
+		{"https://kubeless/python", false, false},
+		{"kubeless/Python@" + digest, true, false},
+	} {
+		err := validateRuntimeImage(test.image, test.requireDigest)
+		if test.valid && err != nil {
+			t.Errorf("Unexpected error for %q: %v", test.image, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("Expecting an error for %q (require digest: %v)", test.image, test.requireDigest)
+		}
+	}
+}
+
+func TestPodAnnotations(t *testing.T) {
+	annotations, err := parsePodAnnotations([]string{
+		"sidecar.istio.io/inject=false",
+		"prometheus.io/path=/custom/metrics?format=prometheus",
+		"empty=",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"sidecar.istio.io/inject": "false",
+		"prometheus.io/path":      "/custom/metrics?format=prometheus",
+		"empty":                   "",
+	}
+	if !reflect.DeepEqual(annotations, expected) {
+		t.Errorf("Expecting %v, got %v", expected, annotations)
+	}
+	for _, annotation := range []string{"no-value", "=value", "invalid key=value", "a/b/c=d"} {
+		if _, err := parsePodAnnotations([]string{annotation}); err == nil {
+			t.Errorf("Expecting an error for %q", annotation)
+		}
+	}
+
+	f := &kubelessApi.Function{}
+	f.Spec.Deployment.Spec.Template.Annotations = map[string]string{seccompPodAnnotation: "runtime/default"}
+	setPodAnnotations(f, expected)
+	if len(f.Spec.Deployment.Spec.Template.Annotations) != 4 || f.Spec.Deployment.Spec.Template.Annotations[seccompPodAnnotation] != "runtime/default" {
+		t.Errorf("Unexpected pod annotations %v", f.Spec.Deployment.Spec.Template.Annotations)
+	}
+}
+
+func TestValidatePriorityClass(t *testing.T) {
+	cli := fake.NewSimpleClientset(&schedulingv1alpha1.PriorityClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "critical"},
+		Value:      1000000,
+	})
+	if err := validatePriorityClass(cli, "critical"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := validatePriorityClass(cli, "missing"); err == nil {
+		t.Error("Expecting an error for a missing PriorityClass")
+	}
+	if err := validatePriorityClassName("critical"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := validatePriorityClassName("Not_Valid"); err == nil {
+		t.Error("Expecting an error for an invalid name")
+	}
+}
+
+func TestScaleToZero(t *testing.T) {
+	for idle, valid := range map[time.Duration]bool{
+		5 * time.Minute:               true,
+		90 * time.Second:              true,
+		30 * time.Second:              false,
+		time.Minute + time.Nanosecond: false,
+	} {
+		if err := validateScaleToZeroIdle(idle); (err == nil) != valid {
+			t.Errorf("Unexpected result for %v: %v", idle, err)
+		}
+	}
+
+	hpa := getScaleToZeroAutoscaler("foo", "myns", 3, map[string]string{"function": "foo"})
+	if hpa.Spec.MinReplicas == nil || *hpa.Spec.MinReplicas != 0 || hpa.Spec.MaxReplicas != 3 {
+		t.Errorf("Expecting between 0 and 3 replicas, got %v and %d", hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas)
+	}
+	if hpa.Name != "foo" || hpa.Spec.ScaleTargetRef.Name != "foo" {
+		t.Errorf("Unexpected autoscaler target %s", hpa.Spec.ScaleTargetRef.Name)
+	}
+	// The controller creates a service monitor for object metrics
+	if len(hpa.Spec.Metrics) != 1 || hpa.Spec.Metrics[0].Type != v2beta1.ObjectMetricSourceType || hpa.Spec.Metrics[0].Object.Target.Name != "foo" {
+		t.Errorf("Unexpected metrics %+v", hpa.Spec.Metrics)
+	}
+}
+
+func TestGPU(t *testing.T) {
+	for _, test := range []struct {
+		gpus    int64
+		gpuType string
+		valid   bool
+	}{
+		{1, defaultGPUType, true},
+		{2, "amd.com/gpu", true},
+		{0, defaultGPUType, false},
+		{1, "gpu", false},
+		{1, "kubernetes.io/gpu", false},
+		{1, "nvidia.com/gpu!", false},
+	} {
+		if err := validateGPU(test.gpus, test.gpuType); (err == nil) != test.valid {
+			t.Errorf("Unexpected result for %d %s: %v", test.gpus, test.gpuType, err)
+		}
+	}
+
+	f, err := getFunctionDescription("test", "default", "", "", "", "python3.7", "", "128Mi", "", "", "Always", "", 8080, 0, false, []string{}, []string{}, []string{}, []string{"cloud.google.com/gke-accelerator=nvidia-tesla-t4"}, kubelessApi.Function{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	setGPU(f, 2, defaultGPUType)
+	setGPU(f, 2, defaultGPUType)
+	resources := f.Spec.Deployment.Spec.Template.Spec.Containers[0].Resources
+	gpus := resources.Limits[v1.ResourceName(defaultGPUType)]
+	if gpus.Value() != 2 || resources.Limits.Memory().String() != "128Mi" {
+		t.Errorf("Unexpected limits %v", resources.Limits)
+	}
+	if _, ok := resources.Requests[v1.ResourceName(defaultGPUType)]; ok {
+		t.Error("The requests of extended resources should default to the limits")
+	}
+	podSpec := f.Spec.Deployment.Spec.Template.Spec
+	if len(podSpec.Tolerations) != 1 || podSpec.Tolerations[0].Key != defaultGPUType {
+		t.Errorf("Expecting a single toleration for the GPU taint, got %v", podSpec.Tolerations)
+	}
+	if podSpec.NodeSelector["cloud.google.com/gke-accelerator"] != "nvidia-tesla-t4" {
+		t.Errorf("Expecting the node selectors to be kept, got %v", podSpec.NodeSelector)
+	}
+}
+
+func TestSetRollingUpdate(t *testing.T) {
+	f := &kubelessApi.Function{}
+	if err := setRollingUpdate(f, "2", "10%"); err != nil {
+		t.Fatal(err)
+	}
+	rollingUpdate := f.Spec.Deployment.Spec.Strategy.RollingUpdate
+	if rollingUpdate.MaxSurge.String() != "2" || rollingUpdate.MaxUnavailable.String() != "10%" {
+		t.Errorf("Unexpected rolling update %v", rollingUpdate)
+	}
+	// Empty values keep the current settings
+	if err := setRollingUpdate(f, "", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if rollingUpdate := f.Spec.Deployment.Spec.Strategy.RollingUpdate; rollingUpdate.MaxSurge.String() != "2" || rollingUpdate.MaxUnavailable.String() != "1" {
+		t.Errorf("Unexpected rolling update %v", rollingUpdate)
+	}
+
+	tests := []struct {
+		maxSurge       string
+		maxUnavailable string
+	}{
+		{"-1", ""},
+		{"", "150%"},
+		{"foo", ""},
+		{"0", ""},
+		{"0%", "0"},
+	}
+	for _, test := range tests {
+		if err := setRollingUpdate(&kubelessApi.Function{}, test.maxSurge, test.maxUnavailable); err == nil {
+			t.Errorf("Expecting an error for --max-surge %q --max-unavailable %q", test.maxSurge, test.maxUnavailable)
+		}
+	}
+	recreate := &kubelessApi.Function{}
+	recreate.Spec.Deployment.Spec.Strategy.Type = appsv1.RecreateDeploymentStrategyType
+	if err := setRollingUpdate(recreate, "1", ""); err == nil {
+		t.Error("Expecting an error for a function with the Recreate strategy")
+	}
+}
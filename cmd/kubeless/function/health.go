@@ -0,0 +1,223 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const defaultHealthPath = "/healthz"
+
+var healthCmd = &cobra.Command{
+	Use:   "health <function_name> FLAG",
+	Short: "check the health of every replica of a function",
+	Long: `run the readiness check of a function against each of its pods and report how many of them are healthy.
+The command fails if any pod is unhealthy`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			logrus.Fatal("Need exactly one argument - function name")
+		}
+		funcName := args[0]
+
+		ns, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if ns == "" {
+			ns = utils.GetDefaultNamespace()
+		}
+		probePath, err := cmd.Flags().GetString("probe-path")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if timeout <= 0 {
+			logrus.Fatal("The timeout must be greater than 0")
+		}
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if output != "" && output != "json" {
+			logrus.Fatal("Wrong output format. Please use only json")
+		}
+
+		clientset := utils.GetClientOutOfCluster()
+		report, err := checkFunctionHealth(clientset, ns, funcName, probePath, newPodProber(clientset, ns, timeout))
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if err := printHealthReport(cmd.OutOrStdout(), report, output); err != nil {
+			logrus.Fatal(err)
+		}
+		if report.Healthy < report.Total {
+			logrus.Fatalf("%d of %d pods of %s are unhealthy", report.Total-report.Healthy, report.Total, funcName)
+		}
+	},
+}
+
+func init() {
+	healthCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function")
+	healthCmd.Flags().String("probe-path", "", "HTTP path to check. Defaults to the path of the readiness (or liveness) probe of the function, or "+defaultHealthPath)
+	healthCmd.Flags().Duration("timeout", 5*time.Second, "Maximum time to wait for the response of each pod")
+	healthCmd.Flags().StringP("output", "o", "", "Output format. One of: json")
+}
+
+type podHealth struct {
+	Pod     string        `json:"pod"`
+	Healthy bool          `json:"healthy"`
+	Status  int           `json:"status,omitempty"`
+	Latency time.Duration `json:"-"`
+	// The latency in milliseconds, for the JSON output
+	LatencyMs int64  `json:"latencyMs,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type healthReport struct {
+	Function  string      `json:"function"`
+	Namespace string      `json:"namespace"`
+	Path      string      `json:"path"`
+	Healthy   int         `json:"healthy"`
+	Total     int         `json:"total"`
+	Pods      []podHealth `json:"pods"`
+}
+
+// podProber requests the given path of a pod and returns the HTTP status of the response
+type podProber func(pod, port, path string) (int, error)
+
+// newPodProber returns a podProber that calls the pods through the API server proxy
+func newPodProber(clientset kubernetes.Interface, ns string, timeout time.Duration) podProber {
+	return func(pod, port, path string) (int, error) {
+		status := 0
+		err := clientset.CoreV1().RESTClient().Get().Namespace(ns).Resource("pods").SubResource("proxy").
+			Name(pod + ":" + port).Suffix(path).Timeout(timeout).Do().StatusCode(&status).Error()
+		return status, err
+	}
+}
+
+// getHealthProbe returns the path and port checked in a pod: the ones of the HTTP readiness probe
+// of the function container, or of its liveness probe, falling back to /healthz and the given port
+func getHealthProbe(pod v1.Pod, defaultPort string) (string, string) {
+	path, port := defaultHealthPath, defaultPort
+	if len(pod.Spec.Containers) == 0 {
+		return path, port
+	}
+	c := pod.Spec.Containers[0]
+	for _, probe := range []*v1.Probe{c.ReadinessProbe, c.LivenessProbe} {
+		if probe != nil && probe.HTTPGet != nil {
+			if probe.HTTPGet.Path != "" {
+				path = probe.HTTPGet.Path
+			}
+			if p := probe.HTTPGet.Port.String(); p != "" && p != "0" {
+				port = p
+			}
+			break
+		}
+	}
+	return path, port
+}
+
+// checkFunctionHealth probes every pod of the function. Pods that are not running are unhealthy.
+// A probe succeeds with a status between 200 and 399, like the HTTP probes of Kubernetes.
+func checkFunctionHealth(clientset kubernetes.Interface, ns, funcName, probePath string, probe podProber) (*healthReport, error) {
+	port, err := getFunctionTargetPort(clientset, ns, funcName)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := utils.GetPodsByLabel(clientset, ns, "function", funcName)
+	if err != nil {
+		return nil, fmt.Errorf("Can't find the function pods: %v", err)
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("There are no pods for the function %s", funcName)
+	}
+
+	report := &healthReport{Function: funcName, Namespace: ns, Path: probePath, Total: len(pods.Items)}
+	for _, pod := range pods.Items {
+		result := podHealth{Pod: pod.Name}
+		path, podPort := getHealthProbe(pod, port)
+		if probePath != "" {
+			path = probePath
+		}
+		if report.Path == "" {
+			report.Path = path
+		}
+		if pod.Status.Phase != v1.PodRunning {
+			result.Error = fmt.Sprintf("Pod is %s", pod.Status.Phase)
+		} else {
+			start := time.Now()
+			result.Status, err = probe(pod.Name, podPort, path)
+			result.Latency = time.Since(start)
+			result.LatencyMs = int64(result.Latency / time.Millisecond)
+			if err != nil {
+				result.Error = err.Error()
+			} else if result.Status < 200 || result.Status >= 400 {
+				result.Error = fmt.Sprintf("Unexpected status %d", result.Status)
+			} else {
+				result.Healthy = true
+				report.Healthy++
+			}
+		}
+		report.Pods = append(report.Pods, result)
+	}
+	sort.Slice(report.Pods, func(i, j int) bool {
+		return report.Pods[i].Pod < report.Pods[j].Pod
+	})
+	return report, nil
+}
+
+func printHealthReport(w io.Writer, report *healthReport, output string) error {
+	if output == "json" {
+		b, err := utils.MarshalJSON(report, "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(b))
+		return nil
+	}
+	table := uitable.New()
+	table.MaxColWidth = 80
+	table.Wrap = true
+	table.AddRow("POD", "STATUS", "LATENCY", "MESSAGE")
+	for _, p := range report.Pods {
+		status := "HEALTHY"
+		if !p.Healthy {
+			status = "UNHEALTHY"
+		}
+		latency := ""
+		if p.Latency > 0 {
+			latency = p.Latency.Round(time.Millisecond).String()
+		}
+		table.AddRow(p.Pod, status, latency, p.Error)
+	}
+	fmt.Fprintln(w, table)
+	fmt.Fprintf(w, "%d/%d pods healthy\n", report.Healthy, report.Total)
+	return nil
+}
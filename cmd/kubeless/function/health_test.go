@@ -0,0 +1,131 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kubeless/kubeless/pkg/utils"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCheckFunctionHealth(t *testing.T) {
+	svc := v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns"},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Port: 8080}},
+		},
+	}
+	pod := func(name string, phase v1.PodPhase, probe *v1.Probe) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "myns", Labels: map[string]string{"function": "foo"}},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "foo", ReadinessProbe: probe}}},
+			Status:     v1.PodStatus{Phase: phase},
+		}
+	}
+	readiness := &v1.Probe{
+		Handler: v1.Handler{
+			HTTPGet: &v1.HTTPGetAction{Path: "/ready", Port: intstr.FromInt(9090)},
+		},
+	}
+	clientset := fake.NewSimpleClientset(&svc,
+		pod("foo-a", v1.PodRunning, nil),
+		pod("foo-b", v1.PodRunning, readiness),
+		pod("foo-c", v1.PodPending, nil),
+		pod("foo-d", v1.PodRunning, nil),
+	)
+	probed := map[string]string{}
+	prober := func(pod, port, path string) (int, error) {
+		probed[pod] = port + path
+		switch pod {
+		case "foo-d":
+			return 503, fmt.Errorf("the server is currently unable to handle the request")
+		default:
+			return 200, nil
+		}
+	}
+
+	report, err := checkFunctionHealth(clientset, "myns", "foo", "", prober)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Healthy != 2 || report.Total != 4 {
+		t.Errorf("Expecting 2/4 healthy pods, got %d/%d", report.Healthy, report.Total)
+	}
+	if probed["foo-a"] != "8080/healthz" || probed["foo-b"] != "9090/ready" {
+		t.Errorf("Unexpected probes %v", probed)
+	}
+	if _, ok := probed["foo-c"]; ok {
+		t.Error("Pods not running should not be probed")
+	}
+	if report.Pods[2].Healthy || report.Pods[2].Error != "Pod is Pending" {
+		t.Errorf("Unexpected result for a pending pod: %+v", report.Pods[2])
+	}
+	if report.Pods[3].Healthy || report.Pods[3].Status != 503 {
+		t.Errorf("Unexpected result for a failing pod: %+v", report.Pods[3])
+	}
+
+	// The given path overrides the probes
+	if _, err := checkFunctionHealth(clientset, "myns", "foo", "/status", prober); err != nil {
+		t.Fatal(err)
+	}
+	if probed["foo-a"] != "8080/status" || probed["foo-b"] != "9090/status" {
+		t.Errorf("Unexpected probes %v", probed)
+	}
+
+	if _, err := checkFunctionHealth(clientset, "myns", "bar", "", prober); err == nil {
+		t.Error("Expecting an error for a missing function")
+	}
+}
+
+func TestPrintHealthReport(t *testing.T) {
+	utils.SetPrettyJSON(false)
+	defer utils.SetPrettyJSON(utils.PrettyJSONDefault())
+	report := &healthReport{
+		Function:  "foo",
+		Namespace: "myns",
+		Path:      "/healthz",
+		Healthy:   1,
+		Total:     2,
+		Pods: []podHealth{
+			{Pod: "foo-a", Healthy: true, Status: 200},
+			{Pod: "foo-b", Error: "Pod is Pending"},
+		},
+	}
+	var buf bytes.Buffer
+	if err := printHealthReport(&buf, report, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "UNHEALTHY") || !strings.Contains(buf.String(), "1/2 pods healthy") {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := printHealthReport(&buf, report, "json"); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"function":"foo","namespace":"myns","path":"/healthz","healthy":1,"total":2,"pods":[{"pod":"foo-a","healthy":true,"status":200},{"pod":"foo-b","healthy":false,"error":"Pod is Pending"}]}`
+	if strings.TrimSpace(buf.String()) != expected {
+		t.Errorf("Expecting:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
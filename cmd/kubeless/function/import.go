@@ -0,0 +1,316 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"text/template"
+
+	"github.com/ghodss/yaml"
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	"github.com/kubeless/kubeless/pkg/client/clientset/versioned"
+	kubelessutil "github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <manifest|directory> FLAG",
+	Short: "create or update a function from a manifest",
+	Long: `create or update a function from a Function manifest in YAML or JSON.
+
+With --template-values the manifest is rendered as a Go template before applying it, using the
+values of the given file. For example, {{ .replicas }} is replaced with the value of "replicas".
+A placeholder without a value is an error.
+
+If a directory is given, every YAML and JSON file in it (and in its subdirectories with
+--recursive) is applied. The files can contain Function, HTTPTrigger or CronJobTrigger
+manifests, and the functions are applied before the triggers. With --prune, the functions and
+triggers matching --selector in the namespaces of the manifests that are not in the directory
+are deleted.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := cmd.Flags().GetString("filename")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if file != "" {
+			if len(args) != 0 {
+				logrus.Fatal("The manifest should be given either as argument or with --filename")
+			}
+			args = []string{file}
+		}
+		if len(args) != 1 {
+			logrus.Fatal("Need exactly one argument - manifest file")
+		}
+
+		valuesFile, err := cmd.Flags().GetString("template-values")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		values := map[string]interface{}{}
+		if valuesFile != "" {
+			values, err = readTemplateValues(valuesFile)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+		}
+		ns, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		dryrun, err := cmd.Flags().GetBool("dryrun")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		recursive, err := cmd.Flags().GetBool("recursive")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		prune, err := cmd.Flags().GetBool("prune")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		selector, err := cmd.Flags().GetString("selector")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		info, err := os.Stat(args[0])
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if info.IsDir() {
+			if prune && selector == "" {
+				logrus.Fatal("--prune requires --selector to choose the objects that can be deleted")
+			}
+			importDirectory(cmd.OutOrStdout(), args[0], recursive, valuesFile != "", values, ns, dryrun, output, prune, selector)
+			return
+		}
+		if recursive || prune {
+			logrus.Fatal("--recursive and --prune can only be used with a directory")
+		}
+
+		content, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if valuesFile != "" {
+			content, err = renderManifest(content, values)
+			if err != nil {
+				logrus.Fatalf("Unable to render %s: %v", args[0], err)
+			}
+		}
+
+		f, err := parseFunctionManifest(content)
+		if err != nil {
+			logrus.Fatalf("Unable to parse %s: %v", args[0], err)
+		}
+		setImportNamespace(&f.ObjectMeta, ns)
+
+		if dryrun {
+			res, err := kubelessutil.DryRunFmt(output, f)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			fmt.Println(res)
+			return
+		}
+
+		kubelessClient, err := kubelessutil.GetKubelessClientOutCluster()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		created, err := applyFunction(kubelessClient, f)
+		if err != nil {
+			logrus.Fatalf("Failed to import %s: %v", f.Name, err)
+		}
+		if created {
+			logrus.Infof("Function %s created in namespace %s", f.Name, f.Namespace)
+		} else {
+			logrus.Infof("Function %s updated in namespace %s", f.Name, f.Namespace)
+		}
+	},
+}
+
+func init() {
+	importCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function. It overrides the namespace of the manifest")
+	importCmd.Flags().StringP("filename", "f", "", "Specify the manifest or directory to import, instead of giving it as argument")
+	importCmd.Flags().BoolP("recursive", "R", false, "Import also the manifests of the subdirectories of the directory")
+	importCmd.Flags().Bool("prune", false, "Delete the functions and triggers matching --selector that are not in the directory")
+	importCmd.Flags().StringP("selector", "l", "", "Label selector of the objects that can be deleted with --prune, e.g. app=shop")
+	importCmd.Flags().StringP("template-values", "", "", "Specify a YAML or JSON file with the values used to render the manifest as a Go template")
+	importCmd.Flags().Bool("dryrun", false, "Output the manifest of the function without applying it")
+	importCmd.Flags().StringP("output", "o", "yaml", "Output format")
+}
+
+// setImportNamespace sets the namespace given in the command line or, if the manifest has no
+// namespace, the default one
+func setImportNamespace(meta *metav1.ObjectMeta, ns string) {
+	if ns != "" {
+		meta.Namespace = ns
+	} else if meta.Namespace == "" {
+		meta.Namespace = kubelessutil.GetDefaultNamespace()
+	}
+}
+
+// importDirectory applies the manifests of a directory. Every file is parsed before applying
+// anything, so an invalid manifest doesn't leave the directory half applied.
+func importDirectory(w io.Writer, dir string, recursive, render bool, values map[string]interface{}, ns string, dryrun bool, output string, prune bool, selector string) {
+	files, err := findManifestFiles(dir, recursive)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if len(files) == 0 {
+		logrus.Fatalf("No YAML or JSON manifest found in %s", dir)
+	}
+	manifests := []*importManifest{}
+	invalid := 0
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err == nil && render {
+			content, err = renderManifest(content, values)
+		}
+		var m *importManifest
+		if err == nil {
+			m, err = parseImportManifest(file, content)
+		}
+		if err != nil {
+			invalid++
+			fmt.Fprintf(w, "%s: invalid manifest: %v\n", file, err)
+			continue
+		}
+		setImportNamespace(m.meta(), ns)
+		manifests = append(manifests, m)
+	}
+	if invalid > 0 {
+		logrus.Fatalf("%d of %d manifests are invalid, nothing has been applied", invalid, len(files))
+	}
+	sortImportManifests(manifests)
+
+	clients := importClients{}
+	if !dryrun || prune {
+		if clients.kubeless, err = kubelessutil.GetKubelessClientOutCluster(); err != nil {
+			logrus.Fatal(err)
+		}
+		if clients.http, err = kubelessutil.GetHTTPTriggerClientOutCluster(); err != nil {
+			logrus.Fatal(err)
+		}
+		if clients.cronjob, err = kubelessutil.GetCronJobTriggerClientOutCluster(); err != nil {
+			logrus.Fatal(err)
+		}
+	}
+
+	if dryrun {
+		for i, m := range manifests {
+			res, err := kubelessutil.DryRunFmt(output, m.object())
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			if i > 0 && output == "yaml" {
+				fmt.Fprintln(w, "---")
+			}
+			fmt.Fprintln(w, res)
+		}
+		if prune {
+			candidates, err := getPruneCandidates(clients, manifests, selector)
+			if err != nil {
+				logrus.Fatalf("Unable to list the objects to prune: %v", err)
+			}
+			for _, key := range candidates {
+				logrus.Infof("%s would be pruned", key)
+			}
+		}
+		return
+	}
+
+	if err := importManifests(w, clients, manifests, prune, selector); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+// readTemplateValues reads the values used to render a manifest
+func readTemplateValues(file string) (map[string]interface{}, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return nil, fmt.Errorf("Unable to parse %s: %v", file, err)
+	}
+	return values, nil
+}
+
+// renderManifest executes the manifest as a Go template. Placeholders without a value are an error.
+func renderManifest(content []byte, values map[string]interface{}) ([]byte, error) {
+	tmpl, err := template.New("manifest").Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, values); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// parseFunctionManifest parses a Function manifest in YAML or JSON
+func parseFunctionManifest(content []byte) (*kubelessApi.Function, error) {
+	f := &kubelessApi.Function{}
+	if err := yaml.Unmarshal(content, f); err != nil {
+		return nil, err
+	}
+	if f.Kind != "" && f.Kind != "Function" {
+		return nil, fmt.Errorf("Expecting a Function manifest, got %s", f.Kind)
+	}
+	if f.Name == "" {
+		return nil, fmt.Errorf("The manifest doesn't contain the name of the function")
+	}
+	f.TypeMeta = metav1.TypeMeta{
+		Kind:       "Function",
+		APIVersion: "kubeless.io/v1beta1",
+	}
+	// The manifest may be exported from another cluster
+	clearServerFields(&f.ObjectMeta)
+	return f, nil
+}
+
+// applyFunction creates the function or updates it if it already exists.
+// It returns true if the function has been created.
+func applyFunction(kubelessClient versioned.Interface, f *kubelessApi.Function) (bool, error) {
+	current, err := kubelessClient.KubelessV1beta1().Functions(f.Namespace).Get(f.Name, metav1.GetOptions{})
+	if err != nil {
+		if !k8sErrors.IsNotFound(err) {
+			return false, err
+		}
+		_, err = kubelessClient.KubelessV1beta1().Functions(f.Namespace).Create(f)
+		return err == nil, err
+	}
+	f.ResourceVersion = current.ResourceVersion
+	_, err = kubelessClient.KubelessV1beta1().Functions(f.Namespace).Update(f)
+	return false, err
+}
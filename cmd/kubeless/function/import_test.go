@@ -0,0 +1,110 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"strings"
+	"testing"
+
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	fFake "github.com/kubeless/kubeless/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const manifestTemplate = `apiVersion: kubeless.io/v1beta1
+kind: Function
+metadata:
+  name: hello
+  namespace: {{ .namespace }}
+  resourceVersion: "42"
+spec:
+  runtime: python3.7
+  handler: hello.handler
+  function: |
+    def handler(event, context):
+      return "{{ .greeting }}"
+`
+
+func TestRenderManifest(t *testing.T) {
+	content, err := renderManifest([]byte(manifestTemplate), map[string]interface{}{"namespace": "staging", "greeting": "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parseFunctionManifest(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Namespace != "staging" || !strings.Contains(f.Spec.Function, "return \"hi\"") {
+		t.Errorf("Unexpected function %+v", f)
+	}
+	if f.ResourceVersion != "" {
+		t.Errorf("Expecting the resourceVersion of the manifest to be dropped")
+	}
+
+	_, err = renderManifest([]byte(manifestTemplate), map[string]interface{}{"namespace": "staging"})
+	if err == nil || !strings.Contains(err.Error(), "greeting") {
+		t.Errorf("Expecting an error for the missing greeting value, got %v", err)
+	}
+}
+
+func TestParseFunctionManifest(t *testing.T) {
+	for _, manifest := range []string{
+		"kind: CronJobTrigger\nmetadata:\n  name: foo",
+		"kind: Function\nspec:\n  runtime: python3.7",
+		"kind: [",
+	} {
+		if _, err := parseFunctionManifest([]byte(manifest)); err == nil {
+			t.Errorf("Expecting an error for %q", manifest)
+		}
+	}
+}
+
+func TestApplyFunction(t *testing.T) {
+	existing := &kubelessApi.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "myns", ResourceVersion: "1"},
+		Spec:       kubelessApi.FunctionSpec{Runtime: "python2.7"},
+	}
+	client := fFake.NewSimpleClientset(existing)
+
+	f := &kubelessApi.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "myns"},
+		Spec:       kubelessApi.FunctionSpec{Runtime: "python3.7"},
+	}
+	created, err := applyFunction(client, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created {
+		t.Error("Expecting the existing function to be updated")
+	}
+	updated, err := client.KubelessV1beta1().Functions("myns").Get("hello", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Spec.Runtime != "python3.7" {
+		t.Errorf("Expecting the runtime to be updated, got %s", updated.Spec.Runtime)
+	}
+
+	f = &kubelessApi.Function{ObjectMeta: metav1.ObjectMeta{Name: "bye", Namespace: "myns"}}
+	created, err = applyFunction(client, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created {
+		t.Error("Expecting the function to be created")
+	}
+}
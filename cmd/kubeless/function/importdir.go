@@ -0,0 +1,314 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	cronjobClientset "github.com/kubeless/cronjob-trigger/pkg/client/clientset/versioned"
+	httpApi "github.com/kubeless/http-trigger/pkg/apis/kubeless/v1beta1"
+	httpClientset "github.com/kubeless/http-trigger/pkg/client/clientset/versioned"
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	"github.com/kubeless/kubeless/pkg/client/clientset/versioned"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// importKinds are the kinds accepted when importing a directory, in the order they are applied
+// so the functions exist before the triggers that reference them
+var importKinds = []string{"Function", "HTTPTrigger", "CronJobTrigger"}
+
+// importManifest is a manifest read from a directory. Only the object of its kind is set.
+type importManifest struct {
+	file     string
+	function *kubelessApi.Function
+	http     *httpApi.HTTPTrigger
+	cronjob  *cronjobApi.CronJobTrigger
+}
+
+// importClients are the clients used to apply the manifests of a directory
+type importClients struct {
+	kubeless versioned.Interface
+	http     httpClientset.Interface
+	cronjob  cronjobClientset.Interface
+}
+
+func (m *importManifest) kind() string {
+	switch {
+	case m.http != nil:
+		return "HTTPTrigger"
+	case m.cronjob != nil:
+		return "CronJobTrigger"
+	default:
+		return "Function"
+	}
+}
+
+func (m *importManifest) meta() *metav1.ObjectMeta {
+	switch {
+	case m.http != nil:
+		return &m.http.ObjectMeta
+	case m.cronjob != nil:
+		return &m.cronjob.ObjectMeta
+	default:
+		return &m.function.ObjectMeta
+	}
+}
+
+func (m *importManifest) object() interface{} {
+	switch {
+	case m.http != nil:
+		return m.http
+	case m.cronjob != nil:
+		return m.cronjob
+	default:
+		return m.function
+	}
+}
+
+// findManifestFiles returns the YAML and JSON files of the directory, in lexical order.
+// The files of the subdirectories are only included if recursive is true.
+func findManifestFiles(dir string, recursive bool) ([]string, error) {
+	files := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// clearServerFields removes the fields of a manifest that only make sense in the original cluster
+func clearServerFields(meta *metav1.ObjectMeta) {
+	meta.ResourceVersion = ""
+	meta.UID = ""
+	meta.SelfLink = ""
+	meta.CreationTimestamp = metav1.Time{}
+}
+
+// parseImportManifest parses a Function, HTTPTrigger or CronJobTrigger manifest
+func parseImportManifest(file string, content []byte) (*importManifest, error) {
+	typeMeta := metav1.TypeMeta{}
+	if err := yaml.Unmarshal(content, &typeMeta); err != nil {
+		return nil, err
+	}
+	m := &importManifest{file: file}
+	switch typeMeta.Kind {
+	case "Function", "":
+		f, err := parseFunctionManifest(content)
+		if err != nil {
+			return nil, err
+		}
+		m.function = f
+	case "HTTPTrigger":
+		m.http = &httpApi.HTTPTrigger{}
+		if err := yaml.Unmarshal(content, m.http); err != nil {
+			return nil, err
+		}
+		m.http.TypeMeta = typeMeta
+		m.http.APIVersion = "kubeless.io/v1beta1"
+	case "CronJobTrigger":
+		m.cronjob = &cronjobApi.CronJobTrigger{}
+		if err := yaml.Unmarshal(content, m.cronjob); err != nil {
+			return nil, err
+		}
+		m.cronjob.TypeMeta = typeMeta
+		m.cronjob.APIVersion = "kubeless.io/v1beta1"
+	default:
+		return nil, fmt.Errorf("Unsupported kind %s. Expecting one of: %s", typeMeta.Kind, strings.Join(importKinds, ", "))
+	}
+	if m.meta().Name == "" {
+		return nil, fmt.Errorf("The manifest doesn't contain the name of the %s", m.kind())
+	}
+	clearServerFields(m.meta())
+	return m, nil
+}
+
+// sortImportManifests sorts the manifests in the order of importKinds, keeping the order of the
+// files for manifests of the same kind
+func sortImportManifests(manifests []*importManifest) {
+	order := map[string]int{}
+	for i, kind := range importKinds {
+		order[kind] = i
+	}
+	sort.SliceStable(manifests, func(i, j int) bool {
+		return order[manifests[i].kind()] < order[manifests[j].kind()]
+	})
+}
+
+// applyImportManifest creates the object of the manifest or updates it if it already exists.
+// It returns true if the object has been created.
+func applyImportManifest(clients importClients, m *importManifest) (bool, error) {
+	switch {
+	case m.http != nil:
+		triggers := clients.http.KubelessV1beta1().HTTPTriggers(m.http.Namespace)
+		current, err := triggers.Get(m.http.Name, metav1.GetOptions{})
+		if err != nil {
+			if !k8sErrors.IsNotFound(err) {
+				return false, err
+			}
+			_, err = triggers.Create(m.http)
+			return err == nil, err
+		}
+		m.http.ResourceVersion = current.ResourceVersion
+		_, err = triggers.Update(m.http)
+		return false, err
+	case m.cronjob != nil:
+		triggers := clients.cronjob.KubelessV1beta1().CronJobTriggers(m.cronjob.Namespace)
+		current, err := triggers.Get(m.cronjob.Name, metav1.GetOptions{})
+		if err != nil {
+			if !k8sErrors.IsNotFound(err) {
+				return false, err
+			}
+			_, err = triggers.Create(m.cronjob)
+			return err == nil, err
+		}
+		m.cronjob.ResourceVersion = current.ResourceVersion
+		_, err = triggers.Update(m.cronjob)
+		return false, err
+	default:
+		return applyFunction(clients.kubeless, m.function)
+	}
+}
+
+// getPruneCandidates returns the objects matching the selector in the namespaces of the
+// manifests that are not in the manifests, as "<kind>/<namespace>/<name>", sorted in the
+// reverse order of importKinds so the triggers are deleted before their functions
+func getPruneCandidates(clients importClients, manifests []*importManifest, selector string) ([]string, error) {
+	keep := map[string]bool{}
+	namespaces := map[string]bool{}
+	for _, m := range manifests {
+		keep[fmt.Sprintf("%s/%s/%s", m.kind(), m.meta().Namespace, m.meta().Name)] = true
+		namespaces[m.meta().Namespace] = true
+	}
+	nsList := []string{}
+	for ns := range namespaces {
+		nsList = append(nsList, ns)
+	}
+	sort.Strings(nsList)
+
+	opts := metav1.ListOptions{LabelSelector: selector}
+	candidates := []string{}
+	add := func(kind, ns, name string) {
+		key := fmt.Sprintf("%s/%s/%s", kind, ns, name)
+		if !keep[key] {
+			candidates = append(candidates, key)
+		}
+	}
+	for _, ns := range nsList {
+		cronjobs, err := clients.cronjob.KubelessV1beta1().CronJobTriggers(ns).List(opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range cronjobs.Items {
+			add("CronJobTrigger", ns, t.Name)
+		}
+		httpTriggers, err := clients.http.KubelessV1beta1().HTTPTriggers(ns).List(opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range httpTriggers.Items {
+			add("HTTPTrigger", ns, t.Name)
+		}
+		functions, err := clients.kubeless.KubelessV1beta1().Functions(ns).List(opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range functions.Items {
+			add("Function", ns, f.Name)
+		}
+	}
+	return candidates, nil
+}
+
+// pruneObject deletes an object returned by getPruneCandidates
+func pruneObject(clients importClients, key string) error {
+	parts := strings.SplitN(key, "/", 3)
+	kind, ns, name := parts[0], parts[1], parts[2]
+	switch kind {
+	case "CronJobTrigger":
+		return clients.cronjob.KubelessV1beta1().CronJobTriggers(ns).Delete(name, &metav1.DeleteOptions{})
+	case "HTTPTrigger":
+		return clients.http.KubelessV1beta1().HTTPTriggers(ns).Delete(name, &metav1.DeleteOptions{})
+	default:
+		return clients.kubeless.KubelessV1beta1().Functions(ns).Delete(name, &metav1.DeleteOptions{})
+	}
+}
+
+// importManifests applies the manifests, that should be already sorted, and deletes the
+// prune candidates if every manifest has been applied. The result of each file is written
+// to w. It returns an error if any object fails to be applied or pruned.
+func importManifests(w io.Writer, clients importClients, manifests []*importManifest, prune bool, selector string) error {
+	failed := 0
+	for _, m := range manifests {
+		created, err := applyImportManifest(clients, m)
+		meta := m.meta()
+		switch {
+		case err != nil:
+			failed++
+			fmt.Fprintf(w, "%s: %s %s failed: %v\n", m.file, m.kind(), meta.Name, err)
+		case created:
+			fmt.Fprintf(w, "%s: %s %s created in namespace %s\n", m.file, m.kind(), meta.Name, meta.Namespace)
+		default:
+			fmt.Fprintf(w, "%s: %s %s updated in namespace %s\n", m.file, m.kind(), meta.Name, meta.Namespace)
+		}
+	}
+	if failed > 0 {
+		if prune {
+			fmt.Fprintln(w, "Skipping prune since some manifests failed to be applied")
+		}
+		return fmt.Errorf("%d of %d manifests failed to be applied", failed, len(manifests))
+	}
+	if !prune {
+		return nil
+	}
+
+	candidates, err := getPruneCandidates(clients, manifests, selector)
+	if err != nil {
+		return fmt.Errorf("Unable to list the objects to prune: %v", err)
+	}
+	for _, key := range candidates {
+		if err := pruneObject(clients, key); err != nil {
+			failed++
+			fmt.Fprintf(w, "%s failed to be pruned: %v\n", key, err)
+			continue
+		}
+		fmt.Fprintf(w, "%s pruned\n", key)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d objects failed to be pruned", failed, len(candidates))
+	}
+	return nil
+}
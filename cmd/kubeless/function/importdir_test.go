@@ -0,0 +1,182 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	cronjobFake "github.com/kubeless/cronjob-trigger/pkg/client/clientset/versioned/fake"
+	httpFake "github.com/kubeless/http-trigger/pkg/client/clientset/versioned/fake"
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	fFake "github.com/kubeless/kubeless/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func writeManifests(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "manifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestFindManifestFiles(t *testing.T) {
+	dir := writeManifests(t, map[string]string{
+		"a.yaml":          "",
+		"b.json":          "",
+		"README.md":       "",
+		"triggers/c.yml":  "",
+		"triggers/d.yaml": "",
+	})
+	defer os.RemoveAll(dir)
+
+	files, err := findManifestFiles(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.json")}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expecting %v, got %v", expected, files)
+	}
+
+	files, err = findManifestFiles(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = append(expected, filepath.Join(dir, "triggers", "c.yml"), filepath.Join(dir, "triggers", "d.yaml"))
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expecting %v, got %v", expected, files)
+	}
+}
+
+func TestParseImportManifest(t *testing.T) {
+	m, err := parseImportManifest("t.yaml", []byte("kind: HTTPTrigger\nmetadata:\n  name: hello\n  resourceVersion: \"3\"\nspec:\n  function-name: hello\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.kind() != "HTTPTrigger" || m.http.Spec.FunctionName != "hello" || m.http.APIVersion != "kubeless.io/v1beta1" {
+		t.Errorf("Unexpected manifest %+v", m.http)
+	}
+	if m.meta().ResourceVersion != "" {
+		t.Error("Expecting the resourceVersion to be dropped")
+	}
+
+	for _, content := range []string{
+		"kind: Deployment\nmetadata:\n  name: hello\n",
+		"kind: CronJobTrigger\nspec:\n  schedule: '* * * * *'\n",
+	} {
+		if _, err := parseImportManifest("t.yaml", []byte(content)); err == nil {
+			t.Errorf("Expecting an error for %q", content)
+		}
+	}
+}
+
+func TestImportManifests(t *testing.T) {
+	dir := writeManifests(t, map[string]string{
+		"a-cron.yaml":          "kind: CronJobTrigger\nmetadata:\n  name: nightly\n  namespace: default\nspec:\n  function-name: hello\n  schedule: '0 2 * * *'\n",
+		"functions/hello.yaml": "kind: Function\nmetadata:\n  name: hello\n  namespace: default\nspec:\n  runtime: python3.7\n  handler: hello.handler\n",
+		"http.json":            `{"kind": "HTTPTrigger", "metadata": {"name": "hello", "namespace": "default"}, "spec": {"function-name": "hello"}}`,
+	})
+	defer os.RemoveAll(dir)
+
+	files, err := findManifestFiles(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifests := []*importManifest{}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := parseImportManifest(file, content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		manifests = append(manifests, m)
+	}
+	sortImportManifests(manifests)
+	kinds := []string{}
+	for _, m := range manifests {
+		kinds = append(kinds, m.kind())
+	}
+	if !reflect.DeepEqual(kinds, importKinds) {
+		t.Errorf("Expecting the manifests to be sorted as %v, got %v", importKinds, kinds)
+	}
+
+	labels := map[string]string{"app": "shop"}
+	clients := importClients{
+		kubeless: fFake.NewSimpleClientset(
+			&kubelessApi.Function{ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default", ResourceVersion: "1"}},
+			&kubelessApi.Function{ObjectMeta: metav1.ObjectMeta{Name: "old", Namespace: "default", Labels: labels}},
+			&kubelessApi.Function{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"}},
+		),
+		http: httpFake.NewSimpleClientset(),
+		cronjob: cronjobFake.NewSimpleClientset(
+			&cronjobApi.CronJobTrigger{ObjectMeta: metav1.ObjectMeta{Name: "old", Namespace: "default", Labels: labels}},
+		),
+	}
+	var out bytes.Buffer
+	if err := importManifests(&out, clients, manifests, true, "app=shop"); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"hello.yaml: Function hello updated in namespace default",
+		"http.json: HTTPTrigger hello created in namespace default",
+		"a-cron.yaml: CronJobTrigger nightly created in namespace default",
+		"CronJobTrigger/default/old pruned",
+		"Function/default/old pruned",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expecting %q in the output, got:\n%s", expected, out.String())
+		}
+	}
+	if strings.Index(out.String(), "CronJobTrigger/default/old") > strings.Index(out.String(), "Function/default/old") {
+		t.Error("Expecting the triggers to be pruned before the functions")
+	}
+
+	functions, err := clients.kubeless.KubelessV1beta1().Functions("default").List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, f := range functions.Items {
+		names = append(names, f.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"hello", "other"}) {
+		t.Errorf("Expecting only the function without the label to be kept, got %v", names)
+	}
+	if _, err := clients.http.KubelessV1beta1().HTTPTriggers("default").Get("hello", metav1.GetOptions{}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// starterTemplate contains the files of a new function for a runtime family.
+// The name of the function replaces {{name}} in the templates.
+type starterTemplate struct {
+	Extension    string
+	Method       string
+	Code         string
+	Dependencies string
+	DepsFile     string
+}
+
+var starterTemplates = map[string]starterTemplate{
+	"python": {
+		Extension: ".py",
+		Method:    "handler",
+		Code: `def handler(event, context):
+    print(event)
+    return event['data']
+`,
+		DepsFile:     "requirements.txt",
+		Dependencies: "# Add the dependencies of the function, one per line\n",
+	},
+	"nodejs": {
+		Extension: ".js",
+		Method:    "handler",
+		Code: `module.exports = {
+  handler: function (event, context) {
+    console.log(event);
+    return event.data;
+  }
+}
+`,
+		DepsFile: "package.json",
+		Dependencies: `{
+  "name": "{{name}}",
+  "version": "1.0.0",
+  "dependencies": {}
+}
+`,
+	},
+	"ruby": {
+		Extension: ".rb",
+		Method:    "handler",
+		Code: `def handler(event, context)
+  puts event
+  JSON.generate(event[:data])
+end
+`,
+		DepsFile:     "Gemfile",
+		Dependencies: "source 'https://rubygems.org'\n",
+	},
+	"go": {
+		Extension: ".go",
+		Method:    "Handler",
+		Code: `package kubeless
+
+import "github.com/kubeless/kubeless/pkg/functions"
+
+// Handler returns the data of the event
+func Handler(event functions.Event, context functions.Context) (string, error) {
+	return event.Data, nil
+}
+`,
+		DepsFile:     "go.mod",
+		Dependencies: "module function\n",
+	},
+}
+
+var runtimeFamilyRegex = regexp.MustCompile(`^[a-z]+`)
+
+var initCmd = &cobra.Command{
+	Use:   "init <function_name> FLAG",
+	Short: "write the files of a new function",
+	Long: `write the files of a new function
+
+Writes a starter handler and an empty dependencies file for the given runtime, and prints
+the command that deploys them. Existing files are never overwritten.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			logrus.Fatal("Need exactly one argument - function name")
+		}
+		funcName := args[0]
+
+		runtime, err := cmd.Flags().GetString("runtime")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		dir, err := cmd.Flags().GetString("dir")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		handlerFile, depsFile, handler, err := writeStarterFiles(funcName, runtime, dir)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		logrus.Infof("Created %s and %s", handlerFile, depsFile)
+		fmt.Printf("kubeless function deploy %s --runtime %s --from-file %s --handler %s --dependencies %s\n", funcName, runtime, handlerFile, handler, depsFile)
+	},
+}
+
+// getStarterTemplate returns the template of the family of the runtime (e.g. python for python3.9)
+func getStarterTemplate(runtime string) (starterTemplate, error) {
+	family := runtimeFamilyRegex.FindString(runtime)
+	tpl, ok := starterTemplates[family]
+	if !ok {
+		families := []string{}
+		for f := range starterTemplates {
+			families = append(families, f)
+		}
+		sort.Strings(families)
+		return starterTemplate{}, fmt.Errorf("There is no starter for the runtime %q. Available runtimes: %s", runtime, strings.Join(families, ", "))
+	}
+	return tpl, nil
+}
+
+// writeStarterFiles writes the handler and dependencies files of a new function in dir.
+// It returns the path of both files and the handler of the function.
+func writeStarterFiles(name, runtime, dir string) (string, string, string, error) {
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return "", "", "", fmt.Errorf("Invalid function name %q: %s", name, strings.Join(errs, "; "))
+	}
+	tpl, err := getStarterTemplate(runtime)
+	if err != nil {
+		return "", "", "", err
+	}
+	handlerFile := filepath.Join(dir, name+tpl.Extension)
+	depsFile := filepath.Join(dir, tpl.DepsFile)
+	for _, file := range []string{handlerFile, depsFile} {
+		if _, err := os.Stat(file); err == nil {
+			return "", "", "", fmt.Errorf("The file %s already exists", file)
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", "", "", err
+	}
+	if err := ioutil.WriteFile(handlerFile, []byte(strings.Replace(tpl.Code, "{{name}}", name, -1)), 0644); err != nil {
+		return "", "", "", err
+	}
+	if err := ioutil.WriteFile(depsFile, []byte(strings.Replace(tpl.Dependencies, "{{name}}", name, -1)), 0644); err != nil {
+		return "", "", "", err
+	}
+	return handlerFile, depsFile, name + "." + tpl.Method, nil
+}
+
+func init() {
+	initCmd.Flags().StringP("runtime", "r", "", "Runtime of the function, like python3.9. Starters are available for python, nodejs, ruby and go")
+	initCmd.Flags().String("dir", ".", "Directory where the files are written. It's created if it doesn't exist")
+	initCmd.MarkFlagRequired("runtime")
+}
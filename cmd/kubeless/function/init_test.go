@@ -0,0 +1,64 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteStarterFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeless-init")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	target := filepath.Join(dir, "hello")
+
+	handlerFile, depsFile, handler, err := writeStarterFiles("hello", "nodejs14", target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if handlerFile != filepath.Join(target, "hello.js") || depsFile != filepath.Join(target, "package.json") || handler != "hello.handler" {
+		t.Errorf("Unexpected files %s, %s and handler %s", handlerFile, depsFile, handler)
+	}
+	deps, err := ioutil.ReadFile(depsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(deps), `"name": "hello"`) {
+		t.Errorf("Expecting the function name in package.json, got %s", deps)
+	}
+
+	// Existing files are not overwritten
+	if _, _, _, err := writeStarterFiles("hello", "nodejs14", target); err == nil {
+		t.Error("Expecting an error for existing files")
+	}
+
+	if _, _, handler, err := writeStarterFiles("hello", "go1.14", target); err != nil || handler != "hello.Handler" {
+		t.Errorf("Unexpected handler %s (%v)", handler, err)
+	}
+	if _, _, _, err := writeStarterFiles("hello", "cobol1", target); err == nil || !strings.Contains(err.Error(), "python") {
+		t.Errorf("Expecting an error listing the available runtimes, got %v", err)
+	}
+	if _, _, _, err := writeStarterFiles("Hello_World", "python3.9", target); err == nil {
+		t.Error("Expecting an error for an invalid function name")
+	}
+}
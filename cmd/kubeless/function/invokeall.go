@@ -0,0 +1,188 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var invokeAllCmd = &cobra.Command{
+	Use:   "invoke-all <function_name> FLAG",
+	Short: "call every replica of a function",
+	Long:  `call every running pod of a function directly, without going through its service. Useful for warming up caches after a deployment`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			logrus.Fatal("Need exactly one argument - function name")
+		}
+		funcName := args[0]
+
+		data, err := cmd.Flags().GetString("data")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		ns, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if ns == "" {
+			ns = utils.GetDefaultNamespace()
+		}
+
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if timeout <= 0 {
+			logrus.Fatal("The timeout must be greater than 0")
+		}
+
+		concurrency, err := cmd.Flags().GetInt("concurrency")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if concurrency <= 0 {
+			logrus.Fatal("The concurrency must be greater than 0")
+		}
+
+		clientset := utils.GetClientOutOfCluster()
+		results, err := invokeAll(clientset, ns, funcName, data, timeout, concurrency)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		printInvokeResults(cmd.OutOrStdout(), results)
+
+		failed := 0
+		for _, r := range results {
+			if r.Error != "" {
+				failed++
+			}
+		}
+		if failed > 0 {
+			logrus.Fatalf("%d of %d calls failed", failed, len(results))
+		}
+	},
+}
+
+func init() {
+	invokeAllCmd.Flags().StringP("data", "d", "", "Specify data for function")
+	invokeAllCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function")
+	invokeAllCmd.Flags().Duration("timeout", 30*time.Second, "Maximum time to wait for the response of each pod")
+	invokeAllCmd.Flags().Int("concurrency", 5, "Number of pods called at the same time")
+}
+
+type invokeResult struct {
+	Pod     string
+	Latency time.Duration
+	Error   string
+}
+
+func getFunctionTargetPort(clientset kubernetes.Interface, ns, funcName string) (string, error) {
+	svc, err := clientset.CoreV1().Services(ns).Get(funcName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("Unable to find the service for %s", funcName)
+	}
+	if len(svc.Spec.Ports) == 0 {
+		return "", fmt.Errorf("The service of %s doesn't expose any port", funcName)
+	}
+	port := svc.Spec.Ports[0]
+	if port.TargetPort.String() != "0" && port.TargetPort.String() != "" {
+		return port.TargetPort.String(), nil
+	}
+	return fmt.Sprintf("%d", port.Port), nil
+}
+
+// invokeAll calls every running pod of the function, at most concurrency pods at the same time
+func invokeAll(clientset kubernetes.Interface, ns, funcName, data string, timeout time.Duration, concurrency int) ([]invokeResult, error) {
+	port, err := getFunctionTargetPort(clientset, ns, funcName)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := utils.GetPodsByLabel(clientset, ns, "function", funcName)
+	if err != nil {
+		return nil, fmt.Errorf("Can't find the function pods: %v", err)
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("There are no pods for the function %s", funcName)
+	}
+
+	results := make([]invokeResult, len(pods.Items))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, pod := range pods.Items {
+		results[i].Pod = pod.Name
+		if pod.Status.Phase != v1.PodRunning {
+			results[i].Error = fmt.Sprintf("Pod is %s", pod.Status.Phase)
+			continue
+		}
+		wg.Add(1)
+		go func(i int, podName string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			req, err := newFunctionRequest(clientset.CoreV1().RESTClient(), ns, "pods", podName+":"+port, []byte(data), data == "")
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			start := time.Now()
+			_, err = req.Timeout(timeout).Do().Raw()
+			results[i].Latency = time.Since(start)
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+		}(i, pod.Name)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Pod < results[j].Pod
+	})
+	return results, nil
+}
+
+func printInvokeResults(w io.Writer, results []invokeResult) {
+	table := uitable.New()
+	table.MaxColWidth = 80
+	table.Wrap = true
+	table.AddRow("POD", "STATUS", "LATENCY", "MESSAGE")
+	for _, r := range results {
+		status := "OK"
+		if r.Error != "" {
+			status = "FAILED"
+		}
+		latency := ""
+		if r.Latency > 0 {
+			latency = r.Latency.Round(time.Millisecond).String()
+		}
+		table.AddRow(r.Pod, status, latency, r.Error)
+	}
+	fmt.Fprintln(w, table)
+}
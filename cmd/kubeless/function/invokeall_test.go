@@ -0,0 +1,96 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetFunctionTargetPort(t *testing.T) {
+	svc := v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns"},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{Name: "http-function-port", Port: 8080, TargetPort: intstr.FromInt(9090)},
+			},
+		},
+	}
+	clientset := fake.NewSimpleClientset(&svc)
+	port, err := getFunctionTargetPort(clientset, "myns", "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != "9090" {
+		t.Errorf("Expecting port 9090, got %s", port)
+	}
+
+	// It should fallback to the service port
+	svc.Spec.Ports[0].TargetPort = intstr.IntOrString{}
+	clientset = fake.NewSimpleClientset(&svc)
+	port, err = getFunctionTargetPort(clientset, "myns", "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != "8080" {
+		t.Errorf("Expecting port 8080, got %s", port)
+	}
+
+	if _, err := getFunctionTargetPort(clientset, "myns", "bar"); err == nil {
+		t.Error("Expecting an error for a missing service")
+	}
+}
+
+func TestInvokeAllSkipsNotRunningPods(t *testing.T) {
+	svc := v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns"},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Port: 8080}},
+		},
+	}
+	pod := func(name string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "myns", Labels: map[string]string{"function": "foo"}},
+			Status:     v1.PodStatus{Phase: v1.PodPending},
+		}
+	}
+	clientset := fake.NewSimpleClientset(&svc, pod("foo-b"), pod("foo-a"))
+	results, err := invokeAll(clientset, "myns", "foo", "", time.Second, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expecting 2 results, got %d", len(results))
+	}
+	if results[0].Pod != "foo-a" || results[1].Pod != "foo-b" {
+		t.Errorf("Expecting results sorted by pod name, got %v", results)
+	}
+	for _, r := range results {
+		if r.Error != "Pod is Pending" {
+			t.Errorf("Unexpected error for %s: %s", r.Pod, r.Error)
+		}
+	}
+
+	if _, err := invokeAll(clientset, "myns", "bar", "", time.Second, 2); err == nil {
+		t.Error("Expecting an error for a function without service")
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"text/template"
 
 	"github.com/ghodss/yaml"
 	"github.com/gosuri/uitable"
@@ -46,12 +47,17 @@ var listCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatal(err.Error())
 		}
-		ns, err := cmd.Flags().GetString("namespace")
+		tmpl, err := utils.GetOutputTemplate(cmd.Flags(), output)
 		if err != nil {
-			logrus.Fatal(err.Error())
+			logrus.Fatal(err)
+		}
+		sortBy, err := cmd.Flags().GetString("sort-by")
+		if err != nil {
+			logrus.Fatal(err)
 		}
-		if ns == "" {
-			ns = utils.GetDefaultNamespace()
+		ns, err := utils.GetNamespaceOrAll(cmd.Flags())
+		if err != nil {
+			logrus.Fatal(err)
 		}
 
 		kubelessClient, err := utils.GetKubelessClientOutCluster()
@@ -61,18 +67,62 @@ var listCmd = &cobra.Command{
 
 		apiV1Client := utils.GetClientOutOfCluster()
 
-		if err := doList(cmd.OutOrStdout(), kubelessClient, apiV1Client, ns, output, args); err != nil {
+		watchFlag, err := cmd.Flags().GetBool("watch")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		watchTimeout, err := cmd.Flags().GetDuration("watch-timeout")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		sinceResourceVersion, err := cmd.Flags().GetString("since-resource-version")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if watchFlag {
+			if len(args) > 0 {
+				logrus.Fatal("--watch can't be used with function names, it watches every function of the namespace")
+			}
+			if sortBy != "" {
+				logrus.Fatal("--sort-by can't be used with --watch")
+			}
+			stop, release := getWatchStop(watchTimeout)
+			defer release()
+			resourceVersion, err := watchFunctions(cmd.OutOrStdout(), kubelessClient, apiV1Client, ns, output, tmpl, sinceResourceVersion, stop)
+			// Printed in the logs so the output can still be processed as a stream
+			if resourceVersion != "" {
+				logrus.Infof("Last observed resource version: %s (resume with --since-resource-version %s)", resourceVersion, resourceVersion)
+			}
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			return
+		}
+		if utils.IsFlagGiven(cmd.Flags(), "watch-timeout") {
+			logrus.Fatal("--watch-timeout can only be used with --watch")
+		}
+		if utils.IsFlagGiven(cmd.Flags(), "since-resource-version") {
+			logrus.Fatal("--since-resource-version can only be used with --watch")
+		}
+
+		if err := doList(cmd.OutOrStdout(), kubelessClient, apiV1Client, ns, output, tmpl, sortBy, args); err != nil {
 			logrus.Fatal(err.Error())
 		}
 	},
 }
 
 func init() {
-	listCmd.Flags().StringP("out", "o", "", "Output format. One of: json|yaml")
+	listCmd.Flags().StringP("out", "o", "", "Output format. One of: json|yaml|template")
+	utils.AddTemplateFlags(listCmd.Flags())
+	utils.AddSortByFlag(listCmd.Flags())
 	listCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function")
+	utils.AddAllNamespacesFlag(listCmd.Flags(), "List the functions of all the namespaces")
+	listCmd.Flags().BoolP("watch", "w", false, "After listing the functions, print every change of them or of their status")
+	listCmd.Flags().Duration("watch-timeout", 0, "Stop watching after this time (e.g. 10m). 0 means until interrupted")
+	listCmd.Flags().String("since-resource-version", "", "With --watch, skip the list and print only the changes made after this resource version (e.g. the last one printed by a previous watch)")
 }
 
-func doList(w io.Writer, kubelessClient versioned.Interface, apiV1Client kubernetes.Interface, ns, output string, args []string) error {
+func doList(w io.Writer, kubelessClient versioned.Interface, apiV1Client kubernetes.Interface, ns, output string, tmpl *template.Template, sortBy string, args []string) error {
 	var list []*kubelessApi.Function
 	if len(args) == 0 {
 		funcList, err := kubelessClient.KubelessV1beta1().Functions(ns).List(metav1.ListOptions{})
@@ -80,6 +130,27 @@ func doList(w io.Writer, kubelessClient versioned.Interface, apiV1Client kuberne
 			return err
 		}
 		list = funcList.Items
+	} else if ns == metav1.NamespaceAll {
+		// Functions can't be retrieved by name across namespaces, so every function is filtered
+		funcList, err := kubelessClient.KubelessV1beta1().Functions(ns).List(metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		names := map[string]bool{}
+		for _, arg := range args {
+			names[arg] = false
+		}
+		for _, f := range funcList.Items {
+			if _, ok := names[f.Name]; ok {
+				names[f.Name] = true
+				list = append(list, f)
+			}
+		}
+		for _, arg := range args {
+			if !names[arg] {
+				return fmt.Errorf("Error listing function %s: not found in any namespace", arg)
+			}
+		}
 	} else {
 		list = make([]*kubelessApi.Function, 0, len(args))
 		for _, arg := range args {
@@ -91,7 +162,10 @@ func doList(w io.Writer, kubelessClient versioned.Interface, apiV1Client kuberne
 		}
 	}
 
-	return printFunctions(w, list, apiV1Client, output)
+	if err := utils.SortObjects(list, sortBy); err != nil {
+		return err
+	}
+	return printFunctions(w, list, apiV1Client, output, tmpl)
 }
 
 func parseDeps(deps, runtime string) (res string, err error) {
@@ -117,7 +191,7 @@ func parseDeps(deps, runtime string) (res string, err error) {
 }
 
 // printFunctions formats the output of function list
-func printFunctions(w io.Writer, functions []*kubelessApi.Function, cli kubernetes.Interface, output string) error {
+func printFunctions(w io.Writer, functions []*kubelessApi.Function, cli kubernetes.Interface, output string, tmpl *template.Template) error {
 	if output == "" {
 		table := uitable.New()
 		table.MaxColWidth = 50
@@ -187,7 +261,7 @@ func printFunctions(w io.Writer, functions []*kubelessApi.Function, cli kubernet
 	} else {
 		switch output {
 		case "json":
-			b, err := json.MarshalIndent(functions, "", "  ")
+			b, err := utils.MarshalJSON(functions, "  ")
 			if err != nil {
 				return err
 			}
@@ -198,8 +272,10 @@ func printFunctions(w io.Writer, functions []*kubelessApi.Function, cli kubernet
 				return err
 			}
 			fmt.Fprintln(w, string(b))
+		case utils.OutputTemplate:
+			return utils.PrintTemplate(w, tmpl, functions)
 		default:
-			return fmt.Errorf("Wrong output format. Please use only json|yaml")
+			return fmt.Errorf("Wrong output format. Please use only json|yaml|template")
 		}
 	}
 	return nil
@@ -32,12 +32,13 @@ import (
 	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
 	"github.com/kubeless/kubeless/pkg/client/clientset/versioned"
 	fFake "github.com/kubeless/kubeless/pkg/client/clientset/versioned/fake"
+	"github.com/kubeless/kubeless/pkg/utils"
 )
 
 func listOutput(t *testing.T, client versioned.Interface, apiV1Client kubernetes.Interface, ns, output string, args []string) string {
 	var buf bytes.Buffer
 
-	if err := doList(&buf, client, apiV1Client, ns, output, args); err != nil {
+	if err := doList(&buf, client, apiV1Client, ns, output, nil, "", args); err != nil {
 		t.Fatalf("doList returned error: %v", err)
 	}
 
@@ -207,6 +208,15 @@ func TestList(t *testing.T) {
 		t.Errorf("table output doesn't show parsed dependencies")
 	}
 
+	// Explicit arg(s) in all the namespaces
+	output = listOutput(t, client, apiV1Client, metav1.NamespaceAll, "", []string{"foo"})
+	if !strings.Contains(output, "foo") || strings.Contains(output, "bar") {
+		t.Errorf("table output should only mention function foo, got %s", output)
+	}
+	if err := doList(&bytes.Buffer{}, client, apiV1Client, metav1.NamespaceAll, "", nil, "", []string{"missing"}); err == nil {
+		t.Error("Expecting an error for a function not found in any namespace")
+	}
+
 	// TODO: Actually validate the output of the following.
 	// Probably need to fix output framing first.
 
@@ -217,6 +227,18 @@ func TestList(t *testing.T) {
 		t.Errorf("table output didn't mention both functions")
 	}
 
+	// json output with and without indentation
+	utils.SetPrettyJSON(true)
+	output = listOutput(t, client, apiV1Client, "myns", "json", []string{})
+	if !strings.Contains(output, "\n  {\n    \"metadata\": {") {
+		t.Errorf("pretty json output should be indented")
+	}
+	utils.SetPrettyJSON(false)
+	output = listOutput(t, client, apiV1Client, "myns", "json", []string{})
+	if strings.Count(output, "\n") != 1 || !strings.HasPrefix(output, "[{\"metadata\":{") {
+		t.Errorf("compact json output should be a single line, got %q", output)
+	}
+
 	// yaml output
 	output = listOutput(t, client, apiV1Client, "myns", "yaml", []string{})
 	t.Log("output is", output)
@@ -0,0 +1,122 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gosuri/uitable"
+)
+
+// loadReport summarizes the requests sent with 'kubeless function call --requests'
+type loadReport struct {
+	Requests  int
+	Succeeded int
+	// Number of failed requests by HTTP status or error
+	Errors    map[string]int
+	Latencies []time.Duration
+	Duration  time.Duration
+}
+
+// runLoadTest sends the given number of requests with call, at most concurrency at the same
+// time. call returns the HTTP status of the response (0 if there is none) and the error if any.
+func runLoadTest(call func() (int, error), requests, concurrency int) *loadReport {
+	report := &loadReport{Requests: requests, Errors: map[string]int{}}
+	var (
+		mutex sync.Mutex
+		wg    sync.WaitGroup
+	)
+	sem := make(chan struct{}, concurrency)
+	start := time.Now()
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			reqStart := time.Now()
+			status, err := call()
+			latency := time.Since(reqStart)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			report.Latencies = append(report.Latencies, latency)
+			switch {
+			case status != 0 && (status < 200 || status >= 300):
+				report.Errors[fmt.Sprintf("status %d", status)]++
+			case err != nil:
+				report.Errors[err.Error()]++
+			default:
+				report.Succeeded++
+			}
+		}()
+	}
+	wg.Wait()
+	report.Duration = time.Since(start)
+	sort.Slice(report.Latencies, func(i, j int) bool {
+		return report.Latencies[i] < report.Latencies[j]
+	})
+	return report
+}
+
+// percentile returns the nearest-rank percentile of the sorted latencies
+func percentile(latencies []time.Duration, p int) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	rank := (p*len(latencies) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return latencies[rank-1]
+}
+
+func printLoadReport(w io.Writer, report *loadReport) {
+	fmt.Fprintf(w, "Requests:  %d in %v (%.1f requests/s)\n", report.Requests, report.Duration.Round(time.Millisecond), float64(report.Requests)/report.Duration.Seconds())
+	fmt.Fprintf(w, "Succeeded: %d\n", report.Succeeded)
+	fmt.Fprintf(w, "Failed:    %d\n", report.Requests-report.Succeeded)
+	fmt.Fprintf(w, "Latency:   p50 %v, p95 %v, p99 %v\n",
+		percentile(report.Latencies, 50).Round(time.Millisecond),
+		percentile(report.Latencies, 95).Round(time.Millisecond),
+		percentile(report.Latencies, 99).Round(time.Millisecond))
+	if len(report.Errors) == 0 {
+		return
+	}
+	errors := []string{}
+	for e := range report.Errors {
+		errors = append(errors, e)
+	}
+	sort.Slice(errors, func(i, j int) bool {
+		if report.Errors[errors[i]] != report.Errors[errors[j]] {
+			return report.Errors[errors[i]] > report.Errors[errors[j]]
+		}
+		return errors[i] < errors[j]
+	})
+	table := uitable.New()
+	table.MaxColWidth = 80
+	table.Wrap = true
+	table.AddRow("COUNT", "ERROR")
+	for _, e := range errors {
+		table.AddRow(report.Errors[e], e)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, table)
+}
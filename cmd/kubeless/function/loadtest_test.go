@@ -0,0 +1,93 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunLoadTest(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		calls    int
+		inFlight int
+		maxSeen  int
+	)
+	call := func() (int, error) {
+		mutex.Lock()
+		calls++
+		n := calls
+		inFlight++
+		if inFlight > maxSeen {
+			maxSeen = inFlight
+		}
+		mutex.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mutex.Lock()
+		inFlight--
+		mutex.Unlock()
+		switch {
+		case n%5 == 0:
+			return 503, fmt.Errorf("the server is currently unable to handle the request")
+		case n%7 == 0:
+			return 0, fmt.Errorf("connection refused")
+		}
+		return 200, nil
+	}
+
+	report := runLoadTest(call, 20, 3)
+	if calls != 20 || report.Requests != 20 || len(report.Latencies) != 20 {
+		t.Errorf("Expecting 20 requests, got %d calls and %d latencies", calls, len(report.Latencies))
+	}
+	if maxSeen > 3 {
+		t.Errorf("Expecting at most 3 requests in flight, got %d", maxSeen)
+	}
+	if report.Succeeded != 14 || report.Errors["status 503"] != 4 || report.Errors["connection refused"] != 2 {
+		t.Errorf("Unexpected results: %d succeeded, errors %v", report.Succeeded, report.Errors)
+	}
+
+	var buf bytes.Buffer
+	printLoadReport(&buf, report)
+	for _, expected := range []string{"Succeeded: 14", "Failed:    6", "p50", "p99", "status 503"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expecting %q in the output:\n%s", expected, buf.String())
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	latencies := []time.Duration{}
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	for p, expected := range map[int]time.Duration{50: 50 * time.Millisecond, 95: 95 * time.Millisecond, 99: 99 * time.Millisecond, 100: 100 * time.Millisecond} {
+		if actual := percentile(latencies, p); actual != expected {
+			t.Errorf("Expecting p%d to be %v, got %v", p, expected, actual)
+		}
+	}
+	if actual := percentile(latencies[:1], 99); actual != time.Millisecond {
+		t.Errorf("Expecting the only latency, got %v", actual)
+	}
+	if actual := percentile(nil, 50); actual != 0 {
+		t.Errorf("Expecting 0 without latencies, got %v", actual)
+	}
+}
@@ -17,13 +17,18 @@ limitations under the License.
 package function
 
 import (
+	"bufio"
+	"fmt"
 	"io"
 	"os"
+	"strings"
+	"sync"
 
 	"github.com/kubeless/kubeless/pkg/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
 var logsCmd = &cobra.Command{
@@ -46,6 +51,17 @@ var logsCmd = &cobra.Command{
 		if ns == "" {
 			ns = utils.GetDefaultNamespace()
 		}
+		container, err := cmd.Flags().GetString("container")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		allContainers, err := cmd.Flags().GetBool("all-containers")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if container != "" && allContainers {
+			logrus.Fatal("The flags --container and --all-containers can't be used together")
+		}
 
 		k8sClient := utils.GetClientOutOfCluster()
 		if err != nil {
@@ -59,22 +75,112 @@ var logsCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatalf("No function pod is running: %v", err)
 		}
-		podLog := &v1.PodLogOptions{
-			Container: funcName,
-			Follow:    follow,
-		}
-		req := k8sClient.Core().Pods(ns).GetLogs(readyPod.Name, podLog)
-
-		readCloser, err := req.Stream()
+		containers, err := getLogContainers(readyPod, funcName, container, allContainers)
 		if err != nil {
+			logrus.Fatal(err)
+		}
+		if err := streamContainerLogs(os.Stdout, k8sClient, ns, readyPod.Name, containers, follow); err != nil {
 			logrus.Fatalf("Getting log failed: %v", err)
 		}
-		defer readCloser.Close()
-		io.Copy(os.Stdout, readCloser)
 	},
 }
 
 func init() {
 	logsCmd.Flags().BoolP("follow", "f", false, "Specify if the logs should be streamed.")
 	logsCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function")
+	logsCmd.Flags().StringP("container", "c", "", "Container of the function pod to get the logs from, e.g. a sidecar. Defaults to the function container")
+	logsCmd.Flags().Bool("all-containers", false, "Get the logs of every container of the function pod, prefixing each line with the name of its container")
+}
+
+// getLogContainers returns the containers of the pod to get the logs from: the given one,
+// every container (init containers first) or, by default, the one named as the function
+func getLogContainers(pod v1.Pod, funcName, container string, all bool) ([]string, error) {
+	names := []string{}
+	for _, c := range pod.Spec.InitContainers {
+		names = append(names, c.Name)
+	}
+	for _, c := range pod.Spec.Containers {
+		names = append(names, c.Name)
+	}
+	if all {
+		return names, nil
+	}
+	if container == "" {
+		container = funcName
+	}
+	for _, name := range names {
+		if name == container {
+			return []string{container}, nil
+		}
+	}
+	return nil, fmt.Errorf("The pod %s has no container %s. Its containers are: %s", pod.Name, container, strings.Join(names, ", "))
+}
+
+// streamContainerLogs copies the logs of the containers to the writer. The logs of several
+// containers are read at the same time and their lines prefixed with the container name.
+func streamContainerLogs(w io.Writer, cli kubernetes.Interface, ns, podName string, containers []string, follow bool) error {
+	if len(containers) == 1 {
+		readCloser, err := cli.CoreV1().Pods(ns).GetLogs(podName, &v1.PodLogOptions{Container: containers[0], Follow: follow}).Stream()
+		if err != nil {
+			return err
+		}
+		defer readCloser.Close()
+		_, err = io.Copy(w, readCloser)
+		return err
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+		errs  = make([]error, len(containers))
+	)
+	for i, container := range containers {
+		wg.Add(1)
+		go func(i int, container string) {
+			defer wg.Done()
+			readCloser, err := cli.CoreV1().Pods(ns).GetLogs(podName, &v1.PodLogOptions{Container: container, Follow: follow}).Stream()
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %v", container, err)
+				return
+			}
+			defer readCloser.Close()
+			if err := copyPrefixedLines(w, &mutex, "["+container+"] ", readCloser); err != nil {
+				errs[i] = fmt.Errorf("%s: %v", container, err)
+			}
+		}(i, container)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyPrefixedLines copies the lines of the reader to the writer with the given prefix.
+// Each line is written at once while holding the mutex, so lines of readers copied
+// at the same time are interleaved but never mixed.
+func copyPrefixedLines(w io.Writer, mutex *sync.Mutex, prefix string, r io.Reader) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			if !strings.HasSuffix(line, "\n") {
+				line += "\n"
+			}
+			mutex.Lock()
+			_, writeErr := io.WriteString(w, prefix+line)
+			mutex.Unlock()
+			if writeErr != nil {
+				return writeErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
 }
@@ -0,0 +1,91 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetLogContainers(t *testing.T) {
+	pod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo-1234"},
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{{Name: "prepare"}},
+			Containers:     []v1.Container{{Name: "foo"}, {Name: "proxy"}},
+		},
+	}
+	tests := []struct {
+		container string
+		all       bool
+		expected  []string
+	}{
+		{expected: []string{"foo"}},
+		{container: "proxy", expected: []string{"proxy"}},
+		{container: "prepare", expected: []string{"prepare"}},
+		{all: true, expected: []string{"prepare", "foo", "proxy"}},
+	}
+	for _, test := range tests {
+		containers, err := getLogContainers(pod, "foo", test.container, test.all)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(containers, test.expected) {
+			t.Errorf("--container %q --all-containers=%v: expecting %v, got %v", test.container, test.all, test.expected, containers)
+		}
+	}
+
+	_, err := getLogContainers(pod, "foo", "missing", false)
+	if err == nil || !strings.Contains(err.Error(), "prepare, foo, proxy") {
+		t.Errorf("Expecting an error listing the containers, got %v", err)
+	}
+}
+
+func TestCopyPrefixedLines(t *testing.T) {
+	var (
+		buf   bytes.Buffer
+		mutex sync.Mutex
+		wg    sync.WaitGroup
+	)
+	for _, container := range []string{"foo", "proxy"} {
+		wg.Add(1)
+		go func(container string) {
+			defer wg.Done()
+			logs := strings.Repeat(container+" line\n", 100) + "last line without break"
+			if err := copyPrefixedLines(&buf, &mutex, "["+container+"] ", strings.NewReader(logs)); err != nil {
+				t.Error(err)
+			}
+		}(container)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 202 {
+		t.Fatalf("Expecting 202 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if line != "[foo] foo line" && line != "[proxy] proxy line" && !strings.HasSuffix(line, "] last line without break") {
+			t.Errorf("Unexpected line %q", line)
+		}
+	}
+}
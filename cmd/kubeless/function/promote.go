@@ -0,0 +1,61 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	kubelessutil "github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote <function_name> FLAG",
+	Short: "replace a function with its canary",
+	Long:  `replace a function with the canary deployed with 'kubeless function deploy --canary' and send all the traffic to it`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			logrus.Fatal("Need exactly one argument - function name")
+		}
+		funcName := args[0]
+
+		ns, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if ns == "" {
+			ns = kubelessutil.GetDefaultNamespace()
+		}
+
+		kubelessClient, err := kubelessutil.GetKubelessClientOutCluster()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		httpClient, err := kubelessutil.GetHTTPTriggerClientOutCluster()
+		if err != nil {
+			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
+		}
+
+		if err := promoteCanary(kubelessClient, httpClient, ns, funcName); err != nil {
+			logrus.Fatal(err)
+		}
+		logrus.Infof("Canary of %s promoted in namespace %s successfully!", funcName, ns)
+	},
+}
+
+func init() {
+	promoteCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function")
+}
@@ -0,0 +1,228 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	"github.com/kubeless/kubeless/pkg/client/clientset/versioned"
+	kubelessutil "github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+	rolledBackRevisionAnnotation = "kubeless.io/rolled-back-to-revision"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <function_name> FLAG",
+	Short: "roll back a function to a previous revision",
+	Long: `roll back the deployment of a function to a previous revision of its rollout history, like 'kubectl rollout undo'.
+By default the function is rolled back to the revision before the current one.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			logrus.Fatal("Need exactly one argument - function name")
+		}
+		funcName := args[0]
+
+		ns, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if ns == "" {
+			ns = kubelessutil.GetDefaultNamespace()
+		}
+		toRevision, err := cmd.Flags().GetInt64("to-revision")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if toRevision < 0 {
+			logrus.Fatalf("Invalid revision %d", toRevision)
+		}
+
+		kubelessClient, err := kubelessutil.GetKubelessClientOutCluster()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		cli := kubelessutil.GetClientOutOfCluster()
+		revision, err := rollbackFunction(cli, kubelessClient, ns, funcName, toRevision)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		logrus.Infof("Function %s rolled back to revision %d", funcName, revision)
+	},
+}
+
+func init() {
+	rollbackCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function")
+	rollbackCmd.Flags().Int64("to-revision", 0, "Revision to roll back to. Defaults to the revision before the current one")
+}
+
+// getRolloutHistory returns the ReplicaSets owned by the deployment sorted by revision
+func getRolloutHistory(cli kubernetes.Interface, dpm *appsv1.Deployment) ([]appsv1.ReplicaSet, error) {
+	selector, err := metav1.LabelSelectorAsSelector(dpm.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	list, err := cli.AppsV1().ReplicaSets(dpm.Namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	history := []appsv1.ReplicaSet{}
+	for _, rs := range list.Items {
+		owner := metav1.GetControllerOf(&rs)
+		if owner == nil || owner.UID != dpm.UID {
+			continue
+		}
+		if _, err := getRevision(rs.ObjectMeta); err != nil {
+			continue
+		}
+		history = append(history, rs)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		ri, _ := getRevision(history[i].ObjectMeta)
+		rj, _ := getRevision(history[j].ObjectMeta)
+		return ri < rj
+	})
+	return history, nil
+}
+
+func getRevision(meta metav1.ObjectMeta) (int64, error) {
+	return strconv.ParseInt(meta.Annotations[deploymentRevisionAnnotation], 10, 64)
+}
+
+// getRollbackTarget returns the ReplicaSet of the given revision or, if it's 0,
+// the one of the latest revision before the current one
+func getRollbackTarget(history []appsv1.ReplicaSet, current, toRevision int64) (*appsv1.ReplicaSet, error) {
+	if toRevision == current {
+		return nil, fmt.Errorf("Revision %d is the current revision", toRevision)
+	}
+	var target *appsv1.ReplicaSet
+	for i := range history {
+		revision, _ := getRevision(history[i].ObjectMeta)
+		if (toRevision == 0 && revision < current) || revision == toRevision {
+			target = &history[i]
+		}
+	}
+	if target == nil {
+		if toRevision == 0 {
+			return nil, fmt.Errorf("There is no revision before the current one (%d)", current)
+		}
+		return nil, fmt.Errorf("Revision %d not found in the rollout history", toRevision)
+	}
+	return target, nil
+}
+
+// checkRollbackSource returns an error if the pod template runs a different source than the
+// function. The source is read from the ConfigMap of the function, which only has the current
+// version, so the checksum verified by the "prepare" container would fail.
+func checkRollbackSource(f *kubelessApi.Function, spec appsv1.ReplicaSetSpec, revision int64) error {
+	checksum := strings.TrimPrefix(f.Spec.Checksum, "sha256:")
+	if checksum == "" {
+		return nil
+	}
+	for _, c := range spec.Template.Spec.InitContainers {
+		if c.Name == "prepare" && !strings.Contains(strings.Join(append(c.Command, c.Args...), " "), checksum) {
+			return fmt.Errorf("Revision %d runs a different version of the source of %s, which is not stored in the cluster. Deploy it again with 'kubeless function update'", revision, f.Name)
+		}
+	}
+	return nil
+}
+
+// getRevisionSpec returns the spec of the function stored in the ReplicaSet of a revision
+func getRevisionSpec(rs *appsv1.ReplicaSet, revision int64) (*kubelessutil.FunctionRevisionSpec, error) {
+	value, ok := rs.Annotations[kubelessutil.FunctionSpecAnnotation]
+	if !ok {
+		return nil, fmt.Errorf("Revision %d was deployed by a previous version of Kubeless and its function spec is not stored in the cluster. Deploy it again with 'kubeless function update'", revision)
+	}
+	spec := &kubelessutil.FunctionRevisionSpec{}
+	if err := json.Unmarshal([]byte(value), spec); err != nil {
+		return nil, fmt.Errorf("Unable to parse the function spec of revision %d: %v", revision, err)
+	}
+	return spec, nil
+}
+
+// rollbackFunction restores the pod template of a previous ReplicaSet in the deployment of
+// the function, writes the spec of that revision back into the Function and records the
+// revision in its annotations. It returns the revision rolled back to.
+func rollbackFunction(cli kubernetes.Interface, kubelessClient versioned.Interface, ns, funcName string, toRevision int64) (int64, error) {
+	f, err := kubelessutil.GetFunctionCustomResource(kubelessClient, funcName, ns)
+	if err != nil {
+		return 0, fmt.Errorf("Unable to find the function %s in namespace %s: %v", funcName, ns, err)
+	}
+	dpm, err := cli.AppsV1().Deployments(ns).Get(funcName, metav1.GetOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("Unable to find the deployment of %s: %v", funcName, err)
+	}
+	current, err := getRevision(dpm.ObjectMeta)
+	if err != nil {
+		return 0, fmt.Errorf("Unable to get the current revision of %s: %v", funcName, err)
+	}
+	history, err := getRolloutHistory(cli, dpm)
+	if err != nil {
+		return 0, err
+	}
+	target, err := getRollbackTarget(history, current, toRevision)
+	if err != nil {
+		return 0, err
+	}
+	revision, _ := getRevision(target.ObjectMeta)
+	if err := checkRollbackSource(f, target.Spec, revision); err != nil {
+		return 0, err
+	}
+
+	spec, err := getRevisionSpec(target, revision)
+	if err != nil {
+		return 0, err
+	}
+
+	template := target.Spec.Template.DeepCopy()
+	delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+	dpm.Spec.Template = *template
+	// Keep the spec of the revision in the deployment so the ReplicaSet reused by the
+	// rollback doesn't get the spec of the current one
+	dpm.Annotations[kubelessutil.FunctionSpecAnnotation] = target.Annotations[kubelessutil.FunctionSpecAnnotation]
+	if _, err := cli.AppsV1().Deployments(ns).Update(dpm); err != nil {
+		return 0, fmt.Errorf("Unable to roll back the deployment of %s: %v", funcName, err)
+	}
+
+	if f.Annotations == nil {
+		f.Annotations = map[string]string{}
+	}
+	f.Annotations[rolledBackRevisionAnnotation] = strconv.FormatInt(revision, 10)
+	// The controller builds the deployment from the function so it needs the spec of the
+	// revision, otherwise the next sync would roll the deployment forward again
+	f.Spec.Handler = spec.Handler
+	f.Spec.Runtime = spec.Runtime
+	f.Spec.Timeout = spec.Timeout
+	f.Spec.Deps = spec.Deps
+	f.Spec.Deployment.Spec.Template = spec.Template
+	if err := kubelessutil.UpdateFunctionCustomResource(kubelessClient, f); err != nil {
+		return 0, fmt.Errorf("The deployment was rolled back but the function %s couldn't be updated: %v", funcName, err)
+	}
+	return revision, nil
+}
@@ -0,0 +1,260 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	fFake "github.com/kubeless/kubeless/pkg/client/clientset/versioned/fake"
+	"github.com/kubeless/kubeless/pkg/langruntime"
+	kubelessutil "github.com/kubeless/kubeless/pkg/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRollbackFunction(t *testing.T) {
+	labels := map[string]string{"function": "foo"}
+	dpm := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foo",
+			Namespace:   "myns",
+			UID:         types.UID("foo-uid"),
+			Annotations: map[string]string{deploymentRevisionAnnotation: "3"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+		},
+	}
+	isController := true
+	newReplicaSet := func(revision, image, checksum string) *appsv1.ReplicaSet {
+		return &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo-" + revision,
+				Namespace: "myns",
+				Labels:    labels,
+				Annotations: map[string]string{
+					deploymentRevisionAnnotation:        revision,
+					kubelessutil.FunctionSpecAnnotation: `{"handler":"foo.v` + revision + `","runtime":"python2.7","template":{"metadata":{},"spec":{"containers":null}}}`,
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "Deployment", Name: "foo", UID: dpm.UID, Controller: &isController},
+				},
+			},
+			Spec: appsv1.ReplicaSetSpec{
+				Template: v1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{"function": "foo", appsv1.DefaultDeploymentUniqueLabelKey: revision},
+					},
+					Spec: v1.PodSpec{
+						InitContainers: []v1.Container{
+							{Name: "prepare", Args: []string{"echo '" + checksum + "  /src/foo.py' > /tmp/func.sha256"}},
+						},
+						Containers: []v1.Container{{Name: "foo", Image: image}},
+					},
+				},
+			},
+		}
+	}
+	f := &kubelessApi.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns"},
+		Spec:       kubelessApi.FunctionSpec{Checksum: "sha256:abc"},
+	}
+
+	tests := []struct {
+		name          string
+		toRevision    int64
+		expected      int64
+		expectedImage string
+		expectedErr   string
+	}{
+		{name: "previous revision", toRevision: 0, expected: 2, expectedImage: "runtime:2"},
+		{name: "given revision", toRevision: 1, expected: 1, expectedImage: "runtime:1"},
+		{name: "current revision", toRevision: 3, expectedErr: "is the current revision"},
+		{name: "missing revision", toRevision: 7, expectedErr: "not found"},
+		{name: "different source", toRevision: 4, expectedErr: "different version of the source"},
+		{name: "missing function spec", toRevision: 5, expectedErr: "function spec is not stored"},
+	}
+	for _, test := range tests {
+		withoutSpec := newReplicaSet("5", "runtime:5", "abc")
+		delete(withoutSpec.Annotations, kubelessutil.FunctionSpecAnnotation)
+		cli := fake.NewSimpleClientset(dpm,
+			newReplicaSet("1", "runtime:1", "abc"),
+			newReplicaSet("2", "runtime:2", "abc"),
+			newReplicaSet("3", "runtime:3", "abc"),
+			newReplicaSet("4", "runtime:4", "def"),
+			withoutSpec,
+		)
+		kubelessClient := fFake.NewSimpleClientset(f)
+		revision, err := rollbackFunction(cli, kubelessClient, "myns", "foo", test.toRevision)
+		if test.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf("%s: expecting error %q, got %v", test.name, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if revision != test.expected {
+			t.Errorf("%s: expecting revision %d, got %d", test.name, test.expected, revision)
+		}
+		updated, err := cli.AppsV1().Deployments("myns").Get("foo", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if image := updated.Spec.Template.Spec.Containers[0].Image; image != test.expectedImage {
+			t.Errorf("%s: expecting image %s, got %s", test.name, test.expectedImage, image)
+		}
+		if _, ok := updated.Spec.Template.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; ok {
+			t.Errorf("%s: the pod-template-hash label should be removed", test.name)
+		}
+		updatedFunction, err := kubelessClient.KubelessV1beta1().Functions("myns").Get("foo", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a := updatedFunction.Annotations[rolledBackRevisionAnnotation]; a != strconv.FormatInt(test.expected, 10) {
+			t.Errorf("%s: expecting the annotation %s to be %d, got %q", test.name, rolledBackRevisionAnnotation, test.expected, a)
+		}
+		if handler := updatedFunction.Spec.Handler; handler != "foo.v"+strconv.FormatInt(test.expected, 10) {
+			t.Errorf("%s: expecting the handler of revision %d, got %s", test.name, test.expected, handler)
+		}
+	}
+}
+
+// newRevision creates the ReplicaSet for the current pod template of the deployment like the
+// deployment controller does
+func newRevision(t *testing.T, cli kubernetes.Interface, ns, name, revision string) {
+	dpm, err := cli.AppsV1().Deployments(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	isController := true
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name + "-" + revision,
+			Namespace:   ns,
+			Labels:      dpm.Spec.Selector.MatchLabels,
+			Annotations: map[string]string{deploymentRevisionAnnotation: revision},
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "Deployment", Name: name, UID: dpm.UID, Controller: &isController},
+			},
+		},
+		Spec: appsv1.ReplicaSetSpec{Template: *dpm.Spec.Template.DeepCopy()},
+	}
+	for k, v := range dpm.Annotations {
+		if k != deploymentRevisionAnnotation {
+			rs.Annotations[k] = v
+		}
+	}
+	rs.Spec.Template.Labels[appsv1.DefaultDeploymentUniqueLabelKey] = revision
+	if _, err := cli.AppsV1().ReplicaSets(ns).Create(rs); err != nil {
+		t.Fatal(err)
+	}
+	if dpm.Annotations == nil {
+		dpm.Annotations = map[string]string{}
+	}
+	dpm.Annotations[deploymentRevisionAnnotation] = revision
+	if _, err := cli.AppsV1().Deployments(ns).Update(dpm); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRollbackFunctionSync(t *testing.T) {
+	cli := fake.NewSimpleClientset()
+	langruntime.AddFakeConfig(cli)
+	lr := langruntime.SetupLangRuntime(cli)
+	lr.ReadConfigMap()
+	or := []metav1.OwnerReference{{Kind: "Function", APIVersion: "k8s.io"}}
+	f := &kubelessApi.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns", Labels: map[string]string{"function": "foo"}},
+		Spec: kubelessApi.FunctionSpec{
+			Function: "function",
+			Checksum: "sha256:abc",
+			Handler:  "foo.v1",
+			Runtime:  "python2.7",
+			ServiceSpec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{Name: "http-function-port", Port: 8080, TargetPort: intstr.FromInt(8080)}},
+			},
+			Deployment: appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: v1.PodTemplateSpec{
+						Spec: v1.PodSpec{
+							Containers: []v1.Container{{Env: []v1.EnvVar{{Name: "FOO", Value: "1"}}}},
+						},
+					},
+				},
+			},
+		},
+	}
+	if err := kubelessutil.EnsureFuncDeployment(cli, f, or, lr, "", "unzip", nil); err != nil {
+		t.Fatal(err)
+	}
+	newRevision(t, cli, "myns", "foo", "1")
+
+	f.Spec.Handler = "foo.v2"
+	f.Spec.Deployment.Spec.Template.Spec.Containers[0].Env[0].Value = "2"
+	if err := kubelessutil.EnsureFuncDeployment(cli, f, or, lr, "", "unzip", nil); err != nil {
+		t.Fatal(err)
+	}
+	newRevision(t, cli, "myns", "foo", "2")
+
+	kubelessClient := fFake.NewSimpleClientset(f)
+	if _, err := rollbackFunction(cli, kubelessClient, "myns", "foo", 1); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := kubelessClient.KubelessV1beta1().Functions("myns").Get("foo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Spec.Handler != "foo.v1" {
+		t.Errorf("Expecting the handler foo.v1, got %s", restored.Spec.Handler)
+	}
+	if env := restored.Spec.Deployment.Spec.Template.Spec.Containers[0].Env; env[0].Value != "1" {
+		t.Errorf("Expecting the environment of the first revision, got %v", env)
+	}
+
+	// The controller syncs the deployment with the rolled back function, it should keep
+	// the pod template of the first revision
+	if err := kubelessutil.EnsureFuncDeployment(cli, restored, or, lr, "", "unzip", nil); err != nil {
+		t.Fatal(err)
+	}
+	dpm, err := cli.AppsV1().Deployments("myns").Get("foo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := cli.AppsV1().ReplicaSets("myns").Get("foo-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := rs.Spec.Template.DeepCopy()
+	delete(expected.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+	if !equality.Semantic.DeepEqual(dpm.Spec.Template, *expected) {
+		t.Errorf("Expecting the pod template of the first revision %v, got %v", *expected, dpm.Spec.Template)
+	}
+	if a := dpm.Annotations[kubelessutil.FunctionSpecAnnotation]; a != rs.Annotations[kubelessutil.FunctionSpecAnnotation] {
+		t.Errorf("Expecting the function spec of the first revision, got %s", a)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kubeless/kubeless/pkg/client/clientset/versioned"
+	kubelessutil "github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+var scaleCmd = &cobra.Command{
+	Use:   "scale <function_name> FLAG",
+	Short: "set the number of replicas of a function",
+	Long:  `set the number of replicas of a function. Functions managed by an autoscaler can't be scaled manually`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			logrus.Fatal("Need exactly one argument - function name")
+		}
+		funcName := args[0]
+
+		ns, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if ns == "" {
+			ns = kubelessutil.GetDefaultNamespace()
+		}
+
+		if !cmd.Flags().Changed("replicas") {
+			logrus.Fatal("The flag --replicas is required")
+		}
+		replicas, err := cmd.Flags().GetInt32("replicas")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if replicas < 0 {
+			logrus.Fatalf("Invalid number of replicas %d", replicas)
+		}
+
+		waitScale, err := cmd.Flags().GetBool("wait")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		kubelessClient, err := kubelessutil.GetKubelessClientOutCluster()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if err := scaleFunction(kubelessClient, ns, funcName, replicas); err != nil {
+			logrus.Fatal(err)
+		}
+		logrus.Infof("Function %s scaled to %d replicas", funcName, replicas)
+
+		if waitScale {
+			logrus.Infof("Waiting for %d replicas of %s to be ready...", replicas, funcName)
+			cli := kubelessutil.GetClientOutOfCluster()
+			if err := waitForReplicas(cli, ns, funcName, replicas, timeout); err != nil {
+				logrus.Fatal(err)
+			}
+			logrus.Infof("Function %s has %d ready replicas", funcName, replicas)
+		}
+	},
+}
+
+func init() {
+	scaleCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function")
+	scaleCmd.Flags().Int32("replicas", 1, "Number of replicas of the function")
+	scaleCmd.Flags().Bool("wait", false, "Wait until the deployment of the function has the given number of ready replicas")
+	scaleCmd.Flags().Duration("timeout", 5*time.Minute, "Maximum time to wait with --wait")
+}
+
+// scaleFunction sets the replicas of the function deployment. A function with an
+// autoscaler is rejected since the autoscaler would override the number of replicas.
+func scaleFunction(kubelessClient versioned.Interface, ns, funcName string, replicas int32) error {
+	f, err := kubelessutil.GetFunctionCustomResource(kubelessClient, funcName, ns)
+	if err != nil {
+		return fmt.Errorf("Unable to find the function %s in namespace %s: %v", funcName, ns, err)
+	}
+	if hpa := f.Spec.HorizontalPodAutoscaler.Name; hpa != "" {
+		return fmt.Errorf("The function %s is managed by the autoscaler %s. Delete it with 'kubeless autoscale delete %s' to scale the function manually", funcName, hpa, funcName)
+	}
+	f.Spec.Deployment.Spec.Replicas = &replicas
+	return kubelessutil.UpdateFunctionCustomResource(kubelessClient, f)
+}
+
+// waitForReplicas waits until the deployment of the function has the given number of ready replicas
+func waitForReplicas(cli kubernetes.Interface, ns, funcName string, replicas int32, timeout time.Duration) error {
+	var ready int32
+	progress := kubelessutil.StartProgress(fmt.Sprintf("Rolling out %s", funcName))
+	err := wait.PollImmediate(time.Second, timeout, func() (bool, error) {
+		dpm, err := cli.AppsV1().Deployments(ns).Get(funcName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		ready = dpm.Status.ReadyReplicas
+		progress.SetPhase(fmt.Sprintf("Rolling out %s: %d/%d ready replicas", funcName, ready, replicas))
+		return dpm.Spec.Replicas != nil && *dpm.Spec.Replicas == replicas &&
+			dpm.Status.Replicas == replicas && ready == replicas, nil
+	})
+	progress.Stop()
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("The function %s has %d ready replicas out of %d after %v", funcName, ready, replicas, timeout)
+	}
+	return err
+}
+
+// waitForRollout waits until the controller has created the deployment of a new function and all
+// its replicas are ready. Each request uses the timeout of the client, timeout is the overall limit.
+func waitForRollout(cli kubernetes.Interface, ns, funcName string, timeout time.Duration) error {
+	var ready, replicas int32
+	found := false
+	progress := kubelessutil.StartProgress(fmt.Sprintf("Waiting for the controller to create the deployment of %s", funcName))
+	err := wait.PollImmediate(time.Second, timeout, func() (bool, error) {
+		dpm, err := cli.AppsV1().Deployments(ns).Get(funcName, metav1.GetOptions{})
+		if err != nil {
+			if k8sErrors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		found = true
+		replicas = 1
+		if dpm.Spec.Replicas != nil {
+			replicas = *dpm.Spec.Replicas
+		}
+		ready = dpm.Status.ReadyReplicas
+		progress.SetPhase(fmt.Sprintf("Rolling out %s: %d/%d ready replicas", funcName, ready, replicas))
+		return dpm.Status.ObservedGeneration >= dpm.Generation &&
+			dpm.Status.UpdatedReplicas == replicas && ready == replicas, nil
+	})
+	progress.Stop()
+	if err == wait.ErrWaitTimeout {
+		if !found {
+			return fmt.Errorf("The deployment of the function %s has not been created after %v. Check the logs of the Kubeless controller", funcName, timeout)
+		}
+		return fmt.Errorf("The function %s has %d ready replicas out of %d after %v", funcName, ready, replicas, timeout)
+	}
+	return err
+}
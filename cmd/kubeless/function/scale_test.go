@@ -0,0 +1,105 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	fFake "github.com/kubeless/kubeless/pkg/client/clientset/versioned/fake"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/autoscaling/v2beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestScaleFunction(t *testing.T) {
+	f := &kubelessApi.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns"},
+	}
+	autoscaled := &kubelessApi.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "myns"},
+		Spec: kubelessApi.FunctionSpec{
+			HorizontalPodAutoscaler: v2beta1.HorizontalPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Name: "bar"},
+			},
+		},
+	}
+	client := fFake.NewSimpleClientset(f, autoscaled)
+
+	if err := scaleFunction(client, "myns", "foo", 3); err != nil {
+		t.Fatal(err)
+	}
+	scaled, err := client.KubelessV1beta1().Functions("myns").Get("foo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *scaled.Spec.Deployment.Spec.Replicas != 3 {
+		t.Errorf("Expecting 3 replicas, got %d", *scaled.Spec.Deployment.Spec.Replicas)
+	}
+
+	if err := scaleFunction(client, "myns", "bar", 3); err == nil {
+		t.Error("Expecting an error for a function with an autoscaler")
+	}
+	if err := scaleFunction(client, "myns", "missing", 3); err == nil {
+		t.Error("Expecting an error for a missing function")
+	}
+}
+
+func TestWaitForReplicas(t *testing.T) {
+	replicas := int32(2)
+	cli := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{Replicas: 2, ReadyReplicas: 2},
+	})
+	if err := waitForReplicas(cli, "myns", "foo", 2, time.Second); err != nil {
+		t.Error(err)
+	}
+	if err := waitForReplicas(cli, "myns", "foo", 3, 10*time.Millisecond); err == nil {
+		t.Error("Expecting a timeout waiting for 3 replicas")
+	}
+}
+
+func TestWaitForRollout(t *testing.T) {
+	replicas := int32(2)
+	cli := fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns", Generation: 1},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, ReadyReplicas: 2},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "myns", Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, ReadyReplicas: 2},
+		},
+	)
+	if err := waitForRollout(cli, "myns", "foo", time.Second); err != nil {
+		t.Error(err)
+	}
+	// The last generation has not been observed yet
+	if err := waitForRollout(cli, "myns", "bar", 10*time.Millisecond); err == nil {
+		t.Error("Expecting a timeout waiting for the rollout of bar")
+	}
+	err := waitForRollout(cli, "myns", "missing", 10*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "has not been created") {
+		t.Errorf("Expecting an error about the missing deployment, got %v", err)
+	}
+}
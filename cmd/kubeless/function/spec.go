@@ -0,0 +1,433 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	"github.com/kubeless/kubeless/pkg/langruntime"
+	kubelessutil "github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// fromSpecFlags are the flags of deploy that can be used with --from-spec.
+// The rest of them build the function so they are part of the spec instead.
+var fromSpecFlags = map[string]bool{
+	"from-spec": true,
+	"set":       true,
+	"namespace": true,
+	"dryrun":    true,
+	"output":    true,
+}
+
+// configFromFileFlags are the flags of deploy that can be used with --config-from-file
+var configFromFileFlags = map[string]bool{
+	"config-from-file": true,
+	"name":             true,
+	"namespace":        true,
+	"dryrun":           true,
+	"output":           true,
+}
+
+var (
+	quantityType    = reflect.TypeOf(resource.Quantity{})
+	intOrStringType = reflect.TypeOf(intstr.IntOrString{})
+	timeType        = reflect.TypeOf(metav1.Time{})
+)
+
+var (
+	// setPathRegex matches a --set path, e.g. spec.deployment.spec.template.spec.containers[0].image
+	setPathRegex = regexp.MustCompile(`^[^.\[\]]+(\[[0-9]+\])*(\.[^.\[\]]+(\[[0-9]+\])*)*$`)
+	// setPathToken matches each field and index of a --set path, e.g. "containers" and "[0]"
+	setPathToken = regexp.MustCompile(`[^.\[\]]+|\[[0-9]+\]`)
+)
+
+// deployFromSpec deploys the function of a spec file after applying the --set overrides
+func deployFromSpec(cmd *cobra.Command, args []string, file string) {
+	if err := checkSpecFlags(cmd.LocalNonPersistentFlags(), fromSpecFlags, "--from-spec"); err != nil {
+		logrus.Fatal(err)
+	}
+	if len(args) > 1 {
+		logrus.Fatal("Need at most one argument - function name")
+	}
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	sets, err := cmd.Flags().GetStringArray("set")
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if len(args) == 1 {
+		sets = append([]string{"metadata.name=" + args[0]}, sets...)
+	}
+	content, err = applySetValues(content, sets)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	f, err := parseFunctionManifest(content)
+	if err != nil {
+		logrus.Fatalf("Unable to parse %s: %v", file, err)
+	}
+
+	submitSpecFunction(cmd, f)
+}
+
+// checkSpecFlags returns an error if a flag given in the command line is not one of the
+// allowed ones. Only the flags of the command itself are checked: global flags don't
+// build the function, and flags set from KUBELESS_* variables or the CLI config aren't
+// given by the user for this deployment.
+func checkSpecFlags(flags *pflag.FlagSet, allowed map[string]bool, option string) error {
+	var err error
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if err != nil || !flag.Changed || kubelessutil.IsFlagFromDefaults(flag) || allowed[flag.Name] {
+			return
+		}
+		err = fmt.Errorf("The flag --%s can't be used with %s, set the field in the spec instead", flag.Name, option)
+	})
+	return err
+}
+
+// deployFromConfigFile deploys a function with the spec of the file and the
+// name and namespace given in the command line
+func deployFromConfigFile(cmd *cobra.Command, args []string, file string) {
+	if err := checkSpecFlags(cmd.LocalNonPersistentFlags(), configFromFileFlags, "--config-from-file"); err != nil {
+		logrus.Fatal(err)
+	}
+	name, err := cmd.Flags().GetString("name")
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	name, err = getConfigFunctionName(name, args)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	spec, err := parseFunctionSpec(content)
+	if err != nil {
+		logrus.Fatalf("Unable to parse %s: %v", file, err)
+	}
+	f := &kubelessApi.Function{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Function",
+			APIVersion: "kubeless.io/v1beta1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: *spec,
+	}
+	submitSpecFunction(cmd, f)
+}
+
+// getConfigFunctionName returns the name of the function given with --name or as argument
+func getConfigFunctionName(name string, args []string) (string, error) {
+	if len(args) > 1 {
+		return "", fmt.Errorf("Need at most one argument - function name")
+	}
+	if len(args) == 1 {
+		if name != "" && name != args[0] {
+			return "", fmt.Errorf("The function name %q doesn't match --name %q", args[0], name)
+		}
+		name = args[0]
+	}
+	if name == "" {
+		return "", fmt.Errorf("Need the function name, as argument or with --name")
+	}
+	return name, nil
+}
+
+// parseFunctionSpec parses the spec of a function in YAML or JSON into the FunctionSpec type.
+// Unlike with manifests, unknown fields are an error so typos don't go unnoticed.
+func parseFunctionSpec(content []byte) (*kubelessApi.FunctionSpec, error) {
+	raw, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("Expecting the fields of the spec of a function: %v", err)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("The spec is empty")
+	}
+	for _, field := range []string{"kind", "apiVersion", "metadata"} {
+		if _, ok := fields[field]; ok {
+			return nil, fmt.Errorf("Found the field %s of a Function manifest, the file should only contain its spec. Use --from-spec to deploy a manifest", field)
+		}
+	}
+	spec := &kubelessApi.FunctionSpec{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// submitSpecFunction creates a function built from a file, unless
+// --dryrun is given, after checking its runtime
+func submitSpecFunction(cmd *cobra.Command, f *kubelessApi.Function) {
+	ns, err := cmd.Flags().GetString("namespace")
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if ns != "" {
+		f.Namespace = ns
+	} else if f.Namespace == "" {
+		f.Namespace = kubelessutil.GetDefaultNamespace()
+	}
+
+	cli := kubelessutil.GetClientOutOfCluster()
+	apiExtensionsClientset := kubelessutil.GetAPIExtensionsClientOutOfCluster()
+	config, err := kubelessutil.GetCachedKubelessConfig(cli, apiExtensionsClientset)
+	if config == nil || err != nil {
+		logrus.Warnf("%v. Runtime check is disabled.", err)
+	} else {
+		lr := langruntime.New(config)
+		lr.ReadConfigMap()
+		if f.Spec.Runtime != "" && !lr.IsValidRuntime(f.Spec.Runtime) {
+			logrus.Fatalf("Invalid runtime: %s. Supported runtimes are: %s",
+				f.Spec.Runtime, strings.Join(lr.GetRuntimes(), ", "))
+		}
+	}
+
+	dryrun, err := cmd.Flags().GetBool("dryrun")
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if dryrun {
+		res, err := kubelessutil.DryRunFmt(output, f)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		fmt.Println(res)
+		return
+	}
+
+	kubelessClient, err := kubelessutil.GetKubelessClientOutCluster()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	logrus.Infof("Deploying function...")
+	if err := kubelessutil.CreateFunctionCustomResource(kubelessClient, f); err != nil {
+		logrus.Fatalf("Failed to deploy %s. Received:\n%s", f.Name, err)
+	}
+	logrus.Infof("Function %s submitted for deployment", f.Name)
+	logrus.Infof("Check the deployment status executing 'kubeless function ls %s -n %s'", f.Name, f.Namespace)
+}
+
+// applySetValues applies the given overrides (path=value) to a Function manifest in YAML or JSON
+// and returns it as JSON. Paths are checked against the Function type and values are converted
+// to the type of their field.
+func applySetValues(content []byte, sets []string) ([]byte, error) {
+	var manifest interface{}
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+	for _, set := range sets {
+		i := strings.Index(set, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("Invalid --set %q, expecting path=value", set)
+		}
+		path, value := set[:i], set[i+1:]
+		if !setPathRegex.MatchString(path) {
+			return nil, fmt.Errorf("Invalid --set path %q", path)
+		}
+		tokens := setPathToken.FindAllString(path, -1)
+		var err error
+		manifest, err = setSpecValue(manifest, reflect.TypeOf(kubelessApi.Function{}), tokens, value, "")
+		if err != nil {
+			return nil, fmt.Errorf("Invalid --set %s: %v", path, err)
+		}
+	}
+	return json.Marshal(manifest)
+}
+
+// setSpecValue sets the value of the path given as tokens in the node, which is decoded from
+// a value of type t, creating the missing objects. It returns the updated node.
+func setSpecValue(node interface{}, t reflect.Type, tokens []string, value, path string) (interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if len(tokens) == 0 {
+		return coerceSpecValue(t, value)
+	}
+	token := tokens[0]
+	isIndex := strings.HasPrefix(token, "[")
+	parent := path
+	if parent == "" {
+		parent = "the function"
+	}
+	if isIndex {
+		path += token
+	} else if path == "" {
+		path = token
+	} else {
+		path += "." + token
+	}
+	switch {
+	case t.Kind() == reflect.Struct && t != quantityType && t != intOrStringType && t != timeType:
+		if isIndex {
+			return nil, fmt.Errorf("%s is not a list", parent)
+		}
+		field, ok := jsonField(t, token)
+		if !ok {
+			return nil, fmt.Errorf("unknown field %s", path)
+		}
+		obj, _ := node.(map[string]interface{})
+		if obj == nil {
+			obj = map[string]interface{}{}
+		}
+		v, err := setSpecValue(obj[token], field.Type, tokens[1:], value, path)
+		if err != nil {
+			return nil, err
+		}
+		obj[token] = v
+		return obj, nil
+	case t.Kind() == reflect.Map:
+		if isIndex {
+			return nil, fmt.Errorf("%s is not a list", parent)
+		}
+		obj, _ := node.(map[string]interface{})
+		if obj == nil {
+			obj = map[string]interface{}{}
+		}
+		v, err := setSpecValue(obj[token], t.Elem(), tokens[1:], value, path)
+		if err != nil {
+			return nil, err
+		}
+		obj[token] = v
+		return obj, nil
+	case t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8:
+		if !isIndex {
+			return nil, fmt.Errorf("%s is a list, expecting an index like [0]", parent)
+		}
+		index, _ := strconv.Atoi(strings.Trim(token, "[]"))
+		list, _ := node.([]interface{})
+		// An index right after the last element appends a new one
+		if index > len(list) {
+			return nil, fmt.Errorf("%s is out of range, the list has %d elements", path, len(list))
+		}
+		if index == len(list) {
+			list = append(list, nil)
+		}
+		v, err := setSpecValue(list[index], t.Elem(), tokens[1:], value, path)
+		if err != nil {
+			return nil, err
+		}
+		list[index] = v
+		return list, nil
+	default:
+		return nil, fmt.Errorf("%s is a single value, it has no field %s", parent, token)
+	}
+}
+
+// jsonField returns the field of the struct with the given JSON name, looking also in inlined structs
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.Anonymous && tag == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if f, ok := jsonField(embedded, name); ok {
+				return f, true
+			}
+			continue
+		}
+		if tag == "" {
+			tag = field.Name
+		}
+		if tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// coerceSpecValue converts a --set value to the type of its field
+func coerceSpecValue(t reflect.Type, value string) (interface{}, error) {
+	switch t {
+	case quantityType:
+		if _, err := resource.ParseQuantity(value); err != nil {
+			return nil, fmt.Errorf("%q is not a valid quantity", value)
+		}
+		return value, nil
+	case intOrStringType:
+		if i, err := strconv.Atoi(value); err == nil {
+			return i, nil
+		}
+		return value, nil
+	case timeType:
+		return value, nil
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return value, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", value)
+		}
+		return b, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, t.Bits())
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid %s", value, t.Kind())
+		}
+		return i, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(value, 10, t.Bits())
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid %s", value, t.Kind())
+		}
+		return i, nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, t.Bits())
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("it is an object or a list, set its fields instead")
+}
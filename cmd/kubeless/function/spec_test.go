@@ -0,0 +1,249 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	kubelessutil "github.com/kubeless/kubeless/pkg/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/api/core/v1"
+)
+
+const baseSpec = `apiVersion: kubeless.io/v1beta1
+kind: Function
+metadata:
+  name: hello
+  labels:
+    team: a
+spec:
+  runtime: python3.7
+  handler: hello.handler
+  function: "def handler(event, context): return 'hello'"
+  deployment:
+    spec:
+      template:
+        spec:
+          containers:
+          - env:
+            - name: FOO
+              value: bar
+`
+
+func TestApplySetValues(t *testing.T) {
+	content, err := applySetValues([]byte(baseSpec), []string{
+		"spec.runtime=python3.8",
+		"metadata.labels.team=b",
+		"metadata.labels.version=2",
+		"spec.deployment.spec.replicas=3",
+		"spec.deployment.spec.template.spec.containers[0].env[1].name=BAR",
+		"spec.deployment.spec.template.spec.containers[0].env[1].value=42",
+		"spec.deployment.spec.template.spec.containers[0].resources.limits.memory=128Mi",
+		"spec.deployment.spec.template.spec.containers[0].ports[0].containerPort=8080",
+		"spec.service.ports[0].targetPort=http",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parseFunctionManifest(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Spec.Runtime != "python3.8" || f.Spec.Handler != "hello.handler" {
+		t.Errorf("Unexpected spec %+v", f.Spec)
+	}
+	if f.Labels["team"] != "b" || f.Labels["version"] != "2" {
+		t.Errorf("Unexpected labels %v", f.Labels)
+	}
+	if f.Spec.Deployment.Spec.Replicas == nil || *f.Spec.Deployment.Spec.Replicas != 3 {
+		t.Errorf("Expecting 3 replicas, got %v", f.Spec.Deployment.Spec.Replicas)
+	}
+	container := f.Spec.Deployment.Spec.Template.Spec.Containers[0]
+	expectedEnv := []v1.EnvVar{{Name: "FOO", Value: "bar"}, {Name: "BAR", Value: "42"}}
+	if len(container.Env) != 2 || container.Env[0] != expectedEnv[0] || container.Env[1] != expectedEnv[1] {
+		t.Errorf("Expecting env %v, got %v", expectedEnv, container.Env)
+	}
+	if mem := container.Resources.Limits[v1.ResourceMemory]; mem.String() != "128Mi" {
+		t.Errorf("Expecting a memory limit of 128Mi, got %s", mem.String())
+	}
+	if container.Ports[0].ContainerPort != 8080 {
+		t.Errorf("Expecting port 8080, got %d", container.Ports[0].ContainerPort)
+	}
+	if f.Spec.ServiceSpec.Ports[0].TargetPort.String() != "http" {
+		t.Errorf("Expecting the target port http, got %s", f.Spec.ServiceSpec.Ports[0].TargetPort.String())
+	}
+}
+
+func TestApplySetValuesErrors(t *testing.T) {
+	tests := []struct {
+		set      string
+		expected string
+	}{
+		{"spec.runtime", "expecting path=value"},
+		{"spec..runtime=python3.8", "Invalid --set path"},
+		{"spec.foo=bar", "unknown field spec.foo"},
+		{"spec.runtime.version=3", "spec.runtime is a single value"},
+		{"spec.deployment.spec.replicas=three", "\"three\" is not a valid int32"},
+		{"spec.deployment.spec.template.spec.containers[0].resources.limits.memory=lots", "not a valid quantity"},
+		{"spec.deployment.spec.template.spec.containers[2].image=foo", "out of range"},
+		{"spec.deployment.spec.template.spec.containers.image=foo", "is a list"},
+		{"metadata[0].name=foo", "metadata is not a list"},
+		{"spec.deployment=foo", "set its fields instead"},
+	}
+	for _, test := range tests {
+		_, err := applySetValues([]byte(baseSpec), []string{test.set})
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: expecting an error containing %q, got %v", test.set, test.expected, err)
+		}
+	}
+}
+
+func TestParseFunctionSpec(t *testing.T) {
+	spec, err := parseFunctionSpec([]byte(`
+runtime: python3.7
+handler: hello.handler
+function: |
+  def handler(event, context):
+    return "hello"
+deployment:
+  spec:
+    replicas: 2
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Runtime != "python3.7" || spec.Handler != "hello.handler" || *spec.Deployment.Spec.Replicas != 2 {
+		t.Errorf("Unexpected spec %v", spec)
+	}
+
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"runtme: python3.7", "unknown field \"runtme\""},
+		{"runtime: python3.7\ndeployment:\n  spec:\n    replicas: two", "cannot unmarshal"},
+		{"apiVersion: kubeless.io/v1beta1\nkind: Function\nspec:\n  runtime: python3.7", "Use --from-spec"},
+		{"", "The spec is empty"},
+		{"- runtime: python3.7", "Expecting the fields of the spec"},
+	}
+	for _, test := range tests {
+		_, err := parseFunctionSpec([]byte(test.content))
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%q: expecting an error containing %q, got %v", test.content, test.expected, err)
+		}
+	}
+}
+
+func TestGetConfigFunctionName(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+		err      bool
+	}{
+		{name: "foo", expected: "foo"},
+		{args: []string{"foo"}, expected: "foo"},
+		{name: "foo", args: []string{"foo"}, expected: "foo"},
+		{name: "foo", args: []string{"bar"}, err: true},
+		{args: []string{"foo", "bar"}, err: true},
+		{err: true},
+	}
+	for _, test := range tests {
+		name, err := getConfigFunctionName(test.name, test.args)
+		if test.err {
+			if err == nil {
+				t.Errorf("--name %q %v: expecting an error", test.name, test.args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != test.expected {
+			t.Errorf("--name %q %v: expecting %q, got %q", test.name, test.args, test.expected, name)
+		}
+	}
+}
+
+// runSpecFlagsCheck runs a command with the given args under a root command with global
+// flags, like the CLI does, and returns the result of checkSpecFlags
+func runSpecFlagsCheck(t *testing.T, args []string, allowed map[string]bool, option string) error {
+	var checkErr error
+	root := &cobra.Command{Use: "kubeless"}
+	root.PersistentFlags().BoolP("quiet", "q", false, "")
+	root.PersistentFlags().Duration("request-timeout", 0, "")
+	root.PersistentFlags().String("audit-log", "", "")
+	deploy := &cobra.Command{
+		Use: "deploy",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := kubelessutil.ApplyEnvDefaults(cmd.Flags()); err != nil {
+				t.Fatal(err)
+			}
+			checkErr = checkSpecFlags(cmd.LocalNonPersistentFlags(), allowed, option)
+		},
+	}
+	deploy.Flags().String("from-spec", "", "")
+	deploy.Flags().String("config-from-file", "", "")
+	deploy.Flags().String("runtime", "", "")
+	deploy.Flags().Bool("dryrun", false, "")
+	root.AddCommand(deploy)
+	root.SetArgs(append([]string{"deploy"}, args...))
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	return checkErr
+}
+
+func TestCheckSpecFlagsFromSpec(t *testing.T) {
+	// Global flags don't build the function
+	err := runSpecFlagsCheck(t, []string{"--from-spec", "f.yaml", "--dryrun", "--quiet", "--request-timeout", "5s", "--audit-log", "audit.log"}, fromSpecFlags, "--from-spec")
+	if err != nil {
+		t.Errorf("Unexpected error with global flags: %v", err)
+	}
+
+	err = runSpecFlagsCheck(t, []string{"--from-spec", "f.yaml", "--runtime", "python3.7"}, fromSpecFlags, "--from-spec")
+	if err == nil || !strings.Contains(err.Error(), "--runtime can't be used with --from-spec") {
+		t.Errorf("Expecting an error for --runtime, got %v", err)
+	}
+
+	// Defaults from the environment are not given by the user
+	os.Setenv("KUBELESS_RUNTIME", "python3.7")
+	defer os.Unsetenv("KUBELESS_RUNTIME")
+	if err := runSpecFlagsCheck(t, []string{"--from-spec", "f.yaml"}, fromSpecFlags, "--from-spec"); err != nil {
+		t.Errorf("Unexpected error with KUBELESS_RUNTIME: %v", err)
+	}
+}
+
+func TestCheckSpecFlagsConfigFromFile(t *testing.T) {
+	err := runSpecFlagsCheck(t, []string{"--config-from-file", "s.yaml", "--dryrun", "--quiet"}, configFromFileFlags, "--config-from-file")
+	if err != nil {
+		t.Errorf("Unexpected error with global flags: %v", err)
+	}
+
+	err = runSpecFlagsCheck(t, []string{"--config-from-file", "s.yaml", "--runtime", "python3.7"}, configFromFileFlags, "--config-from-file")
+	if err == nil || !strings.Contains(err.Error(), "--runtime can't be used with --config-from-file") {
+		t.Errorf("Expecting an error for --runtime, got %v", err)
+	}
+
+	os.Setenv("KUBELESS_RUNTIME", "python3.7")
+	defer os.Unsetenv("KUBELESS_RUNTIME")
+	if err := runSpecFlagsCheck(t, []string{"--config-from-file", "s.yaml"}, configFromFileFlags, "--config-from-file"); err != nil {
+		t.Errorf("Unexpected error with KUBELESS_RUNTIME: %v", err)
+	}
+}
@@ -17,7 +17,6 @@ limitations under the License.
 package function
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"sort"
@@ -55,12 +54,16 @@ var topCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatal(err.Error())
 		}
+		interval, err := cmd.Flags().GetDuration("interval")
+		if err != nil {
+			logrus.Fatal(err)
+		}
 
 		apiV1Client := utils.GetClientOutOfCluster()
 		kubelessClient, err := utils.GetKubelessClientOutCluster()
 		handler := &utils.PrometheusMetricsHandler{}
 
-		err = doTop(cmd.OutOrStdout(), kubelessClient, apiV1Client, handler, ns, functionName, output)
+		err = doTop(cmd.OutOrStdout(), kubelessClient, apiV1Client, handler, ns, functionName, output, interval)
 		if err != nil {
 			logrus.Fatal(err.Error())
 		}
@@ -71,14 +74,31 @@ func init() {
 	topCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function")
 	topCmd.Flags().StringP("function", "f", "", "Specify the function")
 	topCmd.Flags().StringP("out", "o", "", "Output format. One of: json|yaml")
+	topCmd.Flags().Duration("interval", 0, "Sample the metrics twice, separated by this interval, to calculate the request rate")
 }
 
-func doTop(w io.Writer, kubelessClient versioned.Interface, apiV1Client kubernetes.Interface, handler utils.MetricsRetriever, ns, functionName, output string) error {
+func doTop(w io.Writer, kubelessClient versioned.Interface, apiV1Client kubernetes.Interface, handler utils.MetricsRetriever, ns, functionName, output string, interval time.Duration) error {
 	functions, err := getFunctions(kubelessClient, ns, functionName)
 	if err != nil {
 		return fmt.Errorf("Error listing functions: %v", err)
 	}
 
+	metrics := collectMetrics(apiV1Client, handler, ns, functions)
+	if interval > 0 {
+		previous := metrics
+		time.Sleep(interval)
+		metrics = collectMetrics(apiV1Client, handler, ns, functions)
+		utils.SetRequestRates(previous, metrics, interval)
+	}
+
+	// sort the results - useful when using 'watch kubeless function top'
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].FunctionName < metrics[j].FunctionName
+	})
+	return printTop(w, metrics, apiV1Client, output)
+}
+
+func collectMetrics(apiV1Client kubernetes.Interface, handler utils.MetricsRetriever, ns string, functions []*kubelessApi.Function) []*utils.Metric {
 	ch := make(chan []*utils.Metric, len(functions))
 	for _, f := range functions {
 		go func(f *kubelessApi.Function) {
@@ -99,12 +119,7 @@ func doTop(w io.Writer, kubelessClient versioned.Interface, apiV1Client kubernet
 			i = len(functions)
 		}
 	}
-
-	// sort the results - useful when using 'watch kubeless function top'
-	sort.Slice(metrics, func(i, j int) bool {
-		return metrics[i].FunctionName < metrics[j].FunctionName
-	})
-	return printTop(w, metrics, apiV1Client, output)
+	return metrics
 }
 
 func printTop(w io.Writer, metrics []*utils.Metric, cli kubernetes.Interface, output string) error {
@@ -112,19 +127,19 @@ func printTop(w io.Writer, metrics []*utils.Metric, cli kubernetes.Interface, ou
 		table := uitable.New()
 		table.MaxColWidth = 50
 		table.Wrap = true
-		table.AddRow("NAME", "NAMESPACE", "METHOD", "TOTAL_CALLS", "TOTAL_FAILURES", "TOTAL_DURATION_SECONDS", "AVG_DURATION_SECONDS", "MESSAGE")
+		table.AddRow("NAME", "NAMESPACE", "METHOD", "TOTAL_CALLS", "TOTAL_FAILURES", "TOTAL_DURATION_SECONDS", "AVG_DURATION_SECONDS", "P95_DURATION_SECONDS", "ERROR_RATE", "REQUESTS_PER_SECOND", "MESSAGE")
 		for _, f := range metrics {
 			if f.Message != "" {
-				table.AddRow(f.FunctionName, f.Namespace, "", "", "", "", "", f.Message)
+				table.AddRow(f.FunctionName, f.Namespace, "", "", "", "", "", "", "", "", f.Message)
 			} else {
-				table.AddRow(f.FunctionName, f.Namespace, f.Method, f.TotalCalls, f.TotalFailures, f.TotalDurationSeconds, f.AvgDurationSeconds, "")
+				table.AddRow(f.FunctionName, f.Namespace, f.Method, f.TotalCalls, f.TotalFailures, f.TotalDurationSeconds, f.AvgDurationSeconds, f.P95DurationSeconds, f.ErrorRate, f.RequestsPerSecond, "")
 			}
 		}
 		fmt.Fprintln(w, table)
 	} else {
 		switch output {
 		case "json":
-			b, err := json.MarshalIndent(metrics, "", "  ")
+			b, err := utils.MarshalJSON(metrics, "  ")
 			if err != nil {
 				return err
 			}
@@ -58,7 +58,7 @@ func (h *testMetricsHandler) GetRawMetrics(apiClient kubernetes.Interface, names
 func topOutput(t *testing.T, client versioned.Interface, apiV1Client kubernetes.Interface, h utils.MetricsRetriever, ns, functionName, output string) string {
 	var buf bytes.Buffer
 
-	if err := doTop(&buf, client, apiV1Client, h, ns, functionName, output); err != nil {
+	if err := doTop(&buf, client, apiV1Client, h, ns, functionName, output, 0); err != nil {
 		t.Fatalf("doTop returned error: %v", err)
 	}
 
@@ -396,6 +396,10 @@ func TestTop(t *testing.T) {
 		t.Errorf("table output didn't match on AVG_DURATION_SECONDS")
 	}
 
+	if !strings.Contains(output, "P95_DURATION_SECONDS") || !strings.Contains(output, "0.02323") || !strings.Contains(output, "0.02340") {
+		t.Errorf("table output didn't match on P95_DURATION_SECONDS")
+	}
+
 	// Get single function
 	output = topOutput(t, client, apiV1Client, handler, namespace, function2Name, "")
 	t.Log("output is", output)
@@ -405,6 +409,8 @@ func TestTop(t *testing.T) {
 	}
 
 	// json output
+	utils.SetPrettyJSON(true)
+	defer utils.SetPrettyJSON(false)
 	output = topOutput(t, client, apiV1Client, handler, namespace, "", "json")
 	t.Log("output is", output)
 
@@ -422,6 +428,10 @@ func TestTop(t *testing.T) {
 		t.Errorf("table output didn't match on AVG_DURATION_SECONDS")
 	}
 
+	if !strings.Contains(output, "\"p95_duration_seconds\": 0.02323") {
+		t.Errorf("json output didn't match on p95_duration_seconds")
+	}
+
 	// yaml output
 	output = topOutput(t, client, apiV1Client, handler, namespace, "", "yaml")
 	t.Log("output is", output)
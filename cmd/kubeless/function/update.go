@@ -17,7 +17,6 @@ limitations under the License.
 package function
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -35,7 +34,7 @@ var updateCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		cli := utils.GetClientOutOfCluster()
 		apiExtensionsClientset := utils.GetAPIExtensionsClientOutOfCluster()
-		config, err := utils.GetKubelessConfig(cli, apiExtensionsClientset)
+		config, err := utils.GetCachedKubelessConfig(cli, apiExtensionsClientset)
 		if err != nil {
 			logrus.Fatalf("Unable to read the configmap: %v", err)
 		}
@@ -102,6 +101,16 @@ var updateCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatal(err)
 		}
+		requireDigest, err := cmd.Flags().GetBool("require-digest")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if runtimeImage != "" {
+			if err := validateRuntimeImage(runtimeImage, requireDigest); err != nil {
+				logrus.Fatal(err)
+			}
+			logrus.Warn(getRuntimeImageWarning(runtime, runtimeImage))
+		}
 
 		imagePullPolicy, err := cmd.Flags().GetString("image-pull-policy")
 		if err != nil {
@@ -127,6 +136,20 @@ var updateCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatal(err)
 		}
+		if utils.IsFlagGiven(cmd.Flags(), "function-timeout") && utils.IsFlagGiven(cmd.Flags(), "timeout") {
+			logrus.Fatal("The flags --timeout and --function-timeout can't be used together")
+		}
+		// A flag given in the command line wins over the default of the other one
+		if utils.IsFlagGiven(cmd.Flags(), "function-timeout") || (cmd.Flags().Changed("function-timeout") && !utils.IsFlagGiven(cmd.Flags(), "timeout")) {
+			functionTimeout, err := cmd.Flags().GetString("function-timeout")
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			timeout, err = parseFunctionTimeout(functionTimeout)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+		}
 
 		deps, err := cmd.Flags().GetString("dependencies")
 		if err != nil {
@@ -177,6 +200,16 @@ var updateCmd = &cobra.Command{
 			logrus.Fatal(err)
 		}
 
+		maxSurge, err := cmd.Flags().GetString("max-surge")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		maxUnavailable, err := cmd.Flags().GetString("max-unavailable")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
 		previousFunction, err := utils.GetFunction(funcName, ns)
 		if err != nil {
 			logrus.Fatal(err)
@@ -186,10 +219,22 @@ var updateCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatal(err)
 		}
+		if requireDigest {
+			// Without --runtime-image the image is the one of the runtime or the current one
+			if err := validateRuntimeImage(f.Spec.Deployment.Spec.Template.Spec.Containers[0].Image, true); err != nil {
+				logrus.Fatal(err)
+			}
+		}
+
+		if maxSurge != "" || maxUnavailable != "" {
+			if err := setRollingUpdate(f, maxSurge, maxUnavailable); err != nil {
+				logrus.Fatal(err)
+			}
+		}
 
 		if dryrun == true {
 			if output == "json" {
-				j, err := json.MarshalIndent(f, "", "    ")
+				j, err := utils.MarshalJSON(f, "    ")
 				if err != nil {
 					logrus.Fatal(err)
 				}
@@ -235,9 +280,13 @@ func init() {
 	updateCmd.Flags().StringP("service-account", "", "", "Specify service account for the function. For example: --service-account controller-acct")
 	updateCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function")
 	updateCmd.Flags().StringP("dependencies", "d", "", "Specify a file containing list of dependencies for the function")
-	updateCmd.Flags().StringP("runtime-image", "", "", "Custom runtime image")
+	updateCmd.Flags().StringP("runtime-image", "", "", "Custom runtime image. It can be referenced by digest (repo@sha256:<digest>)")
+	updateCmd.Flags().Bool("require-digest", false, "Reject runtime images that are not referenced by digest")
 	updateCmd.Flags().StringP("image-pull-policy", "", "Always", "Image pull policy")
 	updateCmd.Flags().StringP("timeout", "", "180", "Maximum timeout (in seconds) for the function to complete its execution")
+	updateCmd.Flags().StringP("function-timeout", "", "", "Maximum time for the function to complete its execution, in seconds or as a duration (e.g. 5m). It's given to the runtime as FUNC_TIMEOUT")
+	updateCmd.Flags().String("max-surge", "", "Maximum number (e.g. 2) or percentage (e.g. 25%) of extra pods created during a rolling update of the function")
+	updateCmd.Flags().String("max-unavailable", "", "Maximum number (e.g. 1) or percentage (e.g. 10%) of pods of the function that can be unavailable during a rolling update. Defaults to 0")
 	updateCmd.Flags().Bool("headless", false, "Deploy http-based function without a single service IP and load balancing support from Kubernetes. See: https://kubernetes.io/docs/concepts/services-networking/service/#headless-services")
 	updateCmd.Flags().Int32("port", 8080, "Deploy http-based function with a custom port")
 	updateCmd.Flags().Int32("servicePort", 0, "Deploy http-based function with a custom service port")
@@ -0,0 +1,233 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/template"
+	"time"
+
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	"github.com/kubeless/kubeless/pkg/client/clientset/versioned"
+	"github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// watchRowFormat is the format of each change printed by 'function ls --watch'
+const watchRowFormat = "%-10s %-30s %-15s %-15s %s\n"
+
+// getWatchStop returns a channel closed on SIGINT, SIGTERM or after the timeout (if not zero).
+// The returned function releases the signal handlers.
+func getWatchStop(timeout time.Duration) (<-chan struct{}, func()) {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	var timer <-chan time.Time
+	if timeout > 0 {
+		timer = time.After(timeout)
+	}
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-signals:
+		case <-timer:
+		case <-done:
+			return
+		}
+		close(stop)
+	}()
+	return stop, func() {
+		signal.Stop(signals)
+		close(done)
+		<-exited
+	}
+}
+
+// isResourceVersionTooOld returns true if the error means that the changes since the resource
+// version of a watch are no longer available
+func isResourceVersionTooOld(err error) bool {
+	return k8sErrors.IsGone(err) || k8sErrors.IsResourceExpired(err)
+}
+
+// watchFunctions prints the functions of the namespace and then every change of them or
+// of the status of their deployments until stop is closed. If sinceResourceVersion is given
+// the functions are not listed, only the changes made after that resource version are printed.
+// It returns the resource version of the last change of the functions observed.
+func watchFunctions(w io.Writer, kubelessClient versioned.Interface, cli kubernetes.Interface, ns, output string, tmpl *template.Template, sinceResourceVersion string, stop <-chan struct{}) (string, error) {
+	statuses := map[string]string{}
+	if output == "" {
+		fmt.Fprintf(w, watchRowFormat, "EVENT", "NAME", "NAMESPACE", "RUNTIME", "STATUS")
+	}
+	listFunctions := func() (string, error) {
+		functions, err := kubelessClient.KubelessV1beta1().Functions(ns).List(metav1.ListOptions{})
+		if err != nil {
+			return "", err
+		}
+		for _, f := range functions.Items {
+			status, err := getFunctionStatus(cli, f)
+			if err != nil {
+				return "", err
+			}
+			statuses[f.Name] = status
+			if err := printFunctionEvent(w, f, "EXISTING", status, output, tmpl); err != nil {
+				return "", err
+			}
+		}
+		return functions.ResourceVersion, nil
+	}
+
+	// Changes made after the list are received from its resource version
+	resourceVersion := sinceResourceVersion
+	if resourceVersion == "" {
+		var err error
+		if resourceVersion, err = listFunctions(); err != nil {
+			return "", err
+		}
+	}
+	// watchFromList lists the functions again when the changes since resourceVersion are no
+	// longer available in the server, and watches them from that list
+	watchFromList := func() (watch.Interface, error) {
+		logrus.Warnf("The resource version %s is too old, listing the functions again", resourceVersion)
+		listVersion, err := listFunctions()
+		if err != nil {
+			return nil, err
+		}
+		resourceVersion = listVersion
+		return kubelessClient.KubelessV1beta1().Functions(ns).Watch(metav1.ListOptions{ResourceVersion: resourceVersion})
+	}
+	startFunctionWatch := func() (watch.Interface, error) {
+		functionWatch, err := kubelessClient.KubelessV1beta1().Functions(ns).Watch(metav1.ListOptions{ResourceVersion: resourceVersion})
+		if err != nil && isResourceVersionTooOld(err) {
+			return watchFromList()
+		}
+		return functionWatch, err
+	}
+	functionWatch, err := startFunctionWatch()
+	if err != nil {
+		return resourceVersion, err
+	}
+	defer func() { functionWatch.Stop() }()
+	deploymentWatch, err := cli.AppsV1().Deployments(ns).Watch(metav1.ListOptions{LabelSelector: "created-by=kubeless"})
+	if err != nil {
+		return resourceVersion, err
+	}
+	defer func() { deploymentWatch.Stop() }()
+
+	for {
+		select {
+		case <-stop:
+			return resourceVersion, nil
+		case event, ok := <-functionWatch.ResultChan():
+			if !ok {
+				// The server closes watches after a while, resume from the last change received
+				newWatch, err := startFunctionWatch()
+				if err != nil {
+					return resourceVersion, err
+				}
+				functionWatch = newWatch
+				continue
+			}
+			if event.Type == watch.Error {
+				if err := k8sErrors.FromObject(event.Object); !isResourceVersionTooOld(err) {
+					return resourceVersion, err
+				}
+				functionWatch.Stop()
+				newWatch, err := watchFromList()
+				if err != nil {
+					return resourceVersion, err
+				}
+				functionWatch = newWatch
+				continue
+			}
+			f, isFunction := event.Object.(*kubelessApi.Function)
+			if !isFunction {
+				continue
+			}
+			resourceVersion = f.ResourceVersion
+			status := "DELETED"
+			if event.Type == watch.Deleted {
+				delete(statuses, f.Name)
+			} else {
+				status, err = getFunctionStatus(cli, f)
+				if err != nil {
+					return resourceVersion, err
+				}
+				statuses[f.Name] = status
+			}
+			if err := printFunctionEvent(w, f, string(event.Type), status, output, tmpl); err != nil {
+				return resourceVersion, err
+			}
+		case event, ok := <-deploymentWatch.ResultChan():
+			if !ok {
+				// Changes already printed are skipped since their status is the same
+				deploymentWatch, err = cli.AppsV1().Deployments(ns).Watch(metav1.ListOptions{LabelSelector: "created-by=kubeless"})
+				if err != nil {
+					return resourceVersion, err
+				}
+				continue
+			}
+			dpm, isDeployment := event.Object.(*appsv1.Deployment)
+			if !isDeployment || event.Type == watch.Deleted {
+				continue
+			}
+			// Only the changes of the status of the function are printed
+			status := formatDeploymentStatus(dpm)
+			if statuses[dpm.Name] == status {
+				continue
+			}
+			f, err := kubelessClient.KubelessV1beta1().Functions(dpm.Namespace).Get(dpm.Name, metav1.GetOptions{})
+			if err != nil {
+				if k8sErrors.IsNotFound(err) {
+					continue
+				}
+				return resourceVersion, err
+			}
+			statuses[f.Name] = status
+			if err := printFunctionEvent(w, f, string(watch.Modified), status, output, tmpl); err != nil {
+				return resourceVersion, err
+			}
+		}
+	}
+}
+
+func getFunctionStatus(cli kubernetes.Interface, f *kubelessApi.Function) (string, error) {
+	status, err := getDeploymentStatus(cli, f.Name, f.Namespace)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		return "MISSING: Check controller logs", nil
+	}
+	return status, err
+}
+
+func printFunctionEvent(w io.Writer, f *kubelessApi.Function, event, status, output string, tmpl *template.Template) error {
+	if output == "" {
+		_, err := fmt.Fprintf(w, watchRowFormat, event, f.Name, f.Namespace, f.Spec.Runtime, status)
+		return err
+	}
+	// Each change is a separate document so the output can be processed as a stream
+	return utils.PrintObjects(w, output, tmpl, f)
+}
@@ -0,0 +1,217 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	fFake "github.com/kubeless/kubeless/pkg/client/clientset/versioned/fake"
+)
+
+func TestWatchFunctions(t *testing.T) {
+	f := &kubelessApi.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns"},
+		Spec:       kubelessApi.FunctionSpec{Runtime: "python3.7"},
+	}
+	dpm := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns", Labels: map[string]string{"created-by": "kubeless"}},
+		Status:     appsv1.DeploymentStatus{Replicas: 1},
+	}
+	kubelessClient := fFake.NewSimpleClientset(f)
+	functionWatch := watch.NewFake()
+	kubelessClient.PrependWatchReactor("functions", ktesting.DefaultWatchReactor(functionWatch, nil))
+	cli := fake.NewSimpleClientset(dpm)
+	deploymentWatch := watch.NewFake()
+	cli.PrependWatchReactor("deployments", ktesting.DefaultWatchReactor(deploymentWatch, nil))
+
+	var buf bytes.Buffer
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, err := watchFunctions(&buf, kubelessClient, cli, "myns", "", nil, "", stop)
+		done <- err
+	}()
+
+	// The same status is printed only once
+	ready := dpm.DeepCopy()
+	ready.Status.ReadyReplicas = 1
+	deploymentWatch.Modify(ready)
+	deploymentWatch.Modify(ready)
+	functionWatch.Delete(f)
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	expected := [][]string{
+		{"EVENT", "NAME", "NAMESPACE", "RUNTIME", "STATUS"},
+		{"EXISTING", "foo", "myns", "python3.7", "0/1", "NOT", "READY"},
+		{"MODIFIED", "foo", "myns", "python3.7", "1/1", "READY"},
+		{"DELETED", "foo", "myns", "python3.7", "DELETED"},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expecting %d lines, got:\n%s", len(expected), buf.String())
+	}
+	for i := range expected {
+		if strings.Join(strings.Fields(lines[i]), " ") != strings.Join(expected[i], " ") {
+			t.Errorf("Expecting line %d to be %v, got %q", i, expected[i], lines[i])
+		}
+	}
+	if !functionWatch.IsStopped() || !deploymentWatch.IsStopped() {
+		t.Error("Expecting the watchers to be stopped")
+	}
+}
+
+func TestWatchFunctionsSinceResourceVersion(t *testing.T) {
+	f := &kubelessApi.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns", ResourceVersion: "10"},
+		Spec:       kubelessApi.FunctionSpec{Runtime: "python3.7"},
+	}
+	kubelessClient := fFake.NewSimpleClientset(f)
+	functionWatch := watch.NewFake()
+	watchVersion := ""
+	kubelessClient.PrependWatchReactor("functions", func(action ktesting.Action) (bool, watch.Interface, error) {
+		watchVersion = action.(ktesting.WatchAction).GetWatchRestrictions().ResourceVersion
+		return true, functionWatch, nil
+	})
+	cli := fake.NewSimpleClientset()
+	cli.PrependWatchReactor("deployments", ktesting.DefaultWatchReactor(watch.NewFake(), nil))
+
+	var buf bytes.Buffer
+	stop := make(chan struct{})
+	type result struct {
+		resourceVersion string
+		err             error
+	}
+	done := make(chan result)
+	go func() {
+		resourceVersion, err := watchFunctions(&buf, kubelessClient, cli, "myns", "", nil, "10", stop)
+		done <- result{resourceVersion, err}
+	}()
+
+	modified := f.DeepCopy()
+	modified.ResourceVersion = "12"
+	functionWatch.Modify(modified)
+	close(stop)
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("Unexpected error: %v", res.err)
+	}
+	if watchVersion != "10" {
+		t.Errorf("Expecting the watch to start from the resource version 10, got %q", watchVersion)
+	}
+	if res.resourceVersion != "12" {
+		t.Errorf("Expecting the last observed resource version 12, got %q", res.resourceVersion)
+	}
+	// The existing functions are not listed
+	if strings.Contains(buf.String(), "EXISTING") || !strings.Contains(buf.String(), "MODIFIED") {
+		t.Errorf("Expecting only the changes after the resource version, got:\n%s", buf.String())
+	}
+}
+
+func TestWatchFunctionsTooOld(t *testing.T) {
+	f := &kubelessApi.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns"},
+		Spec:       kubelessApi.FunctionSpec{Runtime: "python3.7"},
+	}
+	tests := []struct {
+		name string
+		// tooOld returns the result of the first watch, which starts from a resource version too old
+		tooOld func(w *watch.FakeWatcher) (watch.Interface, error)
+	}{
+		{
+			name: "request error",
+			tooOld: func(w *watch.FakeWatcher) (watch.Interface, error) {
+				return nil, k8sErrors.NewGone("too old resource version: 1 (5)")
+			},
+		},
+		{
+			name: "error event",
+			tooOld: func(w *watch.FakeWatcher) (watch.Interface, error) {
+				go w.Error(&metav1.Status{Status: metav1.StatusFailure, Code: 410, Reason: metav1.StatusReasonExpired, Message: "too old resource version: 1 (5)"})
+				return w, nil
+			},
+		},
+	}
+	for _, test := range tests {
+		kubelessClient := fFake.NewSimpleClientset(f)
+		firstWatch := watch.NewFake()
+		functionWatch := watch.NewFake()
+		calls := 0
+		kubelessClient.PrependWatchReactor("functions", func(action ktesting.Action) (bool, watch.Interface, error) {
+			calls++
+			if calls == 1 {
+				w, err := test.tooOld(firstWatch)
+				return true, w, err
+			}
+			return true, functionWatch, nil
+		})
+		cli := fake.NewSimpleClientset()
+		cli.PrependWatchReactor("deployments", ktesting.DefaultWatchReactor(watch.NewFake(), nil))
+
+		var buf bytes.Buffer
+		stop := make(chan struct{})
+		done := make(chan error)
+		go func() {
+			_, err := watchFunctions(&buf, kubelessClient, cli, "myns", "", nil, "1", stop)
+			done <- err
+		}()
+
+		// The functions are listed again before watching the next changes
+		functionWatch.Delete(f)
+		close(stop)
+		if err := <-done; err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if !strings.Contains(buf.String(), "EXISTING") || !strings.Contains(buf.String(), "DELETED") {
+			t.Errorf("%s: expecting the functions to be listed again, got:\n%s", test.name, buf.String())
+		}
+		if test.name == "error event" && !firstWatch.IsStopped() {
+			t.Errorf("%s: expecting the first watch to be stopped", test.name)
+		}
+	}
+}
+
+func TestGetWatchStop(t *testing.T) {
+	stop, release := getWatchStop(10 * time.Millisecond)
+	defer release()
+	select {
+	case <-stop:
+	case <-time.After(time.Second):
+		t.Fatal("Expecting the watch to stop after the timeout")
+	}
+
+	stop, release = getWatchStop(0)
+	release()
+	select {
+	case <-stop:
+		t.Fatal("Expecting the watch not to stop without a timeout")
+	default:
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getserverconfig
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gosuri/uitable"
+	"github.com/kubeless/kubeless/pkg/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	clientsetAPIExtensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	controllerDeployment = "kubeless-controller-manager"
+	checkPass            = "PASS"
+	checkFail            = "FAIL"
+)
+
+// triggerController describes a trigger controller. It can run in its own deployment
+// or as a container of the kubeless controller manager.
+type triggerController struct {
+	name string
+	crd  string
+}
+
+var triggerControllers = []triggerController{
+	{"cronjob-trigger-controller", "cronjobtriggers.kubeless.io"},
+	{"http-trigger-controller", "httptriggers.kubeless.io"},
+	{"kafka-trigger-controller", "kafkatriggers.kubeless.io"},
+	{"nats-trigger-controller", "natstriggers.kubeless.io"},
+}
+
+type checkResult struct {
+	Component string
+	Status    string
+	Message   string
+}
+
+func pass(component, format string, args ...interface{}) checkResult {
+	return checkResult{component, checkPass, fmt.Sprintf(format, args...)}
+}
+
+func fail(component, format string, args ...interface{}) checkResult {
+	return checkResult{component, checkFail, fmt.Sprintf(format, args...)}
+}
+
+// runChecks verifies the CRDs and controllers of Kubeless. Trigger controllers
+// are only checked if their CRD is installed.
+func runChecks(cli kubernetes.Interface, apiExtensions clientsetAPIExtensions.Interface) []checkResult {
+	results := []checkResult{checkCRD(apiExtensions, "functions.kubeless.io", true)}
+
+	ns, err := utils.GetControllerNamespace(apiExtensions)
+	if err != nil {
+		return append(results, fail("controller", "unable to find the controller namespace: %v", err))
+	}
+
+	if _, err := utils.GetKubelessConfig(cli, apiExtensions); err != nil {
+		results = append(results, fail("config", "%v", err))
+	} else {
+		results = append(results, pass("config", "configmap found in namespace %s", ns))
+	}
+
+	manager, err := cli.AppsV1().Deployments(ns).Get(controllerDeployment, metav1.GetOptions{})
+	if err != nil {
+		results = append(results, fail(controllerDeployment, "%v", err))
+		manager = nil
+	} else {
+		results = append(results, checkDeployment(manager))
+	}
+
+	for _, c := range triggerControllers {
+		crd := checkCRD(apiExtensions, c.crd, false)
+		if crd.Status == "" {
+			continue
+		}
+		results = append(results, crd)
+		results = append(results, checkTriggerController(cli, ns, c.name, manager))
+	}
+	return results
+}
+
+// checkCRD verifies that the CRD is established. A missing optional CRD returns an empty result.
+func checkCRD(apiExtensions clientsetAPIExtensions.Interface, name string, required bool) checkResult {
+	crd, err := apiExtensions.ApiextensionsV1beta1().CustomResourceDefinitions().Get(name, metav1.GetOptions{})
+	if err != nil {
+		if k8sErrors.IsNotFound(err) && !required {
+			return checkResult{}
+		}
+		return fail(name, "%v", err)
+	}
+	for _, c := range crd.Status.Conditions {
+		if c.Type == apiextensionsv1beta1.Established && c.Status == apiextensionsv1beta1.ConditionTrue {
+			return pass(name, "established")
+		}
+	}
+	return fail(name, "not established")
+}
+
+// checkDeployment verifies that the deployment is available
+func checkDeployment(dpm *appsv1.Deployment) checkResult {
+	for _, c := range dpm.Status.Conditions {
+		if c.Type == appsv1.DeploymentAvailable {
+			if c.Status == v1.ConditionTrue {
+				return pass(dpm.Name, "%d/%d replicas available", dpm.Status.AvailableReplicas, dpm.Status.Replicas)
+			}
+			return fail(dpm.Name, "not available: %s", c.Message)
+		}
+	}
+	if dpm.Status.AvailableReplicas > 0 {
+		return pass(dpm.Name, "%d/%d replicas available", dpm.Status.AvailableReplicas, dpm.Status.Replicas)
+	}
+	return fail(dpm.Name, "no replicas available")
+}
+
+// checkTriggerController verifies that the trigger controller is running, either in
+// its own deployment or as a ready container in the pods of the controller manager
+func checkTriggerController(cli kubernetes.Interface, ns, name string, manager *appsv1.Deployment) checkResult {
+	dpm, err := cli.AppsV1().Deployments(ns).Get(name, metav1.GetOptions{})
+	if err == nil {
+		return checkDeployment(dpm)
+	}
+	if !k8sErrors.IsNotFound(err) {
+		return fail(name, "%v", err)
+	}
+	if manager == nil || !hasContainer(manager.Spec.Template.Spec.Containers, name) {
+		return fail(name, "deployment not found in namespace %s", ns)
+	}
+	selector := labels.SelectorFromSet(manager.Spec.Selector.MatchLabels)
+	pods, err := cli.CoreV1().Pods(ns).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return fail(name, "%v", err)
+	}
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name == name && status.Ready {
+				return pass(name, "running in %s", pod.Name)
+			}
+		}
+	}
+	return fail(name, "the container is not ready in any pod of %s", manager.Name)
+}
+
+func hasContainer(containers []v1.Container, name string) bool {
+	for _, c := range containers {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// printChecks prints the results and returns the number of failed checks
+func printChecks(w io.Writer, results []checkResult) int {
+	failed := 0
+	table := uitable.New()
+	table.MaxColWidth = 80
+	table.Wrap = true
+	table.AddRow("COMPONENT", "STATUS", "MESSAGE")
+	for _, r := range results {
+		if r.Status == checkFail {
+			failed++
+		}
+		table.AddRow(r.Component, r.Status, r.Message)
+	}
+	fmt.Fprintln(w, table)
+	return failed
+}
@@ -0,0 +1,106 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getserverconfig
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	fakeextensionsapi "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func crd(name string) *apiextensionsv1beta1.CustomResourceDefinition {
+	return &apiextensionsv1beta1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: apiextensionsv1beta1.CustomResourceDefinitionStatus{
+			Conditions: []apiextensionsv1beta1.CustomResourceDefinitionCondition{
+				{Type: apiextensionsv1beta1.Established, Status: apiextensionsv1beta1.ConditionTrue},
+			},
+		},
+	}
+}
+
+func TestRunChecks(t *testing.T) {
+	labels := map[string]string{"kubeless": "controller"}
+	manager := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubeless-controller-manager", Namespace: "kubeless"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Name: "kubeless-function-controller"}, {Name: "cronjob-trigger-controller"}},
+				},
+			},
+		},
+		Status: appsv1.DeploymentStatus{
+			Replicas:          1,
+			AvailableReplicas: 1,
+			Conditions:        []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: v1.ConditionTrue}},
+		},
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubeless-controller-manager-1", Namespace: "kubeless", Labels: labels},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{{Name: "cronjob-trigger-controller", Ready: true}},
+		},
+	}
+	config := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "kubeless-config", Namespace: "kubeless"}}
+	cli := fake.NewSimpleClientset(manager, pod, config)
+	apiExtensions := fakeextensionsapi.NewSimpleClientset(
+		crd("functions.kubeless.io"),
+		crd("cronjobtriggers.kubeless.io"),
+		// Installed but without a controller
+		crd("kafkatriggers.kubeless.io"),
+	)
+
+	results := runChecks(cli, apiExtensions)
+	status := map[string]string{}
+	for _, r := range results {
+		status[r.Component] = r.Status
+	}
+	expected := map[string]string{
+		"functions.kubeless.io":       checkPass,
+		"config":                      checkPass,
+		"kubeless-controller-manager": checkPass,
+		"cronjobtriggers.kubeless.io": checkPass,
+		"cronjob-trigger-controller":  checkPass,
+		"kafkatriggers.kubeless.io":   checkPass,
+		"kafka-trigger-controller":    checkFail,
+	}
+	for component, s := range expected {
+		if status[component] != s {
+			t.Errorf("Expecting %s to be %s, got %q", component, s, status[component])
+		}
+	}
+	if _, ok := status["http-trigger-controller"]; ok {
+		t.Error("The HTTP trigger controller should not be checked when its CRD is not installed")
+	}
+
+	var out bytes.Buffer
+	if failed := printChecks(&out, results); failed != 1 {
+		t.Errorf("Expecting 1 failed check, got %d", failed)
+	}
+	if !strings.Contains(out.String(), "COMPONENT") {
+		t.Errorf("Unexpected output %s", out.String())
+	}
+}
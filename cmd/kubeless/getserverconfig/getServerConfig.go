@@ -17,6 +17,18 @@ var GetServerConfigCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		cli := utils.GetClientOutOfCluster()
 		apiExtensionsClientset := utils.GetAPIExtensionsClientOutOfCluster()
+
+		check, err := cmd.Flags().GetBool("check")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if check {
+			if failed := printChecks(cmd.OutOrStdout(), runChecks(cli, apiExtensionsClientset)); failed > 0 {
+				logrus.Fatalf("%d checks failed", failed)
+			}
+			return
+		}
+
 		config, err := utils.GetKubelessConfig(cli, apiExtensionsClientset)
 		if err != nil {
 			logrus.Fatalf("Unable to read the configmap: %v", err)
@@ -30,3 +42,7 @@ var GetServerConfigCmd = &cobra.Command{
 			strings.Join(lr.GetRuntimes(), ", "))
 	},
 }
+
+func init() {
+	GetServerConfigCmd.Flags().Bool("check", false, "Verify that the controllers and CRDs of Kubeless are healthy instead of printing the config")
+}
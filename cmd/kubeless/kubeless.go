@@ -22,11 +22,16 @@ import (
 
 	"github.com/kubeless/kubeless/cmd/kubeless/autoscale"
 	"github.com/kubeless/kubeless/cmd/kubeless/completion"
+	"github.com/kubeless/kubeless/cmd/kubeless/config"
 	"github.com/kubeless/kubeless/cmd/kubeless/function"
 	"github.com/kubeless/kubeless/cmd/kubeless/getserverconfig"
+	"github.com/kubeless/kubeless/cmd/kubeless/lint"
 	"github.com/kubeless/kubeless/cmd/kubeless/topic"
 	"github.com/kubeless/kubeless/cmd/kubeless/trigger"
+	"github.com/kubeless/kubeless/cmd/kubeless/validate"
 	"github.com/kubeless/kubeless/cmd/kubeless/version"
+	"github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -37,15 +42,134 @@ func newRootCmd() *cobra.Command {
 		Use:   "kubeless",
 		Short: "Serverless framework for Kubernetes",
 		Long:  globalUsage,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			// Flags not given in the command line default to their KUBELESS_<FLAG> env var
+			// and then to the values stored with 'kubeless config set'
+			if err := utils.ApplyEnvDefaults(cmd.Flags()); err != nil {
+				logrus.Fatal(err)
+			}
+			logFormat, err := cmd.Flags().GetString("log-format")
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			if err := utils.SetLogFormat(logFormat); err != nil {
+				utils.FatalWithCode(utils.ExitUsage, err)
+			}
+			cliConfig, err := utils.ReadCLIConfig()
+			if err != nil {
+				logrus.Warnf("Ignoring the CLI config: %v", err)
+			}
+			if err := utils.ApplyConfigDefaults(cmd.Flags(), cliConfig); err != nil {
+				logrus.Fatal(err)
+			}
+			pretty, err := cmd.Flags().GetBool("pretty")
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			utils.SetPrettyJSON(pretty)
+			noCache, err := cmd.Flags().GetBool("no-cache")
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			utils.SetCacheEnabled(!noCache)
+			quiet, err := cmd.Flags().GetBool("quiet")
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			utils.SetProgressEnabled(!quiet)
+			insecure, err := cmd.Flags().GetBool("insecure-skip-tls-verify")
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			caFile, err := cmd.Flags().GetString("certificate-authority")
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			requestTimeout, err := cmd.Flags().GetDuration("request-timeout")
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			if requestTimeout < 0 {
+				logrus.Fatalf("Invalid --request-timeout %v", requestTimeout)
+			}
+			utils.SetRequestTimeout(requestTimeout)
+			if err := utils.SetTLSOverrides(insecure, caFile); err != nil {
+				logrus.Fatal(err)
+			}
+			if insecure {
+				logrus.Warn("TLS verification is disabled: the certificate of the cluster won't be checked. " +
+					"Your connection is vulnerable to man-in-the-middle attacks, don't use --insecure-skip-tls-verify outside of test clusters")
+			}
+			if logFormat == utils.JSONLogFormat {
+				setLogContext(cmd, args)
+			}
+			auditLog, err := cmd.Flags().GetString("audit-log")
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			if auditLog != "" && utils.IsAuditedCommand(cmd.CommandPath(), cmd.Flags()) {
+				startAudit(cmd, args, auditLog)
+			}
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			if err := utils.FinishAudit(nil); err != nil {
+				logrus.Fatalf("Unable to write the audit log: %v", err)
+			}
+		},
 	}
+	cmd.PersistentFlags().String("audit-log", "", "Append a JSON record of every command that modifies the cluster to the given file")
+	cmd.PersistentFlags().String("certificate-authority", "", "Path to a cert file for the certificate authority of the cluster, instead of the one of the kubeconfig")
+	cmd.PersistentFlags().Bool("insecure-skip-tls-verify", false, "Don't check the certificate of the cluster. This makes the connection insecure")
+	cmd.PersistentFlags().Bool("no-cache", false, "Read the server config from the cluster instead of the local cache")
+	cmd.PersistentFlags().BoolP("quiet", "q", false, "Don't show the progress of long-running operations")
+	cmd.PersistentFlags().Duration("request-timeout", 0, "Maximum time of each request to the cluster (e.g. 30s). Zero means no timeout")
+	cmd.PersistentFlags().String("log-format", utils.TextLogFormat, "Format of the logs of the CLI. One of: text, json")
+	cmd.PersistentFlags().Bool("pretty", utils.PrettyJSONDefault(), "Indent the json output. Enabled by default when the output is a terminal")
 
-	cmd.AddCommand(function.FunctionCmd, topic.TopicCmd, version.VersionCmd, autoscale.AutoscaleCmd, getserverconfig.GetServerConfigCmd, trigger.TriggerCmd, completion.CompletionCmd)
+	// Executables named kubeless-trigger-<name> in the PATH add custom trigger types
+	trigger.AddTriggerPlugins(os.Getenv("PATH"))
+	cmd.AddCommand(function.FunctionCmd, topic.TopicCmd, version.VersionCmd, autoscale.AutoscaleCmd, getserverconfig.GetServerConfigCmd, trigger.TriggerCmd, completion.CompletionCmd, config.ConfigCmd, lint.LintCmd, validate.ValidateCmd)
 	return cmd
 }
 
+// startAudit starts the audit record of a command. Dry runs don't modify the cluster so they are not recorded.
+func startAudit(cmd *cobra.Command, args []string, path string) {
+	if dryrun, err := cmd.Flags().GetBool("dryrun"); err == nil && dryrun {
+		return
+	}
+	ns, _ := cmd.Flags().GetString("namespace")
+	if ns == "" {
+		ns = utils.GetDefaultNamespace()
+	}
+	name := ""
+	if len(args) > 0 {
+		name = args[0]
+	}
+	utils.StartAudit(path, cmd.CommandPath(), ns, name)
+}
+
+// setLogContext adds the namespace of the command, if it has one, and the object given
+// as first argument to the JSON logs
+func setLogContext(cmd *cobra.Command, args []string) {
+	ns := ""
+	if cmd.Flags().Lookup("namespace") != nil {
+		ns, _ = cmd.Flags().GetString("namespace")
+		if ns == "" {
+			ns = utils.GetDefaultNamespace()
+		}
+	}
+	name := ""
+	if len(args) > 0 {
+		name = args[0]
+	}
+	utils.SetLogContext(ns, name)
+}
+
 func main() {
 	cmd := newRootCmd()
 	if err := cmd.Execute(); err != nil {
-		os.Exit(1)
+		utils.FinishAudit(err)
+		// Errors returned by cobra are wrong commands, flags or arguments
+		os.Exit(utils.ExitUsage)
 	}
 }
@@ -0,0 +1,373 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/gosuri/uitable"
+	"github.com/robfig/cron"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	httpApi "github.com/kubeless/http-trigger/pkg/apis/kubeless/v1beta1"
+	kafkaApi "github.com/kubeless/kafka-trigger/pkg/apis/kubeless/v1beta1"
+	kinesisApi "github.com/kubeless/kinesis-trigger/pkg/apis/kubeless/v1beta1"
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	"github.com/kubeless/kubeless/pkg/client/clientset/versioned"
+	"github.com/kubeless/kubeless/pkg/langruntime"
+	"github.com/kubeless/kubeless/pkg/utils"
+	natsApi "github.com/kubeless/nats-trigger/pkg/apis/kubeless/v1beta1"
+)
+
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+// LintCmd validates Kubeless manifests
+var LintCmd = &cobra.Command{
+	Use:   "lint FLAG",
+	Short: "validate function and trigger manifests",
+	Long:  `validate Kubeless Function and Trigger manifests before applying them`,
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := cmd.Flags().GetString("file")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			logrus.Fatalf("Unable to read %s: %v", file, err)
+		}
+
+		ns, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		offline, err := cmd.Flags().GetBool("offline")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		l := &linter{}
+		if !offline {
+			if ns == "" {
+				ns = utils.GetDefaultNamespace()
+			}
+			cli := utils.GetClientOutOfCluster()
+			apiExtensionsClientset := utils.GetAPIExtensionsClientOutOfCluster()
+			config, err := utils.GetCachedKubelessConfig(cli, apiExtensionsClientset)
+			if config == nil || err != nil {
+				logrus.Warnf("%v. Runtime check is disabled.", err)
+			} else {
+				l.runtimes = langruntime.New(config)
+				l.runtimes.ReadConfigMap()
+			}
+			l.kubelessClient, err = utils.GetKubelessClientOutCluster()
+			if err != nil {
+				logrus.Fatalf("Can not create out-of-cluster client: %v", err)
+			}
+		}
+		l.namespace = ns
+
+		findings, err := l.lint(content)
+		if err != nil {
+			logrus.Fatalf("Unable to parse %s: %v", file, err)
+		}
+		printFindings(cmd.OutOrStdout(), findings)
+
+		errors := 0
+		for _, f := range findings {
+			if f.Severity == severityError {
+				errors++
+			}
+		}
+		if errors > 0 {
+			logrus.Fatalf("Found %d errors in %s", errors, file)
+		}
+	},
+}
+
+func init() {
+	LintCmd.Flags().StringP("file", "f", "", "Manifest to validate. It may contain several YAML documents")
+	LintCmd.MarkFlagRequired("file")
+	LintCmd.Flags().StringP("namespace", "n", "", "Namespace used for the resources that don't specify one")
+	LintCmd.Flags().Bool("offline", false, "Skip the checks that require access to the cluster (runtimes and referenced functions)")
+}
+
+type finding struct {
+	Severity string
+	Resource string
+	Message  string
+}
+
+// linter validates manifests. The checks against the cluster are skipped
+// if the runtimes or the kubeless client are not available.
+type linter struct {
+	runtimes       *langruntime.Langruntimes
+	kubelessClient versioned.Interface
+	namespace      string
+
+	findings []finding
+	// functions declared in the manifest, indexed by namespace/name
+	functions map[string]*kubelessApi.Function
+}
+
+func (l *linter) add(severity, resource, format string, a ...interface{}) {
+	l.findings = append(l.findings, finding{Severity: severity, Resource: resource, Message: fmt.Sprintf(format, a...)})
+}
+
+func (l *linter) lint(content []byte) ([]finding, error) {
+	l.findings = []finding{}
+	l.functions = map[string]*kubelessApi.Function{}
+
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(content)))
+	docs := [][]byte{}
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) > 0 {
+			docs = append(docs, doc)
+		}
+	}
+
+	// Functions are parsed first so triggers can reference them regardless of their order
+	triggers := []int{}
+	for i, doc := range docs {
+		typeMeta := metav1.TypeMeta{}
+		if err := yaml.Unmarshal(doc, &typeMeta); err != nil {
+			return nil, fmt.Errorf("document %d: %v", i+1, err)
+		}
+		if typeMeta.Kind == "Function" {
+			if err := l.lintFunction(doc); err != nil {
+				return nil, fmt.Errorf("document %d: %v", i+1, err)
+			}
+		} else {
+			triggers = append(triggers, i)
+		}
+	}
+	for _, i := range triggers {
+		if err := l.lintTrigger(docs[i]); err != nil {
+			return nil, fmt.Errorf("document %d: %v", i+1, err)
+		}
+	}
+	return l.findings, nil
+}
+
+func (l *linter) lintMetadata(kind string, meta *metav1.ObjectMeta) string {
+	if meta.Namespace == "" {
+		meta.Namespace = l.namespace
+	}
+	resource := kind + "/" + meta.Name
+	if meta.Name == "" {
+		l.add(severityError, resource, "metadata.name is required")
+	} else if errs := validation.IsDNS1123Subdomain(meta.Name); len(errs) > 0 {
+		l.add(severityError, resource, "invalid name: %s", strings.Join(errs, "; "))
+	}
+	for k, v := range meta.Labels {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			l.add(severityError, resource, "invalid label key %q: %s", k, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+			l.add(severityError, resource, "invalid value for label %q: %s", k, strings.Join(errs, "; "))
+		}
+	}
+	return resource
+}
+
+func (l *linter) lintFunction(doc []byte) error {
+	f := &kubelessApi.Function{}
+	if err := yaml.Unmarshal(doc, f); err != nil {
+		return err
+	}
+	resource := l.lintMetadata("Function", &f.ObjectMeta)
+	l.functions[f.Namespace+"/"+f.Name] = f
+
+	customImage := len(f.Spec.Deployment.Spec.Template.Spec.Containers) > 0 && f.Spec.Deployment.Spec.Template.Spec.Containers[0].Image != ""
+	if f.Spec.Runtime == "" {
+		if !customImage {
+			l.add(severityError, resource, "spec.runtime is required unless a custom image is used")
+		}
+		return nil
+	}
+	if l.runtimes != nil && !l.runtimes.IsValidRuntime(f.Spec.Runtime) {
+		l.add(severityError, resource, "invalid runtime %s. Supported runtimes are: %s", f.Spec.Runtime, strings.Join(l.runtimes.GetRuntimes(), ", "))
+	}
+	if f.Spec.Handler == "" {
+		l.add(severityError, resource, "spec.handler is required")
+	} else if !strings.Contains(f.Spec.Handler, ".") {
+		l.add(severityError, resource, "spec.handler should have the format <file>.<function>")
+	}
+	if f.Spec.Function == "" {
+		l.add(severityError, resource, "spec.function is required")
+	}
+	if f.Spec.Timeout == "" {
+		l.add(severityWarning, resource, "spec.timeout is not set, the runtime default will be used")
+	}
+	return nil
+}
+
+func (l *linter) lintTrigger(doc []byte) error {
+	typeMeta := metav1.TypeMeta{}
+	if err := yaml.Unmarshal(doc, &typeMeta); err != nil {
+		return err
+	}
+	switch typeMeta.Kind {
+	case "CronJobTrigger":
+		t := &cronjobApi.CronJobTrigger{}
+		if err := yaml.Unmarshal(doc, t); err != nil {
+			return err
+		}
+		resource := l.lintMetadata(typeMeta.Kind, &t.ObjectMeta)
+		if t.Spec.Schedule == "" {
+			l.add(severityError, resource, "spec.schedule is required")
+		} else if _, err := cron.ParseStandard(t.Spec.Schedule); err != nil {
+			l.add(severityError, resource, "invalid schedule %q: %v", t.Spec.Schedule, err)
+		}
+		l.lintFunctionName(resource, t.Namespace, t.Spec.FunctionName)
+	case "HTTPTrigger":
+		t := &httpApi.HTTPTrigger{}
+		if err := yaml.Unmarshal(doc, t); err != nil {
+			return err
+		}
+		resource := l.lintMetadata(typeMeta.Kind, &t.ObjectMeta)
+		if t.Spec.TLSAcme && t.Spec.TLSSecret != "" {
+			l.add(severityError, resource, "spec.tls and spec.tls-secret can't be used together")
+		}
+		if t.Spec.Gateway != "" && t.Spec.Gateway != "nginx" && t.Spec.Gateway != "traefik" && t.Spec.Gateway != "kong" {
+			l.add(severityError, resource, "unsupported gateway %s", t.Spec.Gateway)
+		}
+		l.lintFunctionName(resource, t.Namespace, t.Spec.FunctionName)
+	case "KinesisTrigger":
+		t := &kinesisApi.KinesisTrigger{}
+		if err := yaml.Unmarshal(doc, t); err != nil {
+			return err
+		}
+		resource := l.lintMetadata(typeMeta.Kind, &t.ObjectMeta)
+		if t.Spec.Stream == "" {
+			l.add(severityError, resource, "spec.stream is required")
+		}
+		if t.Spec.Region == "" {
+			l.add(severityError, resource, "spec.aws-region is required")
+		}
+		if t.Spec.Secret == "" {
+			l.add(severityError, resource, "spec.secret is required")
+		}
+		l.lintFunctionName(resource, t.Namespace, t.Spec.FunctionName)
+	case "KafkaTrigger":
+		t := &kafkaApi.KafkaTrigger{}
+		if err := yaml.Unmarshal(doc, t); err != nil {
+			return err
+		}
+		resource := l.lintMetadata(typeMeta.Kind, &t.ObjectMeta)
+		l.lintTopicTrigger(resource, t.Namespace, t.Spec.Topic, t.Spec.FunctionSelector)
+	case "NATSTrigger":
+		t := &natsApi.NATSTrigger{}
+		if err := yaml.Unmarshal(doc, t); err != nil {
+			return err
+		}
+		resource := l.lintMetadata(typeMeta.Kind, &t.ObjectMeta)
+		l.lintTopicTrigger(resource, t.Namespace, t.Spec.Topic, t.Spec.FunctionSelector)
+	case "":
+		l.add(severityError, "unknown", "kind is required")
+	default:
+		l.add(severityWarning, typeMeta.Kind, "kind %s is not a Kubeless resource, skipping it", typeMeta.Kind)
+	}
+	return nil
+}
+
+// lintFunctionName checks that the function referenced by a trigger exists,
+// either in the manifest or in the cluster
+func (l *linter) lintFunctionName(resource, ns, name string) {
+	if name == "" {
+		l.add(severityError, resource, "spec.function-name is required")
+		return
+	}
+	if _, ok := l.functions[ns+"/"+name]; ok || l.kubelessClient == nil {
+		return
+	}
+	_, err := l.kubelessClient.KubelessV1beta1().Functions(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			l.add(severityError, resource, "function %s not found in namespace %s", name, ns)
+		} else {
+			l.add(severityWarning, resource, "unable to check if the function %s exists: %v", name, err)
+		}
+	}
+}
+
+func (l *linter) lintTopicTrigger(resource, ns, topic string, selector metav1.LabelSelector) {
+	if topic == "" {
+		l.add(severityError, resource, "spec.topic is required")
+	}
+	if len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0 {
+		l.add(severityError, resource, "spec.functionSelector is required")
+		return
+	}
+	s, err := metav1.LabelSelectorAsSelector(&selector)
+	if err != nil {
+		l.add(severityError, resource, "invalid spec.functionSelector: %v", err)
+		return
+	}
+	for _, f := range l.functions {
+		if f.Namespace == ns && s.Matches(labels.Set(f.Labels)) {
+			return
+		}
+	}
+	if l.kubelessClient == nil {
+		return
+	}
+	functions, err := l.kubelessClient.KubelessV1beta1().Functions(ns).List(metav1.ListOptions{LabelSelector: s.String()})
+	if err != nil {
+		l.add(severityWarning, resource, "unable to check the functions matching %s: %v", s.String(), err)
+	} else if len(functions.Items) == 0 {
+		l.add(severityWarning, resource, "no function matches %s in namespace %s", s.String(), ns)
+	}
+}
+
+func printFindings(w io.Writer, findings []finding) {
+	if len(findings) == 0 {
+		fmt.Fprintln(w, "No issues found")
+		return
+	}
+	table := uitable.New()
+	table.MaxColWidth = 80
+	table.Wrap = true
+	table.AddRow("SEVERITY", "RESOURCE", "MESSAGE")
+	for _, f := range findings {
+		table.AddRow(f.Severity, f.Resource, f.Message)
+	}
+	fmt.Fprintln(w, table)
+}
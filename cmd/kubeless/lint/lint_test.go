@@ -0,0 +1,170 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	fFake "github.com/kubeless/kubeless/pkg/client/clientset/versioned/fake"
+	"github.com/kubeless/kubeless/pkg/langruntime"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const manifest = `
+apiVersion: kubeless.io/v1beta1
+kind: CronJobTrigger
+metadata:
+  name: every-minute
+spec:
+  function-name: hello
+  schedule: "* * * * *"
+---
+apiVersion: kubeless.io/v1beta1
+kind: Function
+metadata:
+  name: hello
+  labels:
+    app: hello
+spec:
+  runtime: python2.7
+  handler: hello.foo
+  function: "def foo(event, context):\n  return 'hello'"
+  timeout: "180"
+---
+apiVersion: kubeless.io/v1beta1
+kind: HTTPTrigger
+metadata:
+  name: existing
+spec:
+  function-name: existing
+`
+
+func findingsOutput(findings []finding) string {
+	out := []string{}
+	for _, f := range findings {
+		out = append(out, f.Severity+" "+f.Resource+" "+f.Message)
+	}
+	return strings.Join(out, "\n")
+}
+
+func newTestLinter() *linter {
+	clientset := fake.NewSimpleClientset()
+	langruntime.AddFakeConfig(clientset)
+	lr := langruntime.SetupLangRuntime(clientset)
+	lr.ReadConfigMap()
+	existing := &kubelessApi.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "default"},
+	}
+	return &linter{
+		runtimes:       lr,
+		kubelessClient: fFake.NewSimpleClientset(existing),
+		namespace:      "default",
+	}
+}
+
+func TestLintValidManifest(t *testing.T) {
+	findings, err := newTestLinter().lint([]byte(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("Expecting no findings, got:\n%s", findingsOutput(findings))
+	}
+}
+
+func TestLintInvalidManifest(t *testing.T) {
+	invalid := `
+apiVersion: kubeless.io/v1beta1
+kind: Function
+metadata:
+  name: Hello_World
+  labels:
+    "bad label": foo
+spec:
+  runtime: cobol
+  handler: hello
+---
+apiVersion: kubeless.io/v1beta1
+kind: CronJobTrigger
+metadata:
+  name: every-minute
+spec:
+  function-name: missing
+  schedule: "every minute"
+---
+apiVersion: kubeless.io/v1beta1
+kind: NATSTrigger
+metadata:
+  name: topic
+spec:
+  topic: foo
+  functionSelector:
+    matchLabels:
+      app: nothing
+`
+	findings, err := newTestLinter().lint([]byte(invalid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := findingsOutput(findings)
+	expected := []string{
+		"error Function/Hello_World invalid name",
+		"error Function/Hello_World invalid label key \"bad label\"",
+		"error Function/Hello_World invalid runtime cobol",
+		"error Function/Hello_World spec.handler should have the format <file>.<function>",
+		"error Function/Hello_World spec.function is required",
+		"warning Function/Hello_World spec.timeout is not set",
+		"error CronJobTrigger/every-minute invalid schedule",
+		"error CronJobTrigger/every-minute function missing not found in namespace default",
+		"warning NATSTrigger/topic no function matches app=nothing",
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Errorf("Expecting finding %q, got:\n%s", e, out)
+		}
+	}
+}
+
+func TestLintOffline(t *testing.T) {
+	// Without access to the cluster runtimes and references are not checked
+	l := &linter{namespace: "default"}
+	findings, err := l.lint([]byte(`
+kind: Function
+metadata:
+  name: hello
+spec:
+  runtime: cobol
+  handler: hello.foo
+  function: foo
+  timeout: "180"
+---
+kind: HTTPTrigger
+metadata:
+  name: hello
+spec:
+  function-name: other
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("Expecting no findings, got:\n%s", findingsOutput(findings))
+	}
+}
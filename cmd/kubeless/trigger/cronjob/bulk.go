@@ -0,0 +1,79 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+)
+
+// bulkResult is the outcome of a bulk operation on a single trigger
+type bulkResult struct {
+	Name   string
+	Output string
+	Err    error
+}
+
+// runBulk runs the operation on every trigger using a pool of workers. Each operation
+// writes to its own buffer so the output of concurrent operations is never mixed.
+// The results are returned in the same order as the triggers.
+func runBulk(triggers []*cronjobApi.CronJobTrigger, concurrency int, op func(w io.Writer, trigger *cronjobApi.CronJobTrigger) error) []bulkResult {
+	results := make([]bulkResult, len(triggers))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				var out bytes.Buffer
+				err := op(&out, triggers[i])
+				results[i] = bulkResult{Name: triggers[i].Name, Output: out.String(), Err: err}
+			}
+		}()
+	}
+	for i := range triggers {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// printBulkSummary writes the output of each operation followed by the number of
+// operations that succeeded and failed. An error is returned if any of them failed.
+func printBulkSummary(w io.Writer, action string, results []bulkResult) error {
+	failed := []bulkResult{}
+	for _, r := range results {
+		fmt.Fprint(w, r.Output)
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	fmt.Fprintf(w, "\nSummary: %d succeeded, %d failed\n", len(results)-len(failed), len(failed))
+	for _, r := range failed {
+		fmt.Fprintf(w, "  %s: %v\n", r.Name, r.Err)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d cronjob triggers failed to be %s", len(failed), len(results), action)
+	}
+	return nil
+}
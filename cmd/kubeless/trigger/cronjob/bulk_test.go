@@ -0,0 +1,99 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRunBulk(t *testing.T) {
+	triggers := []*cronjobApi.CronJobTrigger{}
+	for i := 0; i < 10; i++ {
+		triggers = append(triggers, &cronjobApi.CronJobTrigger{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("trigger-%d", i)}})
+	}
+
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	results := runBulk(triggers, 3, func(w io.Writer, trigger *cronjobApi.CronJobTrigger) error {
+		mutex.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mutex.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+
+		fmt.Fprintf(w, "start %s\n", trigger.Name)
+		fmt.Fprintf(w, "end %s\n", trigger.Name)
+		if trigger.Name == "trigger-4" {
+			return fmt.Errorf("boom")
+		}
+		return nil
+	})
+
+	if maxRunning > 3 {
+		t.Errorf("Expecting at most 3 operations in parallel, got %d", maxRunning)
+	}
+	for i, r := range results {
+		name := fmt.Sprintf("trigger-%d", i)
+		if r.Name != name {
+			t.Errorf("Expecting the result %d to be %s, got %s", i, name, r.Name)
+		}
+		if expected := fmt.Sprintf("start %s\nend %s\n", name, name); r.Output != expected {
+			t.Errorf("Expecting the output of %s to be %q, got %q", name, expected, r.Output)
+		}
+		if (r.Err != nil) != (name == "trigger-4") {
+			t.Errorf("Unexpected error for %s: %v", name, r.Err)
+		}
+	}
+}
+
+func TestPrintBulkSummary(t *testing.T) {
+	var out bytes.Buffer
+	err := printBulkSummary(&out, "paused", []bulkResult{
+		{Name: "foo", Output: "foo paused\n"},
+		{Name: "bar", Err: fmt.Errorf("forbidden")},
+		{Name: "baz", Output: "baz paused\n"},
+	})
+	if err == nil || err.Error() != "1 of 3 cronjob triggers failed to be paused" {
+		t.Errorf("Unexpected error %v", err)
+	}
+	expected := "foo paused\nbaz paused\n\nSummary: 2 succeeded, 1 failed\n  bar: forbidden\n"
+	if out.String() != expected {
+		t.Errorf("Expecting %q, got %q", expected, out.String())
+	}
+
+	out.Reset()
+	if err := printBulkSummary(&out, "deleted", []bulkResult{{Name: "foo", Output: "foo deleted\n"}}); err != nil {
+		t.Error(err)
+	}
+	if !strings.Contains(out.String(), "Summary: 1 succeeded, 0 failed") {
+		t.Errorf("Unexpected summary %q", out.String())
+	}
+}
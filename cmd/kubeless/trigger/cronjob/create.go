@@ -18,15 +18,13 @@ package cronjob
 
 import (
 	"fmt"
+	"os"
 
-	"github.com/robfig/cron"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
-	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
-	cronjobUtils "github.com/kubeless/cronjob-trigger/pkg/utils"
+	"github.com/itchyny/gojq"
 	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var createCmd = &cobra.Command{
@@ -36,17 +34,13 @@ var createCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 
 		if len(args) != 1 {
-			logrus.Fatal("Need exactly one argument - cronjob trigger name")
+			kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, "Need exactly one argument - cronjob trigger name")
 		}
 		triggerName := args[0]
 
-		schedule, err := cmd.Flags().GetString("schedule")
+		schedule, err := getScheduleFlags(cmd.Flags(), true)
 		if err != nil {
-			logrus.Fatal(err)
-		}
-
-		if _, err := cron.ParseStandard(schedule); err != nil {
-			logrus.Fatalf("Invalid value for --schedule. " + err.Error())
+			kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, err)
 		}
 
 		ns, err := cmd.Flags().GetString("namespace")
@@ -66,75 +60,185 @@ var createCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatal(err)
 		}
+		validatePolicy, err := cmd.Flags().GetString("validate-policy")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if validatePolicy != "" && !dryrun {
+			kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, "--validate-policy can only be used with --dryrun")
+		}
 
 		output, err := cmd.Flags().GetString("output")
 		if err != nil {
 			logrus.Fatal(err)
 		}
+		createdOutput, err := kubelessUtils.GetCreatedOutput(cmd.Flags())
+		if err != nil {
+			kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, err)
+		}
+
+		source, err := getPayloadFlags(cmd.Flags())
+		if err != nil {
+			kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, err)
+		}
 
-		payload, err := cmd.Flags().GetString("payload")
+		payloadFromEnv, err := cmd.Flags().GetString("payload-from-env")
 		if err != nil {
 			logrus.Fatal(err)
 		}
+		payloadCoerceTypes, err := cmd.Flags().GetBool("payload-coerce-types")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if len(payloadFromEnv) > 0 {
+			if source.given() {
+				kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, "You can't provide both --payload-from-env and another payload")
+			}
+		} else if payloadCoerceTypes && !isDotEnvFile(source.payloadFromFile) {
+			kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, "The flag --payload-coerce-types requires --payload-from-env or a .env file in --payload-from-file")
+		}
 
-		payloadFromFile, err := cmd.Flags().GetString("payload-from-file")
+		payloadArrayIndex, err := cmd.Flags().GetInt("payload-array-index")
 		if err != nil {
 			logrus.Fatal(err)
 		}
+		// A default index from the environment or the CLI config only applies to the given payloads
+		useArrayIndex := kubelessUtils.IsFlagGiven(cmd.Flags(), "payload-array-index") || (cmd.Flags().Changed("payload-array-index") && source.given())
+		if useArrayIndex {
+			if !source.given() {
+				kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, "The flag --payload-array-index requires --payload or --payload-from-file")
+			}
+			if isGlobPattern(source.payloadFromFile) {
+				kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, "The flag --payload-array-index can't be used with a glob pattern")
+			}
+		}
 
-		if len(payload) > 0 && len(payloadFromFile) > 0 {
-			err := "You can't provide both raw payload and a payload file"
+		payloadTransform, err := cmd.Flags().GetString("payload-transform")
+		if err != nil {
 			logrus.Fatal(err)
 		}
+		var transform *gojq.Code
+		if len(payloadTransform) > 0 {
+			transform, err = compilePayloadTransform(payloadTransform)
+			if err != nil {
+				kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, err)
+			}
+		}
 
+		payloadNullStrip, err := cmd.Flags().GetBool("payload-null-strip")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		payloadEmptyStrip, err := cmd.Flags().GetBool("payload-empty-strip")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		assertions, err := cmd.Flags().GetStringArray("assert")
+		if err != nil {
+			logrus.Fatal(err)
+		}
 		kubelessClient, err := kubelessUtils.GetKubelessClientOutCluster()
 		if err != nil {
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
 
-		cronJobClient, err := cronjobUtils.GetKubelessClientOutCluster()
+		cronJobClient, err := kubelessUtils.GetCronJobTriggerClientOutCluster()
 		if err != nil {
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
 
 		_, err = kubelessUtils.GetFunctionCustomResource(kubelessClient, functionName, ns)
 		if err != nil {
-			logrus.Fatalf("Unable to find Function %s in namespace %s. Error %s", functionName, ns, err)
+			kubelessUtils.FatalfWithCode(kubelessUtils.ExitCodeForError(err), "Unable to find Function %s in namespace %s. Error %s", functionName, ns, err)
 		}
 
-		parsedPayload, err := parsePayload(payload, payloadFromFile)
-		if err != nil {
-			logrus.Fatalf("Unable to parse the payload of Function %s in namespace %s. Error %s", functionName, ns, err)
+		if err := source.readConfigMap(kubelessUtils.GetClientOutOfCluster, ns); err != nil {
+			kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, err)
 		}
 
-		cronJobTrigger := cronjobApi.CronJobTrigger{}
-		cronJobTrigger.TypeMeta = metav1.TypeMeta{
-			Kind:       "CronJobTrigger",
-			APIVersion: "kubeless.io/v1beta1",
+		var parsedPayload interface{}
+		if len(payloadFromEnv) > 0 {
+			parsedPayload, err = getEnvPayload(os.Environ(), payloadFromEnv, payloadCoerceTypes)
+		} else if useArrayIndex {
+			parsedPayload, err = parsePayloadArrayElement(source.payload, source.payloadFromFile, payloadArrayIndex)
+		} else {
+			parsedPayload, err = parsePayload(source.payload, source.payloadFromFile, source.allowEmptyGlob)
+		}
+		if err != nil {
+			kubelessUtils.FatalfWithCode(kubelessUtils.ExitUsage, "Unable to parse the payload of Function %s in namespace %s. Error %s", functionName, ns, err)
+		}
+		if payloadCoerceTypes && isDotEnvFile(source.payloadFromFile) {
+			parsedPayload = coerceDotEnvPayload(parsedPayload)
+		}
+		if source.mergeBase != "" {
+			parsedPayload, err = applyPayloadMergeBase(source.mergeBase, parsedPayload, source.given() || len(payloadFromEnv) > 0)
+			if err != nil {
+				kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, err)
+			}
+		}
+		if transform != nil {
+			parsedPayload, err = transformPayload(transform, parsedPayload)
+			if err != nil {
+				kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, err)
+			}
+		}
+		if payloadNullStrip || payloadEmptyStrip {
+			parsedPayload = stripPayload(parsedPayload, payloadNullStrip, payloadEmptyStrip)
+		}
+		if len(assertions) > 0 {
+			if err := checkPayloadAssertions(assertions, parsedPayload); err != nil {
+				kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, err)
+			}
+		}
+		annotations := map[string]string{}
+		immutable, err := cmd.Flags().GetBool("immutable")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if immutable {
+			annotations[immutableAnnotation] = "true"
 		}
-		cronJobTrigger.ObjectMeta = metav1.ObjectMeta{
-			Name:      triggerName,
-			Namespace: ns,
+		cronJobTrigger := buildCronJobTrigger(triggerName, ns, functionName, schedule, parsedPayload, annotations)
+
+		annotationsFromFile, err := cmd.Flags().GetString("annotations-from-file")
+		if err != nil {
+			logrus.Fatal(err)
 		}
-		cronJobTrigger.ObjectMeta.Labels = map[string]string{
-			"created-by": "kubeless",
+		if err := kubelessUtils.ApplyAnnotationsFile(&cronJobTrigger.ObjectMeta, annotationsFromFile); err != nil {
+			kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, err)
 		}
-		cronJobTrigger.Spec.FunctionName = functionName
-		cronJobTrigger.Spec.Schedule = schedule
-		cronJobTrigger.Spec.Payload = parsedPayload
 
 		if dryrun == true {
+			if validatePolicy != "" {
+				if err := kubelessUtils.CheckPolicyFile(validatePolicy, cronJobTrigger); err != nil {
+					kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, err)
+				}
+			}
 			res, err := kubelessUtils.DryRunFmt(output, cronJobTrigger)
 			if err != nil {
-				logrus.Fatal(err)
+				kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, err)
 			}
 			fmt.Println(res)
 			return
 		}
 
-		err = cronjobUtils.CreateCronJobCustomResource(cronJobClient, &cronJobTrigger)
+		ifNotExists, err := cmd.Flags().GetBool("if-not-exists")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		created, err := createCronJobTrigger(cronJobClient, cronJobTrigger, ifNotExists)
 		if err != nil {
-			logrus.Fatalf("Failed to create cronjob trigger object %s in namespace %s. Error: %s", triggerName, ns, err)
+			kubelessUtils.FatalfWithCode(kubelessUtils.ExitCodeForError(err), "Failed to create cronjob trigger object %s in namespace %s. Error: %s", triggerName, ns, err)
+		}
+		if !created {
+			logrus.Infof("Cronjob trigger %s already exists in namespace %s, leaving it unchanged", triggerName, ns)
+			return
+		}
+		if createdOutput != "" {
+			if err := kubelessUtils.PrintCreated(cmd.OutOrStdout(), createdOutput, "cronjobtrigger.kubeless.io", triggerName, cronJobTrigger); err != nil {
+				logrus.Fatal(err)
+			}
+			return
 		}
 		logrus.Infof("Cronjob trigger %s created in namespace %s successfully!", triggerName, ns)
 	},
@@ -142,12 +246,22 @@ var createCmd = &cobra.Command{
 
 func init() {
 	createCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the cronjob trigger")
-	createCmd.Flags().StringP("schedule", "", "", "Specify schedule in cron format for scheduled function")
+	addScheduleFlags(createCmd.Flags())
 	createCmd.Flags().StringP("function", "", "", "Name of the function to be associated with trigger")
 	createCmd.MarkFlagRequired("function")
 	createCmd.MarkFlagRequired("schedule")
 	createCmd.Flags().Bool("dryrun", false, "Output JSON manifest of the function without creating it")
-	createCmd.Flags().StringP("output", "o", "yaml", "Output format")
-	createCmd.Flags().StringP("payload", "p", "", "Specify a stringified JSON data to pass to function upon execution")
-	createCmd.Flags().StringP("payload-from-file", "f", "", "Specify a payload file to use. It must be a JSON file")
+	createCmd.Flags().String("validate-policy", "", "Check the --dryrun manifest against the Kyverno validate rules of the given file and fail if any of them is violated")
+	createCmd.Flags().Bool("if-not-exists", false, "Succeed without changes if a trigger with the same name already exists")
+	createCmd.Flags().Bool("immutable", false, "Mark the trigger as immutable. Updating or replacing it will then require --allow-immutable")
+	createCmd.Flags().StringP("annotations-from-file", "", "", "Specify a YAML or JSON file with a flat map of annotations to add to the trigger")
+	createCmd.Flags().StringP("output", "o", "yaml", "Output format. One of: json|yaml with --dryrun. When given without --dryrun, the created trigger is printed as name|json|yaml")
+	addPayloadFlags(createCmd.Flags())
+	createCmd.Flags().String("payload-from-env", "", "Build the payload from the environment variables starting with the given prefix (e.g. PAYLOAD_). The prefix is removed from the keys and '__' nests them, e.g. PAYLOAD_USER__ID=42 is {\"USER\": {\"ID\": \"42\"}}")
+	createCmd.Flags().Bool("payload-coerce-types", false, "Store the values of --payload-from-env or of a .env payload file that look like numbers or booleans as such instead of as strings")
+	createCmd.Flags().StringArray("assert", []string{}, "Check the payload before creating the trigger. Given as <jsonpath>, to check that the path exists, or as <jsonpath>=<value>. For example: --assert '.user.id' --assert '.env=prod'. It can be repeated")
+	createCmd.Flags().Int("payload-array-index", 0, "Use the element at the given index (starting at 0) of a payload that is a JSON array")
+	createCmd.Flags().Bool("payload-null-strip", false, "Remove the keys with a null value from the payload, also in nested objects")
+	createCmd.Flags().Bool("payload-empty-strip", false, "Remove the keys with an empty string, array or object from the payload, also in nested objects")
+	createCmd.Flags().StringP("payload-transform", "", "", "Specify a jq expression applied to the payload before storing it. For example: --payload-transform '{id: .user.id}'")
 }
@@ -0,0 +1,123 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"os"
+	"testing"
+
+	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
+	"github.com/spf13/pflag"
+)
+
+func resetFlags(flags *pflag.FlagSet) {
+	flags.VisitAll(func(f *pflag.Flag) {
+		if v, ok := f.Value.(pflag.SliceValue); ok {
+			v.Replace([]string{})
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+}
+
+func TestCreateFlagsPrecedence(t *testing.T) {
+	flags := createCmd.Flags()
+	defer resetFlags(flags)
+
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		expected map[string]string
+	}{
+		{
+			name: "built-in defaults",
+			args: []string{},
+			expected: map[string]string{
+				// An empty namespace falls back to the current context
+				"namespace": "",
+				"function":  "",
+				"output":    "yaml",
+			},
+		},
+		{
+			name: "env overrides defaults",
+			args: []string{},
+			env: map[string]string{
+				"KUBELESS_NAMESPACE": "foo",
+				"KUBELESS_FUNCTION":  "bar",
+				"KUBELESS_OUTPUT":    "json",
+			},
+			expected: map[string]string{
+				"namespace": "foo",
+				"function":  "bar",
+				"output":    "json",
+			},
+		},
+		{
+			name: "flags override env",
+			args: []string{"--namespace", "myns", "--function=myfunc"},
+			env: map[string]string{
+				"KUBELESS_NAMESPACE": "foo",
+				"KUBELESS_FUNCTION":  "bar",
+				"KUBELESS_OUTPUT":    "json",
+			},
+			expected: map[string]string{
+				"namespace": "myns",
+				"function":  "myfunc",
+				"output":    "json",
+			},
+		},
+	}
+	for _, test := range tests {
+		resetFlags(flags)
+		for k, v := range test.env {
+			os.Setenv(k, v)
+		}
+		if err := flags.Parse(test.args); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if err := kubelessUtils.ApplyEnvDefaults(flags); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		for flag, expected := range test.expected {
+			actual, err := flags.GetString(flag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != expected {
+				t.Errorf("%s: expecting --%s to be %q, got %q", test.name, flag, expected, actual)
+			}
+		}
+		for k := range test.env {
+			os.Unsetenv(k)
+		}
+	}
+}
+
+func TestCreateFlagsInvalidEnv(t *testing.T) {
+	flags := createCmd.Flags()
+	defer resetFlags(flags)
+	resetFlags(flags)
+
+	os.Setenv("KUBELESS_DRYRUN", "maybe")
+	defer os.Unsetenv("KUBELESS_DRYRUN")
+	if err := kubelessUtils.ApplyEnvDefaults(flags); err == nil {
+		t.Error("Expecting an error for an invalid boolean in KUBELESS_DRYRUN")
+	}
+}
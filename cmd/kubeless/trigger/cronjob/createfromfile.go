@@ -0,0 +1,231 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/gosuri/uitable"
+	"github.com/kubeless/cronjob-trigger/pkg/client/clientset/versioned"
+	cronjobUtils "github.com/kubeless/cronjob-trigger/pkg/utils"
+	kubelessVersioned "github.com/kubeless/kubeless/pkg/client/clientset/versioned"
+	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var createFromFileCmd = &cobra.Command{
+	Use:   "create-from-file <file> FLAG",
+	Short: "Create several cron job triggers from a file",
+	Long: `Create the cron job triggers listed in a YAML or CSV file. Each entry has a name, function, schedule and an optional JSON payload. For example:
+
+- name: every-minute
+  function: hello
+  schedule: "* * * * *"
+  payload:
+    foo: bar
+
+Or in CSV format, with a header:
+
+name,function,schedule,payload
+every-minute,hello,* * * * *,"{""foo"": ""bar""}"`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 1 {
+			logrus.Fatal("Need exactly one argument - file with the list of triggers")
+		}
+		file := args[0]
+
+		ns, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if ns == "" {
+			ns = kubelessUtils.GetDefaultNamespace()
+		}
+
+		failFast, err := cmd.Flags().GetBool("fail-fast")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		entries, err := readTriggerEntries(file)
+		if err != nil {
+			logrus.Fatalf("Unable to read %s: %v", file, err)
+		}
+
+		kubelessClient, err := kubelessUtils.GetKubelessClientOutCluster()
+		if err != nil {
+			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
+		}
+
+		cronJobClient, err := kubelessUtils.GetCronJobTriggerClientOutCluster()
+		if err != nil {
+			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
+		}
+
+		results := createTriggersFromEntries(kubelessClient, cronJobClient, ns, entries, failFast)
+		printEntryResults(cmd.OutOrStdout(), results)
+		failed := 0
+		for _, r := range results {
+			if r.Error != nil {
+				failed++
+			}
+		}
+		if failed > 0 {
+			logrus.Fatalf("%d of %d triggers could not be created", failed, len(entries))
+		}
+	},
+}
+
+func init() {
+	createFromFileCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the cronjob triggers")
+	createFromFileCmd.Flags().Bool("fail-fast", false, "Stop at the first trigger that can't be created")
+}
+
+// triggerEntry is an item of the file given to create-from-file
+type triggerEntry struct {
+	Name     string      `json:"name"`
+	Function string      `json:"function"`
+	Schedule string      `json:"schedule"`
+	Payload  interface{} `json:"payload,omitempty"`
+}
+
+// entryResult is the outcome of creating the trigger of an entry.
+// Skipped entries are the ones not processed because of --fail-fast.
+type entryResult struct {
+	Name    string
+	Skipped bool
+	Error   error
+}
+
+// readTriggerEntries parses the list of triggers in YAML (or JSON) or, if the file has the .csv extension, in CSV
+func readTriggerEntries(file string) ([]triggerEntry, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(filepath.Ext(file)) == ".csv" {
+		return parseCSVTriggerEntries(string(content))
+	}
+	entries := []triggerEntry{}
+	if err := yaml.Unmarshal(content, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func parseCSVTriggerEntries(content string) ([]triggerEntry, error) {
+	r := csv.NewReader(strings.NewReader(content))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("The file is empty")
+	}
+	columns := map[string]int{}
+	for i, column := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, required := range []string{"name", "function", "schedule"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("Missing column %q in the header", required)
+		}
+	}
+	get := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	entries := []triggerEntry{}
+	for line, record := range records[1:] {
+		entry := triggerEntry{
+			Name:     get(record, "name"),
+			Function: get(record, "function"),
+			Schedule: get(record, "schedule"),
+		}
+		if payload := get(record, "payload"); payload != "" {
+			if err := json.Unmarshal([]byte(payload), &entry.Payload); err != nil {
+				return nil, fmt.Errorf("Invalid payload in line %d: %v", line+2, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// createTriggersFromEntries creates the trigger of each entry and returns the result of each one.
+// It continues after an error unless failFast is set.
+func createTriggersFromEntries(kubelessClient kubelessVersioned.Interface, cronJobClient versioned.Interface, ns string, entries []triggerEntry, failFast bool) []entryResult {
+	results := []entryResult{}
+	failed := false
+	for _, entry := range entries {
+		if failed && failFast {
+			results = append(results, entryResult{Name: entry.Name, Skipped: true})
+			continue
+		}
+		err := createTriggerFromEntry(kubelessClient, cronJobClient, ns, entry)
+		if err != nil {
+			failed = true
+		}
+		results = append(results, entryResult{Name: entry.Name, Error: err})
+	}
+	return results
+}
+
+func createTriggerFromEntry(kubelessClient kubelessVersioned.Interface, cronJobClient versioned.Interface, ns string, entry triggerEntry) error {
+	if entry.Name == "" || entry.Function == "" || entry.Schedule == "" {
+		return fmt.Errorf("The name, function and schedule are required")
+	}
+	if err := validateSchedule(entry.Schedule); err != nil {
+		return err
+	}
+	if _, err := kubelessUtils.GetFunctionCustomResource(kubelessClient, entry.Function, ns); err != nil {
+		return fmt.Errorf("Unable to find Function %s in namespace %s: %v", entry.Function, ns, err)
+	}
+	trigger := buildCronJobTrigger(entry.Name, ns, entry.Function, entry.Schedule, entry.Payload, nil)
+	return cronjobUtils.CreateCronJobCustomResource(cronJobClient, trigger)
+}
+
+func printEntryResults(w io.Writer, results []entryResult) {
+	table := uitable.New()
+	table.MaxColWidth = 80
+	table.Wrap = true
+	table.AddRow("NAME", "STATUS", "MESSAGE")
+	for _, r := range results {
+		switch {
+		case r.Skipped:
+			table.AddRow(r.Name, "Skipped", "")
+		case r.Error != nil:
+			table.AddRow(r.Name, "Failed", r.Error.Error())
+		default:
+			table.AddRow(r.Name, "Created", "")
+		}
+	}
+	fmt.Fprintln(w, table)
+}
@@ -0,0 +1,129 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	cronjobFake "github.com/kubeless/cronjob-trigger/pkg/client/clientset/versioned/fake"
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	fFake "github.com/kubeless/kubeless/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReadTriggerEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "triggers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	expected := []triggerEntry{
+		{Name: "every-minute", Function: "hello", Schedule: "* * * * *", Payload: map[string]interface{}{"foo": "bar"}},
+		{Name: "nightly", Function: "report", Schedule: "0 2 * * *"},
+	}
+
+	yamlFile := filepath.Join(dir, "schedules.yaml")
+	yamlContent := `- name: every-minute
+  function: hello
+  schedule: "* * * * *"
+  payload:
+    foo: bar
+- name: nightly
+  function: report
+  schedule: 0 2 * * *
+`
+	if err := ioutil.WriteFile(yamlFile, []byte(yamlContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := readTriggerEntries(yamlFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Expecting %v, got %v", expected, entries)
+	}
+
+	csvFile := filepath.Join(dir, "schedules.csv")
+	csvContent := "name,function,schedule,payload\n" +
+		"every-minute,hello,* * * * *,\"{\"\"foo\"\": \"\"bar\"\"}\"\n" +
+		"nightly,report,0 2 * * *,\n"
+	if err := ioutil.WriteFile(csvFile, []byte(csvContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = readTriggerEntries(csvFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Expecting %v, got %v", expected, entries)
+	}
+
+	if _, err := parseCSVTriggerEntries("name,schedule\nfoo,* * * * *\n"); err == nil {
+		t.Error("Expecting an error for a missing column")
+	}
+	if _, err := parseCSVTriggerEntries("name,function,schedule,payload\nfoo,bar,* * * * *,{not json\n"); err == nil {
+		t.Error("Expecting an error for an invalid payload")
+	}
+}
+
+func TestCreateTriggersFromEntries(t *testing.T) {
+	kubelessClient := fFake.NewSimpleClientset(&kubelessApi.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "myns"},
+	})
+	entries := []triggerEntry{
+		{Name: "first", Function: "hello", Schedule: "* * * * *"},
+		{Name: "missing-function", Function: "foo", Schedule: "* * * * *"},
+		{Name: "wrong-schedule", Function: "hello", Schedule: "every minute"},
+		{Name: "last", Function: "hello", Schedule: "@daily", Payload: map[string]interface{}{"foo": "bar"}},
+	}
+
+	cronJobClient := cronjobFake.NewSimpleClientset()
+	results := createTriggersFromEntries(kubelessClient, cronJobClient, "myns", entries, false)
+	if len(results) != 4 || results[0].Error != nil || results[1].Error == nil || results[2].Error == nil || results[3].Error != nil {
+		t.Fatalf("Unexpected results %v", results)
+	}
+	trigger, err := cronJobClient.KubelessV1beta1().CronJobTriggers("myns").Get("last", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trigger.Spec.FunctionName != "hello" || trigger.Spec.Schedule != "@daily" || trigger.ObjectMeta.Labels["created-by"] != "kubeless" {
+		t.Errorf("Unexpected trigger %v", trigger)
+	}
+
+	var buf bytes.Buffer
+	printEntryResults(&buf, results)
+	if !strings.Contains(buf.String(), "missing-function") || !strings.Contains(buf.String(), "Failed") {
+		t.Errorf("Unexpected output %s", buf.String())
+	}
+
+	// With fail-fast the entries after the first error are skipped
+	cronJobClient = cronjobFake.NewSimpleClientset()
+	results = createTriggersFromEntries(kubelessClient, cronJobClient, "myns", entries, true)
+	if results[0].Error != nil || results[1].Error == nil || !results[2].Skipped || !results[3].Skipped {
+		t.Errorf("Unexpected results %v", results)
+	}
+	if _, err := cronJobClient.KubelessV1beta1().CronJobTriggers("myns").Get("last", metav1.GetOptions{}); err == nil {
+		t.Error("The trigger last shouldn't be created with fail-fast")
+	}
+}
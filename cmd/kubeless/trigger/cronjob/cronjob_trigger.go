@@ -19,12 +19,31 @@ package cronjob
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/itchyny/gojq"
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	"github.com/kubeless/cronjob-trigger/pkg/client/clientset/versioned"
 	kubelessutil "github.com/kubeless/kubeless/pkg/utils"
+	"github.com/robfig/cron"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/client-go/util/retry"
 )
 
+// immutableAnnotation marks a trigger that can only be updated or replaced with --allow-immutable
+const immutableAnnotation = "kubeless.io/immutable"
+
 // CronjobTriggerCmd command for CronJob trigger commands
 var CronjobTriggerCmd = &cobra.Command{
 	Use:   "cronjob SUBCOMMAND",
@@ -40,9 +59,30 @@ func init() {
 	CronjobTriggerCmd.AddCommand(deleteCmd)
 	CronjobTriggerCmd.AddCommand(listCmd)
 	CronjobTriggerCmd.AddCommand(updateCmd)
+	CronjobTriggerCmd.AddCommand(replaceCmd)
+	CronjobTriggerCmd.AddCommand(testCmd)
+	CronjobTriggerCmd.AddCommand(inferSchemaCmd)
+	CronjobTriggerCmd.AddCommand(createFromFileCmd)
+	CronjobTriggerCmd.AddCommand(pauseCmd)
+	CronjobTriggerCmd.AddCommand(resumeCmd)
+	CronjobTriggerCmd.AddCommand(patchCmd)
+	CronjobTriggerCmd.AddCommand(runOnceCmd)
+	CronjobTriggerCmd.AddCommand(verifyCmd)
 }
 
-func parsePayload(content string, file string) (interface{}, error) {
+// parsePayload parses the payload given in the command line or in a file. The file can
+// be a glob pattern (e.g. payloads/*.json), in which case the matched files are merged.
+func parsePayload(content string, file string, allowEmptyGlob bool) (interface{}, error) {
+	if isGlobPattern(file) {
+		files, err := filepath.Glob(file)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid payload file pattern %q: %v", file, err)
+		}
+		if len(files) == 0 && !allowEmptyGlob {
+			return nil, fmt.Errorf("No payload file matches %q", file)
+		}
+		return mergePayloadFiles(files)
+	}
 	if len(file) > 0 {
 		content, err := getPayloadRawContent(file)
 		if err != nil {
@@ -55,18 +95,446 @@ func parsePayload(content string, file string) (interface{}, error) {
 	return parsePayloadContent(content), nil
 }
 
+// resolvePayloadFlag detects a file path given in --payload by mistake. Values starting
+// like inline JSON are never taken as a path. With autodetect the file is returned to be
+// read as --payload-from-file, otherwise it's an error.
+func resolvePayloadFlag(payload string, autodetect bool) (string, string, error) {
+	trimmed := strings.TrimSpace(payload)
+	if trimmed == "" || strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		return payload, "", nil
+	}
+	info, err := os.Stat(payload)
+	if err != nil || info.IsDir() {
+		return payload, "", nil
+	}
+	if !autodetect {
+		return "", "", fmt.Errorf("The payload %q is a file, did you mean --payload-from-file %s? Use --payload-autodetect to read files given in --payload", payload, payload)
+	}
+	return "", payload, nil
+}
+
+// addScheduleFlags adds the schedule flags shared by the commands that set the schedule of a trigger.
+// --schedule is an array only to reject it when it's repeated instead of keeping the last value.
+func addScheduleFlags(flags *pflag.FlagSet) {
+	flags.StringArrayP("schedule", "", []string{}, "Specify schedule in cron format for scheduled function")
+	flags.Bool("strict-schedule", false, "Reject schedules restricting both the day of the month and the day of the week, which cron matches when any of them does")
+}
+
+// getScheduleFlags returns the schedule given with --schedule after validating it.
+// If it is not required, no schedule means that it is not changed.
+func getScheduleFlags(flags *pflag.FlagSet, required bool) (string, error) {
+	schedules, err := flags.GetStringArray("schedule")
+	if err != nil {
+		return "", err
+	}
+	if !required && len(schedules) == 0 {
+		return "", nil
+	}
+	// The cronjob trigger controller creates a single CronJob per trigger, from spec.schedule
+	if len(schedules) > 1 {
+		return "", fmt.Errorf("The flag --schedule can only be given once: the cronjob trigger controller runs each trigger on a single schedule")
+	}
+	if len(schedules) == 0 {
+		return "", fmt.Errorf("A schedule is required")
+	}
+	if err := validateSchedule(schedules[0]); err != nil {
+		return "", err
+	}
+	strictSchedule, err := flags.GetBool("strict-schedule")
+	if err != nil {
+		return "", err
+	}
+	if strictSchedule {
+		if err := validateStrictSchedule(schedules[0]); err != nil {
+			return "", err
+		}
+	}
+	return schedules[0], nil
+}
+
+// payloadSource is the payload given with the flags shared by create, update and replace
+type payloadSource struct {
+	payload         string
+	payloadFromFile string
+	allowEmptyGlob  bool
+	mergeBase       string
+}
+
+// addPayloadFlags adds the payload flags shared by create, update and replace
+func addPayloadFlags(flags *pflag.FlagSet) {
+	flags.StringP("payload", "p", "", "Specify a stringified JSON data to pass to function upon execution")
+	flags.StringP("payload-from-file", "f", "", "Specify a payload file to use. It must be a JSON file or a .env file with KEY=value lines. Use configmap://<configmap_name>/<key> to read it from a ConfigMap. A glob pattern (e.g. 'payloads/*.json') merges the matched files in sorted order")
+	flags.Bool("payload-autodetect", false, "Read the payload from the file if the value of --payload is the path of an existing file")
+	flags.Bool("allow-empty-glob", false, "Use an empty payload if the pattern given in --payload-from-file matches no file")
+	flags.String("payload-merge-base", "", "Specify a JSON file or URL with a base payload. The payload given is deep-merged over it")
+}
+
+// getPayloadFlags reads the flags added by addPayloadFlags. A file given in --payload
+// is moved to payloadFromFile with --payload-autodetect.
+func getPayloadFlags(flags *pflag.FlagSet) (*payloadSource, error) {
+	payload, err := flags.GetString("payload")
+	if err != nil {
+		return nil, err
+	}
+	payloadFromFile, err := flags.GetString("payload-from-file")
+	if err != nil {
+		return nil, err
+	}
+	payloadAutodetect, err := flags.GetBool("payload-autodetect")
+	if err != nil {
+		return nil, err
+	}
+	payload, payloadFile, err := resolvePayloadFlag(payload, payloadAutodetect)
+	if err != nil {
+		return nil, err
+	}
+	if len(payloadFile) > 0 {
+		if len(payloadFromFile) > 0 {
+			return nil, fmt.Errorf("You can't provide both raw payload and a payload file")
+		}
+		payloadFromFile = payloadFile
+	}
+	if len(payload) > 0 && len(payloadFromFile) > 0 {
+		return nil, fmt.Errorf("You can't provide both raw payload and a payload file")
+	}
+	allowEmptyGlob, err := flags.GetBool("allow-empty-glob")
+	if err != nil {
+		return nil, err
+	}
+	mergeBase, err := flags.GetString("payload-merge-base")
+	if err != nil {
+		return nil, err
+	}
+	return &payloadSource{
+		payload:         payload,
+		payloadFromFile: payloadFromFile,
+		allowEmptyGlob:  allowEmptyGlob,
+		mergeBase:       mergeBase,
+	}, nil
+}
+
+// given returns true if a payload has been given, inline or as a file
+func (p *payloadSource) given() bool {
+	return len(p.payload) > 0 || len(p.payloadFromFile) > 0
+}
+
+// readConfigMap replaces a configmap://<configmap_name>/<key> payload file with the
+// content of the key. Other payloads are left unchanged.
+func (p *payloadSource) readConfigMap(cli func() kubernetes.Interface, ns string) error {
+	if !isConfigMapPayload(p.payloadFromFile) {
+		return nil
+	}
+	payload, err := getConfigMapPayload(cli(), ns, p.payloadFromFile)
+	if err != nil {
+		return err
+	}
+	p.payload = payload
+	p.payloadFromFile = ""
+	return nil
+}
+
+func isGlobPattern(file string) bool {
+	return strings.ContainsAny(file, "*?[")
+}
+
+// mergePayloadFiles deep-merges the JSON objects of the given files in sorted order.
+// Values of later files override the ones of previous files, except nested objects that are merged.
+func mergePayloadFiles(files []string) (map[string]interface{}, error) {
+	sort.Strings(files)
+	payload := map[string]interface{}{}
+	for _, file := range files {
+		content, err := getPayloadRawContent(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		fragment := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(content), &fragment); err != nil {
+			return nil, fmt.Errorf("Found an error during JSON parsing on %s: %v", file, err)
+		}
+		mergePayloads(payload, fragment)
+	}
+	return payload, nil
+}
+
+// mergePayloads deep-merges src into dst
+func mergePayloads(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]interface{})
+		dstMap, dstIsMap := dst[k].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergePayloads(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// getPayloadMergeBase returns the JSON object of the file or URL given in --payload-merge-base.
+// Remote bases are revalidated with the local cache like the rest of remote payload files.
+func getPayloadMergeBase(base string) (map[string]interface{}, error) {
+	content, err := getPayloadRawContent(base)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read the payload base %s: %v", base, err)
+	}
+	result := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(content), &result); err != nil {
+		return nil, fmt.Errorf("The payload base %s must be a JSON object: %v", base, err)
+	}
+	return result, nil
+}
+
+// applyPayloadMergeBase deep-merges the payload over the given base. If no payload has
+// been given the base is used as is.
+func applyPayloadMergeBase(base string, payload interface{}, given bool) (interface{}, error) {
+	result, err := getPayloadMergeBase(base)
+	if err != nil {
+		return nil, err
+	}
+	if !given {
+		return result, nil
+	}
+	if err, ok := payload.(error); ok {
+		return nil, err
+	}
+	payloadMap, ok := payload.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Only a JSON object payload can be merged with --payload-merge-base")
+	}
+	mergePayloads(result, payloadMap)
+	return result, nil
+}
+
+// envPayloadSeparator nests the keys of a payload built from environment variables
+const envPayloadSeparator = "__"
+
+// getEnvPayload builds a payload with the variables of the environment (given as KEY=value)
+// starting with the prefix. The prefix is removed from the keys, and "__" in the rest of the
+// key creates a nested object. With coerce, values that look like numbers or booleans are
+// stored as such instead of as strings.
+func getEnvPayload(environ []string, prefix string, coerce bool) (map[string]interface{}, error) {
+	vars := map[string]string{}
+	keys := []string{}
+	for _, env := range environ {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], prefix) {
+			continue
+		}
+		key := strings.TrimPrefix(parts[0], prefix)
+		vars[key] = parts[1]
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("No environment variable starts with %s", prefix)
+	}
+	// Sort the keys so conflicts are reported consistently
+	sort.Strings(keys)
+
+	payload := map[string]interface{}{}
+	for _, key := range keys {
+		path := strings.Split(key, envPayloadSeparator)
+		for _, p := range path {
+			if p == "" {
+				return nil, fmt.Errorf("Invalid variable %s%s: its name has an empty key", prefix, key)
+			}
+		}
+		current := payload
+		for _, p := range path[:len(path)-1] {
+			next, exists := current[p]
+			if !exists {
+				next = map[string]interface{}{}
+				current[p] = next
+			}
+			nextMap, ok := next.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("Invalid variable %s%s: %s is already a value", prefix, key, p)
+			}
+			current = nextMap
+		}
+		last := path[len(path)-1]
+		if _, exists := current[last]; exists {
+			return nil, fmt.Errorf("Invalid variable %s%s: %s is already an object", prefix, key, last)
+		}
+		var value interface{} = vars[key]
+		if coerce {
+			value = coerceEnvValue(vars[key])
+		}
+		current[last] = value
+	}
+	return payload, nil
+}
+
+// coerceEnvValue returns the value as a boolean or a number if it looks like one
+func coerceEnvValue(value string) interface{} {
+	if value == "true" || value == "false" {
+		return value == "true"
+	}
+	if n, err := strconv.ParseFloat(value, 64); err == nil && !math.IsInf(n, 0) && !math.IsNaN(n) {
+		return n
+	}
+	return value
+}
+
+// stripPayload recursively removes the keys of the objects of the payload with a null
+// value (if nulls is true) and with an empty string, array or object (if empty is true).
+// Values that become empty after removing their keys are removed too. The elements of
+// the arrays are kept so their positions don't change, but their content is stripped.
+func stripPayload(payload interface{}, nulls, empty bool) interface{} {
+	switch v := payload.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			value = stripPayload(value, nulls, empty)
+			if (nulls && value == nil) || (empty && isEmptyPayloadValue(value)) {
+				delete(v, key)
+				continue
+			}
+			v[key] = value
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = stripPayload(v[i], nulls, empty)
+		}
+		return v
+	default:
+		return payload
+	}
+}
+
+func isEmptyPayloadValue(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+// payloadAssertion checks that a JSONPath of the payload exists and, if a value
+// is given, that every value found is equal to it
+type payloadAssertion struct {
+	raw      string
+	path     *jsonpath.JSONPath
+	value    string
+	hasValue bool
+}
+
+// parsePayloadAssertion parses an assertion given as <jsonpath>[=<value>]. The path can be
+// given with or without braces (e.g. {.user.id}=42 or .user.id=42), braces are required to
+// use filters containing "=".
+func parsePayloadAssertion(raw string) (*payloadAssertion, error) {
+	expr, value, hasValue := raw, "", false
+	if strings.HasPrefix(raw, "{") {
+		end := strings.Index(raw, "}")
+		if end < 0 {
+			return nil, fmt.Errorf("Invalid assertion %q: missing closing brace", raw)
+		}
+		expr = raw[:end+1]
+		if rest := raw[end+1:]; rest != "" {
+			if !strings.HasPrefix(rest, "=") {
+				return nil, fmt.Errorf("Invalid assertion %q. It should be <jsonpath> or <jsonpath>=<value>", raw)
+			}
+			value, hasValue = rest[1:], true
+		}
+	} else {
+		if i := strings.Index(raw, "="); i >= 0 {
+			expr, value, hasValue = raw[:i], raw[i+1:], true
+		}
+		expr = "{" + expr + "}"
+	}
+	path := jsonpath.New("assert")
+	if err := path.Parse(expr); err != nil {
+		return nil, fmt.Errorf("Invalid JSONPath in the assertion %q: %v", raw, err)
+	}
+	return &payloadAssertion{raw: raw, path: path, value: value, hasValue: hasValue}, nil
+}
+
+// check returns an error if the assertion doesn't hold for the payload
+func (a *payloadAssertion) check(payload interface{}) error {
+	results, err := a.path.FindResults(payload)
+	if err != nil || len(results) == 0 || len(results[0]) == 0 {
+		return fmt.Errorf("Assertion %q failed: the path doesn't exist in the payload", a.raw)
+	}
+	if !a.hasValue {
+		return nil
+	}
+	for _, result := range results {
+		for _, v := range result {
+			actual := formatAssertionValue(v.Interface())
+			if actual != a.value {
+				return fmt.Errorf("Assertion %q failed: found %s", a.raw, actual)
+			}
+		}
+	}
+	return nil
+}
+
+// formatAssertionValue returns strings as is and the rest of values as JSON
+func formatAssertionValue(v interface{}) string {
+	if str, ok := v.(string); ok {
+		return str
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(raw)
+}
+
+// checkPayloadAssertions parses the given assertions and checks them against the payload.
+// Every failure is reported.
+func checkPayloadAssertions(assertions []string, payload interface{}) error {
+	if err, ok := payload.(error); ok {
+		return fmt.Errorf("Unable to check the assertions of the payload: %v", err)
+	}
+	failures := []string{}
+	for _, raw := range assertions {
+		assertion, err := parsePayloadAssertion(raw)
+		if err != nil {
+			return err
+		}
+		if err := assertion.check(payload); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%s", strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
 func getPayloadRawContent(file string) (string, error) {
 	contentType, err := kubelessutil.GetContentType(file)
 	if err != nil {
 		return "", err
 	}
 
-	content, _, err := kubelessutil.ParseContent(file, contentType)
+	var content string
+	if strings.HasPrefix(contentType, "url") {
+		// Remote payloads are revalidated with their ETag so bulk operations reuse them
+		content, err = kubelessutil.GetRevalidatedURLContent(file)
+	} else {
+		content, _, err = kubelessutil.ParseContent(file, contentType)
+	}
 	if err != nil {
 		return "", err
 	}
 
 	ext := filepath.Ext(file)
+	if ext == dotEnvExtension {
+		vars, err := parseDotEnv(content)
+		if err != nil {
+			return "", fmt.Errorf("Unable to parse %s: %v", file, err)
+		}
+		raw, err := json.Marshal(vars)
+		if err != nil {
+			return "", err
+		}
+		return string(raw), nil
+	}
 	if ext != ".json" {
 		return "", fmt.Errorf("Sorry, we can't parse %s files yet", ext)
 	}
@@ -74,6 +542,119 @@ func getPayloadRawContent(file string) (string, error) {
 	return content, nil
 }
 
+// dotEnvExtension is the extension of the payload files read as KEY=value lines
+const dotEnvExtension = ".env"
+
+var dotEnvKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// isDotEnvFile returns true if the payload file (or the files matching the pattern) is a .env file
+func isDotEnvFile(file string) bool {
+	return filepath.Ext(file) == dotEnvExtension
+}
+
+// parseDotEnv parses the KEY=value lines of a .env file. Empty lines and lines starting
+// with # are skipped and the "export" prefix is allowed. Values can be quoted: single
+// quotes keep the value as is and double quotes expand \n, \t, \" and \\. Unquoted values
+// end at a " #" comment. If a key is repeated the last value wins.
+func parseDotEnv(content string) (map[string]string, error) {
+	vars := map[string]string{}
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.TrimSuffix(line, "\r"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		parts := strings.SplitN(line, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || !dotEnvKeyRegex.MatchString(key) {
+			return nil, fmt.Errorf("line %d: expecting KEY=value", i+1)
+		}
+		value, err := parseDotEnvValue(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+func parseDotEnvValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+	quote := raw[0]
+	if quote != '"' && quote != '\'' {
+		if i := strings.Index(raw, " #"); i >= 0 {
+			raw = raw[:i]
+		}
+		return strings.TrimSpace(raw), nil
+	}
+	var value strings.Builder
+	for i := 1; i < len(raw); i++ {
+		c := raw[i]
+		if c == quote {
+			// Only a comment can follow the closing quote
+			rest := strings.TrimSpace(raw[i+1:])
+			if rest != "" && !strings.HasPrefix(rest, "#") {
+				return "", fmt.Errorf("unexpected %q after the quoted value", rest)
+			}
+			return value.String(), nil
+		}
+		if c == '\\' && quote == '"' && i+1 < len(raw) {
+			i++
+			switch raw[i] {
+			case 'n':
+				value.WriteByte('\n')
+			case 't':
+				value.WriteByte('\t')
+			case '"', '\\':
+				value.WriteByte(raw[i])
+			default:
+				value.WriteByte('\\')
+				value.WriteByte(raw[i])
+			}
+			continue
+		}
+		value.WriteByte(c)
+	}
+	return "", fmt.Errorf("missing the closing %c", quote)
+}
+
+// coerceDotEnvPayload stores the values of a payload read from .env files that look like
+// numbers or booleans as such, like --payload-coerce-types does with --payload-from-env
+func coerceDotEnvPayload(payload interface{}) interface{} {
+	fields, ok := payload.(map[string]interface{})
+	if !ok {
+		return payload
+	}
+	for k, v := range fields {
+		if value, ok := v.(string); ok {
+			fields[k] = coerceEnvValue(value)
+		}
+	}
+	return fields
+}
+
+// parsePayloadArrayElement reads a payload that is a top-level JSON array, given
+// inline or in a file, and returns the element at the given index
+func parsePayloadArrayElement(content, file string, index int) (interface{}, error) {
+	if len(file) > 0 {
+		var err error
+		content, err = getPayloadRawContent(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var items []interface{}
+	if err := json.Unmarshal([]byte(content), &items); err != nil {
+		return nil, fmt.Errorf("The payload should be a JSON array to use --payload-array-index: %v", err)
+	}
+	if index < 0 || index >= len(items) {
+		return nil, fmt.Errorf("The payload array index %d is out of range, the array has %d elements", index, len(items))
+	}
+	return items[index], nil
+}
+
 func parsePayloadContent(raw string) interface{} {
 	var payload map[string]interface{}
 
@@ -84,3 +665,201 @@ func parsePayloadContent(raw string) interface{} {
 
 	return payload
 }
+
+// compilePayloadTransform compiles the jq expression used to transform the payload
+func compilePayloadTransform(expr string) (*gojq.Code, error) {
+	query, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid payload transformation %q: %v", expr, err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid payload transformation %q: %v", expr, err)
+	}
+	return code, nil
+}
+
+// transformPayload runs the payload through the given jq program. The program
+// should produce exactly one value, which is returned as the new payload.
+func transformPayload(code *gojq.Code, payload interface{}) (interface{}, error) {
+	if err, ok := payload.(error); ok {
+		return nil, err
+	}
+	// gojq only accepts generic JSON values
+	var input interface{}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &input); err != nil {
+		return nil, err
+	}
+
+	results := []interface{}{}
+	iter := code.Run(input)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := v.(error); ok {
+			return nil, fmt.Errorf("Unable to transform the payload: %v", err)
+		}
+		results = append(results, v)
+	}
+	if len(results) != 1 {
+		return nil, fmt.Errorf("The payload transformation should produce exactly one value but it produced %d", len(results))
+	}
+
+	raw, err = json.Marshal(results[0])
+	if err != nil {
+		return nil, fmt.Errorf("The payload transformation produced an invalid JSON value: %v", err)
+	}
+	var transformed interface{}
+	if err := json.Unmarshal(raw, &transformed); err != nil {
+		return nil, fmt.Errorf("The payload transformation produced an invalid JSON value: %v", err)
+	}
+	return transformed, nil
+}
+
+const configMapPayloadScheme = "configmap://"
+
+func isConfigMapPayload(file string) bool {
+	return strings.HasPrefix(file, configMapPayloadScheme)
+}
+
+// getConfigMapPayload returns the value of the ConfigMap key referenced in --payload-from-file
+// as configmap://<configmap_name>/<key>. As with files, the key needs a .json extension.
+func getConfigMapPayload(cli kubernetes.Interface, ns, ref string) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(ref, configMapPayloadScheme), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("Invalid ConfigMap reference %q. It should be in the form configmap://<configmap_name>/<key>", ref)
+	}
+	name, key := parts[0], parts[1]
+	if ext := filepath.Ext(key); ext != ".json" {
+		return "", fmt.Errorf("Sorry, we can't parse %s files yet", ext)
+	}
+	configMap, err := cli.CoreV1().ConfigMaps(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("Unable to find ConfigMap %s in namespace %s: %v", name, ns, err)
+	}
+	if value, ok := configMap.Data[key]; ok {
+		return value, nil
+	}
+	if value, ok := configMap.BinaryData[key]; ok {
+		return string(value), nil
+	}
+	return "", fmt.Errorf("ConfigMap %s in namespace %s doesn't contain the key %s", name, ns, key)
+}
+
+// createCronJobTrigger creates the trigger. With ifNotExists, an existing trigger with the
+// same name is left unchanged and it returns false instead of an error.
+func createCronJobTrigger(cronJobClient versioned.Interface, trigger *cronjobApi.CronJobTrigger, ifNotExists bool) (bool, error) {
+	_, err := cronJobClient.KubelessV1beta1().CronJobTriggers(trigger.Namespace).Create(trigger)
+	if err != nil {
+		if ifNotExists && k8sErrors.IsAlreadyExists(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// validateSchedule checks that the schedule is a valid cron expression
+func validateSchedule(schedule string) error {
+	if _, err := cron.ParseStandard(schedule); err != nil {
+		return fmt.Errorf("Invalid value %q for --schedule. %v", schedule, err)
+	}
+	return nil
+}
+
+// validateStrictSchedule rejects a schedule that restricts both the day of the month and
+// the day of the week. The schedule should be valid for cron.ParseStandard.
+func validateStrictSchedule(schedule string) error {
+	fields := strings.Fields(schedule)
+	// Descriptors like @daily don't restrict the days
+	if len(fields) != 5 {
+		return nil
+	}
+	if isRestrictedField(fields[2]) && isRestrictedField(fields[4]) {
+		return fmt.Errorf("The schedule %q restricts both the day of the month (%s) and the day of the week (%s). "+
+			"Cron runs the function when ANY of them matches, not when both do, which usually means more runs than expected. "+
+			"Use a separate trigger for each condition, or remove --strict-schedule if this is intended", schedule, fields[2], fields[4])
+	}
+	return nil
+}
+
+// isRestrictedField follows the cron parser: a field with a part starting with * or ? (e.g. */2)
+// is not a restriction, so the days must match both fields
+func isRestrictedField(field string) bool {
+	for _, part := range strings.Split(field, ",") {
+		if strings.HasPrefix(part, "*") || strings.HasPrefix(part, "?") {
+			return false
+		}
+	}
+	return true
+}
+
+// updateCronJobTrigger gets the latest version of the trigger, changes it with mutate and
+// updates it. If the trigger is modified by someone else in the meantime the update fails
+// with a conflict, so the changes are applied again to its new version, a bounded number of
+// times. mutate may modify the given trigger or return a new one.
+func updateCronJobTrigger(cronJobClient versioned.Interface, name, ns string, mutate func(*cronjobApi.CronJobTrigger) (*cronjobApi.CronJobTrigger, error)) (*cronjobApi.CronJobTrigger, error) {
+	var updated *cronjobApi.CronJobTrigger
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := cronJobClient.KubelessV1beta1().CronJobTriggers(ns).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		trigger, err := mutate(current)
+		if err != nil {
+			return err
+		}
+		updated, err = cronJobClient.KubelessV1beta1().CronJobTriggers(ns).Update(trigger)
+		return err
+	})
+	return updated, err
+}
+
+// buildCronJobTrigger returns a trigger that calls the function on the given schedule
+func buildCronJobTrigger(name, ns, functionName, schedule string, payload interface{}, annotations map[string]string) *cronjobApi.CronJobTrigger {
+	trigger := &cronjobApi.CronJobTrigger{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "CronJobTrigger",
+			APIVersion: "kubeless.io/v1beta1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels: map[string]string{
+				"created-by": "kubeless",
+			},
+		},
+		Spec: cronjobApi.CronJobTriggerSpec{
+			FunctionName: functionName,
+			Schedule:     schedule,
+			Payload:      payload,
+		},
+	}
+	if len(annotations) > 0 {
+		trigger.ObjectMeta.Annotations = annotations
+	}
+	return trigger
+}
+
+// getCronJobName returns the name of the CronJob created by the controller for the trigger
+func getCronJobName(trigger *cronjobApi.CronJobTrigger) string {
+	return "trigger-" + trigger.Spec.FunctionName
+}
+
+func isImmutable(trigger *cronjobApi.CronJobTrigger) bool {
+	return trigger.ObjectMeta.Annotations[immutableAnnotation] == "true"
+}
+
+// checkMutable returns an error if the trigger is immutable and the change has not been allowed
+func checkMutable(trigger *cronjobApi.CronJobTrigger, allowImmutable bool) error {
+	if isImmutable(trigger) && !allowImmutable {
+		return fmt.Errorf("Cronjob trigger %s in namespace %s is immutable. Use --allow-immutable to modify it anyway", trigger.ObjectMeta.Name, trigger.ObjectMeta.Namespace)
+	}
+	return nil
+}
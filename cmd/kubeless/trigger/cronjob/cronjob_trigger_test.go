@@ -0,0 +1,757 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	cronjobFake "github.com/kubeless/cronjob-trigger/pkg/client/clientset/versioned/fake"
+	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	v1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func TestGetConfigMapPayload(t *testing.T) {
+	cli := fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "payloads",
+			Namespace: "myns",
+		},
+		Data: map[string]string{
+			"nightly.json": `{"report": "daily"}`,
+			"message.txt":  "hello",
+		},
+	})
+
+	content, err := getConfigMapPayload(cli, "myns", "configmap://payloads/nightly.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload := parsePayloadContent(content); !reflect.DeepEqual(payload, map[string]interface{}{"report": "daily"}) {
+		t.Errorf("Unexpected payload %v", payload)
+	}
+
+	for _, ref := range []string{
+		"configmap://payloads",
+		"configmap://payloads/",
+		"configmap:///nightly.json",
+		"configmap://payloads/nightly.json/foo",
+		"configmap://payloads/message.txt",
+		"configmap://payloads/missing.json",
+		"configmap://missing/nightly.json",
+	} {
+		if _, err := getConfigMapPayload(cli, "myns", ref); err == nil {
+			t.Errorf("Expecting an error for the reference %q", ref)
+		}
+	}
+}
+
+func TestSchedules(t *testing.T) {
+	if err := validateSchedule("0 8 * * 1-5"); err != nil {
+		t.Error(err)
+	}
+	if err := validateSchedule("every night"); err == nil {
+		t.Error("Expecting an error for an invalid schedule")
+	}
+
+	trigger := buildCronJobTrigger("foo-trigger", "myns", "foo", "0 8 * * 1-5", nil, nil)
+	if trigger.Spec.Schedule != "0 8 * * 1-5" {
+		t.Errorf("Expecting the schedule in the spec, got %s", trigger.Spec.Schedule)
+	}
+	if name := getCronJobName(trigger); name != "trigger-foo" {
+		t.Errorf("Expecting the CronJob trigger-foo, got %s", name)
+	}
+}
+
+func TestTransformPayload(t *testing.T) {
+	payload := parsePayloadContent(`{"user": {"id": 42, "name": "foo"}, "items": [1, 2, 3]}`)
+
+	code, err := compilePayloadTransform(`{id: .user.id, total: (.items | add)}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transformed, err := transformPayload(code, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{"id": float64(42), "total": float64(6)}
+	if !reflect.DeepEqual(transformed, expected) {
+		t.Errorf("Expecting %v, got %v", expected, transformed)
+	}
+
+	if _, err := compilePayloadTransform(`{id: .user.id`); err == nil {
+		t.Error("Expecting an error for an expression that doesn't compile")
+	}
+
+	for _, expr := range []string{".items[]", "empty", `.user.name | tonumber`} {
+		code, err := compilePayloadTransform(expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := transformPayload(code, payload); err == nil {
+			t.Errorf("Expecting an error for %s", expr)
+		}
+	}
+}
+
+func TestParsePayloadGlob(t *testing.T) {
+	dir, err := ioutil.TempDir("", "payloads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{
+		"01-base.json":     `{"env": "dev", "db": {"host": "localhost", "port": 5432}, "tags": ["a"]}`,
+		"02-override.json": `{"env": "prod", "db": {"host": "db.prod"}, "tags": ["b"]}`,
+		"notes.txt":        "not a payload",
+	} {
+		if err := ioutil.WriteFile(dir+"/"+name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	payload, err := parsePayload("", dir+"/*.json", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"env":  "prod",
+		"db":   map[string]interface{}{"host": "db.prod", "port": float64(5432)},
+		"tags": []interface{}{"b"},
+	}
+	if !reflect.DeepEqual(payload, expected) {
+		t.Errorf("Expecting %v, got %v", expected, payload)
+	}
+
+	if _, err := parsePayload("", dir+"/*.yaml", false); err == nil {
+		t.Error("Expecting an error for a pattern that matches nothing")
+	}
+	payload, err = parsePayload("", dir+"/*.yaml", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(payload, map[string]interface{}{}) {
+		t.Errorf("Expecting an empty payload, got %v", payload)
+	}
+	if _, err := parsePayload("", dir+"/*", false); err == nil {
+		t.Error("Expecting an error for a matched file that is not JSON")
+	}
+}
+
+func TestResolvePayloadFlag(t *testing.T) {
+	dir, err := ioutil.TempDir("", "payload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "payload.json")
+	if err := ioutil.WriteFile(file, []byte(`{"foo": "bar"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A file whose name looks like inline JSON
+	jsonLikeFile := filepath.Join(dir, "{a}")
+	if err := ioutil.WriteFile(jsonLikeFile, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	tests := []struct {
+		name            string
+		payload         string
+		autodetect      bool
+		expectedPayload string
+		expectedFile    string
+		expectedErr     bool
+	}{
+		{name: "empty", payload: ""},
+		{name: "inline object", payload: `{"foo": "bar"}`, expectedPayload: `{"foo": "bar"}`},
+		{name: "inline list", payload: ` [1, 2]`, expectedPayload: ` [1, 2]`},
+		{name: "inline JSON named like a file", payload: "{a}", autodetect: true, expectedPayload: "{a}"},
+		{name: "missing file", payload: "missing.json", expectedPayload: "missing.json"},
+		{name: "directory", payload: dir, autodetect: true, expectedPayload: dir},
+		{name: "file", payload: file, expectedErr: true},
+		{name: "relative file", payload: "payload.json", expectedErr: true},
+		{name: "file with autodetect", payload: file, autodetect: true, expectedFile: file},
+	}
+	for _, test := range tests {
+		payload, payloadFile, err := resolvePayloadFlag(test.payload, test.autodetect)
+		if test.expectedErr {
+			if err == nil || !strings.Contains(err.Error(), "did you mean --payload-from-file") {
+				t.Errorf("%s: expecting a hint to use --payload-from-file, got %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if payload != test.expectedPayload || payloadFile != test.expectedFile {
+			t.Errorf("%s: expecting payload %q and file %q, got %q and %q", test.name, test.expectedPayload, test.expectedFile, payload, payloadFile)
+		}
+	}
+}
+
+func TestGetPayloadFlags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "payload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "payload.json")
+	if err := ioutil.WriteFile(file, []byte(`{"foo": "bar"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected payloadSource
+		err      bool
+	}{
+		{name: "no payload", args: []string{}},
+		{name: "inline", args: []string{"--payload", `{"a": 1}`}, expected: payloadSource{payload: `{"a": 1}`}},
+		{name: "file", args: []string{"-f", file, "--allow-empty-glob"}, expected: payloadSource{payloadFromFile: file, allowEmptyGlob: true}},
+		{name: "autodetect", args: []string{"-p", file, "--payload-autodetect"}, expected: payloadSource{payloadFromFile: file}},
+		{name: "merge base", args: []string{"--payload-merge-base", "base.json"}, expected: payloadSource{mergeBase: "base.json"}},
+		{name: "both", args: []string{"-p", `{"a": 1}`, "-f", file}, err: true},
+		{name: "autodetect and file", args: []string{"-p", file, "--payload-autodetect", "-f", file}, err: true},
+		{name: "file in --payload", args: []string{"-p", file}, err: true},
+	}
+	for _, test := range tests {
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		addPayloadFlags(flags)
+		if err := flags.Parse(test.args); err != nil {
+			t.Fatal(err)
+		}
+		source, err := getPayloadFlags(flags)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expecting an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if *source != test.expected {
+			t.Errorf("%s: expecting %+v, got %+v", test.name, test.expected, *source)
+		}
+	}
+
+	// The payload flags are the same in every command setting the payload of a trigger
+	for _, cmd := range []*cobra.Command{createCmd, updateCmd, replaceCmd} {
+		for _, flag := range []string{"payload", "payload-from-file", "payload-autodetect", "allow-empty-glob", "payload-merge-base", "schedule", "strict-schedule"} {
+			if cmd.Flags().Lookup(flag) == nil {
+				t.Errorf("%s: missing flag --%s", cmd.Name(), flag)
+			}
+		}
+	}
+}
+
+func TestGetScheduleFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		required bool
+		expected string
+		err      bool
+	}{
+		{name: "optional", args: []string{}, expected: ""},
+		{name: "required", args: []string{}, required: true, err: true},
+		// The controller only runs a trigger on the schedule of its spec
+		{name: "several", args: []string{"--schedule", "* * * * *", "--schedule", "@daily"}, err: true},
+		{name: "invalid", args: []string{"--schedule", "foo"}, err: true},
+		{name: "strict", args: []string{"--schedule", "0 0 1 * 1", "--strict-schedule"}, err: true},
+		{name: "not strict", args: []string{"--schedule", "0 0 1 * 1"}, expected: "0 0 1 * 1"},
+	}
+	for _, test := range tests {
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		addScheduleFlags(flags)
+		if err := flags.Parse(test.args); err != nil {
+			t.Fatal(err)
+		}
+		schedule, err := getScheduleFlags(flags, test.required)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expecting an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if schedule != test.expected {
+			t.Errorf("%s: expecting %q, got %q", test.name, test.expected, schedule)
+		}
+	}
+}
+
+func TestApplyPayloadMergeBase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "payload-base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	base := filepath.Join(dir, "base.json")
+	if err := ioutil.WriteFile(base, []byte(`{"source": "cron", "meta": {"team": "a", "env": "prod"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := map[string]interface{}{"meta": map[string]interface{}{"env": "dev"}, "id": "1"}
+	merged, err := applyPayloadMergeBase(base, payload, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"source": "cron",
+		"meta":   map[string]interface{}{"team": "a", "env": "dev"},
+		"id":     "1",
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Expecting %v, got %v", expected, merged)
+	}
+
+	// Without a payload the base is used
+	merged, err = applyPayloadMergeBase(base, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(merged, map[string]interface{}{"source": "cron", "meta": map[string]interface{}{"team": "a", "env": "prod"}}) {
+		t.Errorf("Unexpected payload %v", merged)
+	}
+
+	if _, err := applyPayloadMergeBase(base, []interface{}{"a"}, true); err == nil {
+		t.Error("Expecting an error merging a list")
+	}
+	list := filepath.Join(dir, "list.json")
+	if err := ioutil.WriteFile(list, []byte(`["a", "b"]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := applyPayloadMergeBase(list, payload, true); err == nil || !strings.Contains(err.Error(), "must be a JSON object") {
+		t.Errorf("Expecting an error for a base that is not an object, got %v", err)
+	}
+
+	// Remote bases are fetched
+	kubelessUtils.SetCacheEnabled(false)
+	defer kubelessUtils.SetCacheEnabled(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"source": "remote"}`)
+	}))
+	defer server.Close()
+	merged, err = applyPayloadMergeBase(server.URL+"/base.json", map[string]interface{}{"id": "1"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(merged, map[string]interface{}{"source": "remote", "id": "1"}) {
+		t.Errorf("Unexpected payload %v", merged)
+	}
+}
+
+func TestValidateStrictSchedule(t *testing.T) {
+	for _, schedule := range []string{"0 8 * * 1-5", "0 0 1 * *", "0 0 */2 * 1", "0 0 1 * ?", "@daily"} {
+		if err := validateStrictSchedule(schedule); err != nil {
+			t.Errorf("Unexpected error for %q: %v", schedule, err)
+		}
+	}
+	for _, schedule := range []string{"0 0 1 * 1", "0 0 1-7 * MON", "0 0 1,15 * 0,6"} {
+		err := validateStrictSchedule(schedule)
+		if err == nil || !strings.Contains(err.Error(), schedule) {
+			t.Errorf("Expecting an error for %q, got %v", schedule, err)
+		}
+	}
+}
+
+func TestCheckPayloadAssertions(t *testing.T) {
+	payload := parsePayloadContent(`{"env": "prod", "user": {"id": 42, "admin": false}, "items": [{"name": "a"}, {"name": "b"}]}`)
+	for _, test := range []struct {
+		assertions []string
+		valid      bool
+	}{
+		{[]string{".env", "{.user.id}"}, true},
+		{[]string{".env=prod", ".user.id=42", ".user.admin=false"}, true},
+		{[]string{"{.items[?(@.name==\"b\")].name}=b"}, true},
+		{[]string{".missing"}, false},
+		{[]string{".env=dev"}, false},
+		{[]string{".items[*].name=a"}, false},
+		{[]string{"{.env"}, false},
+		{[]string{"{.env}prod"}, false},
+	} {
+		err := checkPayloadAssertions(test.assertions, payload)
+		if test.valid && err != nil {
+			t.Errorf("Unexpected error for %v: %v", test.assertions, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("Expecting an error for %v", test.assertions)
+		}
+	}
+
+	// Every failure is reported
+	err := checkPayloadAssertions([]string{".env=dev", ".user.id=1"}, payload)
+	if err == nil || !strings.Contains(err.Error(), "found prod") || !strings.Contains(err.Error(), "found 42") {
+		t.Errorf("Expecting both failures to be reported, got %v", err)
+	}
+}
+
+func TestStripPayload(t *testing.T) {
+	raw := `{
+		"id": 42,
+		"name": "",
+		"owner": null,
+		"tags": [],
+		"meta": {"region": null, "labels": {}, "zone": "a"},
+		"items": [{"sku": "x", "note": null}, null, "", {"empty": ""}],
+		"nested": {"deep": {"value": null}}
+	}`
+	tests := []struct {
+		name     string
+		nulls    bool
+		empty    bool
+		expected string
+	}{
+		{
+			name:  "nulls",
+			nulls: true,
+			expected: `{
+				"id": 42,
+				"name": "",
+				"tags": [],
+				"meta": {"labels": {}, "zone": "a"},
+				"items": [{"sku": "x"}, null, "", {"empty": ""}],
+				"nested": {"deep": {}}
+			}`,
+		},
+		{
+			name:  "empty values",
+			empty: true,
+			expected: `{
+				"id": 42,
+				"owner": null,
+				"meta": {"region": null, "zone": "a"},
+				"items": [{"sku": "x", "note": null}, null, "", {}],
+				"nested": {"deep": {"value": null}}
+			}`,
+		},
+		{
+			name:  "nulls and empty values",
+			nulls: true,
+			empty: true,
+			expected: `{
+				"id": 42,
+				"meta": {"zone": "a"},
+				"items": [{"sku": "x"}, null, "", {}]
+			}`,
+		},
+	}
+	for _, test := range tests {
+		stripped := stripPayload(parsePayloadContent(raw), test.nulls, test.empty)
+		expected := parsePayloadContent(test.expected)
+		if !reflect.DeepEqual(stripped, expected) {
+			t.Errorf("%s: expecting %v, got %v", test.name, expected, stripped)
+		}
+	}
+
+	parseErr := parsePayloadContent("{")
+	if _, ok := stripPayload(parseErr, true, true).(error); !ok {
+		t.Error("Expecting parsing errors to be kept")
+	}
+}
+
+func TestGetEnvPayload(t *testing.T) {
+	environ := []string{
+		"PAYLOAD_ENV=prod",
+		"PAYLOAD_USER__ID=42",
+		"PAYLOAD_USER__ADMIN=true",
+		"PAYLOAD_USER__NAME=foo=bar",
+		"PAYLOAD_RATIO=0.5",
+		"OTHER_VAR=1",
+		"PATH=/usr/bin",
+	}
+	payload, err := getEnvPayload(environ, "PAYLOAD_", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"ENV":   "prod",
+		"RATIO": "0.5",
+		"USER": map[string]interface{}{
+			"ID":    "42",
+			"ADMIN": "true",
+			"NAME":  "foo=bar",
+		},
+	}
+	if !reflect.DeepEqual(payload, expected) {
+		t.Errorf("Expecting %v, got %v", expected, payload)
+	}
+
+	payload, err = getEnvPayload(environ, "PAYLOAD_", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = map[string]interface{}{
+		"ENV":   "prod",
+		"RATIO": float64(0.5),
+		"USER": map[string]interface{}{
+			"ID":    float64(42),
+			"ADMIN": true,
+			"NAME":  "foo=bar",
+		},
+	}
+	if !reflect.DeepEqual(payload, expected) {
+		t.Errorf("Expecting %v, got %v", expected, payload)
+	}
+
+	for _, env := range [][]string{
+		{"OTHER_VAR=1"},
+		{"PAYLOAD_USER=foo", "PAYLOAD_USER__ID=42"},
+		{"PAYLOAD_USER__ID=42", "PAYLOAD_USER=foo"},
+		{"PAYLOAD_USER____ID=42"},
+		{"PAYLOAD_USER__=42"},
+	} {
+		if _, err := getEnvPayload(env, "PAYLOAD_", false); err == nil {
+			t.Errorf("Expecting an error for %v", env)
+		}
+	}
+}
+
+func TestCreateCronJobTriggerIfNotExists(t *testing.T) {
+	existing := buildCronJobTrigger("nightly", "myns", "report", "0 2 * * *", nil, nil)
+	cli := cronjobFake.NewSimpleClientset(existing)
+	trigger := buildCronJobTrigger("nightly", "myns", "report", "0 4 * * *", nil, nil)
+
+	if _, err := createCronJobTrigger(cli, trigger, false); !k8sErrors.IsAlreadyExists(err) {
+		t.Errorf("Expecting an already exists error, got %v", err)
+	}
+	created, err := createCronJobTrigger(cli, trigger, true)
+	if err != nil || created {
+		t.Errorf("Expecting the existing trigger to be kept, got %v (%v)", created, err)
+	}
+	stored, err := cli.KubelessV1beta1().CronJobTriggers("myns").Get("nightly", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Spec.Schedule != "0 2 * * *" {
+		t.Errorf("The existing trigger should not be modified, got schedule %q", stored.Spec.Schedule)
+	}
+
+	trigger.ObjectMeta.Name = "hourly"
+	if created, err := createCronJobTrigger(cli, trigger, true); err != nil || !created {
+		t.Errorf("Expecting a new trigger to be created, got %v (%v)", created, err)
+	}
+}
+
+func TestUpdateCronJobTriggerConflict(t *testing.T) {
+	trigger := &cronjobApi.CronJobTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "myns", ResourceVersion: "1"},
+		Spec: cronjobApi.CronJobTriggerSpec{
+			FunctionName: "foo",
+			Schedule:     "0 2 * * *",
+		},
+	}
+	cli := cronjobFake.NewSimpleClientset(trigger)
+	conflict := k8sErrors.NewConflict(schema.GroupResource{Group: "kubeless.io", Resource: "cronjobtriggers"}, "nightly", fmt.Errorf("the object has been modified"))
+
+	// Someone else labels the trigger between the first read and the first update
+	gets := 0
+	cli.PrependReactor("get", "cronjobtriggers", func(action ktesting.Action) (bool, runtime.Object, error) {
+		gets++
+		if gets == 1 {
+			return true, trigger.DeepCopy(), nil
+		}
+		modified := trigger.DeepCopy()
+		modified.ResourceVersion = "2"
+		modified.Labels = map[string]string{"owner": "other"}
+		return true, modified, nil
+	})
+	updates := 0
+	cli.PrependReactor("update", "cronjobtriggers", func(action ktesting.Action) (bool, runtime.Object, error) {
+		updates++
+		if action.(ktesting.UpdateAction).GetObject().(*cronjobApi.CronJobTrigger).ResourceVersion == "1" {
+			return true, nil, conflict
+		}
+		return false, nil, nil
+	})
+
+	mutations := 0
+	updated, err := updateCronJobTrigger(cli, "nightly", "myns", func(t *cronjobApi.CronJobTrigger) (*cronjobApi.CronJobTrigger, error) {
+		mutations++
+		t.Spec.Schedule = "0 3 * * *"
+		return t, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mutations != 2 || updates != 2 {
+		t.Errorf("Expecting the changes to be applied twice, got %d mutations and %d updates", mutations, updates)
+	}
+	// The changes are applied over the concurrent ones instead of overwriting them
+	if updated.Spec.Schedule != "0 3 * * *" || updated.Labels["owner"] != "other" {
+		t.Errorf("Unexpected updated trigger %+v", updated)
+	}
+
+	// The number of attempts is bounded
+	cli = cronjobFake.NewSimpleClientset(trigger)
+	cli.PrependReactor("update", "cronjobtriggers", func(action ktesting.Action) (bool, runtime.Object, error) {
+		return true, nil, conflict
+	})
+	_, err = updateCronJobTrigger(cli, "nightly", "myns", func(t *cronjobApi.CronJobTrigger) (*cronjobApi.CronJobTrigger, error) {
+		return t, nil
+	})
+	if !k8sErrors.IsConflict(err) {
+		t.Errorf("Expecting a conflict error, got %v", err)
+	}
+
+	// Errors of the changes are not retried
+	mutations = 0
+	_, err = updateCronJobTrigger(cli, "nightly", "myns", func(t *cronjobApi.CronJobTrigger) (*cronjobApi.CronJobTrigger, error) {
+		mutations++
+		return nil, fmt.Errorf("invalid change")
+	})
+	if err == nil || mutations != 1 {
+		t.Errorf("Expecting the error of the changes to be returned, got %v after %d attempts", err, mutations)
+	}
+}
+
+func TestParsePayloadArrayElement(t *testing.T) {
+	dir, err := ioutil.TempDir("", "payloads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "payload.json")
+	if err := ioutil.WriteFile(file, []byte(`[{"id": 1}, {"id": 2}, "three"]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := parsePayloadArrayElement("", file, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(payload, map[string]interface{}{"id": float64(2)}) {
+		t.Errorf("Expecting the second element, got %v", payload)
+	}
+	payload, err = parsePayloadArrayElement(`[{"id": 1}, "two"]`, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload != "two" {
+		t.Errorf("Expecting the second element, got %v", payload)
+	}
+
+	for _, index := range []int{3, -1} {
+		if _, err := parsePayloadArrayElement("", file, index); err == nil || !strings.Contains(err.Error(), "out of range") {
+			t.Errorf("Expecting an out of range error for %d, got %v", index, err)
+		}
+	}
+	if _, err := parsePayloadArrayElement(`{"id": 1}`, "", 0); err == nil {
+		t.Error("Expecting an error for a payload that is not an array")
+	}
+}
+
+func TestParseDotEnv(t *testing.T) {
+	vars, err := parseDotEnv(`# Report settings
+REPORT=daily
+export USER_ID=42
+EMPTY=
+
+GREETING="Hello \"world\"\nBye" # a comment
+RAW='no \n escapes # here'
+URL=https://example.com/#anchor # the anchor is kept
+SPACED = value with spaces   
+REPORT=weekly
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"REPORT":   "weekly",
+		"USER_ID":  "42",
+		"EMPTY":    "",
+		"GREETING": "Hello \"world\"\nBye",
+		"RAW":      `no \n escapes # here`,
+		"URL":      "https://example.com/#anchor",
+		"SPACED":   "value with spaces",
+	}
+	if !reflect.DeepEqual(vars, expected) {
+		t.Errorf("Expecting %v, got %v", expected, vars)
+	}
+
+	for content, expectedErr := range map[string]string{
+		"FOO=1\nBAR":          "line 2: expecting KEY=value",
+		"1FOO=bar":            "line 1: expecting KEY=value",
+		`FOO="unterminated`:   "missing the closing \"",
+		`FOO="quoted" extra`:  "unexpected \"extra\"",
+		"FOO='single\nBAR=1'": "line 1: missing the closing '",
+	} {
+		if _, err := parseDotEnv(content); err == nil || !strings.Contains(err.Error(), expectedErr) {
+			t.Errorf("%q: expecting an error containing %q, got %v", content, expectedErr, err)
+		}
+	}
+}
+
+func TestParsePayloadDotEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "payload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := dir + "/report.env"
+	if err := ioutil.WriteFile(file, []byte("REPORT=daily\nUSER_ID=42\nADMIN=true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := parsePayload("", file, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{"REPORT": "daily", "USER_ID": "42", "ADMIN": "true"}
+	if !reflect.DeepEqual(payload, expected) {
+		t.Errorf("Expecting %v, got %v", expected, payload)
+	}
+
+	if !isDotEnvFile(file) || isDotEnvFile(dir+"/report.json") {
+		t.Error("Only files with the .env extension should be read as .env files")
+	}
+	expected = map[string]interface{}{"REPORT": "daily", "USER_ID": float64(42), "ADMIN": true}
+	if coerced := coerceDotEnvPayload(payload); !reflect.DeepEqual(coerced, expected) {
+		t.Errorf("Expecting %v, got %v", expected, coerced)
+	}
+}
@@ -17,11 +17,23 @@ limitations under the License.
 package cronjob
 
 import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	"github.com/kubeless/cronjob-trigger/pkg/client/clientset/versioned"
 	cronjobUtils "github.com/kubeless/cronjob-trigger/pkg/utils"
 	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
 var deleteCmd = &cobra.Command{
@@ -30,10 +42,25 @@ var deleteCmd = &cobra.Command{
 	Short: "delete a cronjob trigger from Kubeless",
 	Long:  `delete a cronjob trigger from Kubeless`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) != 1 {
+		all, err := cmd.Flags().GetBool("all")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		selector, err := cmd.Flags().GetString("selector")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		expired, err := cmd.Flags().GetBool("expired")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		bulk := all || selector != "" || expired
+		if bulk && len(args) != 0 {
+			logrus.Fatal("A cronjob trigger name cannot be provided with --all, --selector or --expired")
+		}
+		if !bulk && len(args) != 1 {
 			logrus.Fatal("Need exactly one argument - cronjob trigger name")
 		}
-		triggerName := args[0]
 
 		ns, err := cmd.Flags().GetString("namespace")
 		if err != nil {
@@ -43,18 +70,207 @@ var deleteCmd = &cobra.Command{
 			ns = kubelessUtils.GetDefaultNamespace()
 		}
 
-		kubelessClient, err := cronjobUtils.GetKubelessClientOutCluster()
+		cascade, err := cmd.Flags().GetString("cascade")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		policy, err := getPropagationPolicy(cascade)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		yes, err := cmd.Flags().GetBool("yes")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		wait, err := cmd.Flags().GetBool("wait")
 		if err != nil {
 			logrus.Fatal(err)
 		}
-		err = cronjobUtils.DeleteCronJobCustomResource(kubelessClient, triggerName, ns)
+		timeout, err := cmd.Flags().GetDuration("timeout")
 		if err != nil {
-			logrus.Fatalf("Failed to delete Cronjob trigger object %s in namespace %s. Error: %s", triggerName, ns, err)
+			logrus.Fatal(err)
+		}
+
+		concurrency, err := cmd.Flags().GetInt("concurrency")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if concurrency < 1 {
+			logrus.Fatal("The concurrency should be at least 1")
+		}
+
+		kubelessClient, err := kubelessUtils.GetCronJobTriggerClientOutCluster()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		var triggers []*cronjobApi.CronJobTrigger
+		if bulk {
+			triggersList, err := kubelessClient.KubelessV1beta1().CronJobTriggers(ns).List(metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			triggers = triggersList.Items
+			if expired {
+				triggers = filterExpiredRunOnce(triggers, time.Now())
+			}
+			if len(triggers) == 0 {
+				logrus.Infof("No cronjob triggers found in namespace %s", ns)
+				return
+			}
+			names := []string{}
+			for _, t := range triggers {
+				names = append(names, t.Name)
+			}
+			msg := fmt.Sprintf("The following cronjob triggers will be deleted from namespace %s: %s", ns, strings.Join(names, ", "))
+			if !yes {
+				if !canConfirm(cmd.InOrStdin()) {
+					kubelessUtils.FatalfWithCode(kubelessUtils.ExitUsage, "Refusing to delete the cronjob triggers %s from namespace %s without confirmation: the standard input is not a terminal. Use --yes to delete them", strings.Join(names, ", "), ns)
+				}
+				if !confirm(cmd.InOrStdin(), cmd.OutOrStdout(), msg) {
+					kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, "Aborted, no cronjob triggers have been deleted")
+				}
+			}
+		} else {
+			trigger, err := cronjobUtils.GetCronJobCustomResource(kubelessClient, args[0], ns)
+			if err != nil {
+				logrus.Fatalf("Failed to delete Cronjob trigger object %s in namespace %s. Error: %s", args[0], ns, err)
+			}
+			triggers = []*cronjobApi.CronJobTrigger{trigger}
+		}
+
+		cli := kubelessUtils.GetClientOutOfCluster()
+		var bulkErr error
+		if bulk {
+			results := runBulk(triggers, concurrency, func(w io.Writer, trigger *cronjobApi.CronJobTrigger) error {
+				if err := deleteCronJobTrigger(kubelessClient, cli, trigger, policy); err != nil {
+					return err
+				}
+				fmt.Fprintf(w, "Cronjob trigger %s deleted from namespace %s successfully!\n", trigger.Name, ns)
+				return nil
+			})
+			bulkErr = printBulkSummary(cmd.OutOrStdout(), "deleted", results)
+			// Only wait for the triggers that have been deleted
+			deleted := []*cronjobApi.CronJobTrigger{}
+			for i, r := range results {
+				if r.Err == nil {
+					deleted = append(deleted, triggers[i])
+				}
+			}
+			triggers = deleted
+		} else {
+			err = deleteCronJobTrigger(kubelessClient, cli, triggers[0], policy)
+			if err != nil {
+				logrus.Fatalf("Failed to delete Cronjob trigger object %s in namespace %s. Error: %s", triggers[0].Name, ns, err)
+			}
+			logrus.Infof("Cronjob trigger %s deleted from namespace %s successfully!", triggers[0].Name, ns)
+		}
+
+		if wait {
+			for _, trigger := range triggers {
+				name := trigger.Name
+				logrus.Infof("Waiting for cronjob trigger %s to be deleted...", name)
+				err = kubelessUtils.WaitForDeletion("cronjob trigger", name, timeout, func() (metav1.Object, error) {
+					return kubelessClient.KubelessV1beta1().CronJobTriggers(ns).Get(name, metav1.GetOptions{})
+				})
+				if err != nil {
+					logrus.Fatal(err)
+				}
+			}
+		}
+		if bulkErr != nil {
+			logrus.Fatal(bulkErr)
 		}
-		logrus.Infof("Cronjob trigger %s deleted from namespace %s successfully!", triggerName, ns)
 	},
 }
 
 func init() {
 	deleteCmd.Flags().StringP("namespace", "n", "", "Specify namespace of the Cronjob trigger")
+	deleteCmd.Flags().StringP("cascade", "", "background", "Deletion propagation policy for the backing CronJob and its Jobs. One of: background|foreground|orphan")
+	deleteCmd.Flags().StringP("selector", "l", "", "Delete the cronjob triggers matching the given label selector (e.g. -l key1=value1,key2=value2)")
+	deleteCmd.Flags().Bool("all", false, "Delete all the cronjob triggers in the namespace")
+	deleteCmd.Flags().Bool("expired", false, "Delete the triggers created with 'run-once' whose time has passed. It can be combined with --selector")
+	deleteCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation when deleting several cronjob triggers")
+	deleteCmd.Flags().Bool("wait", false, "Wait until the cronjob triggers and their finalizers are completely removed")
+	deleteCmd.Flags().Duration("timeout", time.Minute, "Maximum time to wait with --wait")
+	deleteCmd.Flags().Int("concurrency", 5, "Number of cronjob triggers deleted in parallel with --all or --selector")
+}
+
+// filterExpiredRunOnce returns the run-once triggers whose time has passed
+func filterExpiredRunOnce(triggers []*cronjobApi.CronJobTrigger, now time.Time) []*cronjobApi.CronJobTrigger {
+	res := []*cronjobApi.CronJobTrigger{}
+	for _, t := range triggers {
+		if isExpiredRunOnce(t, now) {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+func getPropagationPolicy(cascade string) (metav1.DeletionPropagation, error) {
+	switch cascade {
+	case "background":
+		return metav1.DeletePropagationBackground, nil
+	case "foreground":
+		return metav1.DeletePropagationForeground, nil
+	case "orphan":
+		return metav1.DeletePropagationOrphan, nil
+	default:
+		return "", fmt.Errorf("Invalid value for --cascade %q. Must be one of: background|foreground|orphan", cascade)
+	}
+}
+
+// canConfirm returns false if the input is a file or a pipe, where nobody can answer the confirmation
+func canConfirm(in io.Reader) bool {
+	f, ok := in.(*os.File)
+	return !ok || kubelessUtils.IsTerminal(f)
+}
+
+// confirm asks the user for confirmation, only an explicit yes is accepted
+func confirm(in io.Reader, out io.Writer, msg string) bool {
+	fmt.Fprintf(out, "%s\nDo you want to continue? [y/N] ", msg)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// deleteCronJobTrigger deletes the trigger using the given propagation policy. Unless the dependants
+// should be orphaned, the backing CronJob is explicitly deleted as well so its Jobs don't linger.
+func deleteCronJobTrigger(kubelessClient versioned.Interface, cli kubernetes.Interface, trigger *cronjobApi.CronJobTrigger, policy metav1.DeletionPropagation) error {
+	err := kubelessClient.KubelessV1beta1().CronJobTriggers(trigger.Namespace).Delete(trigger.Name, &metav1.DeleteOptions{
+		PropagationPolicy: &policy,
+	})
+	if err != nil {
+		return err
+	}
+	if policy == metav1.DeletePropagationOrphan {
+		return nil
+	}
+
+	return deleteOwnedCronJob(cli, trigger, getCronJobName(trigger), policy)
+}
+
+// deleteOwnedCronJob deletes the given CronJob only if it belongs to the trigger
+func deleteOwnedCronJob(cli kubernetes.Interface, trigger *cronjobApi.CronJobTrigger, cronJobName string, policy metav1.DeletionPropagation) error {
+	cronJob, err := cli.BatchV1beta1().CronJobs(trigger.Namespace).Get(cronJobName, metav1.GetOptions{})
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	for _, owner := range cronJob.ObjectMeta.OwnerReferences {
+		if owner.UID == trigger.UID {
+			err = cli.BatchV1beta1().CronJobs(trigger.Namespace).Delete(cronJobName, &metav1.DeleteOptions{
+				PropagationPolicy: &policy,
+			})
+			if err != nil && !k8sErrors.IsNotFound(err) {
+				return err
+			}
+			break
+		}
+	}
+	return nil
 }
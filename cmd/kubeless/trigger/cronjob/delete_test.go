@@ -0,0 +1,153 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	cronjobFake "github.com/kubeless/cronjob-trigger/pkg/client/clientset/versioned/fake"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDeleteCronJobTrigger(t *testing.T) {
+	newObjects := func() (*cronjobApi.CronJobTrigger, *batchv1beta1.CronJob) {
+		trigger := &cronjobApi.CronJobTrigger{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo-trigger",
+				Namespace: "myns",
+				UID:       types.UID("foo-uid"),
+			},
+			Spec: cronjobApi.CronJobTriggerSpec{
+				FunctionName: "foo",
+			},
+		}
+		cronJob := &batchv1beta1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "trigger-foo",
+				Namespace: "myns",
+				OwnerReferences: []metav1.OwnerReference{
+					{UID: types.UID("foo-uid")},
+				},
+			},
+		}
+		return trigger, cronJob
+	}
+
+	for _, test := range []struct {
+		cascade         string
+		expectedCronJob bool
+	}{
+		{"background", false},
+		{"foreground", false},
+		{"orphan", true},
+	} {
+		trigger, cronJob := newObjects()
+		kubelessClient := cronjobFake.NewSimpleClientset(trigger)
+		cli := fake.NewSimpleClientset(cronJob)
+		policy, err := getPropagationPolicy(test.cascade)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := deleteCronJobTrigger(kubelessClient, cli, trigger, policy); err != nil {
+			t.Fatalf("%s: %v", test.cascade, err)
+		}
+		if _, err := kubelessClient.KubelessV1beta1().CronJobTriggers("myns").Get("foo-trigger", metav1.GetOptions{}); err == nil {
+			t.Errorf("%s: expecting the trigger to be deleted", test.cascade)
+		}
+		_, err = cli.BatchV1beta1().CronJobs("myns").Get("trigger-foo", metav1.GetOptions{})
+		if exists := err == nil; exists != test.expectedCronJob {
+			t.Errorf("%s: expecting the CronJob to exist: %v", test.cascade, test.expectedCronJob)
+		}
+	}
+
+	if _, err := getPropagationPolicy("cascade"); err == nil {
+		t.Error("Expecting an error for an invalid cascade value")
+	}
+}
+
+func TestDeleteCronJobTriggerOwnedCronJob(t *testing.T) {
+	newTrigger := func(name, function, uid string) *cronjobApi.CronJobTrigger {
+		return &cronjobApi.CronJobTrigger{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "myns", UID: types.UID(uid)},
+			Spec:       cronjobApi.CronJobTriggerSpec{FunctionName: function, Schedule: "0 8 * * 1-5"},
+		}
+	}
+	cronJob := func(name, uid string) *batchv1beta1.CronJob {
+		return &batchv1beta1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "myns",
+				OwnerReferences: []metav1.OwnerReference{
+					{UID: types.UID(uid)},
+				},
+			},
+		}
+	}
+	foo := newTrigger("foo-trigger", "foo", "foo-uid")
+	bar := newTrigger("bar-trigger", "bar", "bar-uid")
+	kubelessClient := cronjobFake.NewSimpleClientset(foo, bar)
+	cli := fake.NewSimpleClientset(
+		cronJob("trigger-foo", "foo-uid"),
+		// Not owned by the trigger
+		cronJob("trigger-bar", "other-uid"),
+	)
+	for _, trigger := range []*cronjobApi.CronJobTrigger{foo, bar} {
+		if err := deleteCronJobTrigger(kubelessClient, cli, trigger, metav1.DeletePropagationBackground); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, expected := range map[string]bool{"trigger-foo": false, "trigger-bar": true} {
+		_, err := cli.BatchV1beta1().CronJobs("myns").Get(name, metav1.GetOptions{})
+		if exists := err == nil; exists != expected {
+			t.Errorf("Expecting the CronJob %s to exist: %v", name, expected)
+		}
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	var out bytes.Buffer
+	if !confirm(strings.NewReader("y\n"), &out, "Delete?") {
+		t.Error("Expecting confirmation for 'y'")
+	}
+	if confirm(strings.NewReader("\n"), &out, "Delete?") {
+		t.Error("Expecting no confirmation by default")
+	}
+	if !strings.Contains(out.String(), "Delete?") {
+		t.Errorf("Expecting the message to be printed, got %s", out.String())
+	}
+
+	if !canConfirm(strings.NewReader("y\n")) {
+		t.Error("Expecting a reader to be able to confirm")
+	}
+	file, err := ioutil.TempFile("", "answers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	if canConfirm(file) {
+		t.Error("Expecting a regular file not to be able to confirm")
+	}
+}
@@ -0,0 +1,202 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// payloadSchemaAnnotation contains the JSON Schema of the payload of a trigger
+const payloadSchemaAnnotation = "kubeless.io/payload-schema"
+
+const jsonSchemaVersion = "http://json-schema.org/draft-07/schema#"
+
+var inferSchemaCmd = &cobra.Command{
+	Use:   "infer-schema FLAG",
+	Short: "Generate a JSON Schema from a sample payload",
+	Long:  "Generate a JSON Schema skeleton from a sample payload. The result can be refined and stored in the " + payloadSchemaAnnotation + " annotation of a trigger",
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := cmd.Flags().GetString("file")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		schema, err := inferPayloadSchema(content)
+		if err != nil {
+			logrus.Fatalf("Unable to parse the payload %s. Error %s", file, err)
+		}
+
+		var res []byte
+		switch output {
+		case "json":
+			res, err = kubelessUtils.MarshalJSON(schema, "  ")
+		case "yaml":
+			res, err = yaml.Marshal(schema)
+		default:
+			logrus.Fatal("Output format needs to be yaml or json")
+		}
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(res))
+	},
+}
+
+func init() {
+	inferSchemaCmd.Flags().StringP("file", "f", "", "Sample payload. It can be a JSON or YAML file")
+	inferSchemaCmd.MarkFlagRequired("file")
+	inferSchemaCmd.Flags().StringP("output", "o", "json", "Output format. One of: json|yaml")
+}
+
+// inferPayloadSchema returns a JSON Schema describing the given sample payload
+func inferPayloadSchema(content []byte) (map[string]interface{}, error) {
+	var payload interface{}
+	if err := yaml.Unmarshal(content, &payload); err != nil {
+		return nil, err
+	}
+	schema := inferSchema(payload)
+	schema["$schema"] = jsonSchemaVersion
+	return schema, nil
+}
+
+func inferSchema(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties := map[string]interface{}{}
+		required := []string{}
+		for k, child := range v {
+			properties[k] = inferSchema(child)
+			required = append(required, k)
+		}
+		sort.Strings(required)
+		schema := map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	case []interface{}:
+		schema := map[string]interface{}{"type": "array"}
+		if len(v) > 0 {
+			items := inferSchema(v[0])
+			for _, child := range v[1:] {
+				items = mergeSchemas(items, inferSchema(child))
+			}
+			schema["items"] = items
+		}
+		return schema
+	case string:
+		return map[string]interface{}{"type": "string"}
+	case bool:
+		return map[string]interface{}{"type": "boolean"}
+	case float64:
+		if v == math.Trunc(v) {
+			return map[string]interface{}{"type": "integer"}
+		}
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{"type": "null"}
+	}
+}
+
+// mergeSchemas returns a schema that accepts the values of both schemas.
+// Objects keep all the properties and require only the ones present in both.
+func mergeSchemas(a, b map[string]interface{}) map[string]interface{} {
+	if reflect.DeepEqual(a, b) {
+		return a
+	}
+	if alternatives, ok := a["anyOf"].([]interface{}); ok {
+		for _, alt := range alternatives {
+			if reflect.DeepEqual(alt, b) {
+				return a
+			}
+		}
+		return map[string]interface{}{"anyOf": append(alternatives, b)}
+	}
+	typeA, typeB := a["type"], b["type"]
+	switch {
+	case typeA == "object" && typeB == "object":
+		propsA := a["properties"].(map[string]interface{})
+		propsB := b["properties"].(map[string]interface{})
+		properties := map[string]interface{}{}
+		for k, p := range propsA {
+			properties[k] = p
+		}
+		for k, p := range propsB {
+			if current, ok := properties[k]; ok {
+				properties[k] = mergeSchemas(current.(map[string]interface{}), p.(map[string]interface{}))
+			} else {
+				properties[k] = p
+			}
+		}
+		requiredB := map[string]bool{}
+		if req, ok := b["required"].([]string); ok {
+			for _, k := range req {
+				requiredB[k] = true
+			}
+		}
+		required := []string{}
+		if req, ok := a["required"].([]string); ok {
+			for _, k := range req {
+				if requiredB[k] {
+					required = append(required, k)
+				}
+			}
+		}
+		schema := map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	case typeA == "array" && typeB == "array":
+		itemsA, okA := a["items"].(map[string]interface{})
+		itemsB, okB := b["items"].(map[string]interface{})
+		if !okA {
+			return b
+		}
+		if !okB {
+			return a
+		}
+		return map[string]interface{}{"type": "array", "items": mergeSchemas(itemsA, itemsB)}
+	case (typeA == "integer" && typeB == "number") || (typeA == "number" && typeB == "integer"):
+		return map[string]interface{}{"type": "number"}
+	}
+	return map[string]interface{}{"anyOf": []interface{}{a, b}}
+}
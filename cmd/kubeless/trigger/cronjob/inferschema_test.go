@@ -0,0 +1,79 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestInferPayloadSchema(t *testing.T) {
+	payload := `{
+  "name": "report",
+  "count": 3,
+  "ratio": 0.5,
+  "enabled": true,
+  "owner": null,
+  "tags": ["a", "b"],
+  "targets": [
+    {"id": 1, "email": "a@example.com"},
+    {"id": 2.5}
+  ],
+  "options": {"retries": 2}
+}`
+	schema, err := inferPayloadSchema([]byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"$schema":"http://json-schema.org/draft-07/schema#",` +
+		`"properties":{` +
+		`"count":{"type":"integer"},` +
+		`"enabled":{"type":"boolean"},` +
+		`"name":{"type":"string"},` +
+		`"options":{"properties":{"retries":{"type":"integer"}},"required":["retries"],"type":"object"},` +
+		`"owner":{"type":"null"},` +
+		`"ratio":{"type":"number"},` +
+		`"tags":{"items":{"type":"string"},"type":"array"},` +
+		`"targets":{"items":{"properties":{"email":{"type":"string"},"id":{"type":"number"}},"required":["id"],"type":"object"},"type":"array"}},` +
+		`"required":["count","enabled","name","options","owner","ratio","tags","targets"],"type":"object"}`
+	if string(raw) != expected {
+		t.Errorf("Expecting:\n%s\ngot:\n%s", expected, string(raw))
+	}
+}
+
+func TestInferSchemaMixedArray(t *testing.T) {
+	schema, err := inferPayloadSchema([]byte(`{"values": [1, "two", 3, []]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := json.Marshal(schema["properties"].(map[string]interface{})["values"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"items":{"anyOf":[{"type":"integer"},{"type":"string"},{"type":"array"}]},"type":"array"}`
+	if string(raw) != expected {
+		t.Errorf("Expecting %s, got %s", expected, string(raw))
+	}
+
+	if _, err := inferPayloadSchema([]byte("{not json")); err == nil {
+		t.Error("Expecting an error for an invalid payload")
+	}
+}
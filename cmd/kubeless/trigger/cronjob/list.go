@@ -19,14 +19,18 @@ package cronjob
 import (
 	"fmt"
 	"io"
+	"sync"
+	"text/template"
+	"time"
 
 	"github.com/gosuri/uitable"
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
 	"github.com/kubeless/cronjob-trigger/pkg/client/clientset/versioned"
-	cronjobUtils "github.com/kubeless/cronjob-trigger/pkg/utils"
 	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
 var listCmd = &cobra.Command{
@@ -35,20 +39,43 @@ var listCmd = &cobra.Command{
 	Short:   "list all Cronjob triggers deployed to Kubeless",
 	Long:    `list all Cronjob triggers deployed to Kubeless`,
 	Run: func(cmd *cobra.Command, args []string) {
-		ns, err := cmd.Flags().GetString("namespace")
+		ns, err := kubelessUtils.GetNamespaceOrAll(cmd.Flags())
 		if err != nil {
-			logrus.Fatal(err.Error())
-		}
-		if ns == "" {
-			ns = kubelessUtils.GetDefaultNamespace()
+			logrus.Fatal(err)
 		}
 
-		kubelessClient, err := cronjobUtils.GetKubelessClientOutCluster()
+		kubelessClient, err := kubelessUtils.GetCronJobTriggerClientOutCluster()
 		if err != nil {
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
 
-		if err := doList(cmd.OutOrStdout(), kubelessClient, ns); err != nil {
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		tmpl, err := kubelessUtils.GetOutputTemplate(cmd.Flags(), output)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		sortBy, err := cmd.Flags().GetString("sort-by")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		concurrency, err := cmd.Flags().GetInt("concurrency")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if concurrency < 1 {
+			logrus.Fatal("The concurrency should be at least 1")
+		}
+
+		var cli kubernetes.Interface
+		if output == "wide" {
+			cli = kubelessUtils.GetClientOutOfCluster()
+		}
+
+		if err := doList(cmd.OutOrStdout(), kubelessClient, cli, ns, output, tmpl, sortBy, concurrency); err != nil {
 			logrus.Fatal(err.Error())
 		}
 	},
@@ -56,20 +83,88 @@ var listCmd = &cobra.Command{
 
 func init() {
 	listCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function")
+	kubelessUtils.AddAllNamespacesFlag(listCmd.Flags(), "List the cronjob triggers of all the namespaces")
+	listCmd.Flags().StringP("output", "o", "", "Output format. One of: json|yaml|template|wide")
+	listCmd.Flags().Int("concurrency", 5, "Number of triggers whose CronJobs are fetched in parallel with the wide output")
+	kubelessUtils.AddTemplateFlags(listCmd.Flags())
+	kubelessUtils.AddSortByFlag(listCmd.Flags())
 }
 
-func doList(w io.Writer, kubelessClient versioned.Interface, ns string) error {
+func doList(w io.Writer, kubelessClient versioned.Interface, cli kubernetes.Interface, ns, output string, tmpl *template.Template, sortBy string, concurrency int) error {
 	triggersList, err := kubelessClient.KubelessV1beta1().CronJobTriggers(ns).List(metav1.ListOptions{})
 	if err != nil {
 		return err
 	}
+	if err := kubelessUtils.SortObjects(triggersList.Items, sortBy); err != nil {
+		return err
+	}
+	if output != "" && output != "wide" {
+		return kubelessUtils.PrintObjects(w, output, tmpl, triggersList.Items)
+	}
 	table := uitable.New()
 	table.MaxColWidth = 50
 	table.Wrap = true
-	table.AddRow("NAME", "NAMESPACE", "SCHEDULE", "FUNCTION NAME")
-	for _, trigger := range triggersList.Items {
-		table.AddRow(trigger.Name, trigger.Namespace, trigger.Spec.Schedule, trigger.Spec.FunctionName)
+	if output == "wide" {
+		statuses := getCronJobStatuses(cli, triggersList.Items, concurrency)
+		table.AddRow("NAME", "NAMESPACE", "SCHEDULE", "FUNCTION NAME", "SUSPENDED", "ACTIVE", "LAST SCHEDULE", "MESSAGE")
+		for i, trigger := range triggersList.Items {
+			st := statuses[i]
+			table.AddRow(trigger.Name, trigger.Namespace, trigger.Spec.Schedule, trigger.Spec.FunctionName, st.Suspended, st.Active, st.LastSchedule, st.Message)
+		}
+	} else {
+		table.AddRow("NAME", "NAMESPACE", "SCHEDULE", "FUNCTION NAME")
+		for _, trigger := range triggersList.Items {
+			table.AddRow(trigger.Name, trigger.Namespace, trigger.Spec.Schedule, trigger.Spec.FunctionName)
+		}
 	}
 	fmt.Fprintln(w, table)
 	return nil
 }
+
+// cronJobStatus summarizes the state of the CronJobs of a trigger
+type cronJobStatus struct {
+	Suspended    bool
+	Active       int
+	LastSchedule string
+	Message      string
+}
+
+// getCronJobStatuses fetches the CronJobs of each trigger using a pool of workers.
+// The statuses are returned in the same order as the triggers. A trigger whose
+// CronJobs can't be fetched has the error in its message.
+func getCronJobStatuses(cli kubernetes.Interface, triggers []*cronjobApi.CronJobTrigger, concurrency int) []cronJobStatus {
+	statuses := make([]cronJobStatus, len(triggers))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				statuses[i] = getCronJobStatus(cli, triggers[i])
+			}
+		}()
+	}
+	for i := range triggers {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return statuses
+}
+
+func getCronJobStatus(cli kubernetes.Interface, trigger *cronjobApi.CronJobTrigger) cronJobStatus {
+	status := cronJobStatus{LastSchedule: "<none>"}
+	name := getCronJobName(trigger)
+	cronJob, err := cli.BatchV1beta1().CronJobs(trigger.Namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		status.Message = fmt.Sprintf("Unable to get the CronJob %s: %v", name, err)
+		return status
+	}
+	status.Suspended = cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend
+	status.Active = len(cronJob.Status.Active)
+	if cronJob.Status.LastScheduleTime != nil {
+		status.LastSchedule = cronJob.Status.LastScheduleTime.UTC().Format(time.RFC3339)
+	}
+	return status
+}
@@ -0,0 +1,96 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	cronjobFake "github.com/kubeless/cronjob-trigger/pkg/client/clientset/versioned/fake"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestListWide(t *testing.T) {
+	suspended := true
+	lastSchedule := metav1.NewTime(time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC))
+	triggers := []*cronjobApi.CronJobTrigger{}
+	cronJobs := []*batchv1beta1.CronJob{}
+	for i := 0; i < 20; i++ {
+		fn := fmt.Sprintf("func%02d", i)
+		triggers = append(triggers, &cronjobApi.CronJobTrigger{
+			ObjectMeta: metav1.ObjectMeta{Name: fn + "-trigger", Namespace: "myns"},
+			Spec:       cronjobApi.CronJobTriggerSpec{FunctionName: fn, Schedule: "* * * * *"},
+		})
+		// The CronJob of the last trigger is missing
+		if i < 19 {
+			cronJobs = append(cronJobs, &batchv1beta1.CronJob{
+				ObjectMeta: metav1.ObjectMeta{Name: "trigger-" + fn, Namespace: "myns"},
+			})
+		}
+	}
+	cronJobs[0].Spec.Suspend = &suspended
+	cronJobs[0].Status.Active = []v1.ObjectReference{{Name: "trigger-func00-1"}}
+	cronJobs[0].Status.LastScheduleTime = &lastSchedule
+
+	kubelessClient := cronjobFake.NewSimpleClientset()
+	for _, trigger := range triggers {
+		if _, err := kubelessClient.KubelessV1beta1().CronJobTriggers("myns").Create(trigger); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cli := fake.NewSimpleClientset()
+	for _, cronJob := range cronJobs {
+		if _, err := cli.BatchV1beta1().CronJobs("myns").Create(cronJob); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	statuses := getCronJobStatuses(cli, triggers, 4)
+	if len(statuses) != len(triggers) {
+		t.Fatalf("Expecting %d statuses, got %d", len(triggers), len(statuses))
+	}
+	if !statuses[0].Suspended || statuses[0].Active != 1 || statuses[0].LastSchedule != "2019-01-02T03:04:05Z" {
+		t.Errorf("Unexpected status %+v", statuses[0])
+	}
+	for i := 1; i < 19; i++ {
+		if statuses[i].Suspended || statuses[i].Active != 0 || statuses[i].LastSchedule != "<none>" || statuses[i].Message != "" {
+			t.Errorf("Unexpected status of %s: %+v", triggers[i].Name, statuses[i])
+		}
+	}
+	if !strings.Contains(statuses[19].Message, "trigger-func19") {
+		t.Errorf("Expecting an error for the missing CronJob, got %+v", statuses[19])
+	}
+
+	var buf bytes.Buffer
+	if err := doList(&buf, kubelessClient, cli, "myns", "wide", nil, "", 4); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if !strings.Contains(lines[0], "LAST SCHEDULE") {
+		t.Errorf("Unexpected header %s", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "func00-trigger") || !strings.Contains(lines[1], "2019-01-02T03:04:05Z") {
+		t.Errorf("Unexpected row %s", lines[1])
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	cronjobUtils "github.com/kubeless/cronjob-trigger/pkg/utils"
+	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var patchCmd = &cobra.Command{
+	Use:   "patch <cronjob_trigger_name> -p <patch>",
+	Short: "patch a cronjob trigger",
+	Long: `patch a cronjob trigger
+
+The patch is a JSON merge patch (--type merge, the default) or a JSON patch (--type json).
+For example:
+
+  kubeless trigger cronjob patch nightly -p '{"spec":{"schedule":"0 3 * * *"}}'
+  kubeless trigger cronjob patch nightly --type json -p '[{"op":"remove","path":"/spec/payload"}]'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			logrus.Fatal("Need exactly one argument - cronjob trigger name")
+		}
+		triggerName := args[0]
+
+		ns, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if ns == "" {
+			ns = kubelessUtils.GetDefaultNamespace()
+		}
+
+		patch, err := cmd.Flags().GetString("patch")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if patch == "" {
+			logrus.Fatal("The patch is required, use --patch (-p)")
+		}
+
+		patchTypeName, err := cmd.Flags().GetString("type")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		patchType, err := getPatchType(patchTypeName)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		allowImmutable, err := cmd.Flags().GetBool("allow-immutable")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		dryrun, err := cmd.Flags().GetBool("dryrun")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		cronJobClient, err := kubelessUtils.GetCronJobTriggerClientOutCluster()
+		if err != nil {
+			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
+		}
+
+		// The patch is applied locally to validate the result before sending it. On conflicts
+		// it's applied again to the latest version of the trigger.
+		mutate := func(trigger *cronjobApi.CronJobTrigger) (*cronjobApi.CronJobTrigger, error) {
+			if err := checkMutable(trigger, allowImmutable); err != nil {
+				return nil, err
+			}
+			return patchCronJobTrigger(trigger, []byte(patch), patchType)
+		}
+
+		if dryrun {
+			cronJobTrigger, err := cronjobUtils.GetCronJobCustomResource(cronJobClient, triggerName, ns)
+			if err != nil {
+				logrus.Fatalf("Unable to find Cronjob trigger %s in namespace %s. Error %s", triggerName, ns, err)
+			}
+			patched, err := mutate(cronJobTrigger)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			res, err := kubelessUtils.DryRunFmt(output, patched)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			fmt.Println(res)
+			return
+		}
+
+		_, err = updateCronJobTrigger(cronJobClient, triggerName, ns, mutate)
+		if err != nil {
+			logrus.Fatalf("Failed to patch cronjob trigger object %s in namespace %s. Error: %s", triggerName, ns, err)
+		}
+		logrus.Infof("Cronjob trigger %s patched in namespace %s successfully!", triggerName, ns)
+	},
+}
+
+func init() {
+	patchCmd.Flags().StringP("namespace", "n", "", "Specify namespace of the cronjob trigger")
+	patchCmd.Flags().StringP("patch", "p", "", "The patch to apply to the cronjob trigger, in JSON")
+	patchCmd.Flags().String("type", "merge", "The type of patch. One of: merge|json. Custom resources don't support strategic merge patches")
+	patchCmd.Flags().Bool("allow-immutable", false, "Allow to patch a trigger created with --immutable")
+	patchCmd.Flags().Bool("dryrun", false, "Output the patched cronjob trigger without modifying it")
+	patchCmd.Flags().StringP("output", "o", "yaml", "Output format")
+}
+
+func getPatchType(name string) (types.PatchType, error) {
+	switch name {
+	case "merge":
+		return types.MergePatchType, nil
+	case "json":
+		return types.JSONPatchType, nil
+	case "strategic":
+		// Like kubectl, since the API server rejects them for custom resources
+		return "", fmt.Errorf("Strategic merge patches are not supported by custom resources like cronjob triggers. Use --type merge or --type json")
+	default:
+		return "", fmt.Errorf("Invalid value for --type %q. Must be one of: merge|json", name)
+	}
+}
+
+// patchCronJobTrigger returns the result of applying the patch to the trigger. The result
+// should keep the name and namespace of the trigger and have a valid schedule.
+func patchCronJobTrigger(trigger *cronjobApi.CronJobTrigger, patch []byte, patchType types.PatchType) (*cronjobApi.CronJobTrigger, error) {
+	original, err := json.Marshal(trigger)
+	if err != nil {
+		return nil, err
+	}
+	var result []byte
+	if patchType == types.JSONPatchType {
+		result, err = kubelessUtils.ApplyJSONPatch(original, patch)
+	} else {
+		result, err = kubelessUtils.ApplyMergePatch(original, patch)
+	}
+	if err != nil {
+		return nil, err
+	}
+	patched := &cronjobApi.CronJobTrigger{}
+	if err := json.Unmarshal(result, patched); err != nil {
+		return nil, fmt.Errorf("The patched cronjob trigger is not valid: %v", err)
+	}
+	if patched.Name != trigger.Name || patched.Namespace != trigger.Namespace {
+		return nil, fmt.Errorf("The name and namespace of a cronjob trigger can't be patched")
+	}
+	if patched.Spec.FunctionName == "" {
+		return nil, fmt.Errorf("The patched cronjob trigger should have a function")
+	}
+	if err := validateSchedule(patched.Spec.Schedule); err != nil {
+		return nil, err
+	}
+	return patched, nil
+}
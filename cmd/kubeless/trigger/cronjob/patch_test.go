@@ -0,0 +1,84 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"testing"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestPatchCronJobTrigger(t *testing.T) {
+	trigger := &cronjobApi.CronJobTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "myns"},
+		Spec: cronjobApi.CronJobTriggerSpec{
+			FunctionName: "foo",
+			Schedule:     "0 2 * * *",
+			Payload:      map[string]interface{}{"report": "daily"},
+		},
+	}
+
+	patched, err := patchCronJobTrigger(trigger, []byte(`{"spec":{"schedule":"0 3 * * *","payload":null}}`), types.MergePatchType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.Spec.Schedule != "0 3 * * *" || patched.Spec.Payload != nil || patched.Spec.FunctionName != "foo" {
+		t.Errorf("Unexpected patched trigger %+v", patched.Spec)
+	}
+	if trigger.Spec.Schedule != "0 2 * * *" {
+		t.Error("The original trigger should not be modified")
+	}
+
+	patched, err = patchCronJobTrigger(trigger, []byte(`[{"op":"replace","path":"/spec/function-name","value":"bar"}]`), types.JSONPatchType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.Spec.FunctionName != "bar" {
+		t.Errorf("Expecting the function bar, got %s", patched.Spec.FunctionName)
+	}
+
+	for _, test := range []struct {
+		patch     string
+		patchType types.PatchType
+	}{
+		{`{"spec":{"schedule":"every day"}}`, types.MergePatchType},
+		{`{"spec":`, types.MergePatchType},
+		{`{"metadata":{"name":"other"}}`, types.MergePatchType},
+		{`{"spec":{"function-name":null}}`, types.MergePatchType},
+		{`[{"op":"replace","path":"/spec/schedule","value":"61 * * * *"}]`, types.JSONPatchType},
+	} {
+		if _, err := patchCronJobTrigger(trigger, []byte(test.patch), test.patchType); err == nil {
+			t.Errorf("Expecting an error for the patch %s", test.patch)
+		}
+	}
+}
+
+func TestGetPatchType(t *testing.T) {
+	for name, expected := range map[string]types.PatchType{"merge": types.MergePatchType, "json": types.JSONPatchType} {
+		patchType, err := getPatchType(name)
+		if err != nil || patchType != expected {
+			t.Errorf("Expecting %s for %s, got %s (%v)", expected, name, patchType, err)
+		}
+	}
+	for _, name := range []string{"strategic", "apply"} {
+		if _, err := getPatchType(name); err == nil {
+			t.Errorf("Expecting an error for %s", name)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	"github.com/kubeless/cronjob-trigger/pkg/client/clientset/versioned"
+	cronjobUtils "github.com/kubeless/cronjob-trigger/pkg/utils"
+	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// pauseReconcileWarning is shown after pausing triggers since the controller doesn't know about the pause
+const pauseReconcileWarning = "The pause is lost when the cronjob trigger controller reconciles the trigger: updating the trigger or restarting the controller resumes its schedule"
+
+var pauseCmd = &cobra.Command{
+	Use:   "pause <cronjob_trigger_name>",
+	Short: "pause the schedule of a cronjob trigger",
+	Long: `pause the schedule of a cronjob trigger
+
+The CronJob of the trigger is suspended so no new Jobs are started until the trigger
+is resumed. Jobs that are already running are not stopped.
+
+The trigger doesn't store the pause: the cronjob trigger controller rewrites the CronJob
+each time it reconciles the trigger, so updating the trigger or restarting the controller
+resumes the schedule.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runSetSuspended(cmd, args, true)
+	},
+}
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume <cronjob_trigger_name>",
+	Short: "resume the schedule of a paused cronjob trigger",
+	Long:  `resume the schedule of a paused cronjob trigger`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runSetSuspended(cmd, args, false)
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{pauseCmd, resumeCmd} {
+		action := "Pause"
+		if cmd == resumeCmd {
+			action = "Resume"
+		}
+		cmd.Flags().StringP("namespace", "n", "", "Specify namespace of the Cronjob trigger")
+		cmd.Flags().StringP("selector", "l", "", action+" the cronjob triggers matching the given label selector (e.g. -l key1=value1,key2=value2)")
+		cmd.Flags().Bool("all", false, action+" all the cronjob triggers in the namespace")
+		cmd.Flags().Int("concurrency", 5, "Number of cronjob triggers modified in parallel with --all or --selector")
+	}
+}
+
+func runSetSuspended(cmd *cobra.Command, args []string, suspend bool) {
+	all, err := cmd.Flags().GetBool("all")
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	selector, err := cmd.Flags().GetString("selector")
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	bulk := all || selector != ""
+	if bulk && len(args) != 0 {
+		logrus.Fatal("A cronjob trigger name cannot be provided with --all or --selector")
+	}
+	if !bulk && len(args) != 1 {
+		logrus.Fatal("Need exactly one argument - cronjob trigger name")
+	}
+
+	concurrency, err := cmd.Flags().GetInt("concurrency")
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if concurrency < 1 {
+		logrus.Fatal("The concurrency should be at least 1")
+	}
+
+	ns, err := cmd.Flags().GetString("namespace")
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if ns == "" {
+		ns = kubelessUtils.GetDefaultNamespace()
+	}
+
+	kubelessClient, err := kubelessUtils.GetCronJobTriggerClientOutCluster()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	triggers, err := getTargetTriggers(kubelessClient, ns, args, selector, bulk)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if len(triggers) == 0 {
+		logrus.Infof("No cronjob triggers found in namespace %s", ns)
+		return
+	}
+
+	cli := kubelessUtils.GetClientOutOfCluster()
+	if !bulk {
+		if err := setTriggerSuspended(cli, triggers[0], suspend); err != nil {
+			logrus.Fatal(err)
+		}
+		logrus.Infof("Cronjob trigger %s in namespace %s is now %s", triggers[0].Name, ns, getSuspendedState(suspend))
+		if suspend {
+			logrus.Warn(pauseReconcileWarning)
+		}
+		return
+	}
+
+	action := "resumed"
+	if suspend {
+		action = "paused"
+	}
+	results := runBulk(triggers, concurrency, func(w io.Writer, trigger *cronjobApi.CronJobTrigger) error {
+		if err := setTriggerSuspended(cli, trigger, suspend); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Cronjob trigger %s in namespace %s is now %s\n", trigger.Name, ns, getSuspendedState(suspend))
+		return nil
+	})
+	if suspend {
+		logrus.Warn(pauseReconcileWarning)
+	}
+	if err := printBulkSummary(cmd.OutOrStdout(), action, results); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+// getTargetTriggers returns the trigger with the given name or, for bulk operations,
+// the triggers of the namespace matching the selector
+func getTargetTriggers(kubelessClient versioned.Interface, ns string, args []string, selector string, bulk bool) ([]*cronjobApi.CronJobTrigger, error) {
+	if bulk {
+		triggersList, err := kubelessClient.KubelessV1beta1().CronJobTriggers(ns).List(metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, err
+		}
+		return triggersList.Items, nil
+	}
+	trigger, err := cronjobUtils.GetCronJobCustomResource(kubelessClient, args[0], ns)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to find Cronjob trigger %s in namespace %s. Error: %s", args[0], ns, err)
+	}
+	return []*cronjobApi.CronJobTrigger{trigger}, nil
+}
+
+// setTriggerSuspended suspends or resumes the CronJob of the trigger. The trigger spec
+// doesn't have a suspend field so the CronJob is modified directly, and the controller
+// overwrites it the next time it reconciles the trigger.
+func setTriggerSuspended(cli kubernetes.Interface, trigger *cronjobApi.CronJobTrigger, suspend bool) error {
+	found, err := setCronJobsSuspended(cli, trigger.Namespace, []string{getCronJobName(trigger)}, suspend)
+	if err != nil {
+		return fmt.Errorf("Unable to update the schedule of the Cronjob trigger %s: %v", trigger.Name, err)
+	}
+	if found == 0 {
+		return fmt.Errorf("Unable to find the CronJob of the Cronjob trigger %s, check the logs of the cronjob trigger controller", trigger.Name)
+	}
+	return nil
+}
+
+func getSuspendedState(suspend bool) string {
+	if suspend {
+		return "paused"
+	}
+	return "active"
+}
@@ -0,0 +1,99 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"testing"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	cronjobFake "github.com/kubeless/cronjob-trigger/pkg/client/clientset/versioned/fake"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSetTriggerSuspended(t *testing.T) {
+	trigger := &cronjobApi.CronJobTrigger{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo-trigger",
+			Namespace: "myns",
+		},
+		Spec: cronjobApi.CronJobTriggerSpec{
+			FunctionName: "foo",
+			Schedule:     "0 8 * * 1-5",
+		},
+	}
+	cli := fake.NewSimpleClientset(
+		&batchv1beta1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: "trigger-foo", Namespace: "myns"}},
+	)
+
+	if err := setTriggerSuspended(cli, trigger, true); err != nil {
+		t.Fatal(err)
+	}
+	if !isSuspended(t, cli, "trigger-foo") {
+		t.Error("The CronJob trigger-foo should be suspended")
+	}
+
+	if err := setTriggerSuspended(cli, trigger, false); err != nil {
+		t.Fatal(err)
+	}
+	if isSuspended(t, cli, "trigger-foo") {
+		t.Error("The CronJob trigger-foo should have been resumed")
+	}
+}
+
+func TestSetTriggerSuspendedMissingCronJob(t *testing.T) {
+	trigger := &cronjobApi.CronJobTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo-trigger", Namespace: "myns"},
+		Spec:       cronjobApi.CronJobTriggerSpec{FunctionName: "foo"},
+	}
+	if err := setTriggerSuspended(fake.NewSimpleClientset(), trigger, true); err == nil {
+		t.Error("Expecting an error if the trigger has no CronJob")
+	}
+}
+
+func TestGetTargetTriggers(t *testing.T) {
+	trigger := func(name string, labels map[string]string) *cronjobApi.CronJobTrigger {
+		return &cronjobApi.CronJobTrigger{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "myns", Labels: labels},
+		}
+	}
+	kubelessClient := cronjobFake.NewSimpleClientset(
+		trigger("foo", map[string]string{"team": "a"}),
+		trigger("bar", map[string]string{"team": "b"}),
+	)
+
+	triggers, err := getTargetTriggers(kubelessClient, "myns", []string{"foo"}, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(triggers) != 1 || triggers[0].Name != "foo" {
+		t.Errorf("Expecting only the trigger foo, got %v", triggers)
+	}
+
+	if _, err := getTargetTriggers(kubelessClient, "myns", []string{"missing"}, "", false); err == nil {
+		t.Error("Expecting an error for a missing trigger")
+	}
+
+	triggers, err = getTargetTriggers(kubelessClient, "myns", nil, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(triggers) != 2 {
+		t.Errorf("Expecting the two triggers of the namespace, got %d", len(triggers))
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// doGracefulReload suspends the given CronJobs, waits for their active Jobs to finish
+// and then applies the update. The CronJobs are resumed once the update has been
+// applied or if the active Jobs don't finish before the timeout. CronJobs that were
+// already suspended (e.g. a paused trigger) are not resumed here, but the controller
+// resumes them when it applies the update.
+func doGracefulReload(cli kubernetes.Interface, ns string, cronJobNames []string, timeout time.Duration, apply func() error) error {
+	logrus.Infof("Suspending CronJobs %v", cronJobNames)
+	suspended, err := suspendCronJobs(cli, ns, cronJobNames)
+	if err != nil {
+		if _, resumeErr := setCronJobsSuspended(cli, ns, suspended, false); resumeErr != nil {
+			logrus.Errorf("Unable to resume the CronJobs: %v", resumeErr)
+		}
+		return err
+	}
+
+	logrus.Infof("Waiting up to %v for the active jobs to complete", timeout)
+	var active []string
+	err = wait.PollImmediate(time.Second, timeout, func() (bool, error) {
+		var err error
+		active, err = getActiveJobs(cli, ns, cronJobNames)
+		if err != nil {
+			return false, err
+		}
+		return len(active) == 0, nil
+	})
+	if err != nil {
+		if _, resumeErr := setCronJobsSuspended(cli, ns, suspended, false); resumeErr != nil {
+			logrus.Errorf("Unable to resume the CronJobs: %v", resumeErr)
+		}
+		if err == wait.ErrWaitTimeout {
+			return fmt.Errorf("The jobs %v are still running after %v. The trigger has not been updated", active, timeout)
+		}
+		return err
+	}
+
+	logrus.Info("Applying the new schedule")
+	applyErr := apply()
+
+	logrus.Infof("Resuming CronJobs %v", suspended)
+	if _, err := setCronJobsSuspended(cli, ns, suspended, false); err != nil {
+		return err
+	}
+	return applyErr
+}
+
+// suspendCronJobs suspends the given CronJobs that are not suspended yet and returns
+// their names, so only those are resumed afterwards. CronJobs that don't exist are ignored.
+func suspendCronJobs(cli kubernetes.Interface, ns string, cronJobNames []string) ([]string, error) {
+	suspended := []string{}
+	for _, name := range cronJobNames {
+		cronJob, err := cli.BatchV1beta1().CronJobs(ns).Get(name, metav1.GetOptions{})
+		if err != nil {
+			if k8sErrors.IsNotFound(err) {
+				continue
+			}
+			return suspended, fmt.Errorf("Unable to get the CronJob %s: %v", name, err)
+		}
+		if cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend {
+			logrus.Warnf("CronJob %s is already suspended. It's not resumed after the reload, but the controller resumes it when it applies the update", name)
+			continue
+		}
+		suspend := true
+		cronJob.Spec.Suspend = &suspend
+		if _, err := cli.BatchV1beta1().CronJobs(ns).Update(cronJob); err != nil {
+			return suspended, fmt.Errorf("Unable to update the CronJob %s: %v", name, err)
+		}
+		suspended = append(suspended, name)
+	}
+	return suspended, nil
+}
+
+// setCronJobsSuspended suspends or resumes the given CronJobs and returns how many of them
+// were found. CronJobs that don't exist are ignored.
+func setCronJobsSuspended(cli kubernetes.Interface, ns string, cronJobNames []string, suspend bool) (int, error) {
+	found := 0
+	for _, name := range cronJobNames {
+		cronJob, err := cli.BatchV1beta1().CronJobs(ns).Get(name, metav1.GetOptions{})
+		if err != nil {
+			if k8sErrors.IsNotFound(err) {
+				continue
+			}
+			return found, fmt.Errorf("Unable to get the CronJob %s: %v", name, err)
+		}
+		found++
+		cronJob.Spec.Suspend = &suspend
+		if _, err := cli.BatchV1beta1().CronJobs(ns).Update(cronJob); err != nil {
+			return found, fmt.Errorf("Unable to update the CronJob %s: %v", name, err)
+		}
+	}
+	return found, nil
+}
+
+// getActiveJobs returns the names of the unfinished Jobs created by the given CronJobs
+func getActiveJobs(cli kubernetes.Interface, ns string, cronJobNames []string) ([]string, error) {
+	owners := map[string]bool{}
+	for _, name := range cronJobNames {
+		owners[name] = true
+	}
+	jobs, err := cli.BatchV1().Jobs(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list the jobs in namespace %s: %v", ns, err)
+	}
+	active := []string{}
+	for _, job := range jobs.Items {
+		owned := false
+		for _, ref := range job.OwnerReferences {
+			if ref.Kind == "CronJob" && owners[ref.Name] {
+				owned = true
+			}
+		}
+		if !owned {
+			continue
+		}
+		if finished, _ := jobFinished(&job); !finished {
+			active = append(active, job.Name)
+		}
+	}
+	sort.Strings(active)
+	return active, nil
+}
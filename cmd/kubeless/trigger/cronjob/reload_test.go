@@ -0,0 +1,149 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func ownedJob(name, cronJobName string, finished bool) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "myns",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "CronJob", Name: cronJobName}},
+		},
+	}
+	if finished {
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+	}
+	return job
+}
+
+func isSuspended(t *testing.T, cli kubernetes.Interface, name string) bool {
+	cronJob, err := cli.BatchV1beta1().CronJobs("myns").Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend
+}
+
+func TestGracefulReload(t *testing.T) {
+	cli := fake.NewSimpleClientset(
+		&batchv1beta1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: "trigger-foo", Namespace: "myns"}},
+		ownedJob("trigger-foo-1", "trigger-foo", true),
+		ownedJob("trigger-bar-1", "trigger-bar", false),
+	)
+
+	applied := false
+	err := doGracefulReload(cli, "myns", []string{"trigger-foo", "trigger-foo-1"}, time.Second, func() error {
+		applied = true
+		if !isSuspended(t, cli, "trigger-foo") {
+			t.Error("The CronJob should be suspended while applying the update")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !applied {
+		t.Error("The update should have been applied")
+	}
+	if isSuspended(t, cli, "trigger-foo") {
+		t.Error("The CronJob should have been resumed")
+	}
+}
+
+func TestGracefulReloadTimeout(t *testing.T) {
+	cli := fake.NewSimpleClientset(
+		&batchv1beta1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: "trigger-foo", Namespace: "myns"}},
+		ownedJob("trigger-foo-1", "trigger-foo", false),
+	)
+
+	active, err := getActiveJobs(cli, "myns", []string{"trigger-foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 1 || active[0] != "trigger-foo-1" {
+		t.Errorf("Unexpected active jobs %v", active)
+	}
+
+	applied := false
+	err = doGracefulReload(cli, "myns", []string{"trigger-foo"}, 10*time.Millisecond, func() error {
+		applied = true
+		return nil
+	})
+	if err == nil {
+		t.Error("Expecting a timeout error")
+	}
+	if applied {
+		t.Error("The update shouldn't be applied while jobs are running")
+	}
+	if isSuspended(t, cli, "trigger-foo") {
+		t.Error("The CronJob should have been resumed after the timeout")
+	}
+}
+
+func TestGracefulReloadKeepsSuspended(t *testing.T) {
+	suspend := true
+	cli := fake.NewSimpleClientset(
+		&batchv1beta1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: "trigger-foo", Namespace: "myns"}},
+		&batchv1beta1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "trigger-foo-1", Namespace: "myns"},
+			Spec:       batchv1beta1.CronJobSpec{Suspend: &suspend},
+		},
+	)
+
+	err := doGracefulReload(cli, "myns", []string{"trigger-foo", "trigger-foo-1"}, time.Second, func() error {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isSuspended(t, cli, "trigger-foo") {
+		t.Error("The CronJob trigger-foo should have been resumed")
+	}
+	if !isSuspended(t, cli, "trigger-foo-1") {
+		t.Error("The CronJob trigger-foo-1 was suspended before the reload and should stay suspended")
+	}
+
+	// The same applies when the active jobs don't finish in time
+	cli = fake.NewSimpleClientset(
+		&batchv1beta1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "trigger-foo", Namespace: "myns"},
+			Spec:       batchv1beta1.CronJobSpec{Suspend: &suspend},
+		},
+		ownedJob("trigger-foo-1", "trigger-foo", false),
+	)
+	err = doGracefulReload(cli, "myns", []string{"trigger-foo"}, 10*time.Millisecond, func() error {
+		return nil
+	})
+	if err == nil {
+		t.Error("Expecting a timeout error")
+	}
+	if !isSuspended(t, cli, "trigger-foo") {
+		t.Error("The CronJob trigger-foo should stay suspended after the timeout")
+	}
+}
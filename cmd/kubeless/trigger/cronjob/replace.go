@@ -0,0 +1,196 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"fmt"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	cronjobUtils "github.com/kubeless/cronjob-trigger/pkg/utils"
+	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var replaceCmd = &cobra.Command{
+	Use:   "replace <cronjob_trigger_name> FLAG",
+	Short: "Replace a cron job trigger",
+	Long: `Replace a cron job trigger with the one described by the given flags.
+
+Unlike update, which only changes the given fields, replace drops everything that is not
+specified: a trigger replaced without --payload has no payload and its annotations are only the
+ones given with --annotations-from-file. The name, namespace and system metadata are kept.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 1 {
+			logrus.Fatal("Need exactly one argument - cronjob trigger name")
+		}
+		triggerName := args[0]
+
+		schedule, err := getScheduleFlags(cmd.Flags(), true)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		ns, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if ns == "" {
+			ns = kubelessUtils.GetDefaultNamespace()
+		}
+
+		functionName, err := cmd.Flags().GetString("function")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		dryrun, err := cmd.Flags().GetBool("dryrun")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		source, err := getPayloadFlags(cmd.Flags())
+		if err != nil {
+			kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, err)
+		}
+
+		annotationsFromFile, err := cmd.Flags().GetString("annotations-from-file")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		if err := source.readConfigMap(kubelessUtils.GetClientOutOfCluster, ns); err != nil {
+			logrus.Fatal(err)
+		}
+
+		var parsedPayload interface{}
+		if source.given() {
+			parsedPayload, err = parsePayload(source.payload, source.payloadFromFile, source.allowEmptyGlob)
+			if err == nil {
+				if payloadErr, ok := parsedPayload.(error); ok {
+					err = payloadErr
+				}
+			}
+			if err != nil {
+				logrus.Fatalf("Unable to parse the payload of Function %s in namespace %s. Error %s", functionName, ns, err)
+			}
+		}
+		if source.mergeBase != "" {
+			parsedPayload, err = applyPayloadMergeBase(source.mergeBase, parsedPayload, parsedPayload != nil)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+		}
+
+		allowImmutable, err := cmd.Flags().GetBool("allow-immutable")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		kubelessClient, err := kubelessUtils.GetKubelessClientOutCluster()
+		if err != nil {
+			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
+		}
+
+		cronJobClient, err := kubelessUtils.GetCronJobTriggerClientOutCluster()
+		if err != nil {
+			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
+		}
+
+		_, err = kubelessUtils.GetFunctionCustomResource(kubelessClient, functionName, ns)
+		if err != nil {
+			logrus.Fatalf("Unable to find Function %s in namespace %s. Error %s", functionName, ns, err)
+		}
+
+		desired := buildCronJobTrigger(triggerName, ns, functionName, schedule, parsedPayload, nil)
+		if err := kubelessUtils.ApplyAnnotationsFile(&desired.ObjectMeta, annotationsFromFile); err != nil {
+			logrus.Fatal(err)
+		}
+		// The trigger is replaced again over its latest version on conflicts
+		mutate := func(current *cronjobApi.CronJobTrigger) (*cronjobApi.CronJobTrigger, error) {
+			if err := checkMutable(current, allowImmutable); err != nil {
+				return nil, err
+			}
+			return replaceCronJobTrigger(current, desired), nil
+		}
+
+		current, err := cronjobUtils.GetCronJobCustomResource(cronJobClient, triggerName, ns)
+		if err != nil {
+			logrus.Fatalf("Unable to find Cronjob trigger %s in namespace %s. Error %s", triggerName, ns, err)
+		}
+		cronJobTrigger, err := mutate(current)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		if dryrun == true {
+			res, err := kubelessUtils.DryRunFmt(output, cronJobTrigger)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			fmt.Println(res)
+			return
+		}
+
+		if _, err := updateCronJobTrigger(cronJobClient, triggerName, ns, mutate); err != nil {
+			logrus.Fatalf("Failed to replace cronjob trigger object %s in namespace %s. Error: %s", triggerName, ns, err)
+		}
+		logrus.Infof("Cronjob trigger %s replaced in namespace %s successfully!", triggerName, ns)
+	},
+}
+
+func init() {
+	replaceCmd.Flags().StringP("namespace", "n", "", "Specify namespace of the cronjob trigger")
+	addScheduleFlags(replaceCmd.Flags())
+	replaceCmd.Flags().StringP("function", "", "", "Name of the function to be associated with trigger")
+	replaceCmd.MarkFlagRequired("function")
+	replaceCmd.MarkFlagRequired("schedule")
+	replaceCmd.Flags().Bool("dryrun", false, "Output JSON manifest of the trigger without replacing it")
+	replaceCmd.Flags().StringP("annotations-from-file", "", "", "Specify a YAML or JSON file with a flat map of annotations of the trigger")
+	replaceCmd.Flags().StringP("output", "o", "yaml", "Output format")
+	addPayloadFlags(replaceCmd.Flags())
+	replaceCmd.Flags().Bool("allow-immutable", false, "Allow to replace a trigger created with --immutable")
+}
+
+// replaceCronJobTrigger returns the desired trigger with the identity and the
+// system metadata of the current one so it can be used to fully update it
+func replaceCronJobTrigger(current, desired *cronjobApi.CronJobTrigger) *cronjobApi.CronJobTrigger {
+	replaced := desired.DeepCopy()
+	replaced.ObjectMeta.Name = current.ObjectMeta.Name
+	replaced.ObjectMeta.Namespace = current.ObjectMeta.Namespace
+	replaced.ObjectMeta.UID = current.ObjectMeta.UID
+	replaced.ObjectMeta.ResourceVersion = current.ObjectMeta.ResourceVersion
+	replaced.ObjectMeta.Generation = current.ObjectMeta.Generation
+	replaced.ObjectMeta.SelfLink = current.ObjectMeta.SelfLink
+	replaced.ObjectMeta.CreationTimestamp = current.ObjectMeta.CreationTimestamp
+	replaced.ObjectMeta.OwnerReferences = current.ObjectMeta.OwnerReferences
+	replaced.ObjectMeta.Finalizers = current.ObjectMeta.Finalizers
+	// Unlike the rest of annotations, the immutable mark is kept when the trigger is replaced
+	if isImmutable(current) {
+		if replaced.ObjectMeta.Annotations == nil {
+			replaced.ObjectMeta.Annotations = map[string]string{}
+		}
+		replaced.ObjectMeta.Annotations[immutableAnnotation] = "true"
+	}
+	return replaced
+}
@@ -0,0 +1,93 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"reflect"
+	"testing"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestReplaceCronJobTrigger(t *testing.T) {
+	created := metav1.Now()
+	current := &cronjobApi.CronJobTrigger{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "foo-trigger",
+			Namespace:         "myns",
+			UID:               types.UID("foo-uid"),
+			ResourceVersion:   "42",
+			CreationTimestamp: created,
+			Labels:            map[string]string{"created-by": "kubeless", "team": "a"},
+			Annotations: map[string]string{
+				"example.com/owner": "team-a",
+			},
+		},
+		Spec: cronjobApi.CronJobTriggerSpec{
+			FunctionName: "foo",
+			Schedule:     "* * * * *",
+			Payload:      map[string]interface{}{"foo": "bar"},
+		},
+	}
+
+	desired := buildCronJobTrigger("foo-trigger", "myns", "bar", "@hourly", nil, nil)
+	replaced := replaceCronJobTrigger(current, desired)
+
+	if replaced.UID != "foo-uid" || replaced.ResourceVersion != "42" || !replaced.CreationTimestamp.Equal(&created) {
+		t.Errorf("The identity of the trigger should be kept: %v", replaced.ObjectMeta)
+	}
+	if replaced.Spec.FunctionName != "bar" || replaced.Spec.Schedule != "@hourly" || replaced.Spec.Payload != nil {
+		t.Errorf("Unexpected spec %v", replaced.Spec)
+	}
+	if len(replaced.Annotations) != 0 {
+		t.Errorf("The annotations not specified should be dropped, got %v", replaced.Annotations)
+	}
+	if !reflect.DeepEqual(replaced.Labels, map[string]string{"created-by": "kubeless"}) {
+		t.Errorf("Unexpected labels %v", replaced.Labels)
+	}
+	// The desired trigger is not modified
+	if desired.UID != "" {
+		t.Error("The desired trigger shouldn't be modified")
+	}
+}
+
+func TestReplaceImmutableCronJobTrigger(t *testing.T) {
+	current := &cronjobApi.CronJobTrigger{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foo-trigger",
+			Namespace:   "myns",
+			Annotations: map[string]string{immutableAnnotation: "true"},
+		},
+	}
+	if err := checkMutable(current, false); err == nil {
+		t.Error("Expecting an error replacing an immutable trigger")
+	}
+	if err := checkMutable(current, true); err != nil {
+		t.Errorf("Unexpected error with --allow-immutable: %v", err)
+	}
+
+	desired := buildCronJobTrigger("foo-trigger", "myns", "bar", "@hourly", nil, nil)
+	if err := checkMutable(desired, false); err != nil {
+		t.Errorf("Unexpected error for a mutable trigger: %v", err)
+	}
+	// The trigger is still immutable after being replaced
+	if replaced := replaceCronJobTrigger(current, desired); !isImmutable(replaced) {
+		t.Errorf("Expecting the replaced trigger to be immutable, got %v", replaced.Annotations)
+	}
+}
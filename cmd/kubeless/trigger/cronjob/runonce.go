@@ -0,0 +1,190 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	cronjobUtils "github.com/kubeless/cronjob-trigger/pkg/utils"
+	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
+)
+
+// runOnceAtAnnotation contains the time, in RFC3339 format, of the call of a trigger created with run-once
+const runOnceAtAnnotation = "kubeless.io/run-once-at"
+
+var runOnceCmd = &cobra.Command{
+	Use:   "run-once <cronjob_trigger_name> FLAG",
+	Short: "schedule a call of a function at a given time",
+	Long: `schedule a call of a function at the given time
+
+The trigger gets a schedule matching the minute, hour, day and month of the given time. Cron
+schedules don't have a year and nothing deletes the trigger after the call, so it calls the
+function again every year at the same time until it's deleted. Run
+'kubeless trigger cronjob delete --expired' periodically to delete the triggers whose time
+has passed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, "Need exactly one argument - cronjob trigger name")
+		}
+		triggerName := args[0]
+
+		ns, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if ns == "" {
+			ns = kubelessUtils.GetDefaultNamespace()
+		}
+		functionName, err := cmd.Flags().GetString("function")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		at, err := cmd.Flags().GetString("at")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		runAt, err := parseRunOnceTime(at, time.Now())
+		if err != nil {
+			kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, err)
+		}
+		payload, err := cmd.Flags().GetString("payload")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		payloadFromFile, err := cmd.Flags().GetString("payload-from-file")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if len(payload) > 0 && len(payloadFromFile) > 0 {
+			kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, "You can't provide both raw payload and a payload file")
+		}
+		dryrun, err := cmd.Flags().GetBool("dryrun")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		createdOutput, err := kubelessUtils.GetCreatedOutput(cmd.Flags())
+		if err != nil {
+			kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, err)
+		}
+
+		var parsedPayload interface{}
+		if len(payload) > 0 || len(payloadFromFile) > 0 {
+			parsedPayload, err = parsePayload(payload, payloadFromFile, false)
+			if err == nil {
+				err, _ = parsedPayload.(error)
+			}
+			if err != nil {
+				kubelessUtils.FatalfWithCode(kubelessUtils.ExitUsage, "Unable to parse the payload of the cronjob trigger %s. Error %s", triggerName, err)
+			}
+		}
+		trigger := buildRunOnceTrigger(triggerName, ns, functionName, runAt, parsedPayload)
+
+		if dryrun {
+			res, err := kubelessUtils.DryRunFmt(output, trigger)
+			if err != nil {
+				kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, err)
+			}
+			fmt.Println(res)
+			return
+		}
+
+		kubelessClient, err := kubelessUtils.GetKubelessClientOutCluster()
+		if err != nil {
+			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
+		}
+		if _, err := kubelessUtils.GetFunctionCustomResource(kubelessClient, functionName, ns); err != nil {
+			kubelessUtils.FatalfWithCode(kubelessUtils.ExitCodeForError(err), "Unable to find Function %s in namespace %s. Error %s", functionName, ns, err)
+		}
+		cronJobClient, err := kubelessUtils.GetCronJobTriggerClientOutCluster()
+		if err != nil {
+			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
+		}
+		if err := cronjobUtils.CreateCronJobCustomResource(cronJobClient, trigger); err != nil {
+			kubelessUtils.FatalfWithCode(kubelessUtils.ExitCodeForError(err), "Failed to create cronjob trigger object %s in namespace %s. Error: %s", triggerName, ns, err)
+		}
+		if createdOutput != "" {
+			if err := kubelessUtils.PrintCreated(cmd.OutOrStdout(), createdOutput, "cronjobtrigger.kubeless.io", triggerName, trigger); err != nil {
+				logrus.Fatal(err)
+			}
+			return
+		}
+		logrus.Infof("Cronjob trigger %s created in namespace %s successfully! It will call %s at %s", triggerName, ns, functionName, runAt.Format(time.RFC3339))
+		logrus.Warnf("The trigger calls %s again every year at the same time until it's deleted. Use 'kubeless trigger cronjob delete --expired' once the call is done", functionName)
+	},
+}
+
+func init() {
+	runOnceCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the cronjob trigger")
+	runOnceCmd.Flags().String("function", "", "Name of the function to be associated with trigger")
+	runOnceCmd.Flags().String("at", "", "Time of the call in RFC3339 format (e.g. 2024-12-31T23:00:00Z). It should be in the future and in a whole minute")
+	runOnceCmd.Flags().StringP("payload", "p", "", "Specify a stringified JSON data to pass to function upon execution")
+	runOnceCmd.Flags().StringP("payload-from-file", "f", "", "Specify a payload file to use. It must be a JSON file or a .env file with KEY=value lines")
+	runOnceCmd.Flags().Bool("dryrun", false, "Output JSON manifest of the cronjob trigger without creating it")
+	runOnceCmd.Flags().StringP("output", "o", "yaml", "Output format. One of: json|yaml with --dryrun. When given without --dryrun, the created trigger is printed as name|json|yaml")
+	runOnceCmd.MarkFlagRequired("function")
+	runOnceCmd.MarkFlagRequired("at")
+}
+
+// parseRunOnceTime parses the time of a run-once trigger. Cron schedules have a
+// precision of one minute, so it should be a whole minute after now.
+func parseRunOnceTime(value string, now time.Time) (time.Time, error) {
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid time %q. It should be in RFC3339 format, like 2024-12-31T23:00:00Z", value)
+	}
+	if at.Second() != 0 || at.Nanosecond() != 0 {
+		return time.Time{}, fmt.Errorf("Invalid time %q. Schedules have a precision of one minute, so the seconds should be 0", value)
+	}
+	if !at.After(now) {
+		return time.Time{}, fmt.Errorf("The time %s is in the past", value)
+	}
+	return at.UTC(), nil
+}
+
+// getRunOnceSchedule returns the cron schedule matching the minute, hour, day and month of the
+// given time in UTC. The CronJobs are scheduled in the time zone of the Kubernetes controller
+// manager, which is UTC in most clusters.
+func getRunOnceSchedule(at time.Time) string {
+	at = at.UTC()
+	return fmt.Sprintf("%d %d %d %d *", at.Minute(), at.Hour(), at.Day(), int(at.Month()))
+}
+
+// buildRunOnceTrigger returns a trigger calling the function at the given time. The time is
+// stored in the annotation kubeless.io/run-once-at so it can be deleted once it has passed.
+func buildRunOnceTrigger(name, ns, functionName string, at time.Time, payload interface{}) *cronjobApi.CronJobTrigger {
+	annotations := map[string]string{runOnceAtAnnotation: at.UTC().Format(time.RFC3339)}
+	return buildCronJobTrigger(name, ns, functionName, getRunOnceSchedule(at), payload, annotations)
+}
+
+// isExpiredRunOnce returns true if the trigger was created with run-once and its time has passed
+func isExpiredRunOnce(trigger *cronjobApi.CronJobTrigger, now time.Time) bool {
+	value, ok := trigger.ObjectMeta.Annotations[runOnceAtAnnotation]
+	if !ok {
+		return false
+	}
+	at, err := time.Parse(time.RFC3339, value)
+	return err == nil && now.After(at)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"testing"
+	"time"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	"github.com/robfig/cron"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseRunOnceTime(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	at, err := parseRunOnceTime("2024-12-31T23:00:00+02:00", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !at.Equal(time.Date(2024, 12, 31, 21, 0, 0, 0, time.UTC)) || at.Location() != time.UTC {
+		t.Errorf("Expecting the time in UTC, got %v", at)
+	}
+	for _, value := range []string{"tomorrow", "2024-12-31 23:00", "2024-12-31T23:00:30Z", "2024-06-01T12:00:00Z", "2023-01-01T00:00:00Z"} {
+		if _, err := parseRunOnceTime(value, now); err == nil {
+			t.Errorf("Expecting an error for %s", value)
+		}
+	}
+}
+
+func TestRunOnceTrigger(t *testing.T) {
+	at := time.Date(2024, 12, 31, 23, 5, 0, 0, time.UTC)
+	trigger := buildRunOnceTrigger("new-year", "myns", "foo", at, nil)
+	if trigger.Spec.Schedule != "5 23 31 12 *" {
+		t.Errorf("Unexpected schedule %q", trigger.Spec.Schedule)
+	}
+	schedule, err := cron.ParseStandard(trigger.Spec.Schedule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := schedule.Next(at.Add(-time.Hour)); !next.Equal(at) {
+		t.Errorf("Expecting the next run at %v, got %v", at, next)
+	}
+	if trigger.Annotations[runOnceAtAnnotation] != "2024-12-31T23:05:00Z" {
+		t.Errorf("Unexpected annotation %q", trigger.Annotations[runOnceAtAnnotation])
+	}
+
+	recurring := &cronjobApi.CronJobTrigger{ObjectMeta: metav1.ObjectMeta{Name: "daily"}}
+	triggers := []*cronjobApi.CronJobTrigger{trigger, recurring}
+	if expired := filterExpiredRunOnce(triggers, at.Add(-time.Minute)); len(expired) != 0 {
+		t.Errorf("Expecting no expired triggers before the time, got %d", len(expired))
+	}
+	expired := filterExpiredRunOnce(triggers, at.Add(time.Minute))
+	if len(expired) != 1 || expired[0].Name != "new-year" {
+		t.Errorf("Expecting only new-year to be expired, got %v", expired)
+	}
+}
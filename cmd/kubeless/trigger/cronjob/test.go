@@ -0,0 +1,198 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	cronjobUtils "github.com/kubeless/cronjob-trigger/pkg/utils"
+	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test <cronjob_trigger_name> FLAG",
+	Short: "Run a cron job trigger now",
+	Long:  `Run a cron job trigger now, without waiting for its schedule, and stream the logs of the call`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 1 {
+			logrus.Fatal("Need exactly one argument - cronjob trigger name")
+		}
+		triggerName := args[0]
+
+		ns, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if ns == "" {
+			ns = kubelessUtils.GetDefaultNamespace()
+		}
+
+		waitJob, err := cmd.Flags().GetBool("wait")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		cronJobClient, err := kubelessUtils.GetCronJobTriggerClientOutCluster()
+		if err != nil {
+			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
+		}
+
+		trigger, err := cronjobUtils.GetCronJobCustomResource(cronJobClient, triggerName, ns)
+		if err != nil {
+			logrus.Fatalf("Unable to find Cronjob trigger %s in namespace %s. Error %s", triggerName, ns, err)
+		}
+
+		cli := kubelessUtils.GetClientOutOfCluster()
+		job, err := createJobFromCronJob(cli, trigger, time.Now())
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		logrus.Infof("Job %s created in namespace %s", job.Name, ns)
+
+		if err := streamJobLogs(cmd.OutOrStdout(), cli, job, timeout); err != nil {
+			logrus.Fatal(err)
+		}
+
+		if waitJob {
+			if err := waitForJob(cli, job, timeout); err != nil {
+				logrus.Fatal(err)
+			}
+			logrus.Infof("Job %s completed successfully", job.Name)
+		}
+	},
+}
+
+func init() {
+	testCmd.Flags().StringP("namespace", "n", "", "Specify namespace of the cronjob trigger")
+	testCmd.Flags().Bool("wait", false, "Wait for the job to complete and exit with an error if it fails")
+	testCmd.Flags().Duration("timeout", 5*time.Minute, "Maximum time to wait for the job to start and, with --wait, to complete")
+}
+
+// createJobFromCronJob creates a one-off Job from the template of the CronJob
+// backing the trigger, like 'kubectl create job --from=cronjob/<name>' does
+func createJobFromCronJob(cli kubernetes.Interface, trigger *cronjobApi.CronJobTrigger, now time.Time) (*batchv1.Job, error) {
+	cronJobName := getCronJobName(trigger)
+	cronJob, err := cli.BatchV1beta1().CronJobs(trigger.Namespace).Get(cronJobName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to find the CronJob %s of the trigger %s: %v", cronJobName, trigger.Name, err)
+	}
+
+	annotations := map[string]string{"cronjob.kubernetes.io/instantiate": "manual"}
+	for k, v := range cronJob.Spec.JobTemplate.Annotations {
+		annotations[k] = v
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-manual-%d", cronJob.Name, now.Unix()),
+			Namespace:   cronJob.Namespace,
+			Labels:      cronJob.Spec.JobTemplate.Labels,
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "batch/v1beta1",
+					Kind:       "CronJob",
+					Name:       cronJob.Name,
+					UID:        cronJob.UID,
+				},
+			},
+		},
+		Spec: cronJob.Spec.JobTemplate.Spec,
+	}
+	return cli.BatchV1().Jobs(job.Namespace).Create(job)
+}
+
+// streamJobLogs waits for the pod of the job to start and follows its logs
+func streamJobLogs(w io.Writer, cli kubernetes.Interface, job *batchv1.Job, timeout time.Duration) error {
+	var podName string
+	progress := kubelessUtils.StartProgress(fmt.Sprintf("Waiting for the job %s to start", job.Name))
+	err := wait.PollImmediate(time.Second, timeout, func() (bool, error) {
+		pods, err := kubelessUtils.GetPodsByLabel(cli, job.Namespace, "job-name", job.Name)
+		if err != nil {
+			return false, err
+		}
+		for _, pod := range pods.Items {
+			if pod.Status.Phase != v1.PodPending {
+				podName = pod.Name
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	progress.Stop()
+	if err != nil {
+		return fmt.Errorf("The job %s didn't start: %v", job.Name, err)
+	}
+
+	readCloser, err := cli.CoreV1().Pods(job.Namespace).GetLogs(podName, &v1.PodLogOptions{Follow: true}).Stream()
+	if err != nil {
+		return fmt.Errorf("Getting log failed: %v", err)
+	}
+	defer readCloser.Close()
+	_, err = io.Copy(w, readCloser)
+	return err
+}
+
+// jobFinished returns if the job has finished and the reason if it failed
+func jobFinished(job *batchv1.Job) (bool, error) {
+	for _, c := range job.Status.Conditions {
+		if c.Status != v1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			return true, nil
+		case batchv1.JobFailed:
+			return true, fmt.Errorf("The job %s failed: %s", job.Name, c.Message)
+		}
+	}
+	return false, nil
+}
+
+func waitForJob(cli kubernetes.Interface, job *batchv1.Job, timeout time.Duration) error {
+	var jobErr error
+	progress := kubelessUtils.StartProgress(fmt.Sprintf("Waiting for the job %s to complete", job.Name))
+	err := wait.PollImmediate(time.Second, timeout, func() (bool, error) {
+		current, err := cli.BatchV1().Jobs(job.Namespace).Get(job.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		var finished bool
+		finished, jobErr = jobFinished(current)
+		return finished, nil
+	})
+	progress.Stop()
+	if err != nil {
+		return fmt.Errorf("Unable to wait for the job %s: %v", job.Name, err)
+	}
+	return jobErr
+}
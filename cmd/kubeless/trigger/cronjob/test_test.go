@@ -0,0 +1,93 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"testing"
+	"time"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCreateJobFromCronJob(t *testing.T) {
+	trigger := &cronjobApi.CronJobTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo-trigger", Namespace: "myns"},
+		Spec:       cronjobApi.CronJobTriggerSpec{FunctionName: "foo"},
+	}
+	cronJob := &batchv1beta1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "trigger-foo", Namespace: "myns", UID: types.UID("cronjob-uid")},
+		Spec: batchv1beta1.CronJobSpec{
+			JobTemplate: batchv1beta1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"function": "foo"}},
+				Spec: batchv1.JobSpec{
+					Template: v1.PodTemplateSpec{
+						Spec: v1.PodSpec{
+							Containers: []v1.Container{{Name: "trigger", Image: "curl"}},
+						},
+					},
+				},
+			},
+		},
+	}
+	cli := fake.NewSimpleClientset(cronJob)
+	job, err := createJobFromCronJob(cli, trigger, time.Unix(1000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Name != "trigger-foo-manual-1000" {
+		t.Errorf("Unexpected job name %s", job.Name)
+	}
+	if job.Annotations["cronjob.kubernetes.io/instantiate"] != "manual" || job.Labels["function"] != "foo" {
+		t.Errorf("Unexpected job metadata %+v", job.ObjectMeta)
+	}
+	if len(job.OwnerReferences) != 1 || job.OwnerReferences[0].UID != cronJob.UID {
+		t.Errorf("Expecting the job to be owned by the CronJob, got %v", job.OwnerReferences)
+	}
+	if job.Spec.Template.Spec.Containers[0].Image != "curl" {
+		t.Errorf("Expecting the job to use the CronJob template")
+	}
+	if _, err := cli.BatchV1().Jobs("myns").Get(job.Name, metav1.GetOptions{}); err != nil {
+		t.Errorf("Expecting the job to be created: %v", err)
+	}
+
+	missing := trigger.DeepCopy()
+	missing.Spec.FunctionName = "bar"
+	if _, err := createJobFromCronJob(cli, missing, time.Now()); err == nil {
+		t.Error("Expecting an error for a trigger without CronJob")
+	}
+}
+
+func TestJobFinished(t *testing.T) {
+	job := &batchv1.Job{}
+	if finished, _ := jobFinished(job); finished {
+		t.Error("Expecting a job without conditions to be running")
+	}
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+	if finished, err := jobFinished(job); !finished || err != nil {
+		t.Errorf("Expecting the job to be completed, got %v, %v", finished, err)
+	}
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: v1.ConditionTrue, Message: "BackoffLimitExceeded"}}
+	if finished, err := jobFinished(job); !finished || err == nil {
+		t.Errorf("Expecting the job to be failed, got %v, %v", finished, err)
+	}
+}
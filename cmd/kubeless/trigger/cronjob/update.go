@@ -18,11 +18,12 @@ package cronjob
 
 import (
 	"fmt"
+	"time"
 
-	"github.com/robfig/cron"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
 	cronjobUtils "github.com/kubeless/cronjob-trigger/pkg/utils"
 	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
 )
@@ -38,17 +39,11 @@ var updateCmd = &cobra.Command{
 		}
 		triggerName := args[0]
 
-		schedule, err := cmd.Flags().GetString("schedule")
+		schedule, err := getScheduleFlags(cmd.Flags(), false)
 		if err != nil {
 			logrus.Fatal(err)
 		}
 
-		if schedule != "" {
-			if _, err := cron.ParseStandard(schedule); err != nil {
-				logrus.Fatalf("Invalid value for --schedule. " + err.Error())
-			}
-		}
-
 		ns, err := cmd.Flags().GetString("namespace")
 		if err != nil {
 			logrus.Fatal(err)
@@ -72,18 +67,23 @@ var updateCmd = &cobra.Command{
 			logrus.Fatal(err)
 		}
 
-		payload, err := cmd.Flags().GetString("payload")
+		source, err := getPayloadFlags(cmd.Flags())
+		if err != nil {
+			kubelessUtils.FatalWithCode(kubelessUtils.ExitUsage, err)
+		}
+
+		gracefulReload, err := cmd.Flags().GetBool("graceful-reload")
 		if err != nil {
 			logrus.Fatal(err)
 		}
 
-		payloadFromFile, err := cmd.Flags().GetString("payload-from-file")
+		timeout, err := cmd.Flags().GetDuration("timeout")
 		if err != nil {
 			logrus.Fatal(err)
 		}
 
-		if len(payload) > 0 && len(payloadFromFile) > 0 {
-			err := "You can't provide both raw payload and a payload file"
+		allowImmutable, err := cmd.Flags().GetBool("allow-immutable")
+		if err != nil {
 			logrus.Fatal(err)
 		}
 
@@ -92,7 +92,7 @@ var updateCmd = &cobra.Command{
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
 
-		cronJobClient, err := cronjobUtils.GetKubelessClientOutCluster()
+		cronJobClient, err := kubelessUtils.GetCronJobTriggerClientOutCluster()
 		if err != nil {
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
@@ -102,18 +102,44 @@ var updateCmd = &cobra.Command{
 			logrus.Fatalf("Unable to find Function %s in namespace %s. Error %s", triggerName, ns, err)
 		}
 
-		parsedPayload, err := parsePayload(payload, payloadFromFile)
+		if err := source.readConfigMap(kubelessUtils.GetClientOutOfCluster, ns); err != nil {
+			logrus.Fatal(err)
+		}
+
+		parsedPayload, err := parsePayload(source.payload, source.payloadFromFile, source.allowEmptyGlob)
 		if err != nil {
 			logrus.Fatalf("Unable to parse the payload of Function %s in namespace %s. Error %s", functionName, ns, err)
 		}
+		if source.mergeBase != "" {
+			parsedPayload, err = applyPayloadMergeBase(source.mergeBase, parsedPayload, source.given())
+			if err != nil {
+				logrus.Fatal(err)
+			}
+		}
+
+		// The changes are applied again to the latest version of the trigger on conflicts
+		mutate := func(trigger *cronjobApi.CronJobTrigger) (*cronjobApi.CronJobTrigger, error) {
+			if err := checkMutable(trigger, allowImmutable); err != nil {
+				return nil, err
+			}
+			trigger.Spec.FunctionName = functionName
+			if schedule != "" {
+				trigger.Spec.Schedule = schedule
+			}
+			trigger.Spec.Payload = parsedPayload
+			return trigger, nil
+		}
 
 		cronJobTrigger, err := cronjobUtils.GetCronJobCustomResource(cronJobClient, triggerName, ns)
 		if err != nil {
 			logrus.Fatalf("Unable to find Cronjob trigger %s in namespace %s. Error %s", triggerName, ns, err)
 		}
-		cronJobTrigger.Spec.FunctionName = functionName
-		cronJobTrigger.Spec.Schedule = schedule
-		cronJobTrigger.Spec.Payload = parsedPayload
+		// The CronJob of the current function is the one to suspend during a graceful reload
+		cronJobNames := []string{getCronJobName(cronJobTrigger)}
+		cronJobTrigger, err = mutate(cronJobTrigger)
+		if err != nil {
+			logrus.Fatal(err)
+		}
 
 		if dryrun == true {
 			res, err := kubelessUtils.DryRunFmt(output, cronJobTrigger)
@@ -124,7 +150,16 @@ var updateCmd = &cobra.Command{
 			return
 		}
 
-		err = cronjobUtils.UpdateCronJobCustomResource(cronJobClient, cronJobTrigger)
+		update := func() error {
+			_, err := updateCronJobTrigger(cronJobClient, triggerName, ns, mutate)
+			return err
+		}
+		if gracefulReload {
+			cli := kubelessUtils.GetClientOutOfCluster()
+			err = doGracefulReload(cli, ns, cronJobNames, timeout, update)
+		} else {
+			err = update()
+		}
 		if err != nil {
 			logrus.Fatalf("Failed to update cronjob trigger object %s in namespace %s. Error: %s", triggerName, ns, err)
 		}
@@ -134,10 +169,12 @@ var updateCmd = &cobra.Command{
 
 func init() {
 	updateCmd.Flags().StringP("namespace", "n", "", "Specify namespace of the cronjob trigger")
-	updateCmd.Flags().StringP("schedule", "", "", "Specify schedule in cron format for scheduled function")
+	addScheduleFlags(updateCmd.Flags())
 	updateCmd.Flags().StringP("function", "", "", "Name of the function to be associated with trigger")
 	updateCmd.Flags().Bool("dryrun", false, "Output JSON manifest of the function without creating it")
 	updateCmd.Flags().StringP("output", "o", "yaml", "Output format")
-	updateCmd.Flags().StringP("payload", "p", "", "Specify a stringified JSON data to pass to function upon execution")
-	updateCmd.Flags().StringP("payload-from-file", "f", "", "Specify a payload file to use. It must be a JSON file")
+	addPayloadFlags(updateCmd.Flags())
+	updateCmd.Flags().Bool("allow-immutable", false, "Allow to update a trigger created with --immutable")
+	updateCmd.Flags().Bool("graceful-reload", false, "Suspend the trigger and wait for its running jobs to complete before applying the update")
+	updateCmd.Flags().Duration("timeout", 5*time.Minute, "Maximum time to wait for the running jobs with --graceful-reload")
 }
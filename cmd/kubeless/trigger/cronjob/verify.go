@@ -0,0 +1,234 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gosuri/uitable"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	cronjobUtils "github.com/kubeless/cronjob-trigger/pkg/utils"
+	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The cronjob trigger controller sets this owner reference in the CronJobs of a trigger
+const (
+	triggerOwnerKind       = "Trigger"
+	triggerOwnerAPIVersion = "kubeless.io/v1beta1"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <cronjob_trigger_name> FLAG",
+	Short: "verify the CronJob owned by a cronjob trigger",
+	Long: `verify the CronJob owned by a cronjob trigger
+
+The CronJob that the controller creates for the trigger should exist, run on the schedule of
+the trigger and have an owner reference to it, so it's removed with the trigger. With --repair
+the discrepancies are fixed: a missing CronJob is created again from the spec of the trigger
+and the owner reference and schedule of an existing one are restored.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			logrus.Fatal("Need exactly one argument - cronjob trigger name")
+		}
+		triggerName := args[0]
+
+		ns, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if ns == "" {
+			ns = kubelessUtils.GetDefaultNamespace()
+		}
+		repair, err := cmd.Flags().GetBool("repair")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		cronJobClient, err := kubelessUtils.GetCronJobTriggerClientOutCluster()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		trigger, err := cronjobUtils.GetCronJobCustomResource(cronJobClient, triggerName, ns)
+		if err != nil {
+			logrus.Fatalf("Unable to find Cronjob trigger %s in namespace %s. Error: %s", triggerName, ns, err)
+		}
+
+		cli := kubelessUtils.GetClientOutOfCluster()
+		create := func(trigger *cronjobApi.CronJobTrigger) error {
+			kubelessClient, err := kubelessUtils.GetKubelessClientOutCluster()
+			if err != nil {
+				return err
+			}
+			f, err := kubelessClient.KubelessV1beta1().Functions(trigger.Namespace).Get(trigger.Spec.FunctionName, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("Unable to find Function %s in namespace %s: %v", trigger.Spec.FunctionName, trigger.Namespace, err)
+			}
+			config, err := kubelessUtils.GetKubelessConfig(cli, kubelessUtils.GetAPIExtensionsClientOutOfCluster())
+			if err != nil {
+				return fmt.Errorf("Unable to read the configmap: %v", err)
+			}
+			pullSecrets := kubelessUtils.GetSecretsAsLocalObjectReference(config.Data["provision-image-secret"], config.Data["builder-image-secret"])
+			return cronjobUtils.EnsureCronJob(cli, f, trigger, config.Data["provision-image"], getTriggerOwnerReferences(trigger), pullSecrets)
+		}
+
+		discrepancies, err := verifyCronJobs(cli, trigger, repair, create)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if len(discrepancies) == 0 {
+			logrus.Infof("The CronJob of the Cronjob trigger %s in namespace %s is correct", triggerName, ns)
+			return
+		}
+		pending := printDiscrepancies(cmd.OutOrStdout(), discrepancies)
+		if pending > 0 {
+			if repair {
+				logrus.Fatalf("%d of %d discrepancies could not be repaired", pending, len(discrepancies))
+			}
+			logrus.Fatalf("Found %d discrepancies, use --repair to fix them", pending)
+		}
+	},
+}
+
+func init() {
+	verifyCmd.Flags().StringP("namespace", "n", "", "Specify namespace of the Cronjob trigger")
+	verifyCmd.Flags().Bool("repair", false, "Recreate the CronJob if it's missing or restore its owner reference and schedule")
+}
+
+// cronJobDiscrepancy is a difference between a CronJob and the trigger owning it
+type cronJobDiscrepancy struct {
+	CronJob  string
+	Problem  string
+	Repaired bool
+	Error    error
+}
+
+func getTriggerOwnerReferences(trigger *cronjobApi.CronJobTrigger) []metav1.OwnerReference {
+	return []metav1.OwnerReference{
+		{
+			Kind:       triggerOwnerKind,
+			APIVersion: triggerOwnerAPIVersion,
+			Name:       trigger.Name,
+			UID:        trigger.UID,
+		},
+	}
+}
+
+// hasTriggerOwnerReference returns true if the CronJob is owned by the trigger,
+// that is, by an object with its UID
+func hasTriggerOwnerReference(cronJob *batchv1beta1.CronJob, trigger *cronjobApi.CronJobTrigger) bool {
+	for _, ref := range cronJob.OwnerReferences {
+		if ref.UID == trigger.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// getOtherTriggerOwner returns the name of the trigger, other than the given one, owning the CronJob
+func getOtherTriggerOwner(cronJob *batchv1beta1.CronJob, trigger *cronjobApi.CronJobTrigger) string {
+	for _, ref := range cronJob.OwnerReferences {
+		if ref.Kind == triggerOwnerKind && ref.Name != trigger.Name {
+			return ref.Name
+		}
+	}
+	return ""
+}
+
+// setTriggerOwnerReference replaces the owner references to previous triggers with the
+// same name (e.g. one that was deleted and created again) with a reference to the trigger
+func setTriggerOwnerReference(cronJob *batchv1beta1.CronJob, trigger *cronjobApi.CronJobTrigger) {
+	refs := []metav1.OwnerReference{}
+	for _, ref := range cronJob.OwnerReferences {
+		if ref.Kind != triggerOwnerKind || ref.Name != trigger.Name {
+			refs = append(refs, ref)
+		}
+	}
+	cronJob.OwnerReferences = append(refs, getTriggerOwnerReferences(trigger)...)
+}
+
+// verifyCronJobs compares the CronJob that the controller creates for the trigger with its spec.
+// With repair, a missing CronJob is created with the given function.
+func verifyCronJobs(cli kubernetes.Interface, trigger *cronjobApi.CronJobTrigger, repair bool, create func(*cronjobApi.CronJobTrigger) error) ([]cronJobDiscrepancy, error) {
+	name := getCronJobName(trigger)
+	cronJob, err := cli.BatchV1beta1().CronJobs(trigger.Namespace).Get(name, metav1.GetOptions{})
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return nil, fmt.Errorf("Unable to get the CronJob %s: %v", name, err)
+	}
+	if k8sErrors.IsNotFound(err) {
+		d := cronJobDiscrepancy{CronJob: name, Problem: "Missing"}
+		if repair {
+			d.Error = create(trigger)
+			d.Repaired = d.Error == nil
+		}
+		return []cronJobDiscrepancy{d}, nil
+	}
+	if owner := getOtherTriggerOwner(cronJob, trigger); owner != "" {
+		// The CronJob name only depends on the function, so it's not modified
+		return []cronJobDiscrepancy{{CronJob: name, Problem: fmt.Sprintf("Owned by the Cronjob trigger %s", owner)}}, nil
+	}
+
+	discrepancies := []cronJobDiscrepancy{}
+	if !hasTriggerOwnerReference(cronJob, trigger) {
+		discrepancies = append(discrepancies, cronJobDiscrepancy{CronJob: name, Problem: "Not owned by the trigger"})
+		setTriggerOwnerReference(cronJob, trigger)
+	}
+	if cronJob.Spec.Schedule != trigger.Spec.Schedule {
+		discrepancies = append(discrepancies, cronJobDiscrepancy{CronJob: name, Problem: fmt.Sprintf("Schedule %q instead of %q", cronJob.Spec.Schedule, trigger.Spec.Schedule)})
+		cronJob.Spec.Schedule = trigger.Spec.Schedule
+	}
+	if repair && len(discrepancies) > 0 {
+		_, err := cli.BatchV1beta1().CronJobs(trigger.Namespace).Update(cronJob)
+		for i := range discrepancies {
+			discrepancies[i].Repaired = err == nil
+			discrepancies[i].Error = err
+		}
+	}
+	return discrepancies, nil
+}
+
+// printDiscrepancies prints a table with the discrepancies found and
+// returns the number of them that have not been repaired
+func printDiscrepancies(w io.Writer, discrepancies []cronJobDiscrepancy) int {
+	pending := 0
+	table := uitable.New()
+	table.MaxColWidth = 80
+	table.Wrap = true
+	table.AddRow("CRONJOB", "PROBLEM", "STATUS")
+	for _, d := range discrepancies {
+		status := "Not repaired"
+		switch {
+		case d.Repaired:
+			status = "Repaired"
+		case d.Error != nil:
+			status = fmt.Sprintf("Repair failed: %v", d.Error)
+		}
+		if !d.Repaired {
+			pending++
+		}
+		table.AddRow(d.CronJob, d.Problem, status)
+	}
+	fmt.Fprintln(w, table)
+	return pending
+}
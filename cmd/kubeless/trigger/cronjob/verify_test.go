@@ -0,0 +1,151 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"testing"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	cronjobUtils "github.com/kubeless/cronjob-trigger/pkg/utils"
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newVerifiedTrigger() *cronjobApi.CronJobTrigger {
+	return &cronjobApi.CronJobTrigger{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo-trigger",
+			Namespace: "myns",
+			UID:       "trigger-uid",
+		},
+		Spec: cronjobApi.CronJobTriggerSpec{
+			FunctionName: "foo",
+			Schedule:     "0 8 * * 1-5",
+		},
+	}
+}
+
+func getVerifiedCronJob(t *testing.T, cli kubernetes.Interface, name string) *batchv1beta1.CronJob {
+	cronJob, err := cli.BatchV1beta1().CronJobs("myns").Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cronJob
+}
+
+func TestVerifyCronJobs(t *testing.T) {
+	trigger := newVerifiedTrigger()
+	cli := fake.NewSimpleClientset(&batchv1beta1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "trigger-foo",
+			Namespace: "myns",
+			// Owned by a previous trigger with the same name
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Trigger", Name: "foo-trigger", UID: "old-uid"}},
+		},
+		Spec: batchv1beta1.CronJobSpec{Schedule: "* * * * *"},
+	})
+	create := func(*cronjobApi.CronJobTrigger) error {
+		t.Fatal("The CronJob exists, it shouldn't be created")
+		return nil
+	}
+
+	discrepancies, err := verifyCronJobs(cli, trigger, false, create)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discrepancies) != 2 {
+		t.Fatalf("Expecting 2 discrepancies, got %v", discrepancies)
+	}
+	for _, d := range discrepancies {
+		if d.Repaired {
+			t.Errorf("Nothing should be repaired without --repair, got %v", d)
+		}
+	}
+	if getVerifiedCronJob(t, cli, "trigger-foo").Spec.Schedule != "* * * * *" {
+		t.Error("The CronJob shouldn't be modified without --repair")
+	}
+
+	discrepancies, err = verifyCronJobs(cli, trigger, true, create)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range discrepancies {
+		if !d.Repaired {
+			t.Errorf("Expecting every discrepancy to be repaired, got %v", d)
+		}
+	}
+	cronJob := getVerifiedCronJob(t, cli, "trigger-foo")
+	if len(cronJob.OwnerReferences) != 1 || cronJob.OwnerReferences[0].UID != "trigger-uid" {
+		t.Errorf("The CronJob should be owned by the trigger, got %v", cronJob.OwnerReferences)
+	}
+	if cronJob.Spec.Schedule != trigger.Spec.Schedule {
+		t.Errorf("Expecting schedule %q in the CronJob, got %q", trigger.Spec.Schedule, cronJob.Spec.Schedule)
+	}
+
+	discrepancies, err = verifyCronJobs(cli, trigger, false, create)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discrepancies) != 0 {
+		t.Errorf("Expecting no discrepancies after the repair, got %v", discrepancies)
+	}
+}
+
+func TestVerifyCronJobsRecreate(t *testing.T) {
+	trigger := newVerifiedTrigger()
+	cli := fake.NewSimpleClientset()
+	f := &kubelessApi.Function{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns"}}
+	create := func(trigger *cronjobApi.CronJobTrigger) error {
+		return cronjobUtils.EnsureCronJob(cli, f, trigger, "provision-image", getTriggerOwnerReferences(trigger), nil)
+	}
+
+	discrepancies, err := verifyCronJobs(cli, trigger, true, create)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discrepancies) != 1 || !discrepancies[0].Repaired {
+		t.Fatalf("Expecting the CronJob to be recreated, got %v", discrepancies)
+	}
+	cronJob := getVerifiedCronJob(t, cli, "trigger-foo")
+	if cronJob.Spec.Schedule != "0 8 * * 1-5" || cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image != "provision-image" {
+		t.Errorf("The CronJob should be created from the spec of the trigger, got %v", cronJob.Spec)
+	}
+}
+
+func TestVerifyCronJobsOtherOwner(t *testing.T) {
+	trigger := newVerifiedTrigger()
+	cli := fake.NewSimpleClientset(&batchv1beta1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "trigger-foo",
+			Namespace:       "myns",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Trigger", Name: "bar-trigger", UID: "bar-uid"}},
+		},
+	})
+	discrepancies, err := verifyCronJobs(cli, trigger, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discrepancies) != 1 || discrepancies[0].Repaired {
+		t.Fatalf("Expecting an unrepaired discrepancy, got %v", discrepancies)
+	}
+	if getVerifiedCronJob(t, cli, "trigger-foo").OwnerReferences[0].Name != "bar-trigger" {
+		t.Error("A CronJob owned by another trigger shouldn't be modified")
+	}
+}
@@ -64,6 +64,10 @@ var createCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatal(err)
 		}
+		createdOutput, err := kubelessUtils.GetCreatedOutput(cmd.Flags())
+		if err != nil {
+			logrus.Fatal(err)
+		}
 
 		kubelessClient, err := kubelessUtils.GetKubelessClientOutCluster()
 		if err != nil {
@@ -150,6 +154,14 @@ var createCmd = &cobra.Command{
 		}
 		httpTrigger.Spec.BasicAuthSecret = basicAuthSecret
 
+		annotationsFromFile, err := cmd.Flags().GetString("annotations-from-file")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if err := kubelessUtils.ApplyAnnotationsFile(&httpTrigger.ObjectMeta, annotationsFromFile); err != nil {
+			logrus.Fatal(err)
+		}
+
 		if dryrun == true {
 			res, err := kubelessUtils.DryRunFmt(output, httpTrigger)
 			if err != nil {
@@ -159,7 +171,7 @@ var createCmd = &cobra.Command{
 			return
 		}
 
-		httpClient, err := httpUtils.GetKubelessClientOutCluster()
+		httpClient, err := kubelessUtils.GetHTTPTriggerClientOutCluster()
 		if err != nil {
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
@@ -168,6 +180,12 @@ var createCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatalf("Failed to deploy HTTP trigger %s in namespace %s. Error: %s", triggerName, ns, err)
 		}
+		if createdOutput != "" {
+			if err := kubelessUtils.PrintCreated(cmd.OutOrStdout(), createdOutput, "httptrigger.kubeless.io", triggerName, &httpTrigger); err != nil {
+				logrus.Fatal(err)
+			}
+			return
+		}
 		logrus.Infof("HTTP trigger %s created in namespace %s successfully!", triggerName, ns)
 	},
 }
@@ -182,7 +200,8 @@ func init() {
 	createCmd.Flags().StringP("basic-auth-secret", "", "", "Specify an existing secret name for basic authentication")
 	createCmd.Flags().StringP("tls-secret", "", "", "Specify an existing secret that contains a TLS private key and certificate to secure ingress")
 	createCmd.Flags().Bool("dryrun", false, "Output JSON manifest of the function without creating it")
-	createCmd.Flags().StringP("output", "o", "yaml", "Output format")
+	createCmd.Flags().StringP("annotations-from-file", "", "", "Specify a YAML or JSON file with a flat map of annotations to add to the trigger")
+	createCmd.Flags().StringP("output", "o", "yaml", "Output format. One of: json|yaml with --dryrun. When given without --dryrun, the created trigger is printed as name|json|yaml")
 	createCmd.Flags().BoolP("cors-enable", "", false, "If true then cors will be enabled on Http Trigger")
 	createCmd.MarkFlagRequired("function-name")
 }
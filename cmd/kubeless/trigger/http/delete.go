@@ -17,11 +17,14 @@ limitations under the License.
 package http
 
 import (
+	"time"
+
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	httpUtils "github.com/kubeless/http-trigger/pkg/utils"
 	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var deleteCmd = &cobra.Command{
@@ -43,7 +46,16 @@ var deleteCmd = &cobra.Command{
 			ns = kubelessUtils.GetDefaultNamespace()
 		}
 
-		httpClient, err := httpUtils.GetKubelessClientOutCluster()
+		wait, err := cmd.Flags().GetBool("wait")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		httpClient, err := kubelessUtils.GetHTTPTriggerClientOutCluster()
 		if err != nil {
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
@@ -52,10 +64,22 @@ var deleteCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatalf("Failed to delete HTTP trigger object %s in namespace %s. Error: %s", triggerName, ns, err)
 		}
+
+		if wait {
+			logrus.Infof("Waiting for HTTP trigger %s to be deleted...", triggerName)
+			err = kubelessUtils.WaitForDeletion("HTTP trigger", triggerName, timeout, func() (metav1.Object, error) {
+				return httpClient.KubelessV1beta1().HTTPTriggers(ns).Get(triggerName, metav1.GetOptions{})
+			})
+			if err != nil {
+				logrus.Fatal(err)
+			}
+		}
 		logrus.Infof("HTTP trigger %s deleted from namespace %s successfully!", triggerName, ns)
 	},
 }
 
 func init() {
 	deleteCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function")
+	deleteCmd.Flags().Bool("wait", false, "Wait until the HTTP trigger and its finalizers are completely removed")
+	deleteCmd.Flags().Duration("timeout", time.Minute, "Maximum time to wait with --wait")
 }
@@ -35,4 +35,6 @@ func init() {
 	HTTPTriggerCmd.AddCommand(deleteCmd)
 	HTTPTriggerCmd.AddCommand(listCmd)
 	HTTPTriggerCmd.AddCommand(updateCmd)
+	HTTPTriggerCmd.AddCommand(openAPICmd)
+	HTTPTriggerCmd.AddCommand(testCmd)
 }
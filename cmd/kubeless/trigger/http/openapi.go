@@ -0,0 +1,232 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	httpApi "github.com/kubeless/http-trigger/pkg/apis/kubeless/v1beta1"
+	"github.com/kubeless/http-trigger/pkg/client/clientset/versioned"
+	kubelessVersioned "github.com/kubeless/kubeless/pkg/client/clientset/versioned"
+	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const openAPIAnnotationPrefix = "kubeless.io/openapi-"
+
+var openAPICmd = &cobra.Command{
+	Use:   "openapi <function_name> FLAG",
+	Short: "Generate an OpenAPI document for a function exposed with http triggers",
+	Long: `Generate a minimal OpenAPI 3 document for a function exposed with http triggers.
+
+The document can be customized using the following annotations in the function or the http trigger
+(the ones in the trigger take precedence):
+  kubeless.io/openapi-title: Title of the API (default: the function name)
+  kubeless.io/openapi-version: Version of the API (default: 1.0.0)
+  kubeless.io/openapi-description: Description of the API
+  kubeless.io/openapi-summary: Summary of the function operations
+  kubeless.io/openapi-methods: Comma separated list of HTTP methods (default: get,post)
+  kubeless.io/openapi-content-type: Content type of the request and response (default: application/json)`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			logrus.Fatal("Need exactly one argument - function name")
+		}
+		functionName := args[0]
+
+		ns, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if ns == "" {
+			ns = kubelessUtils.GetDefaultNamespace()
+		}
+
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		kubelessClient, err := kubelessUtils.GetKubelessClientOutCluster()
+		if err != nil {
+			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
+		}
+
+		httpClient, err := kubelessUtils.GetHTTPTriggerClientOutCluster()
+		if err != nil {
+			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
+		}
+
+		if err := doOpenAPI(cmd.OutOrStdout(), kubelessClient, httpClient, ns, functionName, output); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	openAPICmd.Flags().StringP("namespace", "n", "", "Specify namespace of the function")
+	openAPICmd.Flags().StringP("output", "o", "yaml", "Output format. One of: json|yaml")
+}
+
+type openAPIDocument struct {
+	OpenAPI string                            `json:"openapi"`
+	Info    openAPIInfo                       `json:"info"`
+	Paths   map[string]map[string]interface{} `json:"paths"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary,omitempty"`
+	RequestBody *openAPIContent            `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIContent struct {
+	Content map[string]interface{} `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                 `json:"description"`
+	Content     map[string]interface{} `json:"content,omitempty"`
+}
+
+func doOpenAPI(w io.Writer, kubelessClient kubelessVersioned.Interface, httpClient versioned.Interface, ns, functionName, output string) error {
+	f, err := kubelessUtils.GetFunctionCustomResource(kubelessClient, functionName, ns)
+	if err != nil {
+		return fmt.Errorf("Unable to find Function %s in namespace %s. Error %s", functionName, ns, err)
+	}
+
+	triggersList, err := httpClient.KubelessV1beta1().HTTPTriggers(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	triggers := []*httpApi.HTTPTrigger{}
+	for _, trigger := range triggersList.Items {
+		if trigger.Spec.FunctionName == functionName {
+			triggers = append(triggers, trigger)
+		}
+	}
+	if len(triggers) == 0 {
+		return fmt.Errorf("Function %s in namespace %s is not exposed by any http trigger", functionName, ns)
+	}
+
+	doc, err := buildOpenAPIDocument(functionName, f.ObjectMeta.Annotations, triggers)
+	if err != nil {
+		return err
+	}
+	res, err := kubelessUtils.DryRunFmt(output, doc)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, res)
+	return nil
+}
+
+// getOpenAPIAnnotations returns the kubeless.io/openapi-* annotations indexed by their suffix
+func getOpenAPIAnnotations(annotations ...map[string]string) map[string]string {
+	res := map[string]string{}
+	for _, a := range annotations {
+		for k, v := range a {
+			if strings.HasPrefix(k, openAPIAnnotationPrefix) {
+				res[strings.TrimPrefix(k, openAPIAnnotationPrefix)] = v
+			}
+		}
+	}
+	return res
+}
+
+func getOpenAPIValue(values map[string]string, key, defaultValue string) string {
+	if v, ok := values[key]; ok && v != "" {
+		return v
+	}
+	return defaultValue
+}
+
+func buildOpenAPIDocument(functionName string, functionAnnotations map[string]string, triggers []*httpApi.HTTPTrigger) (*openAPIDocument, error) {
+	// Sort the triggers so the output is stable
+	sort.Slice(triggers, func(i, j int) bool {
+		return triggers[i].Name < triggers[j].Name
+	})
+	values := getOpenAPIAnnotations(functionAnnotations)
+	doc := &openAPIDocument{
+		OpenAPI: "3.0.0",
+		Info: openAPIInfo{
+			Title:       getOpenAPIValue(values, "title", functionName),
+			Description: values["description"],
+			Version:     getOpenAPIValue(values, "version", "1.0.0"),
+		},
+		Paths: map[string]map[string]interface{}{},
+	}
+
+	for _, trigger := range triggers {
+		values := getOpenAPIAnnotations(functionAnnotations, trigger.ObjectMeta.Annotations)
+		scheme := "http"
+		if trigger.Spec.TLSAcme || trigger.Spec.TLSSecret != "" {
+			scheme = "https"
+		}
+		path := "/" + strings.TrimPrefix(trigger.Spec.Path, "/")
+		if _, exists := doc.Paths[path]; exists {
+			return nil, fmt.Errorf("Path %s is exposed by more than one http trigger", path)
+		}
+		contentType := getOpenAPIValue(values, "content-type", "application/json")
+		pathItem := map[string]interface{}{
+			"servers": []openAPIServer{
+				{URL: fmt.Sprintf("%s://%s", scheme, trigger.Spec.HostName)},
+			},
+		}
+		for _, method := range strings.Split(getOpenAPIValue(values, "methods", "get,post"), ",") {
+			method = strings.ToLower(strings.TrimSpace(method))
+			switch method {
+			case "get", "put", "post", "delete", "options", "head", "patch":
+			default:
+				return nil, fmt.Errorf("Unsupported HTTP method %q in %smethods", method, openAPIAnnotationPrefix)
+			}
+			op := openAPIOperation{
+				OperationID: fmt.Sprintf("%s-%s", trigger.Name, method),
+				Summary:     values["summary"],
+				Responses: map[string]openAPIResponse{
+					"200": {
+						Description: "Function response",
+						Content:     map[string]interface{}{contentType: map[string]interface{}{}},
+					},
+				},
+			}
+			if method != "get" && method != "head" && method != "delete" && method != "options" {
+				op.RequestBody = &openAPIContent{
+					Content: map[string]interface{}{contentType: map[string]interface{}{}},
+				}
+			}
+			pathItem[method] = op
+		}
+		doc.Paths[path] = pathItem
+	}
+	return doc, nil
+}
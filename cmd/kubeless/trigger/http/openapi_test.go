@@ -0,0 +1,98 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	httpApi "github.com/kubeless/http-trigger/pkg/apis/kubeless/v1beta1"
+	httpFake "github.com/kubeless/http-trigger/pkg/client/clientset/versioned/fake"
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	fFake "github.com/kubeless/kubeless/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOpenAPI(t *testing.T) {
+	f := &kubelessApi.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "myns",
+			Annotations: map[string]string{
+				"kubeless.io/openapi-title":   "Foo API",
+				"kubeless.io/openapi-methods": "get",
+			},
+		},
+	}
+	trigger := &httpApi.HTTPTrigger{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo-trigger",
+			Namespace: "myns",
+			Annotations: map[string]string{
+				"kubeless.io/openapi-methods": "get, POST",
+			},
+		},
+		Spec: httpApi.HTTPTriggerSpec{
+			FunctionName: "foo",
+			HostName:     "foo.example.com",
+			Path:         "echo",
+			TLSSecret:    "foo-tls",
+		},
+	}
+	kubelessClient := fFake.NewSimpleClientset(f)
+	httpClient := httpFake.NewSimpleClientset(trigger)
+
+	var buf bytes.Buffer
+	if err := doOpenAPI(&buf, kubelessClient, httpClient, "myns", "foo", "json"); err != nil {
+		t.Fatal(err)
+	}
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Unable to parse the document: %v", err)
+	}
+	if doc["openapi"] != "3.0.0" {
+		t.Errorf("Unexpected OpenAPI version %v", doc["openapi"])
+	}
+	if title := doc["info"].(map[string]interface{})["title"]; title != "Foo API" {
+		t.Errorf("Expecting title Foo API, got %v", title)
+	}
+	path, ok := doc["paths"].(map[string]interface{})["/echo"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expecting path /echo in %v", doc["paths"])
+	}
+	url := path["servers"].([]interface{})[0].(map[string]interface{})["url"]
+	if url != "https://foo.example.com" {
+		t.Errorf("Expecting server https://foo.example.com, got %v", url)
+	}
+	// Annotations of the trigger take precedence
+	if _, ok := path["get"]; !ok {
+		t.Error("Expecting a get operation")
+	}
+	post, ok := path["post"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Expecting a post operation")
+	}
+	if _, ok := post["requestBody"]; !ok {
+		t.Error("Expecting a request body for the post operation")
+	}
+
+	// Functions without triggers should fail
+	if err := doOpenAPI(&buf, kubelessClient, httpFake.NewSimpleClientset(), "myns", "foo", "json"); err == nil {
+		t.Error("Expecting an error for a function without http triggers")
+	}
+}
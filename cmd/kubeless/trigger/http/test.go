@@ -0,0 +1,245 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	httpApi "github.com/kubeless/http-trigger/pkg/apis/kubeless/v1beta1"
+	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test <http_trigger_name> FLAG",
+	Short: "Send a request to a function through its http trigger",
+	Long: `Send a request to a function through the host and path of its http trigger and print the
+status, the latency and the body of the response.
+
+The request is sent to the address of the ingress of the trigger. Use --resolve <host>:<ip> to
+send it to a different address, e.g. in clusters without a public DNS for the hostname.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			logrus.Fatal("Need exactly one argument - http trigger name")
+		}
+		triggerName := args[0]
+
+		ns, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if ns == "" {
+			ns = kubelessUtils.GetDefaultNamespace()
+		}
+		data, err := cmd.Flags().GetString("data")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		method, err := cmd.Flags().GetString("method")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if method == "" {
+			method = http.MethodGet
+			if data != "" {
+				method = http.MethodPost
+			}
+		}
+		rawHeaders, err := cmd.Flags().GetStringArray("header")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		headers, err := parseHeaders(rawHeaders)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		resolve, err := cmd.Flags().GetString("resolve")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		httpClient, err := kubelessUtils.GetHTTPTriggerClientOutCluster()
+		if err != nil {
+			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
+		}
+		trigger, err := httpClient.KubelessV1beta1().HTTPTriggers(ns).Get(triggerName, metav1.GetOptions{})
+		if err != nil {
+			logrus.Fatalf("Unable to find HTTP trigger %s in namespace %s. Error %s", triggerName, ns, err)
+		}
+		if trigger.Spec.HostName == "" {
+			logrus.Fatalf("HTTP trigger %s in namespace %s has no hostname", triggerName, ns)
+		}
+		triggerURL := getTriggerURL(trigger)
+
+		address := ""
+		if resolve != "" {
+			host, ip, err := parseResolve(resolve)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			if host != triggerURL.Hostname() {
+				logrus.Fatalf("The host %s given in --resolve doesn't match the hostname of the trigger, %s", host, triggerURL.Hostname())
+			}
+			address = ip
+		} else {
+			address, err = getIngressAddress(kubelessUtils.GetClientOutOfCluster(), ns, triggerName)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			if address == "" {
+				logrus.Warnf("The ingress of the HTTP trigger %s has no address yet, resolving %s with the DNS", triggerName, triggerURL.Hostname())
+			}
+		}
+
+		client := newTriggerTestClient(address, timeout)
+		if err := doTriggerTest(cmd.OutOrStdout(), client, method, triggerURL.String(), data, headers); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	testCmd.Flags().StringP("namespace", "n", "", "Specify namespace of the HTTP trigger")
+	testCmd.Flags().StringP("data", "d", "", "Specify the body of the request")
+	testCmd.Flags().StringP("method", "X", "", "HTTP method of the request. It defaults to POST if --data is given and to GET otherwise")
+	testCmd.Flags().StringArrayP("header", "H", []string{}, "Add a header to the request, given as '<name>: <value>'. It can be repeated")
+	testCmd.Flags().String("resolve", "", "Send the request to the given IP instead of resolving the hostname of the trigger. Given as <host>:<ip>")
+	testCmd.Flags().Duration("timeout", 30*time.Second, "Maximum time to wait for the response")
+}
+
+// getTriggerURL returns the URL exposed by the ingress of the trigger
+func getTriggerURL(trigger *httpApi.HTTPTrigger) *url.URL {
+	scheme := "http"
+	if trigger.Spec.TLSAcme || trigger.Spec.TLSSecret != "" {
+		scheme = "https"
+	}
+	return &url.URL{
+		Scheme: scheme,
+		Host:   trigger.Spec.HostName,
+		Path:   "/" + strings.TrimPrefix(trigger.Spec.Path, "/"),
+	}
+}
+
+// getIngressAddress returns the external IP or hostname of the ingress of the trigger.
+// It returns an empty string if the ingress controller hasn't assigned one yet.
+func getIngressAddress(cli kubernetes.Interface, ns, name string) (string, error) {
+	ingress, err := cli.ExtensionsV1beta1().Ingresses(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("Unable to get the ingress of the HTTP trigger %s: %v", name, err)
+	}
+	for _, lb := range ingress.Status.LoadBalancer.Ingress {
+		if lb.IP != "" {
+			return lb.IP, nil
+		}
+		if lb.Hostname != "" {
+			return lb.Hostname, nil
+		}
+	}
+	return "", nil
+}
+
+// parseResolve parses the value of --resolve, given as <host>:<ip>
+func parseResolve(value string) (string, string, error) {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || net.ParseIP(parts[1]) == nil {
+		return "", "", fmt.Errorf("Invalid value %q for --resolve. It should be <host>:<ip>, e.g. example.com:192.168.99.100", value)
+	}
+	return parts[0], parts[1], nil
+}
+
+// parseHeaders parses headers given as '<name>: <value>'
+func parseHeaders(raw []string) (http.Header, error) {
+	headers := http.Header{}
+	for _, h := range raw {
+		parts := strings.SplitN(h, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("Invalid header %q. It should be given as '<name>: <value>'", h)
+		}
+		headers.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+	return headers, nil
+}
+
+// newTriggerTestClient returns a client that connects to the given address, keeping the port
+// of the URL, instead of resolving the hostname. The hostname is still used in the Host
+// header and for TLS. An empty address resolves the hostname as usual. Proxies are not used
+// when an address is given, since the request should reach it directly.
+func newTriggerTestClient(address string, timeout time.Duration) *http.Client {
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	if address != "" {
+		transport.Proxy = nil
+		dialer := &net.Dialer{Timeout: timeout}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			_, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			return dialer.DialContext(ctx, network, net.JoinHostPort(address, port))
+		}
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
+
+// doTriggerTest sends the request and prints the status, the latency and the body of the response
+func doTriggerTest(w io.Writer, client *http.Client, method, url, data string, headers http.Header) error {
+	req, err := http.NewRequest(method, url, strings.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for name, values := range headers {
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	if data != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	start := time.Now()
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Request to %s failed: %v", url, err)
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("Unable to read the response of %s: %v", url, err)
+	}
+	latency := time.Since(start)
+
+	fmt.Fprintf(w, "%s %s\n", method, url)
+	fmt.Fprintf(w, "Status: %s\n", res.Status)
+	fmt.Fprintf(w, "Latency: %v\n", latency.Round(time.Millisecond))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, string(body))
+	return nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	httpApi "github.com/kubeless/http-trigger/pkg/apis/kubeless/v1beta1"
+	"k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetTriggerURL(t *testing.T) {
+	trigger := &httpApi.HTTPTrigger{
+		Spec: httpApi.HTTPTriggerSpec{
+			HostName: "hello.example.com",
+			Path:     "/greet",
+		},
+	}
+	if u := getTriggerURL(trigger).String(); u != "http://hello.example.com/greet" {
+		t.Errorf("Unexpected URL %s", u)
+	}
+	trigger.Spec.Path = ""
+	trigger.Spec.TLSSecret = "hello-tls"
+	if u := getTriggerURL(trigger).String(); u != "https://hello.example.com/" {
+		t.Errorf("Unexpected URL %s", u)
+	}
+}
+
+func TestGetIngressAddress(t *testing.T) {
+	cli := fake.NewSimpleClientset(
+		&extensionsv1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "with-ip", Namespace: "default"},
+			Status: extensionsv1beta1.IngressStatus{
+				LoadBalancer: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "10.0.0.1"}}},
+			},
+		},
+		&extensionsv1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "with-hostname", Namespace: "default"},
+			Status: extensionsv1beta1.IngressStatus{
+				LoadBalancer: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{Hostname: "lb.example.com"}}},
+			},
+		},
+		&extensionsv1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "default"},
+		},
+	)
+	for name, expected := range map[string]string{"with-ip": "10.0.0.1", "with-hostname": "lb.example.com", "pending": ""} {
+		address, err := getIngressAddress(cli, "default", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if address != expected {
+			t.Errorf("Expecting address %q for %s, got %q", expected, name, address)
+		}
+	}
+	if _, err := getIngressAddress(cli, "default", "missing"); err == nil {
+		t.Error("Expecting an error for a missing ingress")
+	}
+}
+
+func TestParseResolve(t *testing.T) {
+	host, ip, err := parseResolve("hello.example.com:192.168.99.100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host != "hello.example.com" || ip != "192.168.99.100" {
+		t.Errorf("Unexpected host %s and ip %s", host, ip)
+	}
+	for _, value := range []string{"hello.example.com", ":192.168.99.100", "hello.example.com:not-an-ip"} {
+		if _, _, err := parseResolve(value); err == nil {
+			t.Errorf("Expecting an error for %q", value)
+		}
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := parseHeaders([]string{"X-Token: abc", "Accept:text/plain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if headers.Get("X-Token") != "abc" || headers.Get("Accept") != "text/plain" {
+		t.Errorf("Unexpected headers %v", headers)
+	}
+	if _, err := parseHeaders([]string{"X-Token"}); err == nil {
+		t.Error("Expecting an error for a header without value")
+	}
+}
+
+func TestDoTriggerTest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if !strings.HasPrefix(r.Host, "hello.example.com:") || r.Method != http.MethodPost || r.URL.Path != "/greet" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(r.Header.Get("X-Token") + " " + string(body)))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The hostname of the trigger is sent to the address of the server
+	client := newTriggerTestClient(serverURL.Hostname(), 5*time.Second)
+	var out bytes.Buffer
+	triggerURL := "http://hello.example.com:" + serverURL.Port() + "/greet"
+	err = doTriggerTest(&out, client, http.MethodPost, triggerURL, "hi", http.Header{"X-Token": {"abc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"POST " + triggerURL, "Status: 200 OK", "Latency: ", "abc hi"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expecting %q in the output, got:\n%s", expected, out.String())
+		}
+	}
+}
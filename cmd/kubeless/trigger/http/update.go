@@ -48,7 +48,7 @@ var updateCmd = &cobra.Command{
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
 
-		httpClient, err := httpUtils.GetKubelessClientOutCluster()
+		httpClient, err := kubelessUtils.GetHTTPTriggerClientOutCluster()
 		if err != nil {
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
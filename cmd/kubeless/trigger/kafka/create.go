@@ -67,6 +67,10 @@ var createCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatal(err)
 		}
+		createdOutput, err := kubelessUtils.GetCreatedOutput(cmd.Flags())
+		if err != nil {
+			logrus.Fatal(err)
+		}
 
 		labelSelector, err := metav1.ParseToLabelSelector(functionSelector)
 		if err != nil {
@@ -88,6 +92,14 @@ var createCmd = &cobra.Command{
 		kafkaTrigger.Spec.FunctionSelector.MatchLabels = labelSelector.MatchLabels
 		kafkaTrigger.Spec.Topic = topic
 
+		annotationsFromFile, err := cmd.Flags().GetString("annotations-from-file")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if err := kubelessUtils.ApplyAnnotationsFile(&kafkaTrigger.ObjectMeta, annotationsFromFile); err != nil {
+			logrus.Fatal(err)
+		}
+
 		if dryrun == true {
 			res, err := kubelessUtils.DryRunFmt(output, kafkaTrigger)
 			if err != nil {
@@ -97,7 +109,7 @@ var createCmd = &cobra.Command{
 			return
 		}
 
-		kafkaClient, err := kafkaUtils.GetKubelessClientOutCluster()
+		kafkaClient, err := kubelessUtils.GetKafkaTriggerClientOutCluster()
 		if err != nil {
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
@@ -105,6 +117,12 @@ var createCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatalf("Failed to create Kafka trigger object %s in namespace %s. Error: %s", triggerName, ns, err)
 		}
+		if createdOutput != "" {
+			if err := kubelessUtils.PrintCreated(cmd.OutOrStdout(), createdOutput, "kafkatrigger.kubeless.io", triggerName, &kafkaTrigger); err != nil {
+				logrus.Fatal(err)
+			}
+			return
+		}
 		logrus.Infof("Kafka trigger %s created in namespace %s successfully!", triggerName, ns)
 
 	},
@@ -117,5 +135,6 @@ func init() {
 	createCmd.MarkFlagRequired("trigger-topic")
 	createCmd.MarkFlagRequired("function-selector")
 	createCmd.Flags().Bool("dryrun", false, "Output JSON manifest of the function without creating it")
-	createCmd.Flags().StringP("output", "o", "yaml", "Output format")
+	createCmd.Flags().StringP("annotations-from-file", "", "", "Specify a YAML or JSON file with a flat map of annotations to add to the trigger")
+	createCmd.Flags().StringP("output", "o", "yaml", "Output format. One of: json|yaml with --dryrun. When given without --dryrun, the created trigger is printed as name|json|yaml")
 }
@@ -17,11 +17,14 @@ limitations under the License.
 package kafka
 
 import (
+	"time"
+
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	kafkaUtils "github.com/kubeless/kafka-trigger/pkg/utils"
 	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var deleteCmd = &cobra.Command{
@@ -43,7 +46,16 @@ var deleteCmd = &cobra.Command{
 			ns = kubelessUtils.GetDefaultNamespace()
 		}
 
-		kafkaClient, err := kafkaUtils.GetKubelessClientOutCluster()
+		wait, err := cmd.Flags().GetBool("wait")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		kafkaClient, err := kubelessUtils.GetKafkaTriggerClientOutCluster()
 		if err != nil {
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
@@ -51,10 +63,22 @@ var deleteCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatalf("Failed to delete Kafka trigger object %s in namespace %s. Error: %s", triggerName, ns, err)
 		}
+
+		if wait {
+			logrus.Infof("Waiting for Kafka trigger %s to be deleted...", triggerName)
+			err = kubelessUtils.WaitForDeletion("Kafka trigger", triggerName, timeout, func() (metav1.Object, error) {
+				return kafkaClient.KubelessV1beta1().KafkaTriggers(ns).Get(triggerName, metav1.GetOptions{})
+			})
+			if err != nil {
+				logrus.Fatal(err)
+			}
+		}
 		logrus.Infof("Kafka trigger %s deleted from namespace %s successfully!", triggerName, ns)
 	},
 }
 
 func init() {
 	deleteCmd.Flags().StringP("namespace", "n", "", "Specify namespace of the Kafka trigger")
+	deleteCmd.Flags().Bool("wait", false, "Wait until the Kafka trigger and its finalizers are completely removed")
+	deleteCmd.Flags().Duration("timeout", time.Minute, "Maximum time to wait with --wait")
 }
@@ -35,4 +35,5 @@ func init() {
 	KafkaTriggerCmd.AddCommand(deleteCmd)
 	KafkaTriggerCmd.AddCommand(listCmd)
 	KafkaTriggerCmd.AddCommand(updateCmd)
+	KafkaTriggerCmd.AddCommand(testCmd)
 }
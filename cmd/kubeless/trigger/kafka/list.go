@@ -19,10 +19,10 @@ package kafka
 import (
 	"fmt"
 	"io"
+	"text/template"
 
 	"github.com/gosuri/uitable"
 	"github.com/kubeless/kafka-trigger/pkg/client/clientset/versioned"
-	kafkaUtils "github.com/kubeless/kafka-trigger/pkg/utils"
 	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -37,20 +37,30 @@ var listCmd = &cobra.Command{
 	Long:    `list all Kafka triggers deployed to Kubeless`,
 	Run: func(cmd *cobra.Command, args []string) {
 
-		ns, err := cmd.Flags().GetString("namespace")
+		ns, err := kubelessUtils.GetNamespaceOrAll(cmd.Flags())
 		if err != nil {
-			logrus.Fatal(err.Error())
-		}
-		if ns == "" {
-			ns = kubelessUtils.GetDefaultNamespace()
+			logrus.Fatal(err)
 		}
 
-		kafkaClient, err := kafkaUtils.GetKubelessClientOutCluster()
+		kafkaClient, err := kubelessUtils.GetKafkaTriggerClientOutCluster()
 		if err != nil {
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
 
-		if err := doList(cmd.OutOrStdout(), kafkaClient, ns); err != nil {
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		tmpl, err := kubelessUtils.GetOutputTemplate(cmd.Flags(), output)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		sortBy, err := cmd.Flags().GetString("sort-by")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		if err := doList(cmd.OutOrStdout(), kafkaClient, ns, output, tmpl, sortBy); err != nil {
 			logrus.Fatal(err.Error())
 		}
 	},
@@ -58,13 +68,23 @@ var listCmd = &cobra.Command{
 
 func init() {
 	listCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the function")
+	kubelessUtils.AddAllNamespacesFlag(listCmd.Flags(), "List the Kafka triggers of all the namespaces")
+	listCmd.Flags().StringP("output", "o", "", "Output format. One of: json|yaml|template")
+	kubelessUtils.AddTemplateFlags(listCmd.Flags())
+	kubelessUtils.AddSortByFlag(listCmd.Flags())
 }
 
-func doList(w io.Writer, kubelessClient versioned.Interface, ns string) error {
+func doList(w io.Writer, kubelessClient versioned.Interface, ns, output string, tmpl *template.Template, sortBy string) error {
 	triggersList, err := kubelessClient.KubelessV1beta1().KafkaTriggers(ns).List(metav1.ListOptions{})
 	if err != nil {
 		return err
 	}
+	if err := kubelessUtils.SortObjects(triggersList.Items, sortBy); err != nil {
+		return err
+	}
+	if output != "" {
+		return kubelessUtils.PrintObjects(w, output, tmpl, triggersList.Items)
+	}
 	table := uitable.New()
 	table.MaxColWidth = 50
 	table.Wrap = true
@@ -0,0 +1,313 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	kafkaUtils "github.com/kubeless/kafka-trigger/pkg/utils"
+	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	kafkaControllerName = "kafka-trigger-controller"
+	// defaultKafkaBrokers are the brokers used by the controller when KAFKA_BROKERS is not set
+	defaultKafkaBrokers = "kafka.kubeless:9092"
+	kafkaBinPath        = "/opt/bitnami/kafka/bin"
+)
+
+// kafkaBrokerConfig contains the broker settings of the Kafka trigger controller
+type kafkaBrokerConfig struct {
+	Brokers  string
+	TLS      bool
+	SASL     bool
+	Username string
+	Password string
+}
+
+var testCmd = &cobra.Command{
+	Use:   "test <kafka_trigger_name> FLAG",
+	Short: "send a test message to the topic of a Kafka trigger",
+	Long: `send a test message to the topic of a Kafka trigger
+
+The message is produced from a broker pod, using the brokers and the SASL credentials
+configured in the Kafka trigger controller. The connection with the brokers is checked
+before producing the message. Use --logs to print the logs of the functions of the trigger
+while the message is processed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			logrus.Fatal("Need exactly one argument - Kafka trigger name")
+		}
+		triggerName := args[0]
+
+		ns, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if ns == "" {
+			ns = kubelessUtils.GetDefaultNamespace()
+		}
+		message, err := cmd.Flags().GetString("message")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if strings.ContainsAny(message, "\r\n") {
+			logrus.Fatal("The message can't contain new lines, every line is produced as a different message")
+		}
+		kafkaNamespace, err := cmd.Flags().GetString("kafka-namespace")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		logs, err := cmd.Flags().GetBool("logs")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		kafkaClient, err := kubelessUtils.GetKafkaTriggerClientOutCluster()
+		if err != nil {
+			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
+		}
+		trigger, err := kafkaUtils.GetKafkaTriggerCustomResource(kafkaClient, triggerName, ns)
+		if err != nil {
+			logrus.Fatalf("Unable to find Kafka trigger %s in namespace %s. Error %s", triggerName, ns, err)
+		}
+
+		cli := kubelessUtils.GetClientOutOfCluster()
+		config, err := getKafkaBrokerConfig(cli, kafkaNamespace)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if config.TLS {
+			logrus.Fatal("The Kafka trigger controller connects to the brokers with TLS, which is not supported by this command")
+		}
+		pods, err := kubelessUtils.GetPodsByLabel(cli, kafkaNamespace, "kubeless", "kafka")
+		if err != nil {
+			logrus.Fatalf("Can't find the kafka pod: %v", err)
+		}
+		brokerPod, err := kubelessUtils.GetReadyPod(pods)
+		if err != nil {
+			logrus.Fatalf("No kafka pod is running in namespace %s: %v", kafkaNamespace, err)
+		}
+
+		conf, err := kubelessUtils.BuildOutOfClusterConfig()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		since := metav1.Now()
+		logrus.Infof("Producing message to topic %s using the brokers %s...", trigger.Spec.Topic, config.Brokers)
+		if err := produceMessage(conf, cli, kafkaNamespace, brokerPod.Name, getProducerCommand(config, trigger.Spec.Topic), message, os.Stderr); err != nil {
+			logrus.Fatalf("Unable to produce the message: %v", err)
+		}
+		logrus.Infof("Message sent to topic %s", trigger.Spec.Topic)
+
+		if logs {
+			selector := metav1.FormatLabelSelector(&trigger.Spec.FunctionSelector)
+			logrus.Infof("Printing the logs of the pods matching %s for %v...", selector, timeout)
+			if err := tailFunctionLogs(cli, ns, selector, since, timeout, os.Stdout); err != nil {
+				logrus.Fatal(err)
+			}
+		}
+	},
+}
+
+// getKafkaBrokerConfig returns the broker settings of the Kafka trigger controller
+// deployed in the given namespace, resolving the values stored in secrets or configmaps
+func getKafkaBrokerConfig(cli kubernetes.Interface, ns string) (*kafkaBrokerConfig, error) {
+	dpm, err := cli.AppsV1().Deployments(ns).Get(kafkaControllerName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to find the Kafka trigger controller in namespace %s: %v", ns, err)
+	}
+	if len(dpm.Spec.Template.Spec.Containers) == 0 {
+		return nil, fmt.Errorf("The deployment %s in namespace %s has no containers", kafkaControllerName, ns)
+	}
+	env := map[string]string{}
+	for _, e := range dpm.Spec.Template.Spec.Containers[0].Env {
+		value, err := getEnvValue(cli, ns, e)
+		if err != nil {
+			return nil, err
+		}
+		env[e.Name] = value
+	}
+
+	config := &kafkaBrokerConfig{
+		Brokers:  env["KAFKA_BROKERS"],
+		Username: env["KAFKA_USERNAME"],
+		Password: env["KAFKA_PASSWORD"],
+	}
+	if config.Brokers == "" {
+		config.Brokers = defaultKafkaBrokers
+	}
+	// The controller ignores the values that are not valid booleans
+	config.TLS, _ = strconv.ParseBool(env["KAFKA_ENABLE_TLS"])
+	config.SASL, _ = strconv.ParseBool(env["KAFKA_ENABLE_SASL"])
+	if config.SASL && (config.Username == "" || config.Password == "") {
+		return nil, fmt.Errorf("The Kafka trigger controller has SASL enabled but KAFKA_USERNAME or KAFKA_PASSWORD are empty")
+	}
+	return config, nil
+}
+
+// getEnvValue returns the value of an environment variable given directly or from a secret or a configmap
+func getEnvValue(cli kubernetes.Interface, ns string, env v1.EnvVar) (string, error) {
+	if env.ValueFrom == nil {
+		return env.Value, nil
+	}
+	if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+		secret, err := cli.CoreV1().Secrets(ns).Get(ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("Unable to read %s from the secret %s: %v", env.Name, ref.Name, err)
+		}
+		return string(secret.Data[ref.Key]), nil
+	}
+	if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+		configMap, err := cli.CoreV1().ConfigMaps(ns).Get(ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("Unable to read %s from the configmap %s: %v", env.Name, ref.Name, err)
+		}
+		return configMap.Data[ref.Key], nil
+	}
+	// Values from fields or resources are not related to the brokers
+	return "", nil
+}
+
+// getClientProperties returns the Kafka client properties matching the controller settings
+func getClientProperties(config *kafkaBrokerConfig) []string {
+	if !config.SASL {
+		return []string{}
+	}
+	return []string{
+		"security.protocol=SASL_PLAINTEXT",
+		"sasl.mechanism=PLAIN",
+		fmt.Sprintf("sasl.jaas.config=org.apache.kafka.common.security.plain.PlainLoginModule required username=%q password=%q;", config.Username, config.Password),
+	}
+}
+
+// getProducerCommand returns the command that checks the connection with the brokers and
+// produces the messages read from stdin. The client properties are given as arguments so
+// they are not written in the broker pod.
+func getProducerCommand(config *kafkaBrokerConfig, topic string) []string {
+	script := fmt.Sprintf(
+		`%[1]s/kafka-broker-api-versions.sh --bootstrap-server "$1" --command-config <(printf '%%s\n' "${@:3}") > /dev/null && %[1]s/kafka-console-producer.sh --broker-list "$1" --topic "$2" --producer.config <(printf '%%s\n' "${@:3}")`,
+		kafkaBinPath,
+	)
+	return append([]string{"bash", "-c", script, "kafka-test", config.Brokers, topic}, getClientProperties(config)...)
+}
+
+// produceMessage runs the producer command in the given pod, sending the message through its stdin
+func produceMessage(conf *rest.Config, cli kubernetes.Interface, ns, pod string, command []string, message string, out io.Writer) error {
+	cmd := kubelessUtils.Cmd{
+		Stdin:  strings.NewReader(message + "\n"),
+		Stdout: out,
+		Stderr: out,
+	}
+	rt, err := kubelessUtils.ExecRoundTripper(conf, cmd.RoundTripCallback)
+	if err != nil {
+		return err
+	}
+	opts := v1.PodExecOptions{
+		Stdin:     true,
+		Stdout:    true,
+		Stderr:    true,
+		Container: "broker",
+		Command:   command,
+	}
+	req, err := kubelessUtils.Exec(cli.CoreV1(), pod, ns, opts)
+	if err != nil {
+		return err
+	}
+	_, err = rt.RoundTrip(req)
+	return err
+}
+
+// tailFunctionLogs prints the logs written since the given time by the running pods
+// matching the selector, until the timeout expires
+func tailFunctionLogs(cli kubernetes.Interface, ns, selector string, since metav1.Time, timeout time.Duration, out io.Writer) error {
+	pods, err := cli.CoreV1().Pods(ns).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return err
+	}
+	streams := []io.ReadCloser{}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		stream, err := cli.CoreV1().Pods(ns).GetLogs(pod.Name, &v1.PodLogOptions{
+			Container: pod.Labels["function"],
+			Follow:    true,
+			SinceTime: &since,
+		}).Stream()
+		if err != nil {
+			return fmt.Errorf("Unable to get the logs of %s: %v", pod.Name, err)
+		}
+		streams = append(streams, stream)
+	}
+	if len(streams) == 0 {
+		return fmt.Errorf("No running pods match %s in namespace %s", selector, ns)
+	}
+
+	var mutex sync.Mutex
+	wg := sync.WaitGroup{}
+	for _, stream := range streams {
+		wg.Add(1)
+		go func(stream io.ReadCloser) {
+			defer wg.Done()
+			buf := make([]byte, 4096)
+			for {
+				n, err := stream.Read(buf)
+				if n > 0 {
+					mutex.Lock()
+					out.Write(buf[:n])
+					mutex.Unlock()
+				}
+				if err != nil {
+					return
+				}
+			}
+		}(stream)
+	}
+	time.Sleep(timeout)
+	for _, stream := range streams {
+		stream.Close()
+	}
+	wg.Wait()
+	return nil
+}
+
+func init() {
+	testCmd.Flags().StringP("namespace", "n", "", "Specify namespace of the Kafka trigger")
+	testCmd.Flags().String("message", "", "Message to produce in the topic of the trigger")
+	testCmd.Flags().String("kafka-namespace", "kubeless", "Namespace where the Kafka trigger controller and the brokers are deployed")
+	testCmd.Flags().Bool("logs", false, "Print the logs of the functions of the trigger after producing the message")
+	testCmd.Flags().Duration("timeout", 10*time.Second, "Time to print the logs of the functions given with --logs")
+	testCmd.MarkFlagRequired("message")
+}
@@ -0,0 +1,112 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func controllerDeployment(env []v1.EnvVar) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kafkaControllerName,
+			Namespace: "kubeless",
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Name: "kafka-trigger-controller", Env: env}},
+				},
+			},
+		},
+	}
+}
+
+func TestGetKafkaBrokerConfig(t *testing.T) {
+	cli := fake.NewSimpleClientset(controllerDeployment(nil))
+	config, err := getKafkaBrokerConfig(cli, "kubeless")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(config, &kafkaBrokerConfig{Brokers: defaultKafkaBrokers}) {
+		t.Errorf("Unexpected default config %v", config)
+	}
+
+	cli = fake.NewSimpleClientset(controllerDeployment([]v1.EnvVar{
+		{Name: "KAFKA_BROKERS", Value: "my-kafka:9093"},
+		{Name: "KAFKA_ENABLE_SASL", Value: "true"},
+		{Name: "KAFKA_USERNAME", Value: "user"},
+		{Name: "KAFKA_PASSWORD", ValueFrom: &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{Name: "kafka-creds"},
+				Key:                  "password",
+			},
+		}},
+	}), &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka-creds", Namespace: "kubeless"},
+		Data:       map[string][]byte{"password": []byte("s3cr3t")},
+	})
+	config, err = getKafkaBrokerConfig(cli, "kubeless")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &kafkaBrokerConfig{Brokers: "my-kafka:9093", SASL: true, Username: "user", Password: "s3cr3t"}
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("Expecting %v, got %v", expected, config)
+	}
+
+	// SASL without credentials
+	cli = fake.NewSimpleClientset(controllerDeployment([]v1.EnvVar{{Name: "KAFKA_ENABLE_SASL", Value: "true"}}))
+	if _, err := getKafkaBrokerConfig(cli, "kubeless"); err == nil {
+		t.Error("Expecting an error for SASL without credentials")
+	}
+	// Missing controller
+	if _, err := getKafkaBrokerConfig(fake.NewSimpleClientset(), "kubeless"); err == nil {
+		t.Error("Expecting an error if the controller is not deployed")
+	}
+}
+
+func TestGetProducerCommand(t *testing.T) {
+	command := getProducerCommand(&kafkaBrokerConfig{Brokers: "kafka:9092", SASL: true, Username: "user", Password: "pass"}, "orders")
+	if command[0] != "bash" || command[1] != "-c" {
+		t.Fatalf("Unexpected command %v", command)
+	}
+	// The script should be valid bash
+	if out, err := exec.Command("bash", "-n", "-c", command[2]).CombinedOutput(); err != nil {
+		t.Errorf("Invalid script: %v %s", err, out)
+	}
+	args := command[3:]
+	expected := []string{
+		"kafka-test", "kafka:9092", "orders",
+		"security.protocol=SASL_PLAINTEXT",
+		"sasl.mechanism=PLAIN",
+		`sasl.jaas.config=org.apache.kafka.common.security.plain.PlainLoginModule required username="user" password="pass";`,
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expecting arguments %v, got %v", expected, args)
+	}
+	if command := getProducerCommand(&kafkaBrokerConfig{Brokers: "kafka:9092"}, "orders"); len(command) != 6 {
+		t.Errorf("Expecting no client properties without SASL, got %v", command)
+	}
+}
@@ -45,7 +45,7 @@ var updateCmd = &cobra.Command{
 			ns = kubelessUtils.GetDefaultNamespace()
 		}
 
-		kafkaClient, err := kafkaUtils.GetKubelessClientOutCluster()
+		kafkaClient, err := kubelessUtils.GetKafkaTriggerClientOutCluster()
 		if err != nil {
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
@@ -102,6 +102,10 @@ var createCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatal(err)
 		}
+		createdOutput, err := kubelessUtils.GetCreatedOutput(cmd.Flags())
+		if err != nil {
+			logrus.Fatal(err)
+		}
 
 		cli := kubelessUtils.GetClientOutOfCluster()
 		_, err = cli.Core().Secrets(ns).Get(secretName, metav1.GetOptions{})
@@ -128,6 +132,14 @@ var createCmd = &cobra.Command{
 		kinesisTrigger.Spec.Secret = secretName
 		kinesisTrigger.Spec.Endpoint = endpointURL
 
+		annotationsFromFile, err := cmd.Flags().GetString("annotations-from-file")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if err := kubelessUtils.ApplyAnnotationsFile(&kinesisTrigger.ObjectMeta, annotationsFromFile); err != nil {
+			logrus.Fatal(err)
+		}
+
 		if dryrun == true {
 			res, err := kubelessUtils.DryRunFmt(output, kinesisTrigger)
 			if err != nil {
@@ -137,7 +149,7 @@ var createCmd = &cobra.Command{
 			return
 		}
 
-		kinesisClient, err := kinesisUtils.GetKubelessClientOutCluster()
+		kinesisClient, err := kubelessUtils.GetKinesisTriggerClientOutCluster()
 		if err != nil {
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
@@ -145,6 +157,12 @@ var createCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatalf("Failed to create Kinesis trigger object %s in namespace %s. Error: %s", triggerName, ns, err)
 		}
+		if createdOutput != "" {
+			if err := kubelessUtils.PrintCreated(cmd.OutOrStdout(), createdOutput, "kinesistrigger.kubeless.io", triggerName, &kinesisTrigger); err != nil {
+				logrus.Fatal(err)
+			}
+			return
+		}
 		logrus.Infof("Kinesis trigger %s created in namespace %s successfully!", triggerName, ns)
 
 	},
@@ -164,5 +182,6 @@ func init() {
 	createCmd.MarkFlagRequired("function-name")
 	createCmd.MarkFlagRequired("secret")
 	createCmd.Flags().Bool("dryrun", false, "Output JSON manifest of the function without creating it")
-	createCmd.Flags().StringP("output", "o", "yaml", "Output format")
+	createCmd.Flags().StringP("annotations-from-file", "", "", "Specify a YAML or JSON file with a flat map of annotations to add to the trigger")
+	createCmd.Flags().StringP("output", "o", "yaml", "Output format. One of: json|yaml with --dryrun. When given without --dryrun, the created trigger is printed as name|json|yaml")
 }
@@ -48,7 +48,7 @@ var updateCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
-		kinesisClient, err := kinesisUtils.GetKubelessClientOutCluster()
+		kinesisClient, err := kubelessUtils.GetKinesisTriggerClientOutCluster()
 		if err != nil {
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
@@ -67,13 +67,17 @@ var createCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatal(err)
 		}
+		createdOutput, err := kubelessUtils.GetCreatedOutput(cmd.Flags())
+		if err != nil {
+			logrus.Fatal(err)
+		}
 
 		labelSelector, err := metav1.ParseToLabelSelector(functionSelector)
 		if err != nil {
 			logrus.Fatal("Invalid label selector specified " + err.Error())
 		}
 
-		natsClient, err := natsUtils.GetKubelessClientOutCluster()
+		natsClient, err := kubelessUtils.GetNATSTriggerClientOutCluster()
 		if err != nil {
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
@@ -93,6 +97,22 @@ var createCmd = &cobra.Command{
 		natsTrigger.Spec.FunctionSelector.MatchLabels = labelSelector.MatchLabels
 		natsTrigger.Spec.Topic = topic
 
+		queueGroup, err := cmd.Flags().GetString("queue-group")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if queueGroup != "" {
+			natsTrigger.ObjectMeta.Annotations = map[string]string{queueGroupAnnotation: queueGroup}
+		}
+
+		annotationsFromFile, err := cmd.Flags().GetString("annotations-from-file")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if err := kubelessUtils.ApplyAnnotationsFile(&natsTrigger.ObjectMeta, annotationsFromFile); err != nil {
+			logrus.Fatal(err)
+		}
+
 		if dryrun == true {
 			res, err := kubelessUtils.DryRunFmt(output, natsTrigger)
 			if err != nil {
@@ -106,6 +126,12 @@ var createCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatalf("Failed to create NATS trigger object %s in namespace %s. Error: %s", triggerName, ns, err)
 		}
+		if createdOutput != "" {
+			if err := kubelessUtils.PrintCreated(cmd.OutOrStdout(), createdOutput, "natstrigger.kubeless.io", triggerName, &natsTrigger); err != nil {
+				logrus.Fatal(err)
+			}
+			return
+		}
 		logrus.Infof("NATS trigger %s created in namespace %s successfully!", triggerName, ns)
 
 	},
@@ -115,8 +141,10 @@ func init() {
 	createCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the NATS trigger")
 	createCmd.Flags().StringP("trigger-topic", "", "", "Specify topic to listen to in NATS")
 	createCmd.Flags().StringP("function-selector", "", "", "Selector (label query) to select function on (e.g. --function-selector key1=value1,key2=value2)")
+	createCmd.Flags().StringP("queue-group", "", "", "Specify a NATS queue group to subscribe to the topic, for controllers supporting it")
 	createCmd.MarkFlagRequired("trigger-topic")
 	createCmd.MarkFlagRequired("function-selector")
 	createCmd.Flags().Bool("dryrun", false, "Output JSON manifest of the function without creating it")
-	createCmd.Flags().StringP("output", "o", "yaml", "Output format")
+	createCmd.Flags().StringP("annotations-from-file", "", "", "Specify a YAML or JSON file with a flat map of annotations to add to the trigger")
+	createCmd.Flags().StringP("output", "o", "yaml", "Output format. One of: json|yaml with --dryrun. When given without --dryrun, the created trigger is printed as name|json|yaml")
 }
@@ -17,11 +17,14 @@ limitations under the License.
 package nats
 
 import (
+	"time"
+
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
 	natsUtils "github.com/kubeless/nats-trigger/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var deleteCmd = &cobra.Command{
@@ -43,7 +46,16 @@ var deleteCmd = &cobra.Command{
 			ns = kubelessUtils.GetDefaultNamespace()
 		}
 
-		natsClient, err := natsUtils.GetKubelessClientOutCluster()
+		wait, err := cmd.Flags().GetBool("wait")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		natsClient, err := kubelessUtils.GetNATSTriggerClientOutCluster()
 		if err != nil {
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
@@ -52,10 +64,22 @@ var deleteCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatalf("Failed to delete NATS trigger object %s in namespace %s. Error: %s", triggerName, ns, err)
 		}
+
+		if wait {
+			logrus.Infof("Waiting for NATS trigger %s to be deleted...", triggerName)
+			err = kubelessUtils.WaitForDeletion("NATS trigger", triggerName, timeout, func() (metav1.Object, error) {
+				return natsClient.KubelessV1beta1().NATSTriggers(ns).Get(triggerName, metav1.GetOptions{})
+			})
+			if err != nil {
+				logrus.Fatal(err)
+			}
+		}
 		logrus.Infof("NATS trigger %s deleted from namespace %s successfully!", triggerName, ns)
 	},
 }
 
 func init() {
 	deleteCmd.Flags().StringP("namespace", "n", "", "Specify namespace of the NATS trigger")
+	deleteCmd.Flags().Bool("wait", false, "Wait until the NATS trigger and its finalizers are completely removed")
+	deleteCmd.Flags().Duration("timeout", time.Minute, "Maximum time to wait with --wait")
 }
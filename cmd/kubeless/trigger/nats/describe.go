@@ -0,0 +1,140 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/gosuri/uitable"
+	kubelessVersioned "github.com/kubeless/kubeless/pkg/client/clientset/versioned"
+	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
+	"github.com/kubeless/nats-trigger/pkg/client/clientset/versioned"
+	natsUtils "github.com/kubeless/nats-trigger/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var describeCmd = &cobra.Command{
+	Use:   "describe <nats_trigger_name> FLAG",
+	Short: "describe a NATS trigger deployed to Kubeless",
+	Long:  `describe a NATS trigger deployed to Kubeless, including the functions selected by it`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			logrus.Fatal("Need exactly one argument - NATS trigger name")
+		}
+		triggerName := args[0]
+
+		ns, err := kubelessUtils.GetNamespaceOrAll(cmd.Flags())
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		tmpl, err := kubelessUtils.GetOutputTemplate(cmd.Flags(), output)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		natsClient, err := kubelessUtils.GetNATSTriggerClientOutCluster()
+		if err != nil {
+			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
+		}
+		kubelessClient, err := kubelessUtils.GetKubelessClientOutCluster()
+		if err != nil {
+			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
+		}
+		if ns == metav1.NamespaceAll {
+			ns, err = findTriggerNamespace(natsClient, triggerName)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+		}
+
+		if err := doDescribe(cmd.OutOrStdout(), natsClient, kubelessClient, triggerName, ns, output, tmpl); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	describeCmd.Flags().StringP("namespace", "n", "", "Specify namespace of the NATS trigger")
+	kubelessUtils.AddAllNamespacesFlag(describeCmd.Flags(), "Look for the NATS trigger in all the namespaces")
+	describeCmd.Flags().StringP("output", "o", "", "Output format. One of: json|yaml|template")
+	kubelessUtils.AddTemplateFlags(describeCmd.Flags())
+}
+
+// findTriggerNamespace returns the namespace of the NATS trigger with the given name. It fails
+// if there are triggers with that name in several namespaces.
+func findTriggerNamespace(natsClient versioned.Interface, name string) (string, error) {
+	triggers, err := natsClient.KubelessV1beta1().NATSTriggers(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	namespaces := []string{}
+	for _, trigger := range triggers.Items {
+		if trigger.Name == name {
+			namespaces = append(namespaces, trigger.Namespace)
+		}
+	}
+	return kubelessUtils.GetUniqueNamespace("NATS trigger", name, namespaces)
+}
+
+// doDescribe prints the spec of the trigger and the functions of its namespace matching the
+// function selector. The NATS trigger doesn't report the status of its subscriptions.
+func doDescribe(w io.Writer, natsClient versioned.Interface, kubelessClient kubelessVersioned.Interface, name, ns, output string, tmpl *template.Template) error {
+	trigger, err := natsUtils.GetNatsTriggerCustomResource(natsClient, name, ns)
+	if err != nil {
+		return fmt.Errorf("Unable to find NATS trigger %s in namespace %s. Error %s", name, ns, err)
+	}
+	if output != "" {
+		return kubelessUtils.PrintObjects(w, output, tmpl, trigger)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&trigger.Spec.FunctionSelector)
+	if err != nil {
+		return err
+	}
+	functions, err := kubelessClient.KubelessV1beta1().Functions(ns).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return err
+	}
+	names := []string{}
+	for _, f := range functions.Items {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+
+	table := uitable.New()
+	table.MaxColWidth = 80
+	table.Wrap = true
+	table.AddRow("Name:", trigger.Name)
+	table.AddRow("Namespace:", trigger.Namespace)
+	table.AddRow("Topic:", trigger.Spec.Topic)
+	table.AddRow("Function selector:", metav1.FormatLabelSelector(&trigger.Spec.FunctionSelector))
+	table.AddRow("Queue group:", trigger.Annotations[queueGroupAnnotation])
+	table.AddRow("Functions:", strings.Join(names, ", "))
+	fmt.Fprintln(w, table)
+	return nil
+}
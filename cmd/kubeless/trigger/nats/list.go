@@ -19,11 +19,11 @@ package nats
 import (
 	"fmt"
 	"io"
+	"text/template"
 
 	"github.com/gosuri/uitable"
 	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
 	"github.com/kubeless/nats-trigger/pkg/client/clientset/versioned"
-	natsUtils "github.com/kubeless/nats-trigger/pkg/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -37,20 +37,35 @@ var listCmd = &cobra.Command{
 	Long:    `list all NATS triggers deployed to Kubeless`,
 	Run: func(cmd *cobra.Command, args []string) {
 
-		ns, err := cmd.Flags().GetString("namespace")
+		ns, err := kubelessUtils.GetNamespaceOrAll(cmd.Flags())
 		if err != nil {
-			logrus.Fatal(err.Error())
+			logrus.Fatal(err)
 		}
-		if ns == "" {
-			ns = kubelessUtils.GetDefaultNamespace()
+
+		selector, err := cmd.Flags().GetString("selector")
+		if err != nil {
+			logrus.Fatal(err)
 		}
 
-		natsClient, err := natsUtils.GetKubelessClientOutCluster()
+		natsClient, err := kubelessUtils.GetNATSTriggerClientOutCluster()
 		if err != nil {
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
 
-		if err := doList(cmd.OutOrStdout(), natsClient, ns); err != nil {
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		tmpl, err := kubelessUtils.GetOutputTemplate(cmd.Flags(), output)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		sortBy, err := cmd.Flags().GetString("sort-by")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		if err := doList(cmd.OutOrStdout(), natsClient, ns, selector, output, tmpl, sortBy); err != nil {
 			logrus.Fatal(err.Error())
 		}
 	},
@@ -58,19 +73,30 @@ var listCmd = &cobra.Command{
 
 func init() {
 	listCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the NATS trigger")
+	kubelessUtils.AddAllNamespacesFlag(listCmd.Flags(), "List the NATS triggers of all the namespaces")
+	listCmd.Flags().StringP("selector", "l", "", "List the NATS triggers matching the given label selector (e.g. -l key1=value1,key2=value2)")
+	listCmd.Flags().StringP("output", "o", "", "Output format. One of: json|yaml|template")
+	kubelessUtils.AddTemplateFlags(listCmd.Flags())
+	kubelessUtils.AddSortByFlag(listCmd.Flags())
 }
 
-func doList(w io.Writer, kubelessClient versioned.Interface, ns string) error {
-	triggersList, err := kubelessClient.KubelessV1beta1().NATSTriggers(ns).List(metav1.ListOptions{})
+func doList(w io.Writer, kubelessClient versioned.Interface, ns, selector, output string, tmpl *template.Template, sortBy string) error {
+	triggersList, err := kubelessClient.KubelessV1beta1().NATSTriggers(ns).List(metav1.ListOptions{LabelSelector: selector})
 	if err != nil {
 		return err
 	}
+	if err := kubelessUtils.SortObjects(triggersList.Items, sortBy); err != nil {
+		return err
+	}
+	if output != "" {
+		return kubelessUtils.PrintObjects(w, output, tmpl, triggersList.Items)
+	}
 	table := uitable.New()
 	table.MaxColWidth = 50
 	table.Wrap = true
-	table.AddRow("NAME", "NAMESPACE", "TOPIC", "FUNCTION SELECTOR")
+	table.AddRow("NAME", "NAMESPACE", "TOPIC", "FUNCTION SELECTOR", "QUEUE GROUP")
 	for _, trigger := range triggersList.Items {
-		table.AddRow(trigger.Name, trigger.Namespace, trigger.Spec.Topic, metav1.FormatLabelSelector(&trigger.Spec.FunctionSelector))
+		table.AddRow(trigger.Name, trigger.Namespace, trigger.Spec.Topic, metav1.FormatLabelSelector(&trigger.Spec.FunctionSelector), trigger.Annotations[queueGroupAnnotation])
 	}
 	fmt.Fprintln(w, table)
 	return nil
@@ -0,0 +1,120 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	fFake "github.com/kubeless/kubeless/pkg/client/clientset/versioned/fake"
+	natsApi "github.com/kubeless/nats-trigger/pkg/apis/kubeless/v1beta1"
+	natsFake "github.com/kubeless/nats-trigger/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newNATSTrigger(name, ns, topic string, labels map[string]string) *natsApi.NATSTrigger {
+	return &natsApi.NATSTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Labels: labels},
+		Spec: natsApi.NATSTriggerSpec{
+			Topic:            topic,
+			FunctionSelector: metav1.LabelSelector{MatchLabels: map[string]string{"function": name}},
+		},
+	}
+}
+
+func TestList(t *testing.T) {
+	orders := newNATSTrigger("orders", "myns", "orders.*", map[string]string{"team": "shop"})
+	orders.Annotations = map[string]string{queueGroupAnnotation: "workers"}
+	natsClient := natsFake.NewSimpleClientset(
+		orders,
+		newNATSTrigger("audit", "myns", "audit", map[string]string{"team": "security"}),
+		newNATSTrigger("billing", "other", "billing", map[string]string{"team": "shop"}),
+	)
+
+	buf := &bytes.Buffer{}
+	if err := doList(buf, natsClient, "myns", "", "", nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, s := range []string{"QUEUE GROUP", "orders.*", "workers", "function=orders", "audit"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expecting %q in the output:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "billing") {
+		t.Errorf("Unexpected trigger of another namespace:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := doList(buf, natsClient, metav1.NamespaceAll, "team=shop", "", nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	out = buf.String()
+	if !strings.Contains(out, "orders") || !strings.Contains(out, "billing") || strings.Contains(out, "audit") {
+		t.Errorf("Expecting the shop triggers of all the namespaces:\n%s", out)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	orders := newNATSTrigger("orders", "myns", "orders.*", nil)
+	orders.Annotations = map[string]string{queueGroupAnnotation: "workers"}
+	natsClient := natsFake.NewSimpleClientset(orders)
+	kubelessClient := fFake.NewSimpleClientset(
+		&kubelessApi.Function{ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "myns", Labels: map[string]string{"function": "orders"}}},
+		&kubelessApi.Function{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "myns", Labels: map[string]string{"function": "other"}}},
+	)
+
+	buf := &bytes.Buffer{}
+	if err := doDescribe(buf, natsClient, kubelessClient, "orders", "myns", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, s := range []string{"Topic:", "orders.*", "Queue group:", "workers", "Functions:"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expecting %q in the output:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "other") {
+		t.Errorf("Unexpected function not matching the selector:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := doDescribe(buf, natsClient, kubelessClient, "orders", "myns", "json", nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\"topic\"") {
+		t.Errorf("Expecting the JSON of the trigger, got:\n%s", buf.String())
+	}
+
+	if err := doDescribe(buf, natsClient, kubelessClient, "missing", "myns", "", nil); err == nil {
+		t.Error("Expecting an error for a missing trigger")
+	}
+
+	// Looking for the trigger in all the namespaces
+	ns, err := findTriggerNamespace(natsClient, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ns != "myns" {
+		t.Errorf("Expecting namespace myns, got %s", ns)
+	}
+	if _, err := findTriggerNamespace(natsClient, "missing"); err == nil {
+		t.Error("Expecting an error for a trigger not found in any namespace")
+	}
+}
@@ -20,11 +20,15 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// queueGroupAnnotation contains the NATS queue group used by the controllers supporting
+// queue subscriptions, so each message is only delivered once per group
+const queueGroupAnnotation = "kubeless.io/queue-group"
+
 // NATSTriggerCmd command for NATS trigger commands
 var NATSTriggerCmd = &cobra.Command{
 	Use:   "nats SUBCOMMAND",
 	Short: "nats trigger specific operations",
-	Long:  `nats trigger command allows user to create, list, update, delete NATS triggers running on Kubeless`,
+	Long:  `nats trigger command allows user to create, list, describe, update, delete NATS triggers running on Kubeless`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
@@ -34,6 +38,7 @@ func init() {
 	NATSTriggerCmd.AddCommand(createCmd)
 	NATSTriggerCmd.AddCommand(deleteCmd)
 	NATSTriggerCmd.AddCommand(listCmd)
+	NATSTriggerCmd.AddCommand(describeCmd)
 	NATSTriggerCmd.AddCommand(updateCmd)
 	NATSTriggerCmd.AddCommand(publishCmd)
 }
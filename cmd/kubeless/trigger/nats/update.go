@@ -45,7 +45,7 @@ var updateCmd = &cobra.Command{
 			ns = kubelessUtils.GetDefaultNamespace()
 		}
 
-		natsClient, err := natsUtils.GetKubelessClientOutCluster()
+		natsClient, err := kubelessUtils.GetNATSTriggerClientOutCluster()
 		if err != nil {
 			logrus.Fatalf("Can not create out-of-cluster client: %v", err)
 		}
@@ -77,6 +77,21 @@ var updateCmd = &cobra.Command{
 			natsTrigger.Spec.FunctionSelector.MatchLabels = labelSelector.MatchLabels
 		}
 
+		if cmd.Flags().Changed("queue-group") {
+			queueGroup, err := cmd.Flags().GetString("queue-group")
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			if queueGroup == "" {
+				delete(natsTrigger.ObjectMeta.Annotations, queueGroupAnnotation)
+			} else {
+				if natsTrigger.ObjectMeta.Annotations == nil {
+					natsTrigger.ObjectMeta.Annotations = map[string]string{}
+				}
+				natsTrigger.ObjectMeta.Annotations[queueGroupAnnotation] = queueGroup
+			}
+		}
+
 		dryrun, err := cmd.Flags().GetBool("dryrun")
 		if err != nil {
 			logrus.Fatal(err)
@@ -108,6 +123,7 @@ func init() {
 	updateCmd.Flags().StringP("namespace", "n", "", "Specify namespace for the NATS trigger")
 	updateCmd.Flags().StringP("trigger-topic", "", "", "Specify topic to listen to in NATS")
 	updateCmd.Flags().StringP("function-selector", "", "", "Selector (label query) to select function on (e.g. --function-selector key1=value1,key2=value2)")
+	updateCmd.Flags().StringP("queue-group", "", "", "Specify a NATS queue group to subscribe to the topic, for controllers supporting it. An empty value removes it")
 	updateCmd.Flags().Bool("dryrun", false, "Output JSON manifest of the function without creating it")
 	updateCmd.Flags().StringP("output", "o", "yaml", "Output format")
 }
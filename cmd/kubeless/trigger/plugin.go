@@ -0,0 +1,104 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// pluginPrefix is the prefix of the executables implementing custom trigger types.
+// kubeless-trigger-<name> is available as 'kubeless trigger <name>'.
+const pluginPrefix = "kubeless-trigger-"
+
+// findTriggerPlugins returns the path of the trigger plugins found in the directories of
+// the given PATH, by trigger name. If a plugin is in several directories the first one wins.
+func findTriggerPlugins(path string) map[string]string {
+	plugins := map[string]string{}
+	for _, dir := range filepath.SplitList(path) {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			if file.IsDir() || !strings.HasPrefix(file.Name(), pluginPrefix) {
+				continue
+			}
+			name := strings.TrimPrefix(file.Name(), pluginPrefix)
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			} else if file.Mode()&0111 == 0 {
+				// Not executable
+				continue
+			}
+			if _, found := plugins[name]; name != "" && !found {
+				plugins[name] = filepath.Join(dir, file.Name())
+			}
+		}
+	}
+	return plugins
+}
+
+// AddTriggerPlugins adds a subcommand to the trigger command for each plugin found in
+// the given PATH. Plugins can't override the built-in trigger types.
+func AddTriggerPlugins(path string) {
+	for name, plugin := range findTriggerPlugins(path) {
+		if existing, _, err := TriggerCmd.Find([]string{name}); err == nil && existing != TriggerCmd {
+			logrus.Debugf("Ignoring the plugin %s, trigger %s already exists", plugin, name)
+			continue
+		}
+		TriggerCmd.AddCommand(newPluginCmd(name, plugin))
+	}
+}
+
+func newPluginCmd(name, plugin string) *cobra.Command {
+	return &cobra.Command{
+		Use:   name,
+		Short: fmt.Sprintf("%s trigger specific operations (plugin %s)", name, plugin),
+		Long:  fmt.Sprintf("%s trigger type provided by the plugin %s. Flags and arguments are given to the plugin as is.", name, plugin),
+		// Flags are parsed by the plugin
+		DisableFlagParsing: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := runPlugin(plugin, args, os.Stdin, cmd.OutOrStdout(), os.Stderr)
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				os.Exit(exitErr.ExitCode())
+			}
+			if err != nil {
+				logrus.Fatalf("Unable to run the plugin %s: %v", plugin, err)
+			}
+		},
+	}
+}
+
+// runPlugin executes the plugin with the given arguments and the environment of the CLI
+func runPlugin(plugin string, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	c := exec.Command(plugin, args...)
+	c.Stdin = stdin
+	c.Stdout = stdout
+	c.Stderr = stderr
+	c.Env = os.Environ()
+	return c.Run()
+}
@@ -0,0 +1,91 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writePlugin(t *testing.T, dir, name string, mode os.FileMode) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte("#!/bin/sh\necho \"$@\"\n"), mode); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTriggerPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The test plugins are shell scripts")
+	}
+	dir1, err := ioutil.TempDir("", "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir1)
+	dir2, err := ioutil.TempDir("", "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir2)
+
+	first := writePlugin(t, dir1, "kubeless-trigger-sqs", 0755)
+	writePlugin(t, dir2, "kubeless-trigger-sqs", 0755)
+	writePlugin(t, dir2, "kubeless-trigger-notexec", 0644)
+	writePlugin(t, dir2, "kubeless-trigger-http", 0755)
+	writePlugin(t, dir2, "other-binary", 0755)
+
+	path := strings.Join([]string{dir1, filepath.Join(dir1, "missing"), dir2}, string(os.PathListSeparator))
+	plugins := findTriggerPlugins(path)
+	if len(plugins) != 2 || plugins["sqs"] != first || plugins["http"] == "" {
+		t.Errorf("Unexpected plugins %v", plugins)
+	}
+
+	AddTriggerPlugins(path)
+	cmd, _, err := TriggerCmd.Find([]string{"sqs"})
+	if err != nil || cmd.Name() != "sqs" {
+		t.Fatalf("Expecting the sqs plugin to be added, got %v (%v)", cmd, err)
+	}
+	// Built-in triggers are not replaced by plugins
+	httpCmds := 0
+	for _, c := range TriggerCmd.Commands() {
+		if c.Name() == "http" {
+			httpCmds++
+			if strings.Contains(c.Short, "plugin") {
+				t.Error("The http plugin shouldn't replace the built-in trigger")
+			}
+		}
+	}
+	if httpCmds != 1 {
+		t.Errorf("Expecting a single http command, got %d", httpCmds)
+	}
+	TriggerCmd.RemoveCommand(cmd)
+
+	var stdout bytes.Buffer
+	if err := runPlugin(first, []string{"create", "foo", "--queue", "bar"}, nil, &stdout, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "create foo --queue bar\n" {
+		t.Errorf("Unexpected output of the plugin %q", stdout.String())
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	cronjobApi "github.com/kubeless/cronjob-trigger/pkg/apis/kubeless/v1beta1"
+	httpApi "github.com/kubeless/http-trigger/pkg/apis/kubeless/v1beta1"
+	kafkaApi "github.com/kubeless/kafka-trigger/pkg/apis/kubeless/v1beta1"
+	kinesisApi "github.com/kubeless/kinesis-trigger/pkg/apis/kubeless/v1beta1"
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	natsApi "github.com/kubeless/nats-trigger/pkg/apis/kubeless/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const apiVersion = "kubeless.io/v1beta1"
+
+// resources are the kinds covered by the schema
+var resources = map[string]interface{}{
+	"Function":       kubelessApi.Function{},
+	"CronJobTrigger": cronjobApi.CronJobTrigger{},
+	"HTTPTrigger":    httpApi.HTTPTrigger{},
+	"KafkaTrigger":   kafkaApi.KafkaTrigger{},
+	"KinesisTrigger": kinesisApi.KinesisTrigger{},
+	"NATSTrigger":    natsApi.NATSTrigger{},
+}
+
+// schemaTypes is the type of a schema. It is marshalled as a string if there is only one.
+type schemaTypes []string
+
+func (t schemaTypes) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// schema is the subset of JSON Schema generated from the Go types of the resources
+type schema struct {
+	Schema      string             `json:"$schema,omitempty"`
+	Ref         string             `json:"$ref,omitempty"`
+	Type        schemaTypes        `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Properties  map[string]*schema `json:"properties,omitempty"`
+	Items       *schema            `json:"items,omitempty"`
+	AllOf       []*schema          `json:"allOf,omitempty"`
+	OneOf       []*schema          `json:"oneOf,omitempty"`
+	Definitions map[string]*schema `json:"definitions,omitempty"`
+	// AdditionalProperties is the schema of the values of a map. Structs don't accept unknown fields.
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeOf(metav1.Time{})
+	microTimeType  = reflect.TypeOf(metav1.MicroTime{})
+	durationType   = reflect.TypeOf(metav1.Duration{})
+	quantityType   = reflect.TypeOf(resource.Quantity{})
+	intOrStrType   = reflect.TypeOf(intstr.IntOrString{})
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+type schemaGenerator struct {
+	definitions map[string]*schema
+}
+
+// generateSchema returns the JSON Schema of the Kubeless resources. Each kind is a definition
+// and the document should match one of them.
+func generateSchema() *schema {
+	g := &schemaGenerator{definitions: map[string]*schema{}}
+	root := &schema{Schema: "http://json-schema.org/draft-07/schema#"}
+	kinds := []string{}
+	for kind := range resources {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		s := g.resourceSchema(kind)
+		g.definitions[kind] = s
+		root.OneOf = append(root.OneOf, &schema{Ref: "#/definitions/" + kind})
+	}
+	root.Definitions = g.definitions
+	return root
+}
+
+// resourceSchema returns the schema of a kind, requiring its apiVersion, kind and name
+func (g *schemaGenerator) resourceSchema(kind string) *schema {
+	t := reflect.TypeOf(resources[kind])
+	s := &schema{Type: schemaTypes{"object"}, Properties: map[string]*schema{}, AdditionalProperties: false}
+	g.addFields(s, t)
+	s.Properties["apiVersion"] = &schema{Type: schemaTypes{"string"}, Enum: []string{apiVersion}}
+	s.Properties["kind"] = &schema{Type: schemaTypes{"string"}, Enum: []string{kind}}
+	s.Properties["metadata"] = &schema{AllOf: []*schema{
+		{Ref: "#/definitions/" + g.definition(reflect.TypeOf(metav1.ObjectMeta{}))},
+		{Required: []string{"name"}},
+	}}
+	s.Required = []string{"apiVersion", "kind", "metadata"}
+	return s
+}
+
+// definitionName returns the name of the definition of a struct, e.g. k8s.io.api.core.v1.PodSpec
+func definitionName(t reflect.Type) string {
+	return strings.Replace(t.PkgPath(), "/", ".", -1) + "." + t.Name()
+}
+
+// definition adds the schema of the struct to the definitions and returns its name
+func (g *schemaGenerator) definition(t reflect.Type) string {
+	name := definitionName(t)
+	if _, ok := g.definitions[name]; ok {
+		return name
+	}
+	s := &schema{Type: schemaTypes{"object"}, Properties: map[string]*schema{}, AdditionalProperties: false}
+	// Added before its fields to support recursive types
+	g.definitions[name] = s
+	g.addFields(s, t)
+	return name
+}
+
+// addFields adds the properties of the exported fields of a struct, including the embedded ones
+func (g *schemaGenerator) addFields(s *schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(s, ft)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		s.Properties[name] = g.typeSchema(field.Type)
+	}
+}
+
+// typeSchema returns the schema of a Go type
+func (g *schemaGenerator) typeSchema(t reflect.Type) *schema {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType, microTimeType:
+		return &schema{Type: schemaTypes{"string"}, Format: "date-time"}
+	case durationType:
+		return &schema{Type: schemaTypes{"string"}}
+	case quantityType:
+		return &schema{Type: schemaTypes{"string", "number"}}
+	case intOrStrType:
+		return &schema{Type: schemaTypes{"string", "integer"}}
+	case rawMessageType:
+		return &schema{}
+	}
+	if t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType) {
+		// Types with a custom format, like runtime.RawExtension, accept any value
+		return &schema{}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &schema{Type: schemaTypes{"string"}}
+	case reflect.Bool:
+		return &schema{Type: schemaTypes{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schema{Type: schemaTypes{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &schema{Type: schemaTypes{"number"}}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte is encoded in base64
+			return &schema{Type: schemaTypes{"string"}}
+		}
+		return &schema{Type: schemaTypes{"array"}, Items: g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return &schema{Type: schemaTypes{"object"}, AdditionalProperties: g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		return &schema{Ref: "#/definitions/" + g.definition(t)}
+	default:
+		// interface{} accepts any value
+		return &schema{}
+	}
+}
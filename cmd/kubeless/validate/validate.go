@@ -0,0 +1,283 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	kubelessUtils "github.com/kubeless/kubeless/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// ValidateCmd validates Kubeless manifests against their JSON Schema
+var ValidateCmd = &cobra.Command{
+	Use:   "validate FLAG",
+	Short: "validate manifests against the schema of the Kubeless resources",
+	Long: `validate Function and Trigger manifests against the JSON Schema of the Kubeless resources.
+It doesn't require access to the cluster. Use --schema to print the schema, e.g. to configure an editor.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		printSchema, err := cmd.Flags().GetBool("schema")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if printSchema {
+			out, err := kubelessUtils.MarshalJSON(generateSchema(), "  ")
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(out))
+			return
+		}
+
+		file, err := cmd.Flags().GetString("file")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if file == "" {
+			logrus.Fatal("The flag --file is required")
+		}
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			logrus.Fatalf("Unable to read %s: %v", file, err)
+		}
+
+		violations, err := validateManifest(content, generateSchema())
+		if err != nil {
+			logrus.Fatalf("Unable to parse %s: %v", file, err)
+		}
+		printViolations(cmd.OutOrStdout(), violations)
+		if len(violations) > 0 {
+			logrus.Fatalf("Found %d errors in %s", len(violations), file)
+		}
+	},
+}
+
+func init() {
+	ValidateCmd.Flags().StringP("file", "f", "", "Manifest to validate. It can contain several YAML documents")
+	ValidateCmd.Flags().Bool("schema", false, "Print the JSON Schema of the Kubeless resources")
+}
+
+// violation is a part of a manifest that doesn't match the schema
+type violation struct {
+	Line    int
+	Path    string
+	Message string
+}
+
+type validator struct {
+	definitions map[string]*schema
+	violations  []violation
+}
+
+func (v *validator) add(node *yaml.Node, path, format string, args ...interface{}) {
+	if path == "" {
+		path = "."
+	}
+	v.violations = append(v.violations, violation{node.Line, path, fmt.Sprintf(format, args...)})
+}
+
+// validateManifest validates every document of the manifest. Documents are validated
+// against the definition of their kind.
+func validateManifest(content []byte, root *schema) ([]violation, error) {
+	v := &validator{definitions: root.Definitions}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		doc := &yaml.Node{}
+		if err := decoder.Decode(doc); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		node := resolve(doc.Content[0])
+		if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+			continue
+		}
+		kind := scalarField(node, "kind")
+		s, ok := v.definitions[kind]
+		if !ok {
+			kinds := []string{}
+			for k := range resources {
+				kinds = append(kinds, k)
+			}
+			sort.Strings(kinds)
+			if kind == "" {
+				v.add(node, "kind", "kind is required. It should be one of %s", strings.Join(kinds, ", "))
+			} else {
+				v.add(node, "kind", "unknown kind %s. It should be one of %s", kind, strings.Join(kinds, ", "))
+			}
+			continue
+		}
+		v.validate(node, s, "")
+	}
+	return v.violations, nil
+}
+
+// resolve returns the node an alias points to
+func resolve(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// scalarField returns the value of a scalar field of a mapping
+func scalarField(node *yaml.Node, name string) string {
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == name {
+			return resolve(node.Content[i+1]).Value
+		}
+	}
+	return ""
+}
+
+// nodeType returns the JSON type of a node
+func nodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch node.Tag {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!null":
+		return "null"
+	default:
+		return "string"
+	}
+}
+
+func matchesType(actual string, types schemaTypes) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *validator) validate(node *yaml.Node, s *schema, path string) {
+	node = resolve(node)
+	if s.Ref != "" {
+		s = v.definitions[strings.TrimPrefix(s.Ref, "#/definitions/")]
+	}
+	for _, sub := range s.AllOf {
+		v.validate(node, sub, path)
+	}
+	actual := nodeType(node)
+	// Like in Kubernetes, a null value is the same as a missing one
+	if actual == "null" {
+		return
+	}
+	if !matchesType(actual, s.Type) {
+		v.add(node, path, "expecting %s, got %s", strings.Join(s.Type, " or "), actual)
+		return
+	}
+	if len(s.Enum) > 0 && !contains(s.Enum, node.Value) {
+		v.add(node, path, "unsupported value %q. It should be one of %s", node.Value, strings.Join(s.Enum, ", "))
+	}
+	switch actual {
+	case "object":
+		v.validateObject(node, s, path)
+	case "array":
+		if s.Items != nil {
+			for i, item := range node.Content {
+				v.validate(item, s.Items, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+}
+
+func (v *validator) validateObject(node *yaml.Node, s *schema, path string) {
+	found := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		found[key.Value] = true
+		fieldPath := key.Value
+		if path != "" {
+			fieldPath = path + "." + key.Value
+		}
+		if p, ok := s.Properties[key.Value]; ok {
+			v.validate(value, p, fieldPath)
+			continue
+		}
+		switch additional := s.AdditionalProperties.(type) {
+		case *schema:
+			v.validate(value, additional, fieldPath)
+		case bool:
+			if !additional {
+				v.add(key, fieldPath, "unknown field %s", key.Value)
+			}
+		}
+	}
+	for _, r := range s.Required {
+		if !found[r] {
+			requiredPath := r
+			if path != "" {
+				requiredPath = path + "." + r
+			}
+			v.add(node, requiredPath, "%s is required", r)
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func printViolations(w io.Writer, violations []violation) {
+	if len(violations) == 0 {
+		fmt.Fprintln(w, "The manifest is valid")
+		return
+	}
+	table := uitable.New()
+	table.MaxColWidth = 80
+	table.Wrap = true
+	table.AddRow("LINE", "PATH", "MESSAGE")
+	for _, v := range violations {
+		table.AddRow(v.Line, v.Path, v.Message)
+	}
+	fmt.Fprintln(w, table)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const manifest = `apiVersion: kubeless.io/v1beta1
+kind: Function
+metadata:
+  name: hello
+spec:
+  runtime: python3.7
+  handler: hello.handler
+  deployment:
+    spec:
+      replicas: two
+      template:
+        spec:
+          containers:
+          - env:
+            - name: FOO
+              value: 1
+            resources:
+              limits:
+                memory: 128Mi
+                cpu: 0.5
+  foo: bar
+---
+apiVersion: kubeless.io/v1beta1
+kind: CronJobTrigger
+metadata:
+  labels:
+    app: hello
+spec:
+  function-name: hello
+  schedule: "* * * * *"
+  payload:
+    any: [value]
+---
+kind: Deployment
+`
+
+func TestValidateManifest(t *testing.T) {
+	violations, err := validateManifest([]byte(manifest), generateSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []violation{
+		{10, "spec.deployment.spec.replicas", "expecting integer, got string"},
+		{16, "spec.deployment.spec.template.spec.containers[0].env[0].value", "expecting string, got integer"},
+		{21, "spec.foo", "unknown field foo"},
+		{26, "metadata.name", "name is required"},
+		{34, "kind", "unknown kind Deployment. It should be one of CronJobTrigger, Function, HTTPTrigger, KafkaTrigger, KinesisTrigger, NATSTrigger"},
+	}
+	if !reflect.DeepEqual(violations, expected) {
+		t.Errorf("Expecting:\n%v\ngot:\n%v", expected, violations)
+	}
+
+	var out bytes.Buffer
+	printViolations(&out, violations)
+	if !strings.Contains(out.String(), "spec.foo") {
+		t.Errorf("Unexpected output %s", out.String())
+	}
+}
+
+func TestValidateManifestValid(t *testing.T) {
+	valid := `{"apiVersion": "kubeless.io/v1beta1", "kind": "HTTPTrigger", "metadata": {"name": "hello"}, "spec": {"function-name": "hello", "path": "hello"}}`
+	violations, err := validateManifest([]byte(valid), generateSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(violations) != 0 {
+		t.Errorf("Unexpected violations %v", violations)
+	}
+	if _, err := validateManifest([]byte("kind: [Function"), generateSchema()); err == nil {
+		t.Error("Expecting an error for an invalid YAML document")
+	}
+}
+
+func TestGenerateSchema(t *testing.T) {
+	raw, err := json.Marshal(generateSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &s); err != nil {
+		t.Fatal(err)
+	}
+	definitions := s["definitions"].(map[string]interface{})
+	for kind := range resources {
+		if _, ok := definitions[kind]; !ok {
+			t.Errorf("Missing definition of %s", kind)
+		}
+	}
+	if len(s["oneOf"].([]interface{})) != len(resources) {
+		t.Errorf("Expecting a reference to each kind in oneOf")
+	}
+	quantity := definitions["k8s.io.api.core.v1.ResourceRequirements"].(map[string]interface{})["properties"].(map[string]interface{})["limits"]
+	expected := map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": []interface{}{"string", "number"}}}
+	if !reflect.DeepEqual(quantity, expected) {
+		t.Errorf("Expecting %v, got %v", expected, quantity)
+	}
+}
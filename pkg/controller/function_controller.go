@@ -337,8 +337,14 @@ func (c *FunctionController) ensureK8sResources(funcObj *kubelessApi.Function) e
 	if prebuiltImage == "" {
 		if c.config.Data["enable-build-step"] == "true" {
 			var isBuilding bool
-			prebuiltImage, isBuilding, err = c.startImageBuildJob(funcObj, or)
+			// Config-only changes (env, resources...) keep the image already built
+			prebuiltImage, err = utils.GetReusableBuild(c.clientset, funcObj)
 			if err != nil {
+				logrus.Errorf("Unable to check the current build of %s: %v", funcObj.ObjectMeta.Name, err)
+			}
+			if prebuiltImage != "" {
+				logrus.Infof("Reusing image %s, the source and dependencies of %s didn't change", prebuiltImage, funcObj.ObjectMeta.Name)
+			} else if prebuiltImage, isBuilding, err = c.startImageBuildJob(funcObj, or); err != nil {
 				logrus.Errorf("Unable to build function: %v", err)
 			} else {
 				if isBuilding {
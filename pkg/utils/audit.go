@@ -0,0 +1,208 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+)
+
+// auditedCommands are the paths of the commands that modify objects in the cluster.
+// Commands are matched by their full path so a subcommand with a common name
+// (e.g. "test") is not audited because of another command with the same name.
+var auditedCommands = map[string]bool{
+	"kubeless autoscale create":                 true,
+	"kubeless autoscale delete":                 true,
+	"kubeless function delete":                  true,
+	"kubeless function deploy":                  true,
+	"kubeless function import":                  true,
+	"kubeless function promote":                 true,
+	"kubeless function rollback":                true,
+	"kubeless function scale":                   true,
+	"kubeless function update":                  true,
+	"kubeless topic create":                     true,
+	"kubeless topic delete":                     true,
+	"kubeless trigger cronjob create":           true,
+	"kubeless trigger cronjob create-from-file": true,
+	"kubeless trigger cronjob delete":           true,
+	"kubeless trigger cronjob patch":            true,
+	"kubeless trigger cronjob pause":            true,
+	"kubeless trigger cronjob replace":          true,
+	"kubeless trigger cronjob resume":           true,
+	"kubeless trigger cronjob run-once":         true,
+	"kubeless trigger cronjob test":             true,
+	"kubeless trigger cronjob update":           true,
+	"kubeless trigger http create":              true,
+	"kubeless trigger http delete":              true,
+	"kubeless trigger http update":              true,
+	"kubeless trigger kafka create":             true,
+	"kubeless trigger kafka delete":             true,
+	"kubeless trigger kafka update":             true,
+	"kubeless trigger kinesis create":           true,
+	"kubeless trigger kinesis create-stream":    true,
+	"kubeless trigger kinesis delete":           true,
+	"kubeless trigger kinesis update":           true,
+	"kubeless trigger nats create":              true,
+	"kubeless trigger nats delete":              true,
+	"kubeless trigger nats update":              true,
+}
+
+// auditedWithFlag are the commands that only modify the cluster when the given
+// boolean flag is set
+var auditedWithFlag = map[string]string{
+	"kubeless trigger cronjob verify": "repair",
+}
+
+// AuditRecord is an entry of the audit log
+type AuditRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	User      string    `json:"user"`
+	Command   string    `json:"command"`
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Result    string    `json:"result"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type auditLog struct {
+	sync.Mutex
+	path    string
+	record  AuditRecord
+	fatal   string
+	written bool
+}
+
+var currentAudit *auditLog
+
+// auditHook keeps the message of a fatal error so it's recorded before exiting
+type auditHook struct{}
+
+func (auditHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.FatalLevel}
+}
+
+func (auditHook) Fire(entry *logrus.Entry) error {
+	if a := currentAudit; a != nil {
+		a.Lock()
+		a.fatal = entry.Message
+		a.Unlock()
+	}
+	return nil
+}
+
+// IsAuditedCommand returns true for the commands recorded in the audit log.
+// The command is identified by its path, e.g. "kubeless function deploy".
+func IsAuditedCommand(path string, flags *pflag.FlagSet) bool {
+	if flag, ok := auditedWithFlag[path]; ok {
+		enabled, err := flags.GetBool(flag)
+		return err == nil && enabled
+	}
+	return auditedCommands[path]
+}
+
+// StartAudit prepares the audit record of a command. The record is appended to
+// the file in FinishAudit or, if the command ends with a fatal error, before exiting.
+func StartAudit(path, command, namespace, name string) {
+	currentAudit = &auditLog{
+		path: path,
+		record: AuditRecord{
+			Timestamp: time.Now().UTC(),
+			User:      getKubeconfigUser(),
+			Command:   command,
+			Namespace: namespace,
+			Name:      name,
+		},
+	}
+	logrus.AddHook(auditHook{})
+	logrus.RegisterExitHandler(func() {
+		a := currentAudit
+		a.Lock()
+		msg := a.fatal
+		a.Unlock()
+		if msg == "" {
+			msg = "unknown error"
+		}
+		if err := a.write(msg); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to write the audit log: %v\n", err)
+		}
+	})
+}
+
+// FinishAudit records the result of the command started with StartAudit.
+// It does nothing if the audit log is not enabled or the record is already written.
+func FinishAudit(cmdErr error) error {
+	if currentAudit == nil {
+		return nil
+	}
+	msg := ""
+	if cmdErr != nil {
+		msg = cmdErr.Error()
+	}
+	return currentAudit.write(msg)
+}
+
+func (a *auditLog) write(errMsg string) error {
+	a.Lock()
+	defer a.Unlock()
+	if a.written {
+		return nil
+	}
+	a.written = true
+	record := a.record
+	record.Result = "success"
+	if errMsg != "" {
+		record.Result = "failure"
+		record.Error = errMsg
+	}
+	return appendAuditRecord(a.path, record)
+}
+
+func appendAuditRecord(path string, record AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// getKubeconfigUser returns the user of the kubeconfig context in use
+func getKubeconfigUser() string {
+	raw, err := getOutOfClusterClientConfig().RawConfig()
+	if err != nil {
+		return ""
+	}
+	context := getContextOverride()
+	if context == "" {
+		context = raw.CurrentContext
+	}
+	if c, ok := raw.Contexts[context]; ok {
+		return c.AuthInfo
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+	defer func() { currentAudit = nil }()
+
+	if err := FinishAudit(nil); err != nil {
+		t.Errorf("Unexpected error without an audit log: %v", err)
+	}
+
+	currentAudit = &auditLog{path: path, record: AuditRecord{User: "admin", Command: "kubeless trigger cronjob create", Namespace: "myns", Name: "foo"}}
+	if err := FinishAudit(nil); err != nil {
+		t.Fatal(err)
+	}
+	// The record is only written once
+	if err := FinishAudit(errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+	currentAudit = &auditLog{path: path, record: AuditRecord{Command: "kubeless function delete", Name: "bar"}}
+	if err := currentAudit.write("not found"); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expecting 2 records, got %d", len(lines))
+	}
+	records := []AuditRecord{}
+	for _, l := range lines {
+		r := AuditRecord{}
+		if err := json.Unmarshal([]byte(l), &r); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+	if records[0].Result != "success" || records[0].Error != "" || records[0].User != "admin" || records[0].Name != "foo" {
+		t.Errorf("Unexpected record %+v", records[0])
+	}
+	if records[1].Result != "failure" || records[1].Error != "not found" {
+		t.Errorf("Unexpected record %+v", records[1])
+	}
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	if !IsAuditedCommand("kubeless trigger cronjob create", flags) || IsAuditedCommand("kubeless trigger cronjob list", flags) {
+		t.Error("Only the commands that modify the cluster should be audited")
+	}
+	// Commands are matched by their path, not by their name
+	if IsAuditedCommand("create", flags) || IsAuditedCommand("kubeless trigger http test", flags) {
+		t.Error("Commands with the name of an audited command shouldn't be audited")
+	}
+	// verify only modifies the cluster with --repair
+	flags.Bool("repair", false, "")
+	if IsAuditedCommand("kubeless trigger cronjob verify", flags) {
+		t.Error("verify shouldn't be audited without --repair")
+	}
+	flags.Set("repair", "true")
+	if !IsAuditedCommand("kubeless trigger cronjob verify", flags) {
+		t.Error("verify --repair should be audited")
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	clientsetAPIExtensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CacheTTL is the time during which the cached server data is used
+const CacheTTL = 5 * time.Minute
+
+var cacheEnabled = true
+
+// SetCacheEnabled enables or disables the local cache of server data
+func SetCacheEnabled(enabled bool) {
+	cacheEnabled = enabled
+}
+
+// CLICacheDir returns the directory storing the cached server data
+func CLICacheDir() string {
+	return filepath.Join(GetHomeDir(), ".kubeless", "cache")
+}
+
+// cacheEntry is the content of a cache file
+type cacheEntry struct {
+	Key       string          `json:"key"`
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// getCachePath returns the file caching the given data. The key identifies the
+// cluster and context so switching context doesn't use the data of another cluster.
+func getCachePath(dir, key, name string) string {
+	sum := sha256.Sum256([]byte(key + "\n" + name))
+	return filepath.Join(dir, name+"-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// readCache loads the data cached with the given key into obj. It returns false
+// if there is no data or if it's older than the ttl.
+func readCache(path, key string, ttl time.Duration, now time.Time, obj interface{}) bool {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	entry := cacheEntry{}
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return false
+	}
+	if entry.Key != key || now.Sub(entry.Timestamp) > ttl || now.Before(entry.Timestamp) {
+		return false
+	}
+	return json.Unmarshal(entry.Data, obj) == nil
+}
+
+// writeCache stores obj as the data cached with the given key
+func writeCache(path, key string, now time.Time, obj interface{}) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(cacheEntry{Key: key, Timestamp: now, Data: data})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, content, 0600)
+}
+
+// getClusterCacheKey identifies the cluster and context used by the CLI
+func getClusterCacheKey() (string, error) {
+	clientConfig := getOutOfClusterClientConfig()
+	raw, err := clientConfig.RawConfig()
+	if err != nil {
+		return "", err
+	}
+	context := getContextOverride()
+	if context == "" {
+		context = raw.CurrentContext
+	}
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return "", err
+	}
+	return context + "@" + config.Host, nil
+}
+
+// GetCachedKubelessConfig returns the Kubeless ConfigMap like GetKubelessConfig but
+// it reuses the copy stored in the local cache if it has been read in the last CacheTTL
+func GetCachedKubelessConfig(cli kubernetes.Interface, cliAPIExtensions clientsetAPIExtensions.Interface) (*v1.ConfigMap, error) {
+	if !cacheEnabled {
+		return GetKubelessConfig(cli, cliAPIExtensions)
+	}
+	cluster, err := getClusterCacheKey()
+	if err != nil {
+		return GetKubelessConfig(cli, cliAPIExtensions)
+	}
+	// The location of the config can be changed with env vars
+	key := cluster + "/" + getControllerNamespaceEnv() + "/" + os.Getenv("KUBELESS_CONFIG")
+	return getCachedConfigMap(getCachePath(CLICacheDir(), key, "server-config"), key, time.Now(), func() (*v1.ConfigMap, error) {
+		return GetKubelessConfig(cli, cliAPIExtensions)
+	})
+}
+
+// revalidatedURLContent is the cached copy of a URL together with its validators
+type revalidatedURLContent struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Body         string `json:"body"`
+}
+
+// GetRevalidatedURLContent downloads the given URL. Responses with an ETag or a Last-Modified
+// header are kept in the local cache and the next requests send them in If-None-Match and
+// If-Modified-Since, so the cached copy is used when the server responds 304 Not Modified.
+func GetRevalidatedURLContent(url string) (string, error) {
+	path := ""
+	if cacheEnabled {
+		path = getCachePath(CLICacheDir(), url, "url-revalidated")
+	}
+	return getRevalidatedURLContent(http.DefaultClient, path, url, time.Now())
+}
+
+// getRevalidatedURLContent downloads url with client. An empty path disables the cache.
+func getRevalidatedURLContent(client *http.Client, path, url string, now time.Time) (string, error) {
+	cached := revalidatedURLContent{}
+	// The cached copy never expires, it's validated by the server in every request
+	hasCache := path != "" && readCache(path, url, time.Duration(math.MaxInt64), now, &cached)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	if hasCache {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && hasCache {
+		logrus.Debugf("Using the cached copy of %s", url)
+		return cached.Body, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("Unable to download %s: %s", url, resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	content := revalidatedURLContent{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Body:         string(body),
+	}
+	if path != "" && (content.ETag != "" || content.LastModified != "") {
+		if err := writeCache(path, url, now, content); err != nil {
+			logrus.Debugf("Unable to cache %s: %v", url, err)
+		}
+	}
+	return content.Body, nil
+}
+
+// getCachedConfigMap returns the ConfigMap cached in path or fetches and caches it
+func getCachedConfigMap(path, key string, now time.Time, fetch func() (*v1.ConfigMap, error)) (*v1.ConfigMap, error) {
+	config := &v1.ConfigMap{}
+	if readCache(path, key, CacheTTL, now, config) {
+		return config, nil
+	}
+	config, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	if err := writeCache(path, key, now, config); err != nil {
+		logrus.Debugf("Unable to cache the server config: %v", err)
+	}
+	return config, nil
+}
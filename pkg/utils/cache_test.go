@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetCachedConfigMap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeless-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	calls := 0
+	fetch := func() (*v1.ConfigMap, error) {
+		calls++
+		return &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "kubeless-config"},
+			Data:       map[string]string{"runtime-images": fmt.Sprintf("v%d", calls)},
+		}, nil
+	}
+	now := time.Now()
+	path := getCachePath(dir, "minikube@https://192.168.99.100:8443", "server-config")
+	if filepath.Dir(path) != dir {
+		t.Errorf("Unexpected cache path %s", path)
+	}
+
+	for _, test := range []struct {
+		name          string
+		key           string
+		path          string
+		now           time.Time
+		expectedCalls int
+		expectedData  string
+	}{
+		{"first call fetches the config", "minikube", path, now, 1, "v1"},
+		{"cached config is reused", "minikube", path, now.Add(time.Minute), 1, "v1"},
+		{"expired config is fetched again", "minikube", path, now.Add(CacheTTL + 2*time.Minute), 2, "v2"},
+		{"another context doesn't use the cached config", "gke", path, now.Add(CacheTTL + 2*time.Minute), 3, "v3"},
+		{"each context has its own file", "gke", getCachePath(dir, "gke", "server-config"), now, 4, "v4"},
+	} {
+		config, err := getCachedConfigMap(test.path, test.key, test.now, fetch)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if calls != test.expectedCalls {
+			t.Errorf("%s: expecting %d calls to the server, got %d", test.name, test.expectedCalls, calls)
+		}
+		if config.Name != "kubeless-config" || config.Data["runtime-images"] != test.expectedData {
+			t.Errorf("%s: unexpected config %v", test.name, config)
+		}
+	}
+
+	if getCachePath(dir, "minikube", "server-config") == getCachePath(dir, "gke", "server-config") {
+		t.Error("Different contexts should use different files")
+	}
+
+	if err := ioutil.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config := &v1.ConfigMap{}
+	if readCache(path, "minikube", CacheTTL, now, config) {
+		t.Error("A corrupted cache file should be ignored")
+	}
+}
+
+func TestGetRevalidatedURLContent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeless-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	version := "v1"
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `"` + version + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("ETag", etag)
+		fmt.Fprintf(w, `{"version": %q}`, version)
+	}))
+	defer server.Close()
+
+	url := server.URL + "/payload.json"
+	path := getCachePath(dir, url, "url-revalidated")
+	now := time.Now()
+	for i, expected := range []string{"v1", "v1", "v2"} {
+		if i == 2 {
+			version = "v2"
+		}
+		content, err := getRevalidatedURLContent(http.DefaultClient, path, url, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content != fmt.Sprintf(`{"version": %q}`, expected) {
+			t.Errorf("Request %d: unexpected content %s", i, content)
+		}
+	}
+	if downloads != 2 {
+		t.Errorf("Expecting 2 downloads, the second request should use the cache. Got %d", downloads)
+	}
+
+	// Without cache the content is always downloaded
+	if _, err := getRevalidatedURLContent(http.DefaultClient, "", url, now); err != nil || downloads != 3 {
+		t.Errorf("Expecting a new download without cache, got %d downloads (%v)", downloads, err)
+	}
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	if _, err := getRevalidatedURLContent(http.DefaultClient, path, notFound.URL, now); err == nil {
+		t.Error("Expecting an error for a 404 response")
+	}
+}
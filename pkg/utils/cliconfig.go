@@ -0,0 +1,111 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// CLIConfigKeys maps the keys allowed in the CLI config file to the flags they default
+var CLIConfigKeys = map[string][]string{
+	"namespace":  {"namespace"},
+	"output":     {"output", "out"},
+	"kubeconfig": {},
+	"context":    {},
+}
+
+// GetHomeDir returns the home directory of the current user
+func GetHomeDir() string {
+	home := os.Getenv("HOMEDRIVE") + os.Getenv("HOMEPATH")
+	if home == "" {
+		for _, h := range []string{"HOME", "USERPROFILE"} {
+			if home = os.Getenv(h); home != "" {
+				break
+			}
+		}
+	}
+	return home
+}
+
+// CLIConfigPath returns the path of the file storing the CLI defaults
+func CLIConfigPath() string {
+	return filepath.Join(GetHomeDir(), ".kubeless", "config.yaml")
+}
+
+// ValidateCLIConfigKey returns an error if the key is not supported in the CLI config
+func ValidateCLIConfigKey(key string) error {
+	if _, ok := CLIConfigKeys[key]; !ok {
+		keys := []string{}
+		for k := range CLIConfigKeys {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return fmt.Errorf("Unknown config key %q. Supported keys are: %s", key, strings.Join(keys, ", "))
+	}
+	return nil
+}
+
+// ReadCLIConfig returns the CLI defaults stored in the config file.
+// A missing file is not an error, it just means that nothing has been set.
+func ReadCLIConfig() (map[string]string, error) {
+	config := map[string]string{}
+	content, err := ioutil.ReadFile(CLIConfigPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("Unable to parse %s: %v", CLIConfigPath(), err)
+	}
+	return config, nil
+}
+
+// WriteCLIConfig stores the given CLI defaults in the config file
+func WriteCLIConfig(config map[string]string) error {
+	for k := range config {
+		if err := ValidateCLIConfigKey(k); err != nil {
+			return err
+		}
+	}
+	content, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	path := CLIConfigPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, content, 0644)
+}
+
+// getCLIConfigValue returns a single value of the CLI config, ignoring read errors
+func getCLIConfigValue(key string) string {
+	config, err := ReadCLIConfig()
+	if err != nil {
+		return ""
+	}
+	return config[key]
+}
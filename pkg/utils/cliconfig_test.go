@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestCLIConfig(t *testing.T) {
+	home, err := ioutil.TempDir("", "kubeless-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+
+	// A missing file results in an empty config
+	config, err := ReadCLIConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config) != 0 {
+		t.Errorf("Expecting an empty config, got %v", config)
+	}
+
+	expected := map[string]string{"namespace": "foo", "output": "json"}
+	if err := WriteCLIConfig(expected); err != nil {
+		t.Fatal(err)
+	}
+	config, err = ReadCLIConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("Expecting %v, got %v", expected, config)
+	}
+
+	// Unknown keys should be rejected
+	if err := WriteCLIConfig(map[string]string{"foo": "bar"}); err == nil {
+		t.Error("Expecting an error for an unknown key")
+	}
+}
+
+func TestApplyConfigDefaults(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringP("namespace", "n", "", "")
+	flags.StringP("out", "o", "", "")
+	if err := flags.Parse([]string{"-n", "bar"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ApplyConfigDefaults(flags, map[string]string{"namespace": "foo", "output": "yaml", "context": "minikube"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Flags given in the command line have precedence
+	if ns, _ := flags.GetString("namespace"); ns != "bar" {
+		t.Errorf("Expecting namespace bar, got %s", ns)
+	}
+	if out, _ := flags.GetString("out"); out != "yaml" {
+		t.Errorf("Expecting output yaml, got %s", out)
+	}
+	if IsFlagFromDefaults(flags.Lookup("namespace")) || !IsFlagFromDefaults(flags.Lookup("out")) {
+		t.Error("Only --out should be marked as set from the CLI config")
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"os"
+
+	"github.com/sirupsen/logrus"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Exit codes of the CLI, so scripts can tell the class of a failure without parsing stderr
+const (
+	ExitSuccess       = 0
+	ExitError         = 1
+	ExitUsage         = 2
+	ExitNotFound      = 3
+	ExitAlreadyExists = 4
+	ExitTimeout       = 5
+	ExitForbidden     = 6
+)
+
+// ExitCodeForError returns the exit code for an error returned by the API server.
+// Errors of other kinds are ExitError.
+func ExitCodeForError(err error) int {
+	switch {
+	case err == nil:
+		return ExitSuccess
+	case k8sErrors.IsNotFound(err):
+		return ExitNotFound
+	case k8sErrors.IsAlreadyExists(err):
+		return ExitAlreadyExists
+	case err == wait.ErrWaitTimeout || k8sErrors.IsTimeout(err) || k8sErrors.IsServerTimeout(err):
+		return ExitTimeout
+	case k8sErrors.IsForbidden(err) || k8sErrors.IsUnauthorized(err):
+		return ExitForbidden
+	}
+	return ExitError
+}
+
+// FatalWithCode logs the message like logrus.Fatal but exits with the given code.
+// The exit handlers registered in logrus are run as well. JSON logs include the
+// code in the field exitCode.
+func FatalWithCode(code int, args ...interface{}) {
+	withExitCode(code, func() { fatalEntry(code).Fatal(args...) })
+}
+
+// FatalfWithCode logs the message like logrus.Fatalf but exits with the given code
+func FatalfWithCode(code int, format string, args ...interface{}) {
+	withExitCode(code, func() { fatalEntry(code).Fatalf(format, args...) })
+}
+
+func fatalEntry(code int) *logrus.Entry {
+	entry := logrus.NewEntry(logrus.StandardLogger())
+	if jsonLogs {
+		entry = entry.WithField("exitCode", code)
+	}
+	return entry
+}
+
+func withExitCode(code int, fatal func()) {
+	logger := logrus.StandardLogger()
+	exit := logger.ExitFunc
+	logger.ExitFunc = func(int) {
+		logger.ExitFunc = exit
+		if exit == nil {
+			os.Exit(code)
+		}
+		exit(code)
+	}
+	fatal()
+}
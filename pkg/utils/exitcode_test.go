@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestExitCodeForError(t *testing.T) {
+	resource := schema.GroupResource{Group: "kubeless.io", Resource: "cronjobtriggers"}
+	tests := []struct {
+		err      error
+		expected int
+	}{
+		{nil, ExitSuccess},
+		{fmt.Errorf("boom"), ExitError},
+		{k8sErrors.NewNotFound(resource, "foo"), ExitNotFound},
+		{k8sErrors.NewAlreadyExists(resource, "foo"), ExitAlreadyExists},
+		{k8sErrors.NewTimeoutError("slow", 1), ExitTimeout},
+		{wait.ErrWaitTimeout, ExitTimeout},
+		{k8sErrors.NewForbidden(resource, "foo", fmt.Errorf("denied")), ExitForbidden},
+		{k8sErrors.NewUnauthorized("who are you"), ExitForbidden},
+	}
+	for _, test := range tests {
+		if code := ExitCodeForError(test.err); code != test.expected {
+			t.Errorf("Expecting exit code %d for %v, got %d", test.expected, test.err, code)
+		}
+	}
+}
+
+func TestFatalWithCode(t *testing.T) {
+	logger := logrus.StandardLogger()
+	out := logger.Out
+	defer func() {
+		logger.ExitFunc = nil
+		logger.Out = out
+	}()
+	logger.Out = ioutil.Discard
+
+	tests := []struct {
+		fatal    func()
+		expected int
+	}{
+		{func() { FatalWithCode(ExitNotFound, "not found") }, ExitNotFound},
+		{func() { FatalfWithCode(ExitUsage, "invalid %s", "flag") }, ExitUsage},
+		{func() { logrus.Fatal("generic") }, ExitError},
+	}
+	for _, test := range tests {
+		// The process exits in the first call, logrus keeps calling it in tests
+		codes := []int{}
+		logger.ExitFunc = func(code int) { codes = append(codes, code) }
+		test.fatal()
+		if len(codes) == 0 || codes[0] != test.expected {
+			t.Errorf("Expecting exit code %d, got %v", test.expected, codes)
+		}
+	}
+}
+
+func TestFatalWithCodeJSON(t *testing.T) {
+	logger := logrus.StandardLogger()
+	out := logger.Out
+	defer func() {
+		logger.ExitFunc = nil
+		logger.Out = out
+		SetLogFormat(TextLogFormat)
+	}()
+	var buf bytes.Buffer
+	logger.Out = &buf
+	logger.ExitFunc = func(int) {}
+	if err := SetLogFormat(JSONLogFormat); err != nil {
+		t.Fatal(err)
+	}
+
+	FatalWithCode(ExitUsage, "invalid flag")
+	entry := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["exitCode"] != float64(ExitUsage) || entry["level"] != "fatal" || entry["msg"] != "invalid flag" {
+		t.Errorf("Unexpected entry %v", entry)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const envPrefix = "KUBELESS_"
+
+// defaultSourceAnnotation is set in the flags whose value is taken from an environment
+// variable or the CLI config, with the name of the variable or the path of the file
+const defaultSourceAnnotation = "kubeless-default-source"
+
+// FlagEnvName returns the environment variable used as default for the given flag
+// For example: --function-name is bound to KUBELESS_FUNCTION_NAME
+func FlagEnvName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(flag, "-", "_", -1))
+}
+
+// ApplyEnvDefaults sets every flag that has not been given in the command line
+// with the value of its environment variable (if any). Flags explicitly set always win.
+func ApplyEnvDefaults(flags *pflag.FlagSet) error {
+	var err error
+	flags.VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed {
+			return
+		}
+		env := FlagEnvName(f.Name)
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			return
+		}
+		if setErr := flags.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("Invalid value %q in %s: %v", value, env, setErr)
+			return
+		}
+		err = flags.SetAnnotation(f.Name, defaultSourceAnnotation, []string{env})
+	})
+	return err
+}
+
+// ApplyConfigDefaults sets the flags that have not been given in the command line
+// nor in the environment with the values stored in the CLI config file
+func ApplyConfigDefaults(flags *pflag.FlagSet, config map[string]string) error {
+	for key, value := range config {
+		for _, name := range CLIConfigKeys[key] {
+			f := flags.Lookup(name)
+			if f == nil || f.Changed {
+				continue
+			}
+			if err := flags.Set(name, value); err != nil {
+				return fmt.Errorf("Invalid value %q for %s in %s: %v", value, key, CLIConfigPath(), err)
+			}
+			if err := flags.SetAnnotation(name, defaultSourceAnnotation, []string{CLIConfigPath()}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// IsFlagFromDefaults returns true if the flag has not been given in the command line
+// but set from an environment variable or the CLI config
+func IsFlagFromDefaults(f *pflag.Flag) bool {
+	_, ok := f.Annotations[defaultSourceAnnotation]
+	return ok
+}
+
+// IsFlagGiven returns true if the flag has been given in the command line. Unlike
+// flags.Changed, it is false for the flags set from an environment variable or the
+// CLI config, so defaults never trigger the checks of flags that can't be combined.
+func IsFlagGiven(flags *pflag.FlagSet, name string) bool {
+	f := flags.Lookup(name)
+	return f != nil && f.Changed && !IsFlagFromDefaults(f)
+}
+
+// AllNamespacesAlias is the value of --namespace meaning every namespace in the list and describe commands
+const AllNamespacesAlias = "all"
+
+// AddAllNamespacesFlag adds the flag used to work with the objects of every namespace
+func AddAllNamespacesFlag(flags *pflag.FlagSet, usage string) {
+	flags.BoolP("all-namespaces", "A", false, usage)
+}
+
+// GetNamespaceOrAll returns the namespace given with --namespace, or the default one if empty.
+// It returns metav1.NamespaceAll with --all-namespaces or with "--namespace all". A namespace
+// literally named "all" can still be used with "--namespace all --all-namespaces=false".
+func GetNamespaceOrAll(flags *pflag.FlagSet) (string, error) {
+	ns, err := flags.GetString("namespace")
+	if err != nil {
+		return "", err
+	}
+	allNamespaces, err := flags.GetBool("all-namespaces")
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case allNamespaces:
+		if ns != "" && ns != AllNamespacesAlias {
+			return "", fmt.Errorf("The flags --namespace and --all-namespaces can't be used together")
+		}
+		return metav1.NamespaceAll, nil
+	case ns == AllNamespacesAlias && !flags.Changed("all-namespaces"):
+		return metav1.NamespaceAll, nil
+	case ns == "":
+		return GetDefaultNamespace(), nil
+	}
+	return ns, nil
+}
+
+// GetUniqueNamespace returns the only namespace in which an object with the given name was
+// found. The describe commands use it to find an object when given all the namespaces.
+func GetUniqueNamespace(kind, name string, namespaces []string) (string, error) {
+	switch len(namespaces) {
+	case 0:
+		return "", fmt.Errorf("Unable to find %s %s in any namespace", kind, name)
+	case 1:
+		return namespaces[0], nil
+	}
+	sort.Strings(namespaces)
+	return "", fmt.Errorf("Found %s %s in several namespaces (%s), use --namespace to choose one", kind, name, strings.Join(namespaces, ", "))
+}
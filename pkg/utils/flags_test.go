@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetNamespaceOrAll(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+		err      bool
+	}{
+		{args: []string{"--namespace", "foo"}, expected: "foo"},
+		{args: []string{"--all-namespaces"}, expected: metav1.NamespaceAll},
+		{args: []string{"-A"}, expected: metav1.NamespaceAll},
+		{args: []string{"-n", "all"}, expected: metav1.NamespaceAll},
+		{args: []string{"-n", "all", "-A"}, expected: metav1.NamespaceAll},
+		// The namespace named "all"
+		{args: []string{"-n", "all", "--all-namespaces=false"}, expected: "all"},
+		{args: []string{"-n", "foo", "-A"}, err: true},
+	}
+	for _, test := range tests {
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		flags.StringP("namespace", "n", "", "")
+		AddAllNamespacesFlag(flags, "")
+		if err := flags.Parse(test.args); err != nil {
+			t.Fatal(err)
+		}
+		ns, err := GetNamespaceOrAll(flags)
+		if test.err {
+			if err == nil {
+				t.Errorf("%v: expecting an error", test.args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: %v", test.args, err)
+		}
+		if ns != test.expected {
+			t.Errorf("%v: expecting namespace %q, got %q", test.args, test.expected, ns)
+		}
+	}
+}
+
+func TestApplyEnvDefaultsSource(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("runtime", "", "")
+	flags.String("handler", "", "")
+	flags.String("memory", "", "")
+	if err := flags.Parse([]string{"--handler", "foo.bar"}); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("KUBELESS_RUNTIME", "python3.7")
+	defer os.Unsetenv("KUBELESS_RUNTIME")
+	os.Setenv("KUBELESS_HANDLER", "bar.foo")
+	defer os.Unsetenv("KUBELESS_HANDLER")
+	if err := ApplyEnvDefaults(flags); err != nil {
+		t.Fatal(err)
+	}
+	if !IsFlagFromDefaults(flags.Lookup("runtime")) {
+		t.Error("--runtime should be marked as set from KUBELESS_RUNTIME")
+	}
+	if IsFlagFromDefaults(flags.Lookup("handler")) {
+		t.Error("--handler was given in the command line")
+	}
+	if IsFlagFromDefaults(flags.Lookup("memory")) {
+		t.Error("--memory has not been set")
+	}
+	if IsFlagGiven(flags, "runtime") || !IsFlagGiven(flags, "handler") || IsFlagGiven(flags, "memory") || IsFlagGiven(flags, "unknown") {
+		t.Error("Only --handler has been given in the command line")
+	}
+}
@@ -0,0 +1,285 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ApplyMergePatch applies a JSON merge patch (RFC 7386) to the given JSON document
+func ApplyMergePatch(doc, patch []byte) ([]byte, error) {
+	var original, patchObj interface{}
+	if err := json.Unmarshal(doc, &original); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &patchObj); err != nil {
+		return nil, fmt.Errorf("Invalid merge patch: %v", err)
+	}
+	if _, ok := patchObj.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("Invalid merge patch: it should be a JSON object")
+	}
+	return json.Marshal(mergePatch(original, patchObj))
+}
+
+func mergePatch(doc, patch interface{}) interface{} {
+	patchMap, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	docMap, ok := doc.(map[string]interface{})
+	if !ok {
+		docMap = map[string]interface{}{}
+	}
+	for k, v := range patchMap {
+		if v == nil {
+			delete(docMap, k)
+			continue
+		}
+		docMap[k] = mergePatch(docMap[k], v)
+	}
+	return docMap
+}
+
+// jsonPatchOperation is a single operation of a JSON patch
+type jsonPatchOperation struct {
+	Op    string           `json:"op"`
+	Path  *string          `json:"path"`
+	From  *string          `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// ApplyJSONPatch applies a JSON patch (RFC 6902) to the given JSON document
+func ApplyJSONPatch(doc, patch []byte) ([]byte, error) {
+	var result interface{}
+	if err := json.Unmarshal(doc, &result); err != nil {
+		return nil, err
+	}
+	ops := []jsonPatchOperation{}
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("Invalid JSON patch: it should be a list of operations: %v", err)
+	}
+	for i, op := range ops {
+		var err error
+		result, err = applyJSONPatchOperation(result, op)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to apply the operation %d (%s) of the JSON patch: %v", i, op.Op, err)
+		}
+	}
+	return json.Marshal(result)
+}
+
+func applyJSONPatchOperation(doc interface{}, op jsonPatchOperation) (interface{}, error) {
+	if op.Path == nil {
+		return nil, fmt.Errorf("missing path")
+	}
+	path, err := parseJSONPointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("missing value")
+		}
+		if err := json.Unmarshal(*op.Value, &value); err != nil {
+			return nil, err
+		}
+	case "move", "copy":
+		if op.From == nil {
+			return nil, fmt.Errorf("missing from")
+		}
+		from, err := parseJSONPointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err = getJSONPointer(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if doc, err = removeJSONPointer(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			// Copy the value so later operations don't modify both locations
+			raw, _ := json.Marshal(value)
+			json.Unmarshal(raw, &value)
+		}
+	}
+
+	switch op.Op {
+	case "add", "move", "copy":
+		return addJSONPointer(doc, path, value)
+	case "remove":
+		return removeJSONPointer(doc, path)
+	case "replace":
+		if _, err := getJSONPointer(doc, path); err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return value, nil
+		}
+		if doc, err = removeJSONPointer(doc, path); err != nil {
+			return nil, err
+		}
+		return addJSONPointer(doc, path, value)
+	case "test":
+		current, err := getJSONPointer(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("the value of %s doesn't match", *op.Path)
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("unknown operation")
+	}
+}
+
+// parseJSONPointer splits a JSON pointer (RFC 6901) in its unescaped tokens
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid path %q, it should start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(t, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+func getJSONPointer(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch container := doc.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("%q not found", token)
+			}
+			doc = value
+		case []interface{}:
+			i, err := getArrayIndex(token, len(container))
+			if err != nil {
+				return nil, err
+			}
+			doc = container[i]
+		default:
+			return nil, fmt.Errorf("%q not found", token)
+		}
+	}
+	return doc, nil
+}
+
+// updateJSONPointer replaces the parent of the path with the result of the given function
+func updateJSONPointer(doc interface{}, path []string, update func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	parent, err := getJSONPointer(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	newParent, err := update(parent, path[len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 1 {
+		return newParent, nil
+	}
+	return setJSONPointer(doc, path[:len(path)-1], newParent)
+}
+
+func setJSONPointer(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	return updateJSONPointer(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			i, err := getArrayIndex(token, len(container))
+			if err != nil {
+				return nil, err
+			}
+			container[i] = value
+			return container, nil
+		}
+		return nil, fmt.Errorf("%q not found", token)
+	})
+}
+
+func addJSONPointer(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateJSONPointer(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			if token == "-" {
+				return append(container, value), nil
+			}
+			// The index can be the length of the array to append the value
+			i, err := getArrayIndex(token, len(container)+1)
+			if err != nil {
+				return nil, err
+			}
+			result := append([]interface{}{}, container[:i]...)
+			result = append(result, value)
+			return append(result, container[i:]...), nil
+		}
+		return nil, fmt.Errorf("can't add %q to a value that is not an object or an array", token)
+	})
+}
+
+func removeJSONPointer(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("the whole document can't be removed")
+	}
+	return updateJSONPointer(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := container[token]; !ok {
+				return nil, fmt.Errorf("%q not found", token)
+			}
+			delete(container, token)
+			return container, nil
+		case []interface{}:
+			i, err := getArrayIndex(token, len(container))
+			if err != nil {
+				return nil, err
+			}
+			return append(append([]interface{}{}, container[:i]...), container[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("%q not found", token)
+	})
+}
+
+func getArrayIndex(token string, length int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i >= length || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return i, nil
+}
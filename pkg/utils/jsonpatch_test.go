@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func assertJSONEqual(t *testing.T, name string, actual []byte, expected string) {
+	var a, e interface{}
+	if err := json.Unmarshal(actual, &a); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	if err := json.Unmarshal([]byte(expected), &e); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	if !reflect.DeepEqual(a, e) {
+		t.Errorf("%s: expecting %s, got %s", name, expected, actual)
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	doc := []byte(`{"spec":{"schedule":"* * * * *","function-name":"foo","payload":{"a":1,"b":2}}}`)
+	result, err := ApplyMergePatch(doc, []byte(`{"spec":{"schedule":"0 2 * * *","payload":{"b":null,"c":[1,2]}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSONEqual(t, "merge", result, `{"spec":{"schedule":"0 2 * * *","function-name":"foo","payload":{"a":1,"c":[1,2]}}}`)
+
+	for _, patch := range []string{`{"spec":`, `["spec"]`, `"foo"`} {
+		if _, err := ApplyMergePatch(doc, []byte(patch)); err == nil {
+			t.Errorf("Expecting an error for the patch %s", patch)
+		}
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	doc := []byte(`{"spec":{"schedule":"* * * * *","payload":{"items":[1,2,3],"a/b":"x"}}}`)
+	tests := []struct {
+		name     string
+		patch    string
+		expected string
+	}{
+		{
+			name:     "replace",
+			patch:    `[{"op":"replace","path":"/spec/schedule","value":"0 2 * * *"}]`,
+			expected: `{"spec":{"schedule":"0 2 * * *","payload":{"items":[1,2,3],"a/b":"x"}}}`,
+		},
+		{
+			name:     "add and remove in arrays",
+			patch:    `[{"op":"add","path":"/spec/payload/items/1","value":9},{"op":"add","path":"/spec/payload/items/-","value":4},{"op":"remove","path":"/spec/payload/items/0"}]`,
+			expected: `{"spec":{"schedule":"* * * * *","payload":{"items":[9,2,3,4],"a/b":"x"}}}`,
+		},
+		{
+			name:     "escaped pointer",
+			patch:    `[{"op":"remove","path":"/spec/payload/a~1b"}]`,
+			expected: `{"spec":{"schedule":"* * * * *","payload":{"items":[1,2,3]}}}`,
+		},
+		{
+			name:     "move, copy and test",
+			patch:    `[{"op":"test","path":"/spec/schedule","value":"* * * * *"},{"op":"copy","from":"/spec/payload/items","path":"/spec/copy"},{"op":"move","from":"/spec/payload/a~1b","path":"/spec/moved"},{"op":"add","path":"/spec/copy/-","value":4}]`,
+			expected: `{"spec":{"schedule":"* * * * *","payload":{"items":[1,2,3]},"copy":[1,2,3,4],"moved":"x"}}`,
+		},
+	}
+	for _, test := range tests {
+		result, err := ApplyJSONPatch(doc, []byte(test.patch))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		assertJSONEqual(t, test.name, result, test.expected)
+	}
+
+	for _, patch := range []string{
+		`{"op":"replace","path":"/spec/schedule","value":"x"}`,
+		`[{"op":"replace","path":"/spec/missing","value":"x"}]`,
+		`[{"op":"remove","path":"/spec/payload/items/3"}]`,
+		`[{"op":"add","path":"spec","value":"x"}]`,
+		`[{"op":"add","path":"/spec/schedule"}]`,
+		`[{"op":"test","path":"/spec/schedule","value":"0 2 * * *"}]`,
+		`[{"op":"rename","path":"/spec/schedule"}]`,
+		`[{"op":"remove","path":""}]`,
+	} {
+		if _, err := ApplyJSONPatch(doc, []byte(patch)); err == nil {
+			t.Errorf("Expecting an error for the patch %s", patch)
+		}
+	}
+}
@@ -24,6 +24,8 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
 	"github.com/sirupsen/logrus"
@@ -39,6 +41,7 @@ import (
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 
 	monitoringv1alpha1 "github.com/coreos/prometheus-operator/pkg/client/monitoring/v1alpha1"
 
@@ -68,28 +71,91 @@ func GetClient() kubernetes.Interface {
 	return clientset
 }
 
+// requestTimeout is the maximum time of each request to the cluster. Zero means no timeout.
+var requestTimeout time.Duration
+
+// SetRequestTimeout sets the maximum time of each request made with the out of cluster clients
+func SetRequestTimeout(timeout time.Duration) {
+	requestTimeout = timeout
+}
+
+// tlsOverrides replaces the TLS settings of the kubeconfig when they are given in the command line
+var tlsOverrides struct {
+	insecure bool
+	caFile   string
+}
+
+// SetTLSOverrides sets the TLS settings used instead of the ones of the kubeconfig.
+// An empty caFile keeps the certificate authority of the kubeconfig.
+func SetTLSOverrides(insecure bool, caFile string) error {
+	if insecure && caFile != "" {
+		return fmt.Errorf("--insecure-skip-tls-verify and --certificate-authority can't be used together")
+	}
+	if caFile != "" {
+		if _, err := os.Stat(caFile); err != nil {
+			return fmt.Errorf("Unable to read the certificate authority: %v", err)
+		}
+	}
+	tlsOverrides.insecure = insecure
+	tlsOverrides.caFile = caFile
+	return nil
+}
+
+// applyTLSOverrides sets the TLS overrides in the given config. The CA of the
+// kubeconfig is dropped in both cases since it can't be combined with them.
+func applyTLSOverrides(config *rest.Config) {
+	if tlsOverrides.insecure {
+		config.TLSClientConfig.Insecure = true
+		config.TLSClientConfig.CAFile = ""
+		config.TLSClientConfig.CAData = nil
+	} else if tlsOverrides.caFile != "" {
+		config.TLSClientConfig.CAFile = tlsOverrides.caFile
+		config.TLSClientConfig.CAData = nil
+	}
+}
+
 // BuildOutOfClusterConfig returns k8s config
 func BuildOutOfClusterConfig() (*rest.Config, error) {
+	config, err := getOutOfClusterClientConfig().ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	applyTLSOverrides(config)
+	config.Timeout = requestTimeout
+	return config, nil
+}
+
+// getOutOfClusterClientConfig returns the loader of the kubeconfig used outside of the cluster
+func getOutOfClusterClientConfig() clientcmd.ClientConfig {
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 	kubeconfigEnv := os.Getenv("KUBECONFIG")
 	if kubeconfigEnv == "" {
-		home := os.Getenv("HOMEDRIVE") + os.Getenv("HOMEPATH")
-		if home == "" {
-			for _, h := range []string{"HOME", "USERPROFILE"} {
-				if home = os.Getenv(h); home != "" {
-					break
-				}
-			}
+		kubeconfigPath := getCLIConfigValue("kubeconfig")
+		if kubeconfigPath == "" {
+			kubeconfigPath = filepath.Join(GetHomeDir(), ".kube", "config")
 		}
-		kubeconfigPath := filepath.Join(home, ".kube", "config")
 		loadingRules.ExplicitPath = kubeconfigPath
 	}
-	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		loadingRules, &clientcmd.ConfigOverrides{}).ClientConfig()
-	if err != nil {
-		return nil, err
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, getClientConfigOverrides())
+}
+
+// ContextEnv is the environment variable that selects the kubeconfig context.
+// It has precedence over the context of the CLI config.
+const ContextEnv = "KUBELESS_CONTEXT"
+
+// getContextOverride returns the kubeconfig context to use instead of the current one, if any
+func getContextOverride() string {
+	if context := os.Getenv(ContextEnv); context != "" {
+		return context
+	}
+	return getCLIConfigValue("context")
+}
+
+// getClientConfigOverrides returns the kubeconfig overrides set in the environment or in the CLI config
+func getClientConfigOverrides() *clientcmd.ConfigOverrides {
+	return &clientcmd.ConfigOverrides{
+		CurrentContext: getContextOverride(),
 	}
-	return config, nil
 }
 
 // GetClientOutOfCluster returns a k8s clientset to the request from outside of cluster
@@ -167,7 +233,11 @@ func GetKubelessClientOutCluster() (versioned.Interface, error) {
 func GetDefaultNamespace() string {
 	rules := clientcmd.NewDefaultClientConfigLoadingRules()
 	rules.DefaultClientConfig = &clientcmd.DefaultClientConfig
-	overrides := &clientcmd.ConfigOverrides{ClusterDefaults: clientcmd.ClusterDefaults}
+	if kubeconfigPath := getCLIConfigValue("kubeconfig"); kubeconfigPath != "" && os.Getenv("KUBECONFIG") == "" {
+		rules.ExplicitPath = kubeconfigPath
+	}
+	overrides := getClientConfigOverrides()
+	overrides.ClusterDefaults = clientcmd.ClusterDefaults
 
 	if ns, _, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).Namespace(); err == nil {
 		return ns
@@ -229,6 +299,38 @@ func DeleteFunctionCustomResource(kubelessClient versioned.Interface, funcName,
 	return nil
 }
 
+// deletionPollInterval is the time between checks in WaitForDeletion
+var deletionPollInterval = time.Second
+
+// WaitForDeletion polls the given object until it is not found. If it still exists
+// after the timeout, the returned error includes its pending finalizers.
+func WaitForDeletion(kind, name string, timeout time.Duration, get func() (metav1.Object, error)) error {
+	var finalizers []string
+	progress := StartProgress(fmt.Sprintf("Waiting for the %s %s to be deleted", kind, name))
+	err := wait.PollImmediate(deletionPollInterval, timeout, func() (bool, error) {
+		obj, err := get()
+		if err != nil {
+			if k8sErrors.IsNotFound(err) {
+				return true, nil
+			}
+			return false, err
+		}
+		finalizers = obj.GetFinalizers()
+		if len(finalizers) > 0 {
+			progress.SetPhase(fmt.Sprintf("Waiting for the %s %s to be deleted (finalizers: %s)", kind, name, strings.Join(finalizers, ", ")))
+		}
+		return false, nil
+	})
+	progress.Stop()
+	if err == wait.ErrWaitTimeout {
+		if len(finalizers) == 0 {
+			return fmt.Errorf("The %s %s still exists after %v", kind, name, timeout)
+		}
+		return fmt.Errorf("The %s %s still exists after %v. Remaining finalizers: %s", kind, name, timeout, strings.Join(finalizers, ", "))
+	}
+	return err
+}
+
 // GetFunctionCustomResource will delete custom function object
 func GetFunctionCustomResource(kubelessClient versioned.Interface, funcName, ns string) (*kubelessApi.Function, error) {
 	functionObj, err := kubelessClient.KubelessV1beta1().Functions(ns).Get(funcName, metav1.GetOptions{})
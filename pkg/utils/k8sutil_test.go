@@ -5,13 +5,17 @@ import (
 	"encoding/json"
 	"io"
 	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	v2beta1 "k8s.io/api/autoscaling/v2beta1"
 	corev1 "k8s.io/api/core/v1"
 	extensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	fakeextensionsapi "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	resource "k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -377,3 +381,77 @@ func TestGetAnnotationsFromCRD(t *testing.T) {
 	}
 
 }
+
+func TestWaitForDeletion(t *testing.T) {
+	defer func(interval time.Duration) { deletionPollInterval = interval }(deletionPollInterval)
+	deletionPollInterval = time.Millisecond
+
+	notFound := k8sErrors.NewNotFound(schema.GroupResource{Resource: "functions"}, "foo")
+	calls := 0
+	err := WaitForDeletion("function", "foo", time.Second, func() (metav1.Object, error) {
+		calls++
+		if calls < 3 {
+			return &metav1.ObjectMeta{Name: "foo"}, nil
+		}
+		return nil, notFound
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("Expecting 3 calls, got %d", calls)
+	}
+
+	err = WaitForDeletion("function", "foo", 10*time.Millisecond, func() (metav1.Object, error) {
+		return &metav1.ObjectMeta{Name: "foo", Finalizers: []string{"kubeless.io/function"}}, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "kubeless.io/function") {
+		t.Errorf("Expecting an error with the remaining finalizers, got %v", err)
+	}
+}
+
+func TestApplyTLSOverrides(t *testing.T) {
+	defer SetTLSOverrides(false, "")
+
+	caFile, err := ioutil.TempFile("", "ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(caFile.Name())
+
+	if err := SetTLSOverrides(true, caFile.Name()); err == nil {
+		t.Error("Expecting an error when using both overrides")
+	}
+	if err := SetTLSOverrides(false, "/does/not/exist"); err == nil {
+		t.Error("Expecting an error for a missing certificate authority")
+	}
+
+	newConfig := func() *rest.Config {
+		return &rest.Config{TLSClientConfig: rest.TLSClientConfig{CAFile: "/kube/ca.crt", CAData: []byte("ca")}}
+	}
+
+	config := newConfig()
+	SetTLSOverrides(false, "")
+	applyTLSOverrides(config)
+	if config.Insecure || config.CAFile != "/kube/ca.crt" || string(config.CAData) != "ca" {
+		t.Errorf("Expecting the config to be unchanged, got %+v", config.TLSClientConfig)
+	}
+
+	config = newConfig()
+	if err := SetTLSOverrides(true, ""); err != nil {
+		t.Fatal(err)
+	}
+	applyTLSOverrides(config)
+	if !config.Insecure || config.CAFile != "" || config.CAData != nil {
+		t.Errorf("Expecting an insecure config without CA, got %+v", config.TLSClientConfig)
+	}
+
+	config = newConfig()
+	if err := SetTLSOverrides(false, caFile.Name()); err != nil {
+		t.Fatal(err)
+	}
+	applyTLSOverrides(config)
+	if config.Insecure || config.CAFile != caFile.Name() || config.CAData != nil {
+		t.Errorf("Expecting the CA to be %s, got %+v", caFile.Name(), config.TLSClientConfig)
+	}
+}
@@ -47,6 +47,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
@@ -325,6 +326,80 @@ func getChecksum(content string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
+// BuildChecksumAnnotation is set in the pod template of the functions that run a built
+// image. It contains the checksum of the function parts included in the image.
+const BuildChecksumAnnotation = "kubeless.io/build-checksum"
+
+// GetBuildChecksum returns the checksum of the runtime, source, dependencies and build
+// arguments of a function. Changes in the rest of the spec (env, resources...) don't require
+// a new image.
+func GetBuildChecksum(funcObj *kubelessApi.Function) (string, error) {
+	source := funcObj.Spec.Checksum
+	if source == "" {
+		source = funcObj.Spec.Function
+	}
+	content := fmt.Sprintf("%s\n%s\n%s\n%s", funcObj.Spec.Runtime, funcObj.Spec.FunctionContentType, source, funcObj.Spec.Deps)
+	// The build arguments are only added when given so the checksum of the images
+	// built without them doesn't change
+	if initContainers := funcObj.Spec.Deployment.Spec.Template.Spec.InitContainers; len(initContainers) > 0 && len(initContainers[0].Env) > 0 {
+		buildEnv, err := json.Marshal(initContainers[0].Env)
+		if err != nil {
+			return "", err
+		}
+		content = fmt.Sprintf("%s\n%s", content, buildEnv)
+	}
+	return getChecksum(content)
+}
+
+// FunctionSpecAnnotation is set in the deployment of a function with the fields of the function
+// spec that build its pod template. The deployment controller copies the annotations of the
+// deployment to the ReplicaSet of each revision, so the spec of a revision can be restored.
+const FunctionSpecAnnotation = "kubeless.io/function-spec"
+
+// FunctionRevisionSpec is the part of the spec of a function stored in FunctionSpecAnnotation
+type FunctionRevisionSpec struct {
+	Handler  string             `json:"handler,omitempty"`
+	Runtime  string             `json:"runtime,omitempty"`
+	Timeout  string             `json:"timeout,omitempty"`
+	Deps     string             `json:"deps,omitempty"`
+	Template v1.PodTemplateSpec `json:"template"`
+}
+
+// getFunctionSpecAnnotation returns the value of FunctionSpecAnnotation for the function
+func getFunctionSpecAnnotation(funcObj *kubelessApi.Function) (string, error) {
+	spec, err := json.Marshal(FunctionRevisionSpec{
+		Handler:  funcObj.Spec.Handler,
+		Runtime:  funcObj.Spec.Runtime,
+		Timeout:  funcObj.Spec.Timeout,
+		Deps:     funcObj.Spec.Deps,
+		Template: funcObj.Spec.Deployment.Spec.Template,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(spec), nil
+}
+
+// GetReusableBuild returns the image used by the current Deployment of the function if it was
+// built from the same runtime, source and dependencies. It returns an empty string otherwise.
+func GetReusableBuild(client kubernetes.Interface, funcObj *kubelessApi.Function) (string, error) {
+	dpm, err := client.AppsV1().Deployments(funcObj.ObjectMeta.Namespace).Get(funcObj.ObjectMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	checksum, err := GetBuildChecksum(funcObj)
+	if err != nil {
+		return "", err
+	}
+	if dpm.Spec.Template.Annotations[BuildChecksumAnnotation] != checksum || len(dpm.Spec.Template.Spec.Containers) == 0 {
+		return "", nil
+	}
+	return dpm.Spec.Template.Spec.Containers[0].Image, nil
+}
+
 // populatePodSpec populates a basic Pod Spec that uses init containers to populate
 // the runtime container with the function content and its dependencies.
 // The caller should define the runtime container(s).
@@ -357,8 +432,11 @@ func populatePodSpec(funcObj *kubelessApi.Function, lr *langruntime.Langruntimes
 	// prepare init-containers if some function is specified
 
 	resources := v1.ResourceRequirements{}
+	// the env of the init containers is only used while building the function (e.g. proxy settings)
+	buildEnv := []v1.EnvVar{}
 	if len(funcObj.Spec.Deployment.Spec.Template.Spec.InitContainers) > 0 {
 		resources = funcObj.Spec.Deployment.Spec.Template.Spec.InitContainers[0].Resources
+		buildEnv = funcObj.Spec.Deployment.Spec.Template.Spec.InitContainers[0].Env
 	}
 
 	if funcObj.Spec.Function != "" {
@@ -402,8 +480,9 @@ func populatePodSpec(funcObj *kubelessApi.Function, lr *langruntime.Langruntimes
 	_, err := lr.GetRuntimeInfo(funcObj.Spec.Runtime)
 	envVars := []v1.EnvVar{}
 	if len(result.Containers) > 0 {
-		envVars = result.Containers[0].Env
+		envVars = append(envVars, result.Containers[0].Env...)
 	}
+	envVars = append(envVars, buildEnv...)
 
 	hasDeps := funcObj.Spec.Deps != "" || strings.Contains(funcObj.Spec.FunctionContentType, "deps")
 	if hasDeps && err != nil {
@@ -626,6 +705,10 @@ func EnsureFuncDeployment(client kubernetes.Interface, funcObj *kubelessApi.Func
 		"prometheus.io/port":   strconv.Itoa(int(svcTargetPort(funcObj))),
 	}
 	maxUnavailable := intstr.FromInt(0)
+	functionSpec, err := getFunctionSpecAnnotation(funcObj)
+	if err != nil {
+		return err
+	}
 
 	// add deployment and copy all func's Spec.Deployment to the deployment
 	dpm := funcObj.Spec.Deployment.DeepCopy()
@@ -635,18 +718,28 @@ func EnsureFuncDeployment(client kubernetes.Interface, funcObj *kubelessApi.Func
 		MatchLabels: map[string]string{"created-by": funcObj.ObjectMeta.Labels["created-by"], "function": funcObj.ObjectMeta.Labels["function"]},
 	}
 
-	dpm.Spec.Strategy = appsv1.DeploymentStrategy{
-		RollingUpdate: &appsv1.RollingUpdateDeployment{
-			MaxUnavailable: &maxUnavailable,
-		},
+	// Keep the rolling update settings of the function, by default no pod is unavailable
+	if dpm.Spec.Strategy.Type != appsv1.RecreateDeploymentStrategyType {
+		rollingUpdate := &appsv1.RollingUpdateDeployment{}
+		if dpm.Spec.Strategy.RollingUpdate != nil {
+			rollingUpdate = dpm.Spec.Strategy.RollingUpdate
+		}
+		if rollingUpdate.MaxUnavailable == nil {
+			rollingUpdate.MaxUnavailable = &maxUnavailable
+		}
+		dpm.Spec.Strategy = appsv1.DeploymentStrategy{
+			RollingUpdate: rollingUpdate,
+		}
 	}
 
 	// append data to dpm deployment
 	dpm.Labels = addDefaultLabel(mergeMap(dpm.Labels, funcObj.Labels))
 	dpm.Spec.Template.Labels = mergeMap(dpm.Spec.Template.Labels, funcObj.Labels)
 	dpm.Annotations = mergeMap(dpm.Annotations, funcObj.Annotations)
+	dpm.Annotations[FunctionSpecAnnotation] = functionSpec
+	// The default annotations can be overridden in the pod template (e.g. to disable the scraping)
+	dpm.Spec.Template.Annotations = mergeMap(podAnnotations, dpm.Spec.Template.Annotations)
 	dpm.Spec.Template.Annotations = mergeMap(dpm.Spec.Template.Annotations, funcObj.Annotations)
-	dpm.Spec.Template.Annotations = mergeMap(dpm.Spec.Template.Annotations, podAnnotations)
 
 	if len(dpm.Spec.Template.Spec.Containers) == 0 {
 		dpm.Spec.Template.Spec.Containers = append(dpm.Spec.Template.Spec.Containers, v1.Container{})
@@ -676,8 +769,23 @@ func EnsureFuncDeployment(client kubernetes.Interface, funcObj *kubelessApi.Func
 		} else {
 			if dpm.Spec.Template.Spec.Containers[0].Image == "" {
 				dpm.Spec.Template.Spec.Containers[0].Image = prebuiltRuntimeImage
+				// Store the checksum of the build so config-only changes can reuse the image
+				buildChecksum, err := GetBuildChecksum(funcObj)
+				if err != nil {
+					return err
+				}
+				dpm.Spec.Template.Annotations[BuildChecksumAnnotation] = buildChecksum
 			}
 			dpm.Spec.Template.Spec.ImagePullSecrets = imagePullSecrets
+			// the function is already built so the init containers that only
+			// contain build settings (resources, env) are not needed
+			initContainers := []v1.Container{}
+			for _, c := range dpm.Spec.Template.Spec.InitContainers {
+				if c.Image != "" {
+					initContainers = append(initContainers, c)
+				}
+			}
+			dpm.Spec.Template.Spec.InitContainers = initContainers
 		}
 		timeout := funcObj.Spec.Timeout
 		if timeout == "" {
@@ -775,7 +883,9 @@ func EnsureFuncDeployment(client kubernetes.Interface, funcObj *kubelessApi.Func
 			return fmt.Errorf("Found a conflicting deployment object %s/%s. Aborting", funcObj.ObjectMeta.Namespace, funcObj.ObjectMeta.Name)
 		}
 		newDpm.ObjectMeta.Labels = funcObj.ObjectMeta.Labels
-		newDpm.ObjectMeta.Annotations = funcObj.Spec.Deployment.ObjectMeta.Annotations
+		newDpm.ObjectMeta.Annotations = mergeMap(mergeMap(nil, funcObj.Spec.Deployment.ObjectMeta.Annotations), map[string]string{
+			FunctionSpecAnnotation: functionSpec,
+		})
 		newDpm.ObjectMeta.OwnerReferences = or
 		// We should maintain previous selector to avoid duplicated ReplicaSets
 		selector := newDpm.Spec.Selector
@@ -868,9 +978,20 @@ func GetInClusterConfig() (*rest.Config, error) {
 	return config, nil
 }
 
+// controllerNamespaceEnv is the environment variable with the namespace of the controller.
+// KUBELESS_NAMESPACE is used if it isn't set, but that one is also the default of --namespace.
+const controllerNamespaceEnv = "KUBELESS_CONTROLLER_NAMESPACE"
+
+func getControllerNamespaceEnv() string {
+	if ns := os.Getenv(controllerNamespaceEnv); ns != "" {
+		return ns
+	}
+	return os.Getenv("KUBELESS_NAMESPACE")
+}
+
 func getConfigLocation(apiExtensionsClientset clientsetAPIExtensions.Interface) (ConfigLocation, error) {
 	configLocation := ConfigLocation{}
-	controllerNamespace := os.Getenv("KUBELESS_NAMESPACE")
+	controllerNamespace := getControllerNamespaceEnv()
 	kubelessConfig := os.Getenv("KUBELESS_CONFIG")
 
 	annotationsCRD, err := GetAnnotationsFromCRD(apiExtensionsClientset, "functions.kubeless.io")
@@ -896,6 +1017,15 @@ func getConfigLocation(apiExtensionsClientset clientsetAPIExtensions.Interface)
 	return configLocation, nil
 }
 
+// GetControllerNamespace returns the namespace where the Kubeless controller is installed
+func GetControllerNamespace(cliAPIExtensions clientsetAPIExtensions.Interface) (string, error) {
+	configLocation, err := getConfigLocation(cliAPIExtensions)
+	if err != nil {
+		return "", err
+	}
+	return configLocation.Namespace, nil
+}
+
 // GetKubelessConfig Returns Kubeless ConfigMap
 func GetKubelessConfig(cli kubernetes.Interface, cliAPIExtensions clientsetAPIExtensions.Interface) (*v1.ConfigMap, error) {
 	configLocation, err := getConfigLocation(cliAPIExtensions)
@@ -911,11 +1041,12 @@ func GetKubelessConfig(cli kubernetes.Interface, cliAPIExtensions clientsetAPIEx
 	return config, nil
 }
 
-// DryRunFmt stringify the given interface in a specific format
+// DryRunFmt stringify the given interface in a specific format. In both formats the keys
+// are sorted, so the same object always results in the same output.
 func DryRunFmt(format string, trigger interface{}) (string, error) {
 	switch format {
 	case "json":
-		j, err := json.MarshalIndent(trigger, "", "    ")
+		j, err := MarshalSortedJSON(trigger, "    ")
 		if err != nil {
 			return "", err
 		}
@@ -931,6 +1062,24 @@ func DryRunFmt(format string, trigger interface{}) (string, error) {
 	}
 }
 
+// DryRunFmtList returns the given objects as a stream of documents in the given format.
+// YAML documents are separated with "---" and JSON documents with a new line, so the
+// stream can be given to 'kubectl apply -f -' or processed with tools like jq.
+func DryRunFmtList(format string, objs ...interface{}) (string, error) {
+	docs := []string{}
+	for _, obj := range objs {
+		doc, err := DryRunFmt(format, obj)
+		if err != nil {
+			return "", err
+		}
+		docs = append(docs, strings.TrimSuffix(doc, "\n"))
+	}
+	if format == "yaml" {
+		return strings.Join(docs, "\n---\n"), nil
+	}
+	return strings.Join(docs, "\n"), nil
+}
+
 // getCompressionType returns the compression type (if any) of the given file by looking at the file extension
 func getCompressionType(filename string) (compressionType string) {
 	if strings.HasSuffix(filename, ".zip") {
@@ -1041,3 +1190,44 @@ func getSha256(bytes []byte) (string, error) {
 	checksum := hex.EncodeToString(h.Sum(nil))
 	return "sha256:" + checksum, nil
 }
+
+// ReadAnnotationsFile reads a YAML or JSON file containing a flat map of annotations
+func ReadAnnotationsFile(file string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	raw := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("Unable to parse %s: %v", file, err)
+	}
+	annotations := map[string]string{}
+	for k, v := range raw {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return nil, fmt.Errorf("Invalid annotation %q in %s: %s", k, file, strings.Join(errs, "; "))
+		}
+		value, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("Invalid annotation %q in %s: the value should be a string", k, file)
+		}
+		annotations[k] = value
+	}
+	return annotations, nil
+}
+
+// ApplyAnnotationsFile adds the annotations of the given file to the object metadata.
+// Annotations already present in the object are not overridden.
+func ApplyAnnotationsFile(meta *metav1.ObjectMeta, file string) error {
+	if file == "" {
+		return nil
+	}
+	annotations, err := ReadAnnotationsFile(file)
+	if err != nil {
+		return err
+	}
+	if len(annotations) == 0 {
+		return nil
+	}
+	meta.Annotations = mergeMap(annotations, meta.Annotations)
+	return nil
+}
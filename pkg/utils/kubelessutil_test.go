@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
@@ -470,6 +473,15 @@ func TestEnsureImage(t *testing.T) {
 	}
 }
 
+func hasEnvVar(env []v1.EnvVar, name string) bool {
+	for _, e := range env {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func getDefaultFunc(name, ns string) *kubelessApi.Function {
 	fPort := int32(8080)
 	f := kubelessApi.Function{
@@ -550,6 +562,7 @@ func TestEnsureDeployment(t *testing.T) {
 					v1.ResourceLimitsCPU: resource.MustParse("100m"),
 				},
 			},
+			Env: []v1.EnvVar{{Name: "PIP_INDEX_URL", Value: "https://pypi.example.com/simple"}},
 		},
 	}
 
@@ -573,12 +586,16 @@ func TestEnsureDeployment(t *testing.T) {
 	if err != nil {
 		t.Errorf("Unexpected error: %s", err)
 	}
+	functionSpec, err := getFunctionSpecAnnotation(f1)
+	if err != nil {
+		t.Fatal(err)
+	}
 	expectedObjectMeta := metav1.ObjectMeta{
 		Name:            f1Name,
 		Namespace:       ns,
 		Labels:          addDefaultLabel(funcLabels),
 		OwnerReferences: or,
-		Annotations:     funcAnno,
+		Annotations:     mergeMap(mergeMap(nil, funcAnno), map[string]string{FunctionSpecAnnotation: functionSpec}),
 	}
 	if !reflect.DeepEqual(dpm.ObjectMeta, expectedObjectMeta) {
 		t.Errorf("Unable to set metadata. Received:\n %+v\nExpecting:\n %+v", dpm.ObjectMeta, expectedObjectMeta)
@@ -722,6 +739,44 @@ func TestEnsureDeployment(t *testing.T) {
 	if !found {
 		t.Fatalf("Cannot find volume mount /var/run/secrets/kubeless.io/my-secret")
 	}
+
+	// the build env is only given to the containers that build the function
+	if !hasEnvVar(container.Env, "PIP_INDEX_URL") {
+		t.Errorf("Expecting PIP_INDEX_URL in the env of the install container, got %v", container.Env)
+	}
+	if hasEnvVar(dpm.Spec.Template.Spec.Containers[0].Env, "PIP_INDEX_URL") {
+		t.Errorf("Unexpected PIP_INDEX_URL in the env of the runtime container")
+	}
+}
+
+func TestEnsureDeploymentPodAnnotations(t *testing.T) {
+	funcName := "func-annotations"
+	clientset, or, ns, lr := prepareDeploymentTest(funcName)
+	f := getDefaultFunc(funcName, ns)
+	f.Spec.Deployment.Spec.Template.ObjectMeta = metav1.ObjectMeta{
+		Annotations: map[string]string{
+			"prometheus.io/scrape":    "false",
+			"sidecar.istio.io/inject": "false",
+		},
+	}
+	err := EnsureFuncDeployment(clientset, f, or, lr, "", "unzip", []v1.LocalObjectReference{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	dpm, err := clientset.AppsV1().Deployments(ns).Get(funcName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expectedAnnotations := map[string]string{
+		"prometheus.io/scrape":    "false",
+		"prometheus.io/path":      "/metrics",
+		"sidecar.istio.io/inject": "false",
+	}
+	for k, v := range expectedAnnotations {
+		if dpm.Spec.Template.Annotations[k] != v {
+			t.Errorf("Expecting annotation %s to be %q but received %q", k, v, dpm.Spec.Template.Annotations[k])
+		}
+	}
 }
 
 func TestEnsureDeploymentWithoutFuncNorHandler(t *testing.T) {
@@ -880,6 +935,80 @@ func TestDeploymentWithPrebuiltImage(t *testing.T) {
 	}
 }
 
+func TestGetReusableBuild(t *testing.T) {
+	funcName := "func"
+	clientset, or, ns, lr := prepareDeploymentTest(funcName)
+	f := getDefaultFunc(funcName, ns)
+	if image, err := GetReusableBuild(clientset, f); err != nil || image != "" {
+		t.Fatalf("Expecting no image for a function not deployed, got %q (%v)", image, err)
+	}
+	err := EnsureFuncDeployment(clientset, f, or, lr, "user/image:test", "unzip", []v1.LocalObjectReference{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// Changes in the configuration keep the build
+	f.Spec.Deployment.Spec.Template.Spec.Containers[0].Env = append(f.Spec.Deployment.Spec.Template.Spec.Containers[0].Env, v1.EnvVar{Name: "FOO", Value: "bar"})
+	if image, err := GetReusableBuild(clientset, f); err != nil || image != "user/image:test" {
+		t.Errorf("Expecting to reuse user/image:test, got %q (%v)", image, err)
+	}
+
+	// Changes in the dependencies require a new build
+	f.Spec.Deps = "requests"
+	if image, err := GetReusableBuild(clientset, f); err != nil || image != "" {
+		t.Errorf("Expecting a new build after changing the dependencies, got %q (%v)", image, err)
+	}
+}
+
+func TestGetBuildChecksum(t *testing.T) {
+	f := getDefaultFunc("func", "default")
+	noBuildArgs, err := GetBuildChecksum(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Spec.Deployment.Spec.Template.Spec.InitContainers = []v1.Container{{}}
+	if checksum, err := GetBuildChecksum(f); err != nil || checksum != noBuildArgs {
+		t.Errorf("Expecting the same checksum without build arguments, got %q (%v)", checksum, err)
+	}
+
+	f.Spec.Deployment.Spec.Template.Spec.InitContainers[0].Env = []v1.EnvVar{{Name: "PIP_INDEX_URL", Value: "https://pypi.example.com/simple"}}
+	withBuildArg, err := GetBuildChecksum(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withBuildArg == noBuildArgs {
+		t.Error("Expecting a different checksum after adding a build argument")
+	}
+
+	// Changing only the value of a build argument requires a new build
+	f.Spec.Deployment.Spec.Template.Spec.InitContainers[0].Env[0].Value = "https://pypi.example.org/simple"
+	if checksum, err := GetBuildChecksum(f); err != nil || checksum == withBuildArg {
+		t.Errorf("Expecting a different checksum after changing a build argument, got %q (%v)", checksum, err)
+	}
+}
+
+func TestDeploymentWithRollingUpdate(t *testing.T) {
+	funcName := "func"
+	clientset, or, ns, lr := prepareDeploymentTest(funcName)
+	f := getDefaultFunc(funcName, ns)
+	maxSurge := intstr.FromString("50%")
+	f.Spec.Deployment.Spec.Strategy.RollingUpdate = &appsv1.RollingUpdateDeployment{
+		MaxSurge: &maxSurge,
+	}
+	err := EnsureFuncDeployment(clientset, f, or, lr, "", "unzip", []v1.LocalObjectReference{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	dpm, err := clientset.AppsV1().Deployments(ns).Get(funcName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	rollingUpdate := dpm.Spec.Strategy.RollingUpdate
+	if rollingUpdate.MaxSurge.String() != "50%" || rollingUpdate.MaxUnavailable.String() != "0" {
+		t.Errorf("Unexpected rolling update %v", rollingUpdate)
+	}
+}
+
 func TestDeploymentWithVolumes(t *testing.T) {
 	funcName := "func"
 	clientset, or, ns, lr := prepareDeploymentTest(funcName)
@@ -1073,3 +1202,66 @@ func TestGetProvisionContainer(t *testing.T) {
 		t.Errorf("Unexpected command: %s", c.Args[0])
 	}
 }
+
+func TestApplyAnnotationsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "annotations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeFile := func(name, content string) string {
+		file := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+
+	file := writeFile("annotations.yaml", "example.com/owner: team-a\nexample.com/cost-center: \"1234\"\n")
+	meta := metav1.ObjectMeta{
+		Annotations: map[string]string{"example.com/owner": "team-b"},
+	}
+	if err := ApplyAnnotationsFile(&meta, file); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		// Annotations set by the command take precedence
+		"example.com/owner":       "team-b",
+		"example.com/cost-center": "1234",
+	}
+	if !reflect.DeepEqual(meta.Annotations, expected) {
+		t.Errorf("Expecting %v, got %v", expected, meta.Annotations)
+	}
+
+	file = writeFile("annotations.json", `{"example.com/owner": "team-a"}`)
+	meta = metav1.ObjectMeta{}
+	if err := ApplyAnnotationsFile(&meta, file); err != nil {
+		t.Fatal(err)
+	}
+	if meta.Annotations["example.com/owner"] != "team-a" {
+		t.Errorf("Unexpected annotations %v", meta.Annotations)
+	}
+
+	for name, content := range map[string]string{
+		"nested.yaml":  "example.com/owner:\n  name: team-a\n",
+		"number.yaml":  "example.com/cost-center: 1234\n",
+		"invalid.yaml": "not a valid key!: foo\n",
+	} {
+		if err := ApplyAnnotationsFile(&metav1.ObjectMeta{}, writeFile(name, content)); err == nil {
+			t.Errorf("Expecting an error for %s", name)
+		}
+	}
+}
+
+func TestGetControllerNamespaceEnv(t *testing.T) {
+	os.Setenv("KUBELESS_NAMESPACE", "dev")
+	defer os.Unsetenv("KUBELESS_NAMESPACE")
+	if ns := getControllerNamespaceEnv(); ns != "dev" {
+		t.Errorf("Expecting KUBELESS_NAMESPACE to be used without %s, got %q", controllerNamespaceEnv, ns)
+	}
+	os.Setenv(controllerNamespaceEnv, "kubeless-system")
+	defer os.Unsetenv(controllerNamespaceEnv)
+	if ns := getControllerNamespaceEnv(); ns != "kubeless-system" {
+		t.Errorf("Expecting %s to have precedence, got %q", controllerNamespaceEnv, ns)
+	}
+}
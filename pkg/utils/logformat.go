@@ -0,0 +1,80 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Log formats supported by --log-format
+const (
+	TextLogFormat = "text"
+	JSONLogFormat = "json"
+)
+
+var jsonLogs = false
+
+// logFieldsFormatter adds a set of fields to every entry that doesn't set them already
+type logFieldsFormatter struct {
+	formatter logrus.Formatter
+	fields    logrus.Fields
+}
+
+func (f *logFieldsFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	data := logrus.Fields{}
+	for k, v := range f.fields {
+		data[k] = v
+	}
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	entry.Data = data
+	return f.formatter.Format(entry)
+}
+
+// SetLogFormat switches the logs of the CLI to the given format. JSON logs have the
+// fields level, msg and time plus the context given with SetLogContext.
+func SetLogFormat(format string) error {
+	switch format {
+	case TextLogFormat:
+		jsonLogs = false
+		logrus.SetFormatter(&logrus.TextFormatter{})
+	case JSONLogFormat:
+		jsonLogs = true
+		logrus.SetFormatter(&logFieldsFormatter{formatter: &logrus.JSONFormatter{}, fields: logrus.Fields{}})
+	default:
+		return fmt.Errorf("Invalid log format %q. Supported formats are: %s, %s", format, TextLogFormat, JSONLogFormat)
+	}
+	return nil
+}
+
+// SetLogContext sets the namespace and the name of the object handled by the command
+// as fields of every JSON log entry. Empty values are not included. Text logs are not modified.
+func SetLogContext(namespace, object string) {
+	f, ok := logrus.StandardLogger().Formatter.(*logFieldsFormatter)
+	if !ok {
+		return
+	}
+	if namespace != "" {
+		f.fields["namespace"] = namespace
+	}
+	if object != "" {
+		f.fields["object"] = object
+	}
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSetLogFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+	defer logrus.SetOutput(os.Stderr)
+	defer SetLogFormat(TextLogFormat)
+
+	if err := SetLogFormat("xml"); err == nil {
+		t.Error("Expecting an error for an unknown format")
+	}
+
+	if err := SetLogFormat(JSONLogFormat); err != nil {
+		t.Fatal(err)
+	}
+	SetLogContext("myns", "")
+	logrus.Info("Deploying function...")
+	SetLogContext("", "foo")
+	logrus.WithField("object", "bar").Warn("Ignoring the CLI config")
+
+	entries := []map[string]interface{}{}
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		entry := map[string]interface{}{}
+		if err := decoder.Decode(&entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expecting 2 JSON entries, got %d", len(entries))
+	}
+	if entries[0]["level"] != "info" || entries[0]["msg"] != "Deploying function..." || entries[0]["namespace"] != "myns" {
+		t.Errorf("Unexpected entry %v", entries[0])
+	}
+	if _, ok := entries[0]["object"]; ok {
+		t.Errorf("An empty object should not be included: %v", entries[0])
+	}
+	// Fields of the entry take precedence over the context
+	if entries[1]["level"] != "warning" || entries[1]["namespace"] != "myns" || entries[1]["object"] != "bar" {
+		t.Errorf("Unexpected entry %v", entries[1])
+	}
+
+	buf.Reset()
+	if err := SetLogFormat(TextLogFormat); err != nil {
+		t.Fatal(err)
+	}
+	SetLogContext("myns", "foo")
+	logrus.Info("Deploying function...")
+	if bytes.Contains(buf.Bytes(), []byte("namespace=")) || !bytes.Contains(buf.Bytes(), []byte(`msg="Deploying function..."`)) {
+		t.Errorf("Unexpected text log %q", buf.String())
+	}
+}
@@ -18,9 +18,13 @@ package utils
 
 import (
 	"bytes"
+	"math"
+	"sort"
+	"time"
 
 	"k8s.io/client-go/kubernetes"
 
+	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/expfmt"
 )
 
@@ -34,6 +38,9 @@ type Metric struct {
 	TotalFailures        float64 `json:"total_failures,omitempty"`
 	TotalDurationSeconds float64 `json:"total_duration_seconds,omitempty"`
 	AvgDurationSeconds   float64 `json:"avg_duration_seconds,omitempty"`
+	P95DurationSeconds   float64 `json:"p95_duration_seconds,omitempty"`
+	ErrorRate            float64 `json:"error_rate,omitempty"`
+	RequestsPerSecond    float64 `json:"requests_per_second,omitempty"`
 }
 
 // MetricsRetriever is an interface for retreiving metrics from an endpoint
@@ -73,6 +80,7 @@ func parseMetrics(namespace, functionName string, rawMetrics []byte) ([]*Metric,
 					}
 					if m == "function_duration_seconds" {
 						tmp[label.GetValue()].TotalDurationSeconds = metric.GetHistogram().GetSampleSum()
+						tmp[label.GetValue()].P95DurationSeconds = histogramQuantile(0.95, metric.GetHistogram())
 					}
 					if m == "function_calls_total" {
 						tmp[label.GetValue()].TotalCalls = metric.GetCounter().GetValue()
@@ -94,12 +102,68 @@ func parseMetrics(namespace, functionName string, rawMetrics []byte) ([]*Metric,
 	}
 
 	for _, v := range tmp {
+		if v.TotalCalls > 0 {
+			v.ErrorRate = v.TotalFailures / v.TotalCalls
+		}
 		parsedMetrics = append(parsedMetrics, v)
 	}
 
 	return parsedMetrics, nil
 }
 
+// histogramQuantile estimates the quantile q (0 <= q <= 1) of a histogram
+// interpolating linearly within the bucket that contains it, like Prometheus does
+func histogramQuantile(q float64, h *dto.Histogram) float64 {
+	buckets := append([]*dto.Bucket{}, h.GetBucket()...)
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].GetUpperBound() < buckets[j].GetUpperBound()
+	})
+	count := float64(h.GetSampleCount())
+	if count == 0 || len(buckets) == 0 {
+		return 0
+	}
+	rank := q * count
+	lowerBound, lowerCount := 0.0, 0.0
+	for _, b := range buckets {
+		upperBound, upperCount := b.GetUpperBound(), float64(b.GetCumulativeCount())
+		if upperCount >= rank {
+			if math.IsInf(upperBound, 1) {
+				// the quantile is above the highest finite bucket
+				return lowerBound
+			}
+			if upperCount == lowerCount {
+				return upperBound
+			}
+			return lowerBound + (upperBound-lowerBound)*(rank-lowerCount)/(upperCount-lowerCount)
+		}
+		lowerBound, lowerCount = upperBound, upperCount
+	}
+	return lowerBound
+}
+
+// SetRequestRates sets the requests per second of the current metrics
+// based on the number of calls made since the previous sample
+func SetRequestRates(previous, current []*Metric, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	key := func(m *Metric) string {
+		return m.Namespace + "/" + m.FunctionName + "/" + m.Method
+	}
+	calls := map[string]float64{}
+	for _, m := range previous {
+		calls[key(m)] = m.TotalCalls
+	}
+	for _, m := range current {
+		prev, ok := calls[key(m)]
+		if !ok || m.TotalCalls < prev {
+			// new method or the function has been restarted
+			prev = 0
+		}
+		m.RequestsPerSecond = (m.TotalCalls - prev) / interval.Seconds()
+	}
+}
+
 // GetRawMetrics returns the raw metrics for a Prometheus endpoint
 func (h *PrometheusMetricsHandler) GetRawMetrics(apiV1Client kubernetes.Interface, namespace, functionName string) ([]byte, error) {
 
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+const testRawMetrics = `# HELP function_failures_total Number of exceptions in user function
+# TYPE function_failures_total counter
+function_failures_total{method="GET"} 5.0
+# HELP function_calls_total Number of calls to user function
+# TYPE function_calls_total counter
+function_calls_total{method="GET"} 100.0
+# HELP function_duration_seconds Duration of user function in seconds
+# TYPE function_duration_seconds histogram
+function_duration_seconds_bucket{le="0.1",method="GET"} 50.0
+function_duration_seconds_bucket{le="0.5",method="GET"} 90.0
+function_duration_seconds_bucket{le="1.0",method="GET"} 100.0
+function_duration_seconds_bucket{le="+Inf",method="GET"} 100.0
+function_duration_seconds_count{method="GET"} 100.0
+function_duration_seconds_sum{method="GET"} 20.0
+`
+
+func TestParseMetrics(t *testing.T) {
+	metrics, err := parseMetrics("myns", "foo", []byte(testRawMetrics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("Expecting a single metric, got %d", len(metrics))
+	}
+	m := metrics[0]
+	if m.ErrorRate != 0.05 {
+		t.Errorf("Expecting an error rate of 0.05, got %v", m.ErrorRate)
+	}
+	// 95 calls fall in the (0.5, 1.0] bucket, half way between 90 and 100
+	if m.P95DurationSeconds != 0.75 {
+		t.Errorf("Expecting a p95 of 0.75, got %v", m.P95DurationSeconds)
+	}
+}
+
+func TestSetRequestRates(t *testing.T) {
+	previous := []*Metric{
+		{FunctionName: "foo", Namespace: "myns", Method: "GET", TotalCalls: 100},
+		{FunctionName: "foo", Namespace: "myns", Method: "POST", TotalCalls: 50},
+	}
+	current := []*Metric{
+		{FunctionName: "foo", Namespace: "myns", Method: "GET", TotalCalls: 120},
+		// The function has been restarted
+		{FunctionName: "foo", Namespace: "myns", Method: "POST", TotalCalls: 10},
+		{FunctionName: "foo", Namespace: "myns", Method: "PUT", TotalCalls: 5},
+	}
+	SetRequestRates(previous, current, 10*time.Second)
+	expected := []float64{2, 1, 0.5}
+	for i, m := range current {
+		if m.RequestsPerSecond != expected[i] {
+			t.Errorf("Expecting %v requests per second for %s, got %v", expected[i], m.Method, m.RequestsPerSecond)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"text/template"
+
+	"github.com/spf13/pflag"
+)
+
+// OutputTemplate is the output format that renders objects through a Go template
+const OutputTemplate = "template"
+
+// prettyJSON controls if the json output is indented. It defaults to true only
+// when writing to a terminal so the output stays in a single line when piped.
+var prettyJSON = IsTerminal(os.Stdout)
+
+// IsTerminal returns true if the given file is a terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// PrettyJSONDefault returns the default value for the --pretty flag
+func PrettyJSONDefault() bool {
+	return IsTerminal(os.Stdout)
+}
+
+// SetPrettyJSON enables or disables the indentation of the json output
+func SetPrettyJSON(pretty bool) {
+	prettyJSON = pretty
+}
+
+// MarshalJSON returns the json encoding of the given object, indented with
+// the given string if pretty output is enabled or compacted in a single line otherwise
+func MarshalJSON(obj interface{}, indent string) ([]byte, error) {
+	if prettyJSON {
+		return json.MarshalIndent(obj, "", indent)
+	}
+	return json.Marshal(obj)
+}
+
+// MarshalSortedJSON is like MarshalJSON but the keys of every object are sorted, including the
+// fields of structs and the JSON that types with their own marshaler write as is. The output
+// only depends on the content of the object, like the YAML output.
+func MarshalSortedJSON(obj interface{}, indent string) ([]byte, error) {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	// Numbers are kept as they are instead of converting them to float64
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return MarshalJSON(generic, indent)
+}
+
+// AddTemplateFlags adds the flags used with --output=template
+func AddTemplateFlags(flags *pflag.FlagSet) {
+	flags.String("template", "", "Go template used to render each object with the template output. For example: --template '{{.metadata.name}}'")
+	flags.String("template-file", "", "File containing the Go template used to render each object with the template output")
+}
+
+// GetOutputTemplate returns the template to use for the given output format.
+// It's nil unless the output format is OutputTemplate.
+func GetOutputTemplate(flags *pflag.FlagSet, output string) (*template.Template, error) {
+	text, err := flags.GetString("template")
+	if err != nil {
+		return nil, err
+	}
+	file, err := flags.GetString("template-file")
+	if err != nil {
+		return nil, err
+	}
+	if output != OutputTemplate {
+		if text != "" || file != "" {
+			return nil, fmt.Errorf("The flags --template and --template-file require --output=%s", OutputTemplate)
+		}
+		return nil, nil
+	}
+	return ParseOutputTemplate(text, file)
+}
+
+// ParseOutputTemplate parses the template given either as text or as a file
+func ParseOutputTemplate(text, file string) (*template.Template, error) {
+	if text != "" && file != "" {
+		return nil, fmt.Errorf("You can't provide both a template and a template file")
+	}
+	if file != "" {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		text = string(content)
+	}
+	if text == "" {
+		return nil, fmt.Errorf("A template is required. Use --template or --template-file")
+	}
+	t, err := template.New("output").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid template: %v", err)
+	}
+	return t, nil
+}
+
+// PrintTemplate renders the given object through the template. If the object is a list,
+// each item is rendered separately. Objects are decoded into generic maps first, so
+// fields are referenced by their JSON name, e.g. {{.metadata.name}}.
+func PrintTemplate(w io.Writer, t *template.Template, obj interface{}) error {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return err
+	}
+	items, ok := decoded.([]interface{})
+	if !ok {
+		items = []interface{}{decoded}
+	}
+	for _, item := range items {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, item); err != nil {
+			return fmt.Errorf("Unable to render the template: %v", err)
+		}
+		if buf.Len() == 0 || buf.Bytes()[buf.Len()-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PrintObjects prints the given object, or list of objects, as json, yaml or through a template
+func PrintObjects(w io.Writer, output string, tmpl *template.Template, obj interface{}) error {
+	if output == OutputTemplate {
+		return PrintTemplate(w, tmpl, obj)
+	}
+	if output != "json" && output != "yaml" {
+		return fmt.Errorf("Wrong output format. Please use only json|yaml|template")
+	}
+	res, err := DryRunFmt(output, obj)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, res)
+	return err
+}
+
+// OutputName is the output format of the create commands that prints <resource>/<name> created
+const OutputName = "name"
+
+// GetCreatedOutput returns the format used to report the objects created by a command: the
+// value of --output if it is given in the command line, like kubectl, or an empty string to
+// just log a message. The default output of the CLI config or KUBELESS_OUTPUT is meant for
+// the commands that list objects, so it's ignored.
+func GetCreatedOutput(flags *pflag.FlagSet) (string, error) {
+	if !IsFlagGiven(flags, "output") {
+		return "", nil
+	}
+	output, err := flags.GetString("output")
+	if err != nil {
+		return "", err
+	}
+	if output != OutputName && output != "json" && output != "yaml" {
+		return "", fmt.Errorf("Wrong output format. Please use only name|json|yaml")
+	}
+	return output, nil
+}
+
+// PrintCreated prints an object created by a command. The resource is the name of its
+// type with the API group, like cronjobtrigger.kubeless.io.
+func PrintCreated(w io.Writer, output, resource, name string, obj interface{}) error {
+	if output == OutputName {
+		_, err := fmt.Fprintf(w, "%s/%s created\n", resource, name)
+		return err
+	}
+	return PrintObjects(w, output, nil, obj)
+}
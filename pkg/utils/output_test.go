@@ -0,0 +1,255 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPrintTemplate(t *testing.T) {
+	functions := []*kubelessApi.Function{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "myns"},
+			Spec:       kubelessApi.FunctionSpec{Runtime: "python2.7"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "myns"},
+			Spec:       kubelessApi.FunctionSpec{Runtime: "nodejs8"},
+		},
+	}
+	tmpl, err := ParseOutputTemplate("{{.metadata.name}} {{.spec.runtime}}", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Lists render each item
+	var buf bytes.Buffer
+	if err := PrintTemplate(&buf, tmpl, functions); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "foo python2.7\nbar nodejs8\n"; buf.String() != expected {
+		t.Errorf("Expecting %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := PrintTemplate(&buf, tmpl, functions[0]); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "foo python2.7\n"; buf.String() != expected {
+		t.Errorf("Expecting %q, got %q", expected, buf.String())
+	}
+}
+
+func TestParseOutputTemplate(t *testing.T) {
+	file, err := ioutil.TempFile("", "template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString("{{range $k, $v := .metadata.labels}}{{$k}}={{$v}}\n{{end}}"); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	tmpl, err := ParseOutputTemplate("", file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	f := kubelessApi.Function{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"a": "1", "b": "2"}}}
+	if err := PrintTemplate(&buf, tmpl, f); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "a=1\nb=2\n"; buf.String() != expected {
+		t.Errorf("Expecting %q, got %q", expected, buf.String())
+	}
+
+	if _, err := ParseOutputTemplate("{{.metadata.name}}", file.Name()); err == nil {
+		t.Error("Expecting an error when both a template and a file are given")
+	}
+	if _, err := ParseOutputTemplate("", ""); err == nil {
+		t.Error("Expecting an error without template")
+	}
+	if _, err := ParseOutputTemplate("{{.metadata.name", ""); err == nil {
+		t.Error("Expecting an error for an invalid template")
+	}
+}
+
+func TestPrintObjects(t *testing.T) {
+	defer SetPrettyJSON(prettyJSON)
+	SetPrettyJSON(true)
+	var buf bytes.Buffer
+	obj := map[string]string{"foo": "bar"}
+	if err := PrintObjects(&buf, "json", nil, obj); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "{\n    \"foo\": \"bar\"\n}\n" {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+	if err := PrintObjects(&buf, "xml", nil, obj); err == nil {
+		t.Error("Expecting an error for an unknown format")
+	}
+}
+
+func TestDryRunFmtPretty(t *testing.T) {
+	defer SetPrettyJSON(prettyJSON)
+	obj := map[string]interface{}{"foo": "bar", "list": []int{1, 2}}
+
+	SetPrettyJSON(true)
+	res, err := DryRunFmt("json", obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\n    \"foo\": \"bar\",\n    \"list\": [\n        1,\n        2\n    ]\n}"
+	if res != expected {
+		t.Errorf("Expecting indented output %q, got %q", expected, res)
+	}
+
+	SetPrettyJSON(false)
+	res, err = DryRunFmt("json", obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != `{"foo":"bar","list":[1,2]}` {
+		t.Errorf("Expecting compact output, got %q", res)
+	}
+
+	// YAML is not affected
+	res, err = DryRunFmt("yaml", obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "foo: bar\nlist:\n- 1\n- 2\n" {
+		t.Errorf("Unexpected yaml output %q", res)
+	}
+}
+
+func TestDryRunFmtSortedKeys(t *testing.T) {
+	defer SetPrettyJSON(prettyJSON)
+	SetPrettyJSON(false)
+	newObj := func() interface{} {
+		return struct {
+			Name    string          `json:"name"`
+			Payload interface{}     `json:"payload"`
+			Raw     json.RawMessage `json:"raw"`
+		}{
+			Name: "foo",
+			Payload: map[string]interface{}{
+				"zeta": 1, "alpha": "a", "mu": map[string]interface{}{"y": true, "b": nil},
+				"delta": []interface{}{map[string]interface{}{"k2": 2, "k1": 1}},
+				"big":   int64(9007199254740993),
+			},
+			// Written as is by encoding/json
+			Raw: json.RawMessage(`{"second":2,"first":1}`),
+		}
+	}
+	expected := map[string]string{
+		"json": `{"name":"foo","payload":{"alpha":"a","big":9007199254740993,"delta":[{"k1":1,"k2":2}],"mu":{"b":null,"y":true},"zeta":1},"raw":{"first":1,"second":2}}`,
+		"yaml": "name: foo\npayload:\n  alpha: a\n  big: 9007199254740993\n  delta:\n  - k1: 1\n    k2: 2\n  mu:\n    b: null\n    \"y\": true\n  zeta: 1\nraw:\n  first: 1\n  second: 2\n",
+	}
+	for format, output := range expected {
+		for i := 0; i < 20; i++ {
+			res, err := DryRunFmt(format, newObj())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res != output {
+				t.Fatalf("%s: expecting %q, got %q", format, output, res)
+			}
+		}
+	}
+}
+
+func TestDryRunFmtList(t *testing.T) {
+	defer SetPrettyJSON(prettyJSON)
+	SetPrettyJSON(false)
+	objs := []interface{}{
+		map[string]string{"kind": "Function"},
+		map[string]string{"kind": "CronJobTrigger"},
+	}
+
+	res, err := DryRunFmtList("yaml", objs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "kind: Function\n---\nkind: CronJobTrigger" {
+		t.Errorf("Unexpected yaml stream %q", res)
+	}
+
+	res, err = DryRunFmtList("json", objs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "{\"kind\":\"Function\"}\n{\"kind\":\"CronJobTrigger\"}" {
+		t.Errorf("Unexpected json stream %q", res)
+	}
+
+	if _, err := DryRunFmtList("xml", objs...); err == nil {
+		t.Error("Expecting an error for an unknown format")
+	}
+}
+
+func TestPrintCreated(t *testing.T) {
+	flags := pflag.NewFlagSet("create", pflag.ContinueOnError)
+	flags.StringP("output", "o", "yaml", "Output format")
+	// The default output is only used by --dryrun
+	if output, err := GetCreatedOutput(flags); err != nil || output != "" {
+		t.Errorf("Expecting no output by default, got %q (%v)", output, err)
+	}
+	flags.Set("output", "name")
+	output, err := GetCreatedOutput(flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := PrintCreated(&buf, output, "cronjobtrigger.kubeless.io", "nightly", nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "cronjobtrigger.kubeless.io/nightly created\n" {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := PrintCreated(&buf, "yaml", "cronjobtrigger.kubeless.io", "nightly", map[string]string{"foo": "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "foo: bar\n\n" {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+
+	flags.Set("output", "wide")
+	if _, err := GetCreatedOutput(flags); err == nil {
+		t.Error("Expecting an error for an unsupported format")
+	}
+}
+
+func TestGetCreatedOutputDefaults(t *testing.T) {
+	for _, value := range []string{"wide", "json"} {
+		flags := pflag.NewFlagSet("create", pflag.ContinueOnError)
+		flags.StringP("output", "o", "yaml", "Output format")
+		os.Setenv("KUBELESS_OUTPUT", value)
+		err := ApplyEnvDefaults(flags)
+		os.Unsetenv("KUBELESS_OUTPUT")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output, err := GetCreatedOutput(flags); err != nil || output != "" {
+			t.Errorf("Expecting KUBELESS_OUTPUT=%s to be ignored, got %q (%v)", value, output, err)
+		}
+
+		flags = pflag.NewFlagSet("create", pflag.ContinueOnError)
+		flags.StringP("output", "o", "yaml", "Output format")
+		if err := ApplyConfigDefaults(flags, map[string]string{"output": value}); err != nil {
+			t.Fatal(err)
+		}
+		if output, err := GetCreatedOutput(flags); err != nil || output != "" {
+			t.Errorf("Expecting output=%s in the CLI config to be ignored, got %q (%v)", value, output, err)
+		}
+	}
+}
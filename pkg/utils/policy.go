@@ -0,0 +1,466 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Policy is a Kyverno ClusterPolicy or Policy. Only the subset needed to check
+// the manifests generated by the CLI is supported: validate rules with a
+// pattern or anyPattern, matched by kind, name and namespace.
+type Policy struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Rules []PolicyRule `json:"rules"`
+	} `json:"spec"`
+}
+
+// PolicyRule is a rule of a Kyverno policy
+type PolicyRule struct {
+	Name     string          `json:"name"`
+	Match    *policyMatch    `json:"match"`
+	Exclude  *policyMatch    `json:"exclude"`
+	Validate *policyValidate `json:"validate"`
+	Mutate   interface{}     `json:"mutate"`
+	Generate interface{}     `json:"generate"`
+}
+
+type policyMatch struct {
+	Resources *policyResources `json:"resources"`
+	Any       []policyMatch    `json:"any"`
+	All       []policyMatch    `json:"all"`
+}
+
+type policyResources struct {
+	Kinds      []string `json:"kinds"`
+	Name       string   `json:"name"`
+	Names      []string `json:"names"`
+	Namespaces []string `json:"namespaces"`
+}
+
+type policyValidate struct {
+	Message    string        `json:"message"`
+	Pattern    interface{}   `json:"pattern"`
+	AnyPattern []interface{} `json:"anyPattern"`
+	Deny       interface{}   `json:"deny"`
+	Foreach    interface{}   `json:"foreach"`
+}
+
+// errPatternSkipped is returned when a conditional anchor is not satisfied,
+// in which case the pattern doesn't apply to the resource
+var errPatternSkipped = errors.New("conditional anchor not satisfied")
+
+var policyDocSeparator = regexp.MustCompile("(?m)^---\\s*$")
+
+// LoadPolicies reads the Kyverno policies of a YAML or JSON file. The file may
+// contain several documents. Rules that can't be evaluated locally are an error
+// so a policy is never reported as satisfied without being checked.
+func LoadPolicies(file string) ([]Policy, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	policies := []Policy{}
+	for _, doc := range policyDocSeparator.Split(string(content), -1) {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		policy := Policy{}
+		if err := yaml.Unmarshal([]byte(doc), &policy); err != nil {
+			return nil, fmt.Errorf("Unable to parse %s: %v", file, err)
+		}
+		if err := checkPolicy(policy); err != nil {
+			return nil, fmt.Errorf("Invalid policy in %s: %v", file, err)
+		}
+		policies = append(policies, policy)
+	}
+	if len(policies) == 0 {
+		return nil, fmt.Errorf("No policy found in %s", file)
+	}
+	return policies, nil
+}
+
+func checkPolicy(policy Policy) error {
+	if !strings.HasPrefix(policy.APIVersion, "kyverno.io/") || (policy.Kind != "ClusterPolicy" && policy.Kind != "Policy") {
+		return fmt.Errorf("%s %q is not a Kyverno ClusterPolicy or Policy", policy.Kind, policy.Metadata.Name)
+	}
+	for _, rule := range policy.Spec.Rules {
+		id := fmt.Sprintf("rule %q of policy %q", rule.Name, policy.Metadata.Name)
+		if rule.Mutate != nil || rule.Generate != nil {
+			return fmt.Errorf("%s is not a validate rule. Only validate rules are supported", id)
+		}
+		if rule.Validate == nil {
+			return fmt.Errorf("%s has no validate section", id)
+		}
+		if rule.Validate.Deny != nil || rule.Validate.Foreach != nil {
+			return fmt.Errorf("%s uses deny or foreach. Only pattern and anyPattern are supported", id)
+		}
+		patterns := rule.Validate.AnyPattern
+		if rule.Validate.Pattern != nil {
+			patterns = append(patterns, rule.Validate.Pattern)
+		}
+		if len(patterns) == 0 {
+			return fmt.Errorf("%s has no pattern", id)
+		}
+		for _, p := range patterns {
+			if err := checkPattern(p); err != nil {
+				return fmt.Errorf("%s: %v", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+func checkPattern(pattern interface{}) error {
+	switch p := pattern.(type) {
+	case map[string]interface{}:
+		for k, v := range p {
+			if strings.HasSuffix(k, ")") && !strings.HasPrefix(k, "(") && !strings.HasPrefix(k, "=(") && !strings.HasPrefix(k, "X(") {
+				return fmt.Errorf("unsupported anchor %q", k)
+			}
+			if err := checkPattern(v); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if len(p) != 1 {
+			return fmt.Errorf("lists in a pattern should have a single element, matched against every item")
+		}
+		return checkPattern(p[0])
+	}
+	return nil
+}
+
+// ValidatePolicies evaluates the policies against the given objects and
+// returns a description of every violation found
+func ValidatePolicies(policies []Policy, objs ...interface{}) ([]string, error) {
+	violations := []string{}
+	for _, obj := range objs {
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		res := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &res); err != nil {
+			return nil, err
+		}
+		kind, _ := res["kind"].(string)
+		apiVersion, _ := res["apiVersion"].(string)
+		meta, _ := res["metadata"].(map[string]interface{})
+		name, _ := meta["name"].(string)
+		ns, _ := meta["namespace"].(string)
+		for _, policy := range policies {
+			if policy.Kind == "Policy" && policy.Metadata.Namespace != ns {
+				continue
+			}
+			for _, rule := range policy.Spec.Rules {
+				if !rule.Match.matches(apiVersion, kind, name, ns) {
+					continue
+				}
+				if rule.Exclude != nil && rule.Exclude.matches(apiVersion, kind, name, ns) {
+					continue
+				}
+				if err := rule.Validate.validate(res); err != nil {
+					msg := rule.Validate.Message
+					if msg == "" {
+						msg = "validation failed"
+					}
+					violations = append(violations, fmt.Sprintf("%s %s/%s: %s/%s: %s (%v)", kind, ns, name, policy.Metadata.Name, rule.Name, msg, err))
+				}
+			}
+		}
+	}
+	return violations, nil
+}
+
+// CheckPolicyFile evaluates the policies of the given file against the objects
+// and returns an error listing the violations, if any
+func CheckPolicyFile(file string, objs ...interface{}) error {
+	policies, err := LoadPolicies(file)
+	if err != nil {
+		return err
+	}
+	violations, err := ValidatePolicies(policies, objs...)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("Found %d policy violation(s):\n - %s", len(violations), strings.Join(violations, "\n - "))
+	}
+	return nil
+}
+
+func (m *policyMatch) matches(apiVersion, kind, name, ns string) bool {
+	if m == nil {
+		return false
+	}
+	if m.Resources != nil && !m.Resources.matches(apiVersion, kind, name, ns) {
+		return false
+	}
+	for _, all := range m.All {
+		if !all.matches(apiVersion, kind, name, ns) {
+			return false
+		}
+	}
+	if len(m.Any) > 0 {
+		for _, any := range m.Any {
+			if any.matches(apiVersion, kind, name, ns) {
+				return true
+			}
+		}
+		return false
+	}
+	return m.Resources != nil || len(m.All) > 0
+}
+
+func (r *policyResources) matches(apiVersion, kind, name, ns string) bool {
+	if len(r.Kinds) > 0 {
+		found := false
+		for _, k := range r.Kinds {
+			// Kinds can be given as Kind, version/Kind or group/version/Kind
+			i := strings.LastIndex(k, "/")
+			if i == -1 {
+				found = wildcardMatch(k, kind)
+			} else {
+				version := k[:i]
+				found = wildcardMatch(k[i+1:], kind) && (wildcardMatch(version, apiVersion) || wildcardMatch(version, apiVersion[strings.LastIndex(apiVersion, "/")+1:]))
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	names := r.Names
+	if r.Name != "" {
+		names = append(names, r.Name)
+	}
+	return matchesAny(names, name) && matchesAny(r.Namespaces, ns)
+}
+
+// matchesAny returns true if the list of patterns is empty or if the value
+// matches any of them
+func matchesAny(patterns []string, value string) bool {
+	for _, p := range patterns {
+		if wildcardMatch(p, value) {
+			return true
+		}
+	}
+	return len(patterns) == 0
+}
+
+func (v *policyValidate) validate(res map[string]interface{}) error {
+	if v.Pattern != nil {
+		if err := matchPattern(res, v.Pattern, ""); err != nil && err != errPatternSkipped {
+			return err
+		}
+	}
+	if len(v.AnyPattern) == 0 {
+		return nil
+	}
+	errs := []string{}
+	for _, p := range v.AnyPattern {
+		err := matchPattern(res, p, "")
+		if err == nil || err == errPatternSkipped {
+			return nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return fmt.Errorf("none of the patterns matched: %s", strings.Join(errs, "; "))
+}
+
+// matchPattern checks a value of the resource against a Kyverno pattern. Maps
+// should contain every field of the pattern, every item of a list should match
+// its pattern and scalars are compared with matchValuePattern.
+func matchPattern(value, pattern interface{}, path string) error {
+	switch p := pattern.(type) {
+	case map[string]interface{}:
+		return matchMapPattern(value, p, path)
+	case []interface{}:
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expecting a list", policyPath(path))
+		}
+		for i, item := range items {
+			err := matchPattern(item, p[0], fmt.Sprintf("%s[%d]", path, i))
+			if err != nil && err != errPatternSkipped {
+				return err
+			}
+		}
+		return nil
+	default:
+		if !matchValuePattern(value, p) {
+			return fmt.Errorf("%s: value %s doesn't match %s", policyPath(path), policyValueString(value), policyValueString(p))
+		}
+		return nil
+	}
+}
+
+func matchMapPattern(value interface{}, pattern map[string]interface{}, path string) error {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s: expecting an object", policyPath(path))
+	}
+	keys := []string{}
+	for k := range pattern {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	// Conditional anchors are checked first: if any of them doesn't match the
+	// rest of the pattern doesn't apply
+	for _, k := range keys {
+		if !strings.HasPrefix(k, "(") {
+			continue
+		}
+		field := strings.TrimSuffix(strings.TrimPrefix(k, "("), ")")
+		v, ok := obj[field]
+		if !ok || matchPattern(v, pattern[k], joinPolicyPath(path, field)) != nil {
+			return errPatternSkipped
+		}
+	}
+	for _, k := range keys {
+		switch {
+		case strings.HasPrefix(k, "("):
+			continue
+		case strings.HasPrefix(k, "=("):
+			field := strings.TrimSuffix(strings.TrimPrefix(k, "=("), ")")
+			if v, ok := obj[field]; ok {
+				if err := matchPattern(v, pattern[k], joinPolicyPath(path, field)); err != nil && err != errPatternSkipped {
+					return err
+				}
+			}
+		case strings.HasPrefix(k, "X("):
+			field := strings.TrimSuffix(strings.TrimPrefix(k, "X("), ")")
+			if _, ok := obj[field]; ok {
+				return fmt.Errorf("%s: field is not allowed", joinPolicyPath(path, field))
+			}
+		default:
+			v, ok := obj[k]
+			if !ok {
+				return fmt.Errorf("%s: field is required", joinPolicyPath(path, k))
+			}
+			if err := matchPattern(v, pattern[k], joinPolicyPath(path, k)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// matchValuePattern compares a scalar with a pattern. String patterns support
+// alternatives (a | b), negation (!a), wildcards (* and ?) and the comparison
+// operators >, >=, < and <= with numbers and quantities (e.g. <=1Gi).
+func matchValuePattern(value, pattern interface{}) bool {
+	p, ok := pattern.(string)
+	if !ok {
+		return policyValueString(value) == policyValueString(pattern)
+	}
+	for _, alt := range strings.Split(p, "|") {
+		if matchStringPattern(value, strings.TrimSpace(alt)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchStringPattern(value interface{}, pattern string) bool {
+	if strings.HasPrefix(pattern, "!") {
+		return !matchStringPattern(value, strings.TrimPrefix(pattern, "!"))
+	}
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		if !strings.HasPrefix(pattern, op) {
+			continue
+		}
+		expected, err := resource.ParseQuantity(strings.TrimSpace(strings.TrimPrefix(pattern, op)))
+		if err != nil {
+			return false
+		}
+		actual, err := resource.ParseQuantity(policyValueString(value))
+		if err != nil {
+			return false
+		}
+		c := actual.Cmp(expected)
+		switch op {
+		case ">=":
+			return c >= 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		default:
+			return c < 0
+		}
+	}
+	if value == nil {
+		return pattern == "*"
+	}
+	return wildcardMatch(pattern, policyValueString(value))
+}
+
+// wildcardMatch matches a value with a pattern in which * is any sequence of
+// characters and ? a single one
+func wildcardMatch(pattern, value string) bool {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.Replace(expr, "\\*", ".*", -1)
+	expr = strings.Replace(expr, "\\?", ".", -1)
+	return regexp.MustCompile("^" + expr + "$").MatchString(value)
+}
+
+func policyValueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func joinPolicyPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func policyPath(path string) string {
+	if path == "" {
+		return "<root>"
+	}
+	return path
+}
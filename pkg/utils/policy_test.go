@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testPolicies = `apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: functions
+spec:
+  rules:
+  - name: require-team
+    match:
+      resources:
+        kinds:
+        - kubeless.io/v1beta1/Function
+    validate:
+      message: "The label team is required"
+      pattern:
+        metadata:
+          labels:
+            team: "?*"
+  - name: limit-memory
+    match:
+      any:
+      - resources:
+          kinds:
+          - Function
+    exclude:
+      resources:
+        names:
+        - "big-*"
+    validate:
+      message: "Memory limits up to 512Mi"
+      pattern:
+        spec:
+          deployment:
+            spec:
+              template:
+                spec:
+                  containers:
+                  - resources:
+                      limits:
+                        memory: "<=512Mi"
+---
+apiVersion: kyverno.io/v1
+kind: Policy
+metadata:
+  name: python
+  namespace: other
+spec:
+  rules:
+  - name: python-only
+    match:
+      resources:
+        kinds:
+        - Function
+    validate:
+      pattern:
+        spec:
+          runtime: "python*"
+`
+
+func testPolicyFunction(name, memory string, labels map[string]string) *kubelessApi.Function {
+	return &kubelessApi.Function{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Function",
+			APIVersion: "kubeless.io/v1beta1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    labels,
+		},
+		Spec: kubelessApi.FunctionSpec{
+			Runtime: "nodejs12",
+			Deployment: appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: v1.PodTemplateSpec{
+						Spec: v1.PodSpec{
+							Containers: []v1.Container{{
+								Resources: v1.ResourceRequirements{
+									Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse(memory)},
+								},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestValidatePolicies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "policy.yaml")
+	if err := ioutil.WriteFile(file, []byte(testPolicies), 0644); err != nil {
+		t.Fatal(err)
+	}
+	policies, err := LoadPolicies(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policies) != 2 {
+		t.Fatalf("Expecting 2 policies, got %d", len(policies))
+	}
+
+	tests := []struct {
+		name     string
+		function *kubelessApi.Function
+		expected []string
+	}{
+		{
+			name:     "compliant",
+			function: testPolicyFunction("hello", "256Mi", map[string]string{"team": "a"}),
+			expected: []string{},
+		},
+		{
+			name:     "missing label and too much memory",
+			function: testPolicyFunction("hello", "1Gi", nil),
+			expected: []string{
+				"Function default/hello: functions/require-team: The label team is required (metadata.labels: field is required)",
+				"Function default/hello: functions/limit-memory: Memory limits up to 512Mi (spec.deployment.spec.template.spec.containers[0].resources.limits.memory: value 1Gi doesn't match <=512Mi)",
+			},
+		},
+		{
+			name:     "excluded by name",
+			function: testPolicyFunction("big-hello", "1Gi", map[string]string{"team": "a"}),
+			expected: []string{},
+		},
+	}
+	for _, test := range tests {
+		violations, err := ValidatePolicies(policies, test.function)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if strings.Join(violations, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("%s: expecting violations:\n%s\ngot:\n%s", test.name, strings.Join(test.expected, "\n"), strings.Join(violations, "\n"))
+		}
+	}
+}
+
+func TestLoadPoliciesUnsupported(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	policies := map[string]string{
+		"not kyverno": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: foo\n",
+		"deny":        "apiVersion: kyverno.io/v1\nkind: ClusterPolicy\nmetadata:\n  name: foo\nspec:\n  rules:\n  - name: r\n    match:\n      resources:\n        kinds: [Function]\n    validate:\n      deny: {}\n",
+		"mutate":      "apiVersion: kyverno.io/v1\nkind: ClusterPolicy\nmetadata:\n  name: foo\nspec:\n  rules:\n  - name: r\n    match:\n      resources:\n        kinds: [Function]\n    mutate:\n      patchStrategicMerge: {}\n",
+		"anchor":      "apiVersion: kyverno.io/v1\nkind: ClusterPolicy\nmetadata:\n  name: foo\nspec:\n  rules:\n  - name: r\n    match:\n      resources:\n        kinds: [Function]\n    validate:\n      pattern:\n        +(spec): {}\n",
+	}
+	for name, content := range policies {
+		file := filepath.Join(dir, "policy.yaml")
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadPolicies(file); err == nil {
+			t.Errorf("%s: expecting an error", name)
+		}
+	}
+}
+
+func TestMatchPatternAnchors(t *testing.T) {
+	pattern := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{
+					"(name)":      "web-*",
+					"image":       "!*:latest",
+					"=(replicas)": ">1",
+					"X(command)":  nil,
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name      string
+		container map[string]interface{}
+		valid     bool
+	}{
+		{"skipped by conditional anchor", map[string]interface{}{"name": "db", "image": "db:latest"}, true},
+		{"valid image", map[string]interface{}{"name": "web-1", "image": "web:1.0"}, true},
+		{"latest image", map[string]interface{}{"name": "web-1", "image": "web:latest"}, false},
+		{"equality anchor", map[string]interface{}{"name": "web-1", "image": "web:1.0", "replicas": float64(1)}, false},
+		{"negation anchor", map[string]interface{}{"name": "web-1", "image": "web:1.0", "command": "sh"}, false},
+	}
+	for _, test := range tests {
+		res := map[string]interface{}{
+			"spec": map[string]interface{}{
+				"containers": []interface{}{test.container},
+			},
+		}
+		err := matchPattern(res, pattern, "")
+		if valid := err == nil || err == errPatternSkipped; valid != test.valid {
+			t.Errorf("%s: expecting valid to be %v, got error %v", test.name, test.valid, err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// progressEnabled controls if the progress of long-running operations is shown.
+// It's only written to a terminal so it never ends up in piped or redirected output.
+var progressEnabled = IsTerminal(os.Stderr)
+
+// progressFrames are the frames of the spinner
+var progressFrames = []string{"|", "/", "-", "\\"}
+
+const progressInterval = 100 * time.Millisecond
+
+// SetProgressEnabled enables or disables the progress indicator. It's never
+// enabled if stderr is not a terminal.
+func SetProgressEnabled(enabled bool) {
+	progressEnabled = enabled && IsTerminal(os.Stderr)
+}
+
+// Progress is a spinner showing the current phase of a long-running operation.
+// A nil Progress is valid and shows nothing.
+type Progress struct {
+	out     io.Writer
+	mutex   sync.Mutex
+	phase   string
+	width   int
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// StartProgress shows a spinner with the given phase in stderr until Stop is called.
+// It returns nil if the progress is disabled.
+func StartProgress(phase string) *Progress {
+	if !progressEnabled {
+		return nil
+	}
+	return newProgress(os.Stderr, phase, progressInterval)
+}
+
+func newProgress(out io.Writer, phase string, interval time.Duration) *Progress {
+	p := &Progress{
+		out:     out,
+		phase:   phase,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go func() {
+		defer close(p.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			p.draw(progressFrames[frame%len(progressFrames)])
+			select {
+			case <-p.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return p
+}
+
+// draw replaces the current line with the given frame and the phase
+func (p *Progress) draw(frame string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	line := frame + " " + p.phase
+	padding := ""
+	if p.width > len(line) {
+		padding = strings.Repeat(" ", p.width-len(line))
+	}
+	fmt.Fprintf(p.out, "\r%s%s", line, padding)
+	p.width = len(line)
+}
+
+// SetPhase changes the phase shown next to the spinner
+func (p *Progress) SetPhase(phase string) {
+	if p == nil {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.phase = phase
+}
+
+// Stop removes the spinner leaving the cursor at the beginning of an empty line.
+// It must be called before writing anything else to the terminal.
+func (p *Progress) Stop() {
+	if p == nil {
+		return
+	}
+	select {
+	case <-p.done:
+		return
+	default:
+		close(p.done)
+	}
+	<-p.stopped
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	fmt.Fprintf(p.out, "\r%s\r", strings.Repeat(" ", p.width))
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	out := &bytes.Buffer{}
+	p := newProgress(out, "Building the function", time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	p.SetPhase("Rolling out")
+	time.Sleep(10 * time.Millisecond)
+	p.Stop()
+	// Stopping twice is a no-op
+	p.Stop()
+
+	output := out.String()
+	if !strings.Contains(output, "\r| Building the function") {
+		t.Errorf("Expecting the first phase in the output, got %q", output)
+	}
+	// The shorter phase overwrites the previous one
+	if !strings.Contains(output, "Rolling out          ") {
+		t.Errorf("Expecting the second phase padded to remove the first one, got %q", output)
+	}
+	clear := "\r" + strings.Repeat(" ", len("- Rolling out")) + "\r"
+	if !strings.HasSuffix(output, clear) {
+		t.Errorf("Expecting the line to be cleared at the end, got %q", output)
+	}
+}
+
+func TestNilProgress(t *testing.T) {
+	var p *Progress
+	p.SetPhase("foo")
+	p.Stop()
+}
+
+func TestProgressDisabled(t *testing.T) {
+	defer func(enabled bool) { progressEnabled = enabled }(progressEnabled)
+	// stderr is not a terminal while testing
+	SetProgressEnabled(true)
+	if p := StartProgress("foo"); p != nil {
+		t.Error("Expecting a nil progress when stderr is not a terminal")
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// AddSortByFlag adds the flag used to sort the output of the list commands
+func AddSortByFlag(flags *pflag.FlagSet) {
+	flags.String("sort-by", "", "Sort the list by the value of the given JSONPath in each object. For example: --sort-by .metadata.creationTimestamp")
+}
+
+// sortKey is the value an object is sorted by
+type sortKey struct {
+	found   bool
+	numeric bool
+	number  float64
+	text    string
+}
+
+// SortObjects sorts the given slice in place by the value of the JSONPath in each item. Like
+// in the template output the items are decoded into generic maps first, so fields are referenced
+// by their JSON name. If every value found is a number they are compared as numbers, otherwise
+// as strings. Items without the field go last. An empty path leaves the slice as is.
+func SortObjects(items interface{}, sortBy string) error {
+	if sortBy == "" {
+		return nil
+	}
+	list := reflect.ValueOf(items)
+	if list.Kind() != reflect.Slice {
+		return fmt.Errorf("Only lists can be sorted, got %T", items)
+	}
+	if !strings.HasPrefix(sortBy, "{") {
+		sortBy = "{" + sortBy + "}"
+	}
+	path := jsonpath.New("sort-by").AllowMissingKeys(true)
+	if err := path.Parse(sortBy); err != nil {
+		return fmt.Errorf("Invalid value %q for --sort-by: %v", sortBy, err)
+	}
+
+	keys := make([]sortKey, list.Len())
+	numeric := true
+	for i := range keys {
+		raw, err := json.Marshal(list.Index(i).Interface())
+		if err != nil {
+			return err
+		}
+		var decoded interface{}
+		if err := json.Unmarshal(raw, &decoded); err != nil {
+			return err
+		}
+		results, err := path.FindResults(decoded)
+		if err != nil {
+			return fmt.Errorf("Unable to sort by %s: %v", sortBy, err)
+		}
+		if len(results) == 0 || len(results[0]) == 0 {
+			continue
+		}
+		if len(results) > 1 || len(results[0]) > 1 {
+			return fmt.Errorf("Unable to sort by %s: it should select a single value of each object", sortBy)
+		}
+		keys[i] = newSortKey(results[0][0].Interface())
+		numeric = numeric && keys[i].numeric
+	}
+
+	indexes := make([]int, len(keys))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		ka, kb := keys[indexes[a]], keys[indexes[b]]
+		if !ka.found || !kb.found {
+			return ka.found && !kb.found
+		}
+		if numeric {
+			return ka.number < kb.number
+		}
+		return ka.text < kb.text
+	})
+	sorted := reflect.MakeSlice(list.Type(), list.Len(), list.Len())
+	for i, index := range indexes {
+		sorted.Index(i).Set(list.Index(index))
+	}
+	reflect.Copy(list, sorted)
+	return nil
+}
+
+func newSortKey(value interface{}) sortKey {
+	switch v := value.(type) {
+	case float64:
+		return sortKey{found: true, numeric: true, number: v, text: fmt.Sprint(v)}
+	case string:
+		return sortKey{found: true, text: v}
+	default:
+		raw, _ := json.Marshal(v)
+		return sortKey{found: true, text: string(raw)}
+	}
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	kubelessApi "github.com/kubeless/kubeless/pkg/apis/kubeless/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSortObjects(t *testing.T) {
+	newFunction := func(name, timeout string, age time.Duration) *kubelessApi.Function {
+		return &kubelessApi.Function{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).Add(-age)),
+			},
+			Spec: kubelessApi.FunctionSpec{Timeout: timeout},
+		}
+	}
+	names := func(list []*kubelessApi.Function) []string {
+		res := []string{}
+		for _, f := range list {
+			res = append(res, f.Name)
+		}
+		return res
+	}
+	list := []*kubelessApi.Function{
+		newFunction("b", "180", time.Hour),
+		newFunction("c", "", 3*time.Hour),
+		newFunction("a", "30", 2*time.Hour),
+	}
+
+	tests := []struct {
+		sortBy   string
+		expected []string
+	}{
+		{"", []string{"b", "c", "a"}},
+		{".metadata.name", []string{"a", "b", "c"}},
+		{"{.metadata.creationTimestamp}", []string{"c", "a", "b"}},
+		// Strings are compared as strings even if they look like numbers
+		{".spec.timeout", []string{"c", "b", "a"}},
+	}
+	for _, test := range tests {
+		if err := SortObjects(list, test.sortBy); err != nil {
+			t.Fatal(err)
+		}
+		if got := names(list); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Sorting by %q: expecting %v, got %v", test.sortBy, test.expected, got)
+		}
+	}
+
+	// Numbers are compared as numbers and items without the field go last
+	items := []map[string]interface{}{
+		{"name": "ten", "replicas": 10},
+		{"name": "none"},
+		{"name": "two", "replicas": 2},
+	}
+	if err := SortObjects(items, ".replicas"); err != nil {
+		t.Fatal(err)
+	}
+	order := []string{}
+	for _, item := range items {
+		order = append(order, item["name"].(string))
+	}
+	if !reflect.DeepEqual(order, []string{"two", "ten", "none"}) {
+		t.Errorf("Unexpected order %v", order)
+	}
+
+	if err := SortObjects(list, ".metadata.name["); err == nil {
+		t.Error("Expecting an error for an invalid path")
+	}
+	if err := SortObjects(items, "{.name}{.replicas}"); err == nil {
+		t.Error("Expecting an error for a path selecting several values")
+	}
+	if err := SortObjects(list[0], ".metadata.name"); err == nil {
+		t.Error("Expecting an error for an object that is not a list")
+	}
+}
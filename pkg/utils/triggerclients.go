@@ -0,0 +1,73 @@
+/*
+Copyright (c) 2016-2017 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	cronjobVersioned "github.com/kubeless/cronjob-trigger/pkg/client/clientset/versioned"
+	httpVersioned "github.com/kubeless/http-trigger/pkg/client/clientset/versioned"
+	kafkaVersioned "github.com/kubeless/kafka-trigger/pkg/client/clientset/versioned"
+	kinesisVersioned "github.com/kubeless/kinesis-trigger/pkg/client/clientset/versioned"
+	natsVersioned "github.com/kubeless/nats-trigger/pkg/client/clientset/versioned"
+)
+
+// The clients of the triggers are built with the config of the CLI (context and
+// TLS overrides) instead of the one of the utils of each trigger
+
+// GetCronJobTriggerClientOutCluster returns the clientset of the CronJob triggers from outside of cluster
+func GetCronJobTriggerClientOutCluster() (cronjobVersioned.Interface, error) {
+	config, err := BuildOutOfClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	return cronjobVersioned.NewForConfig(config)
+}
+
+// GetHTTPTriggerClientOutCluster returns the clientset of the HTTP triggers from outside of cluster
+func GetHTTPTriggerClientOutCluster() (httpVersioned.Interface, error) {
+	config, err := BuildOutOfClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	return httpVersioned.NewForConfig(config)
+}
+
+// GetKafkaTriggerClientOutCluster returns the clientset of the Kafka triggers from outside of cluster
+func GetKafkaTriggerClientOutCluster() (kafkaVersioned.Interface, error) {
+	config, err := BuildOutOfClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	return kafkaVersioned.NewForConfig(config)
+}
+
+// GetKinesisTriggerClientOutCluster returns the clientset of the Kinesis triggers from outside of cluster
+func GetKinesisTriggerClientOutCluster() (kinesisVersioned.Interface, error) {
+	config, err := BuildOutOfClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	return kinesisVersioned.NewForConfig(config)
+}
+
+// GetNATSTriggerClientOutCluster returns the clientset of the NATS triggers from outside of cluster
+func GetNATSTriggerClientOutCluster() (natsVersioned.Interface, error) {
+	config, err := BuildOutOfClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	return natsVersioned.NewForConfig(config)
+}